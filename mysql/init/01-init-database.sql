@@ -17,15 +17,19 @@ USE botgroup_chat;
 -- 创建用户表
 CREATE TABLE users (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
-    phone VARCHAR(11) NOT NULL UNIQUE,
+    phone VARCHAR(16) DEFAULT '' COMMENT '展示用的手机号（E.164格式，如 +8613800138000），登录身份见 user_identities',
     nickname VARCHAR(50),
     avatar_url TEXT,
     status INTEGER DEFAULT 1,
+    role VARCHAR(20) NOT NULL DEFAULT 'user' COMMENT '角色：user|moderator|admin',
+    password_hash VARCHAR(100) NOT NULL DEFAULT '' COMMENT '邮箱登录密码的bcrypt摘要，为空表示未设置密码',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    last_login_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    last_login_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_role (role)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 插入测试数据
 INSERT INTO users (id, phone, nickname, avatar_url, status, created_at, updated_at, last_login_at) VALUES
-(1, '13800138000', '测试用户', NULL, 1, '2025-03-26 08:39:15', '2025-03-26 08:39:15', '2025-03-26 08:39:15');
\ No newline at end of file
+(1, '+8613800138000', '测试用户', NULL, 1, '2025-03-26 08:39:15', '2025-03-26 08:39:15', '2025-03-26 08:39:15');
\ No newline at end of file
@@ -7,7 +7,6 @@ USE botgroup_chat;
 -- 创建微信用户表
 CREATE TABLE wechat_users (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
-    uid BIGINT COMMENT '关联用户ID，关联users表的id字段',
     openid VARCHAR(64) UNIQUE NOT NULL COMMENT '微信OpenID',
     nickname VARCHAR(100) COMMENT '微信昵称',
     avatar_url TEXT COMMENT '微信头像URL',
@@ -19,15 +18,11 @@ CREATE TABLE wechat_users (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     
     -- 索引
-    INDEX idx_uid (uid),
     INDEX idx_openid (openid),
     INDEX idx_qr_scene (qr_scene),
-    INDEX idx_subscribe_time (subscribe_time),
-    
-    -- 外键约束
-    FOREIGN KEY (uid) REFERENCES users(id) ON DELETE SET NULL
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='微信用户信息表';
+    INDEX idx_subscribe_time (subscribe_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='微信用户信息表，与用户的关联见 user_identities';
 
 -- 插入测试数据 (可选)
--- INSERT INTO wechat_users (uid, openid, nickname, avatar_url, subscribe_scene, qr_scene) VALUES
--- (1, 'test_openid_123456', '微信测试用户', 'https://example.com/avatar.jpg', 'qr_scene', 'login_test_scene');
\ No newline at end of file
+-- INSERT INTO wechat_users (openid, nickname, avatar_url, subscribe_scene, qr_scene) VALUES
+-- ('test_openid_123456', '微信测试用户', 'https://example.com/avatar.jpg', 'qr_scene', 'login_test_scene');
\ No newline at end of file
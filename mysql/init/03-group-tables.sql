@@ -9,30 +9,64 @@ CREATE TABLE llm_groups (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
     name VARCHAR(100) NOT NULL COMMENT '群组名称',
     description TEXT COMMENT '群组描述',
+    owner_id BIGINT NOT NULL DEFAULT 0 COMMENT '所有者用户ID',
+    system_key VARCHAR(100) DEFAULT NULL COMMENT '系统群组标识，对应配置文件中的群组id',
+    visibility VARCHAR(20) NOT NULL DEFAULT 'private' COMMENT '可见性：private|unlisted|public',
+    is_group_discussion_mode TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否群聊讨论模式',
+    category VARCHAR(50) NOT NULL DEFAULT '' COMMENT '分类',
+    tags TEXT COMMENT '标签(JSON数组)',
+    forked_from BIGINT DEFAULT NULL COMMENT '克隆来源群组ID',
+    like_count INT NOT NULL DEFAULT 0 COMMENT '点赞数',
+    favorite_count INT NOT NULL DEFAULT 0 COMMENT '收藏数',
+    fork_count INT NOT NULL DEFAULT 0 COMMENT '被克隆次数',
+    moderation_status VARCHAR(20) NOT NULL DEFAULT 'approved' COMMENT '审核状态：approved|hidden',
+    moderation_reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '审核说明',
+    published_at TIMESTAMP NULL DEFAULT NULL COMMENT '首次公开时间',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at DATETIME(3) NULL DEFAULT NULL COMMENT '删除时间，非空表示已移入回收站',
     -- 索引
     INDEX idx_name (name),
-    INDEX idx_created_at (created_at)
+    INDEX idx_owner_id (owner_id),
+    INDEX idx_visibility (visibility),
+    INDEX idx_created_at (created_at),
+    INDEX idx_category (category),
+    INDEX idx_forked_from (forked_from),
+    INDEX idx_moderation_status (moderation_status),
+    INDEX idx_published_at (published_at),
+    INDEX idx_deleted_at (deleted_at),
+    UNIQUE INDEX idx_system_key (system_key)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='群组信息表';
 
 -- 创建群组角色表
 CREATE TABLE group_characters (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
     gid BIGINT NOT NULL COMMENT '群组ID，关联groups表的id字段',
+    owner_id BIGINT NOT NULL DEFAULT 0 COMMENT '所有者用户ID',
+    system_key VARCHAR(100) DEFAULT NULL COMMENT '系统角色标识，对应配置文件中的角色id',
     name VARCHAR(100) NOT NULL COMMENT '角色名称',
     personality VARCHAR(100) NOT NULL DEFAULT '' COMMENT '角色性格描述',
     model VARCHAR(50) COMMENT 'AI模型名称',
     avatar TEXT COMMENT '角色头像URL',
     custom_prompt TEXT COMMENT '自定义提示词',
+    tags TEXT COMMENT '角色标签(JSON数组)',
+    rag TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否启用知识库',
+    knowledge VARCHAR(255) DEFAULT NULL COMMENT '知识库文件',
+    extension MEDIUMTEXT COMMENT '角色扩展信息(JSON)',
+    sort_order INT NOT NULL DEFAULT 0 COMMENT '群组内排序，越小越靠前',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at DATETIME(3) NULL DEFAULT NULL COMMENT '删除时间，非空表示已移入回收站',
     
     -- 索引
     INDEX idx_gid (gid),
+    INDEX idx_owner_id (owner_id),
     INDEX idx_name (name),
     INDEX idx_model (model),
     INDEX idx_created_at (created_at),
+    INDEX idx_deleted_at (deleted_at),
+    INDEX idx_gid_sort_order (gid, sort_order),
+    UNIQUE INDEX idx_gid_system_key (gid, system_key),
     
     -- 外键约束
     FOREIGN KEY (gid) REFERENCES llm_groups(id) ON DELETE CASCADE
@@ -0,0 +1,53 @@
+-- 定时任务（角色定时发言）相关表创建脚本
+-- 这个脚本会在 MySQL 容器首次启动时自动执行，已有数据库可手动执行
+
+-- 使用数据库
+USE botgroup_chat;
+
+-- 创建定时任务表
+CREATE TABLE IF NOT EXISTS scheduled_tasks (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    owner_id BIGINT NOT NULL COMMENT '创建者用户ID',
+    gid BIGINT NOT NULL COMMENT '群组ID，关联llm_groups表的id字段',
+    character_id BIGINT NOT NULL COMMENT '发言角色ID，关联group_characters表的id字段',
+    name VARCHAR(100) NOT NULL COMMENT '任务名称',
+    description TEXT COMMENT '任务描述',
+    prompt TEXT COMMENT '发言指令',
+    cron_expr VARCHAR(100) NOT NULL COMMENT 'Cron表达式',
+    enabled TINYINT(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT '任务状态：pending|running|completed|failed',
+    last_run_at TIMESTAMP NULL DEFAULT NULL COMMENT '最近一次执行时间',
+    next_run_at TIMESTAMP NULL DEFAULT NULL COMMENT '下一次执行时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    -- 索引
+    INDEX idx_owner_id (owner_id),
+    INDEX idx_gid (gid),
+    INDEX idx_character_id (character_id),
+    INDEX idx_enabled_next_run (enabled, next_run_at),
+
+    -- 外键约束
+    FOREIGN KEY (gid) REFERENCES llm_groups(id) ON DELETE CASCADE,
+    FOREIGN KEY (character_id) REFERENCES group_characters(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='定时任务表';
+
+-- 创建任务执行记录表
+CREATE TABLE IF NOT EXISTS task_executions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    task_id BIGINT NOT NULL COMMENT '任务ID，关联scheduled_tasks表的id字段',
+    `trigger` VARCHAR(20) NOT NULL DEFAULT 'schedule' COMMENT '触发方式：schedule|manual',
+    instance VARCHAR(100) COMMENT '执行实例标识',
+    start_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '开始时间',
+    end_time TIMESTAMP NULL DEFAULT NULL COMMENT '结束时间',
+    status VARCHAR(20) NOT NULL COMMENT '执行状态：running|success|failed',
+    output TEXT COMMENT '角色发言内容',
+    log TEXT COMMENT '执行日志或错误信息',
+
+    -- 索引
+    INDEX idx_task_id (task_id),
+    INDEX idx_start_time (start_time),
+
+    -- 外键约束
+    FOREIGN KEY (task_id) REFERENCES scheduled_tasks(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='定时任务执行记录表';
@@ -0,0 +1,21 @@
+-- 公开群组目录相关表创建脚本
+-- 这个脚本会在 MySQL 容器首次启动时自动执行，已有数据库可手动执行
+
+-- 使用数据库
+USE botgroup_chat;
+
+-- 创建群组点赞/收藏记录表
+CREATE TABLE IF NOT EXISTS group_reactions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    gid BIGINT NOT NULL COMMENT '群组ID，关联llm_groups表的id字段',
+    type VARCHAR(20) NOT NULL COMMENT '类型：like|favorite',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_user_gid_type (user_id, gid, type),
+    INDEX idx_gid (gid),
+
+    -- 外键约束
+    FOREIGN KEY (gid) REFERENCES llm_groups(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='群组点赞收藏记录表';
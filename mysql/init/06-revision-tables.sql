@@ -0,0 +1,22 @@
+-- 群组/角色修订记录表创建脚本
+-- 这个脚本会在 MySQL 容器首次启动时自动执行，已有数据库可手动执行
+
+-- 使用数据库
+USE botgroup_chat;
+
+-- 创建修订记录表，记录写入后不再修改
+CREATE TABLE IF NOT EXISTS revisions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL COMMENT '对象类型：group|character',
+    entity_id BIGINT NOT NULL COMMENT '群组或角色ID',
+    version INT NOT NULL COMMENT '版本号，从1开始递增',
+    action VARCHAR(20) NOT NULL COMMENT '产生方式：initial|create|update|rollback',
+    source_version INT NOT NULL DEFAULT 0 COMMENT '回滚时的目标版本号',
+    author_id BIGINT NOT NULL DEFAULT 0 COMMENT '修改人用户ID',
+    snapshot MEDIUMTEXT COMMENT '修改后的完整内容(JSON)',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_entity_version (entity_type, entity_id, version),
+    INDEX idx_author_id (author_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='群组角色修订记录表';
@@ -0,0 +1,60 @@
+-- 登录令牌相关表创建脚本
+-- 这个脚本会在 MySQL 容器首次启动时自动执行，已有数据库可手动执行
+
+-- 使用数据库
+USE botgroup_chat;
+
+-- 创建刷新令牌表，只保存令牌的SHA-256摘要
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    session_id VARCHAR(64) NOT NULL COMMENT '登录会话ID，同一次登录轮换产生的令牌相同',
+    token_hash VARCHAR(64) NOT NULL COMMENT '令牌SHA-256摘要',
+    expires_at DATETIME NOT NULL COMMENT '过期时间',
+    used_at DATETIME NULL DEFAULT NULL COMMENT '轮换时间，非空表示已换发新令牌',
+    revoked_at DATETIME NULL DEFAULT NULL COMMENT '吊销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_token_hash (token_hash),
+    INDEX idx_user_id (user_id),
+    INDEX idx_session_id (session_id),
+    INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='刷新令牌表';
+
+-- 创建登录会话表，每次登录一条，session_id 与刷新令牌及访问令牌中的 sid 一致
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    session_id VARCHAR(64) NOT NULL COMMENT '登录会话ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    login_type VARCHAR(20) NOT NULL DEFAULT '' COMMENT '登录方式：phone|wechat|email|第三方登录提供商标识',
+    device VARCHAR(100) NOT NULL DEFAULT '' COMMENT '由User-Agent识别的设备描述',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '' COMMENT '登录时的User-Agent',
+    ip VARCHAR(64) NOT NULL DEFAULT '' COMMENT '登录IP',
+    last_seen_at DATETIME NOT NULL COMMENT '最近活跃时间',
+    expires_at DATETIME NOT NULL COMMENT '过期时间，与最新刷新令牌一致',
+    revoked_at DATETIME NULL DEFAULT NULL COMMENT '吊销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_session_id (session_id),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录会话表';
+
+-- 创建个人API密钥表，只保存密钥的SHA-256摘要
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    name VARCHAR(100) NOT NULL COMMENT '密钥名称',
+    prefix VARCHAR(16) NOT NULL COMMENT '密钥明文前缀，用于识别密钥',
+    key_hash VARCHAR(64) NOT NULL COMMENT '密钥SHA-256摘要',
+    scopes TEXT COMMENT '权限范围（JSON数组）：chat|groups:read|groups:write',
+    expires_at DATETIME NULL DEFAULT NULL COMMENT '过期时间，为空表示永不过期',
+    last_used_at DATETIME NULL DEFAULT NULL COMMENT '最近使用时间',
+    revoked_at DATETIME NULL DEFAULT NULL COMMENT '吊销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_key_hash (key_hash),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='个人API密钥表';
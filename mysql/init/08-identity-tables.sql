@@ -0,0 +1,23 @@
+-- 登录身份相关表创建脚本
+-- 这个脚本会在 MySQL 容器首次启动时自动执行，已有数据库可手动执行
+
+-- 使用数据库
+USE botgroup_chat;
+
+-- 创建登录身份表，一个用户可以绑定多个手机号或微信，同一身份只能属于一个用户
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    provider VARCHAR(20) NOT NULL COMMENT '身份类型，与登录方式一致：phone|wechat|email|第三方登录提供商标识',
+    subject VARCHAR(100) NOT NULL COMMENT '手机号（E.164格式）、邮箱、微信OpenID或第三方账号ID',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_provider_subject (provider, subject),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户登录身份表';
+
+-- 测试用户的手机号登录身份
+INSERT IGNORE INTO user_identities (user_id, provider, subject) VALUES
+(1, 'phone', '+8613800138000');
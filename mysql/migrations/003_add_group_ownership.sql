@@ -0,0 +1,25 @@
+-- 为群组和角色表添加所有者与可见性字段
+-- 执行时间: 2025-04-10
+
+USE botgroup_chat;
+
+-- 群组所有者与可见性
+ALTER TABLE llm_groups
+ADD COLUMN owner_id BIGINT NOT NULL DEFAULT 0 COMMENT '所有者用户ID' AFTER description,
+ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private' COMMENT '可见性：private|unlisted|public' AFTER owner_id;
+
+CREATE INDEX idx_owner_id ON llm_groups(owner_id);
+CREATE INDEX idx_visibility ON llm_groups(visibility);
+
+-- 角色所有者
+ALTER TABLE group_characters
+ADD COLUMN owner_id BIGINT NOT NULL DEFAULT 0 COMMENT '所有者用户ID' AFTER gid;
+
+CREATE INDEX idx_owner_id ON group_characters(owner_id);
+
+-- 历史数据没有所有者，设为公开以保持原有的可访问性
+UPDATE llm_groups SET visibility = 'public' WHERE owner_id = 0;
+
+-- 显示表结构确认
+DESCRIBE llm_groups;
+DESCRIBE group_characters;
@@ -0,0 +1,26 @@
+-- 为群组和角色表添加系统标识及配置文件中的扩展字段
+-- 配置文件中的群组会在服务启动时按 system_key 同步到这两张表
+-- 执行时间: 2025-04-15
+
+USE botgroup_chat;
+
+-- 群组系统标识与讨论模式
+ALTER TABLE llm_groups
+ADD COLUMN system_key VARCHAR(100) DEFAULT NULL COMMENT '系统群组标识，对应配置文件中的群组id' AFTER owner_id,
+ADD COLUMN is_group_discussion_mode TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否群聊讨论模式' AFTER visibility;
+
+-- 用户群组的 system_key 为 NULL，唯一索引不会冲突
+CREATE UNIQUE INDEX idx_system_key ON llm_groups(system_key);
+
+-- 角色系统标识、标签与知识库
+ALTER TABLE group_characters
+ADD COLUMN system_key VARCHAR(100) DEFAULT NULL COMMENT '系统角色标识，对应配置文件中的角色id' AFTER owner_id,
+ADD COLUMN tags TEXT COMMENT '角色标签(JSON数组)' AFTER custom_prompt,
+ADD COLUMN rag TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否启用知识库' AFTER tags,
+ADD COLUMN knowledge VARCHAR(255) DEFAULT NULL COMMENT '知识库文件' AFTER rag;
+
+CREATE UNIQUE INDEX idx_gid_system_key ON group_characters(gid, system_key);
+
+-- 显示表结构确认
+DESCRIBE llm_groups;
+DESCRIBE group_characters;
@@ -0,0 +1,10 @@
+-- 为角色表添加扩展信息字段，保存导入角色卡中无法映射的内容（开场白、对话示例、标签等）
+-- 执行时间: 2025-04-20
+
+USE botgroup_chat;
+
+ALTER TABLE group_characters
+ADD COLUMN extension MEDIUMTEXT COMMENT '角色扩展信息(JSON)' AFTER knowledge;
+
+-- 显示表结构确认
+DESCRIBE group_characters;
@@ -0,0 +1,27 @@
+-- 为群组表添加公开目录所需的分类、标签、计数、克隆来源及审核字段
+-- 点赞/收藏记录表见 mysql/init/05-group-directory-tables.sql
+-- 执行时间: 2025-04-25
+
+USE botgroup_chat;
+
+ALTER TABLE llm_groups
+ADD COLUMN category VARCHAR(50) NOT NULL DEFAULT '' COMMENT '分类' AFTER is_group_discussion_mode,
+ADD COLUMN tags TEXT COMMENT '标签(JSON数组)' AFTER category,
+ADD COLUMN forked_from BIGINT DEFAULT NULL COMMENT '克隆来源群组ID' AFTER tags,
+ADD COLUMN like_count INT NOT NULL DEFAULT 0 COMMENT '点赞数' AFTER forked_from,
+ADD COLUMN favorite_count INT NOT NULL DEFAULT 0 COMMENT '收藏数' AFTER like_count,
+ADD COLUMN fork_count INT NOT NULL DEFAULT 0 COMMENT '被克隆次数' AFTER favorite_count,
+ADD COLUMN moderation_status VARCHAR(20) NOT NULL DEFAULT 'approved' COMMENT '审核状态：approved|hidden' AFTER fork_count,
+ADD COLUMN moderation_reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '审核说明' AFTER moderation_status,
+ADD COLUMN published_at TIMESTAMP NULL DEFAULT NULL COMMENT '首次公开时间' AFTER moderation_reason;
+
+CREATE INDEX idx_category ON llm_groups(category);
+CREATE INDEX idx_forked_from ON llm_groups(forked_from);
+CREATE INDEX idx_moderation_status ON llm_groups(moderation_status);
+CREATE INDEX idx_published_at ON llm_groups(published_at);
+
+-- 已公开的群组以创建时间作为公开时间
+UPDATE llm_groups SET published_at = created_at WHERE visibility = 'public' AND published_at IS NULL;
+
+-- 显示表结构确认
+DESCRIBE llm_groups;
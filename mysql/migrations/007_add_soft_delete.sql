@@ -0,0 +1,17 @@
+-- 为群组表和角色表添加软删除字段，删除的群组/角色进入回收站，超过保留期后由后台任务彻底删除
+-- 执行时间: 2025-04-29
+
+USE botgroup_chat;
+
+ALTER TABLE llm_groups
+ADD COLUMN deleted_at DATETIME(3) NULL DEFAULT NULL COMMENT '删除时间，非空表示已移入回收站' AFTER updated_at;
+
+ALTER TABLE group_characters
+ADD COLUMN deleted_at DATETIME(3) NULL DEFAULT NULL COMMENT '删除时间，非空表示已移入回收站' AFTER updated_at;
+
+CREATE INDEX idx_deleted_at ON llm_groups(deleted_at);
+CREATE INDEX idx_deleted_at ON group_characters(deleted_at);
+
+-- 显示表结构确认
+DESCRIBE llm_groups;
+DESCRIBE group_characters;
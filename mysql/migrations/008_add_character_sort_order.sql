@@ -0,0 +1,20 @@
+-- 为角色表添加群组内排序字段，支持批量调整角色顺序
+-- 执行时间: 2025-05-02
+
+USE botgroup_chat;
+
+ALTER TABLE group_characters
+ADD COLUMN sort_order INT NOT NULL DEFAULT 0 COMMENT '群组内排序，越小越靠前' AFTER extension;
+
+CREATE INDEX idx_gid_sort_order ON group_characters(gid, sort_order);
+
+-- 已有角色按创建顺序编号
+UPDATE group_characters gc
+JOIN (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY gid ORDER BY id) AS rn
+    FROM group_characters
+) ordered ON ordered.id = gc.id
+SET gc.sort_order = ordered.rn;
+
+-- 显示表结构确认
+DESCRIBE group_characters;
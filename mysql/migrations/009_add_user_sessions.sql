@@ -0,0 +1,36 @@
+-- 添加登录会话表，支持查看和退出已登录的设备
+-- 执行时间: 2025-05-02
+
+USE botgroup_chat;
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    session_id VARCHAR(64) NOT NULL COMMENT '登录会话ID',
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    login_type VARCHAR(20) NOT NULL DEFAULT '' COMMENT '登录方式：phone|wechat',
+    device VARCHAR(100) NOT NULL DEFAULT '' COMMENT '由User-Agent识别的设备描述',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '' COMMENT '登录时的User-Agent',
+    ip VARCHAR(64) NOT NULL DEFAULT '' COMMENT '登录IP',
+    last_seen_at DATETIME NOT NULL COMMENT '最近活跃时间',
+    expires_at DATETIME NOT NULL COMMENT '过期时间，与最新刷新令牌一致',
+    revoked_at DATETIME NULL DEFAULT NULL COMMENT '吊销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_session_id (session_id),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='登录会话表';
+
+-- 已有刷新令牌按会话补记登录会话，登录方式和设备信息未知
+INSERT IGNORE INTO user_sessions (session_id, user_id, last_seen_at, expires_at, revoked_at, created_at)
+SELECT session_id,
+       MIN(user_id),
+       MAX(created_at),
+       MAX(expires_at),
+       CASE WHEN SUM(revoked_at IS NULL AND used_at IS NULL) > 0 THEN NULL ELSE MAX(COALESCE(revoked_at, used_at)) END,
+       MIN(created_at)
+FROM refresh_tokens
+GROUP BY session_id;
+
+-- 显示表结构确认
+DESCRIBE user_sessions;
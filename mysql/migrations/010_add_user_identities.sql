@@ -0,0 +1,49 @@
+-- 添加登录身份表，替代 users.openid 和 wechat_users.uid，支持一个账号绑定手机号和微信
+-- 执行时间: 2025-05-02
+
+USE botgroup_chat;
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    provider VARCHAR(20) NOT NULL COMMENT '身份类型，与登录方式一致：phone|wechat',
+    subject VARCHAR(100) NOT NULL COMMENT '手机号或微信OpenID',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_provider_subject (provider, subject),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户登录身份表';
+
+-- 由已有用户的手机号和微信OpenID生成登录身份，同一手机号对应多个用户时保留最早的用户
+INSERT IGNORE INTO user_identities (user_id, provider, subject, created_at)
+SELECT id, 'phone', phone, created_at FROM users
+WHERE phone IS NOT NULL AND phone <> ''
+ORDER BY id;
+
+INSERT IGNORE INTO user_identities (user_id, provider, subject, created_at)
+SELECT id, 'wechat', openid, created_at FROM users
+WHERE openid IS NOT NULL AND openid <> ''
+ORDER BY id;
+
+INSERT IGNORE INTO user_identities (user_id, provider, subject, created_at)
+SELECT uid, 'wechat', openid, created_at FROM wechat_users
+WHERE uid IS NOT NULL
+ORDER BY id;
+
+-- 删除旧的关联字段
+ALTER TABLE users DROP INDEX idx_users_openid;
+ALTER TABLE users DROP COLUMN openid;
+
+ALTER TABLE wechat_users DROP FOREIGN KEY wechat_users_ibfk_1;
+ALTER TABLE wechat_users DROP INDEX idx_uid;
+ALTER TABLE wechat_users DROP COLUMN uid;
+
+ALTER TABLE users
+MODIFY COLUMN phone VARCHAR(11) DEFAULT '' COMMENT '展示用的手机号，登录身份见 user_identities';
+
+-- 显示表结构确认
+DESCRIBE user_identities;
+DESCRIBE users;
+DESCRIBE wechat_users;
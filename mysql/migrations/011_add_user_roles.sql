@@ -0,0 +1,15 @@
+-- 为用户表添加角色字段，支持管理后台（/api/admin）的权限控制
+-- 执行时间: 2025-05-02
+
+USE botgroup_chat;
+
+ALTER TABLE users
+ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' COMMENT '角色：user|moderator|admin' AFTER status;
+
+CREATE INDEX idx_role ON users(role);
+
+-- 首个管理员需手动指定，之后可通过 PUT /api/admin/users/:id/role 设置其他用户的角色
+-- UPDATE users SET role = 'admin' WHERE id = 1;
+
+-- 显示表结构确认
+DESCRIBE users;
@@ -0,0 +1,24 @@
+-- 添加个人API密钥表，脚本可通过 Authorization: Bearer bgk_... 调用 /api/chat 和群组接口
+-- 执行时间: 2025-05-02
+
+USE botgroup_chat;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL COMMENT '用户ID',
+    name VARCHAR(100) NOT NULL COMMENT '密钥名称',
+    prefix VARCHAR(16) NOT NULL COMMENT '密钥明文前缀，用于识别密钥',
+    key_hash VARCHAR(64) NOT NULL COMMENT '密钥SHA-256摘要',
+    scopes TEXT COMMENT '权限范围（JSON数组）：chat|groups:read|groups:write',
+    expires_at DATETIME NULL DEFAULT NULL COMMENT '过期时间，为空表示永不过期',
+    last_used_at DATETIME NULL DEFAULT NULL COMMENT '最近使用时间',
+    revoked_at DATETIME NULL DEFAULT NULL COMMENT '吊销时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    -- 索引
+    UNIQUE INDEX idx_key_hash (key_hash),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='个人API密钥表';
+
+-- 显示表结构确认
+DESCRIBE api_keys;
@@ -0,0 +1,10 @@
+-- 为用户表添加密码字段，支持邮箱注册和密码登录（邮箱作为 user_identities 中 provider = 'email' 的登录身份）
+-- 执行时间: 2025-05-02
+
+USE botgroup_chat;
+
+ALTER TABLE users
+ADD COLUMN password_hash VARCHAR(100) NOT NULL DEFAULT '' COMMENT '邮箱登录密码的bcrypt摘要，为空表示未设置密码' AFTER role;
+
+-- 显示表结构确认
+DESCRIBE users;
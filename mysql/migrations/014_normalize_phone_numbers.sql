@@ -0,0 +1,19 @@
+-- 手机号统一为 E.164 格式（+国家码+号码），支持国际手机号；已有的中国大陆11位手机号补上 +86
+-- 执行时间: 2025-05-02
+
+USE botgroup_chat;
+
+ALTER TABLE users
+MODIFY COLUMN phone VARCHAR(16) DEFAULT '' COMMENT '展示用的手机号（E.164格式，如 +8613800138000），登录身份见 user_identities';
+
+UPDATE users SET phone = CONCAT('+86', phone)
+WHERE phone REGEXP '^1[3-9][0-9]{9}$';
+
+UPDATE user_identities SET subject = CONCAT('+86', subject)
+WHERE provider = 'phone' AND subject REGEXP '^1[3-9][0-9]{9}$';
+
+ALTER TABLE user_identities
+MODIFY COLUMN subject VARCHAR(100) NOT NULL COMMENT '手机号（E.164格式）、邮箱、微信OpenID或第三方账号ID';
+
+-- 显示表结构确认
+DESCRIBE users;
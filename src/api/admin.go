@@ -0,0 +1,273 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminSearchUsersHandler 搜索用户
+// q 匹配用户ID、昵称、手机号或已绑定的登录身份，status、role 过滤
+func AdminSearchUsersHandler(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	filter := repository.UserListFilter{
+		Keyword: c.Query("q"),
+		Role:    c.Query("role"),
+	}
+	if value := c.Query("status"); value != "" {
+		status, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.AdminUserListResponse{
+				Success: false,
+				Message: "无效的status参数",
+			})
+			return
+		}
+		filter.Status = &status
+	}
+
+	adminService := services.NewAdminService()
+	users, total, err := adminService.SearchUsers(filter, page, pageSize)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), models.AdminUserListResponse{
+			Success: false,
+			Message: "搜索用户失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AdminUserListResponse{
+		Success: true,
+		Message: "搜索用户成功",
+		Data:    users,
+		Total:   total,
+	})
+}
+
+// AdminGetUserHandler 获取用户详情及其登录身份
+func AdminGetUserHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.AdminUserResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	adminService := services.NewAdminService()
+	detail, err := adminService.GetUser(uint(id))
+	if err != nil {
+		c.JSON(adminErrorStatus(err), models.AdminUserResponse{
+			Success: false,
+			Message: "获取用户失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AdminUserResponse{
+		Success: true,
+		Message: "获取用户成功",
+		Data:    detail,
+	})
+}
+
+// AdminSetUserStatusHandler 启用或禁用用户，禁用后该用户的登录会话全部失效
+func AdminSetUserStatusHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UserInfoResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	var req models.UserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, UserInfoResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	adminService := services.NewAdminService()
+	user, err := adminService.SetUserStatus(currentUserID(c), uint(id), *req.Status)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), UserInfoResponse{
+			Success: false,
+			Message: "更新用户状态失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserInfoResponse{
+		Success: true,
+		Message: "更新用户状态成功",
+		Data:    user,
+	})
+}
+
+// AdminSetUserRoleHandler 设置用户角色
+func AdminSetUserRoleHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, UserInfoResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	var req models.UserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, UserInfoResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	adminService := services.NewAdminService()
+	user, err := adminService.SetUserRole(currentUserID(c), uint(id), req.Role)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), UserInfoResponse{
+			Success: false,
+			Message: "设置用户角色失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserInfoResponse{
+		Success: true,
+		Message: "设置用户角色成功",
+		Data:    user,
+	})
+}
+
+// AdminListGroupsHandler 获取全部用户的群组，用于审核
+// q 按名称或描述搜索，visibility、moderation_status、owner_id 过滤
+func AdminListGroupsHandler(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	filter := repository.GroupListFilter{
+		Keyword:    c.Query("q"),
+		Visibility: c.Query("visibility"),
+		Moderation: c.Query("moderation_status"),
+		Sort:       repository.GroupSortRecent,
+	}
+	if value := c.Query("owner_id"); value != "" {
+		ownerID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.LlmGroupListResponse{
+				Success: false,
+				Message: "无效的owner_id参数",
+			})
+			return
+		}
+		filter.OwnerID = uint(ownerID)
+	}
+
+	adminService := services.NewAdminService()
+	groups, total, err := adminService.ListGroups(filter, page, pageSize)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), models.LlmGroupListResponse{
+			Success: false,
+			Message: "获取群组列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LlmGroupListResponse{
+		Success: true,
+		Message: "获取群组列表成功",
+		Data:    groups,
+		Total:   total,
+	})
+}
+
+// AdminModerateGroupHandler 下架或恢复公开目录中的群组
+func AdminModerateGroupHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	var req models.GroupModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	adminService := services.NewAdminService()
+	group, err := adminService.ModerateGroup(uint(id), &req)
+	if err != nil {
+		c.JSON(adminErrorStatus(err), models.LlmGroupResponse{
+			Success: false,
+			Message: "审核群组失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LlmGroupResponse{
+		Success: true,
+		Message: "审核群组成功",
+		Data:    group,
+	})
+}
+
+// AdminStatsHandler 获取使用统计
+func AdminStatsHandler(c *gin.Context) {
+	adminService := services.NewAdminService()
+	stats, err := adminService.UsageStats()
+	if err != nil {
+		c.JSON(adminErrorStatus(err), models.UsageStatsResponse{
+			Success: false,
+			Message: "获取使用统计失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UsageStatsResponse{
+		Success: true,
+		Message: "获取使用统计成功",
+		Data:    stats,
+	})
+}
+
+// AdminConfigHandler 查看运行配置，密钥和密码已脱敏
+func AdminConfigHandler(c *gin.Context) {
+	adminService := services.NewAdminService()
+	c.JSON(http.StatusOK, models.AdminConfigResponse{
+		Success: true,
+		Message: "获取运行配置成功",
+		Data:    adminService.Config(),
+	})
+}
+
+// adminErrorStatus 根据管理后台相关错误返回对应的HTTP状态码
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUserMerged):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrModifySelf),
+		errors.Is(err, services.ErrInvalidRole):
+		return http.StatusBadRequest
+	default:
+		return groupErrorStatus(err)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAPIKeysHandler 获取当前用户的个人API密钥列表（不含密钥明文）
+func ListAPIKeysHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIKeyListResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	keys, err := userService.ListAPIKeys(user.ID)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), models.APIKeyListResponse{
+			Success: false,
+			Message: "获取API密钥失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeyListResponse{
+		Success: true,
+		Message: "获取API密钥成功",
+		Data:    keys,
+	})
+}
+
+// CreateAPIKeyHandler 创建个人API密钥，密钥明文只在本次响应中返回
+func CreateAPIKeyHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIKeyCreateResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	var req models.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIKeyCreateResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	result, err := userService.CreateAPIKey(user.ID, req)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), models.APIKeyCreateResponse{
+			Success: false,
+			Message: "创建API密钥失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeyCreateResponse{
+		Success: true,
+		Message: "创建API密钥成功，请妥善保存，密钥只显示一次",
+		Data:    result,
+	})
+}
+
+// RevokeAPIKeyHandler 吊销指定的个人API密钥
+func RevokeAPIKeyHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIKeyResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIKeyResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.RevokeAPIKey(user.ID, uint(id)); err != nil {
+		c.JSON(apiKeyErrorStatus(err), models.APIKeyResponse{
+			Success: false,
+			Message: "吊销API密钥失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeyResponse{
+		Success: true,
+		Message: "吊销API密钥成功",
+	})
+}
+
+// apiKeyErrorStatus 根据API密钥相关错误返回对应的HTTP状态码
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAPIScope),
+		errors.Is(err, services.ErrTooManyAPIKeys):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
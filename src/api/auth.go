@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/auth"
+	"project/src/middleware"
+	"project/src/models"
+	"project/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshTokenHandler 使用刷新令牌换取新的访问令牌和刷新令牌
+func RefreshTokenHandler(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.UserLoginResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	userData, err := userService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(authErrorStatus(err), models.UserLoginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserLoginResponse{
+		Success: true,
+		Message: "刷新成功",
+		Data:    userData,
+	})
+}
+
+// LogoutHandler 退出登录，吊销 Authorization 头中的访问令牌及请求体中刷新令牌所属的登录会话
+func LogoutHandler(c *gin.Context) {
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.UserLoginResponse{
+				Success: false,
+				Message: "请求参数无效: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.Logout(c.GetHeader("Authorization"), req.RefreshToken); err != nil {
+		c.JSON(authErrorStatus(err), models.UserLoginResponse{
+			Success: false,
+			Message: "退出登录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserLoginResponse{
+		Success: true,
+		Message: "已退出登录",
+	})
+}
+
+// authErrorStatus 根据登录令牌错误返回对应的HTTP状态码
+func authErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidRefreshToken),
+		errors.Is(err, services.ErrRefreshTokenReused),
+		errors.Is(err, services.ErrSessionRevoked):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrUserDisabled):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// loginClient 获取登录请求的客户端信息，用于记录登录会话
+func loginClient(c *gin.Context, loginType string) models.LoginClient {
+	return models.LoginClient{
+		LoginType: loginType,
+		UserAgent: c.Request.UserAgent(),
+		IP:        middleware.GetRealClientIP(c),
+	}
+}
+
+// JWKSHandler 返回访问令牌的验证公钥（JWKS），HMAC 密钥不会公开
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.GetTokenIssuer().JWKS())
+}
@@ -3,8 +3,12 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
-	"project/src/config"
+	"net/url"
+	"project/src/constants"
+	"project/src/middleware"
 	"project/src/services"
 	"project/src/services/go-captcha/captdata"
 	"project/src/services/go-captcha/checkdata"
@@ -13,13 +17,62 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// CaptchaCheckRequest 验证码检查请求结构
+// CaptchaAnswer 验证码答案，按验证码类型填写对应的字段
+type CaptchaAnswer struct {
+	Dots  string `json:"dots" form:"dots"`   // click、click_shape：点击坐标，如 "x1,y1,x2,y2"
+	Point string `json:"point" form:"point"` // slide、drag：拼图位置，如 "x,y"
+	Angle string `json:"angle" form:"angle"` // rotate：旋转角度
+}
+
+// CaptchaCheckRequest 验证码检查请求结构，未填写 key 时按风险评估结果要求客户端完成人机验证
 type CaptchaCheckRequest struct {
-	Dots      string `json:"dots" form:"dots" binding:"required"`
-	Key       string `json:"key" form:"key" binding:"required"`
+	CaptchaAnswer
+	Key       string `json:"key" form:"key"`
 	ExtraData string `json:"extraData" form:"extraData" binding:"required"`
 }
 
+// CaptchaVerifyRequest 人机验证请求结构，匿名聊天要求人机验证时使用
+type CaptchaVerifyRequest struct {
+	CaptchaAnswer
+	Key string `json:"key" form:"key" binding:"required"`
+}
+
+// captchaGenerators 各类型验证码的生成函数
+var captchaGenerators = map[string]http.HandlerFunc{
+	constants.CaptchaTypeClick:      captdata.GetClickBasicCaptData,
+	constants.CaptchaTypeClickShape: captdata.GetClickShapesCaptData,
+	constants.CaptchaTypeSlide:      captdata.GetSlideBasicCaptData,
+	constants.CaptchaTypeDrag:       captdata.GetSlideRegionCaptData,
+	constants.CaptchaTypeRotate:     captdata.GetRotateBasicCaptData,
+}
+
+// captchaVerifier 验证码校验函数及其答案参数名
+type captchaVerifier struct {
+	param string
+	check http.HandlerFunc
+}
+
+// captchaVerifiers 各类型验证码的校验函数
+var captchaVerifiers = map[string]captchaVerifier{
+	constants.CaptchaTypeClick:      {param: "dots", check: checkdata.CheckClickData},
+	constants.CaptchaTypeClickShape: {param: "dots", check: checkdata.CheckClickData},
+	constants.CaptchaTypeSlide:      {param: "point", check: checkdata.CheckSlideData},
+	constants.CaptchaTypeDrag:       {param: "point", check: checkdata.CheckSlideData},
+	constants.CaptchaTypeRotate:     {param: "angle", check: checkdata.CheckRotateData},
+}
+
+// answer 返回验证码类型对应的答案
+func (r CaptchaAnswer) answer(captchaType string) string {
+	switch captchaVerifiers[captchaType].param {
+	case "point":
+		return r.Point
+	case "angle":
+		return r.Angle
+	default:
+		return r.Dots
+	}
+}
+
 // ExtraDataStruct 解析extraData的结构
 type ExtraDataStruct struct {
 	Phone string `json:"phone" binding:"required"`
@@ -30,11 +83,63 @@ type CaptchaResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Success bool   `json:"success"`
+	Level   string `json:"level,omitempty"` // 需要人机验证时要求的难度：easy 或 hard
 }
 
-// CaptchaHandler 验证码处理器 - 直接调用原始函数
+// CaptchaHandler 验证码处理器 - 按 type 参数或配置选择验证码类型，响应中的 captcha_type 为实际使用的类型
+// scene 为 sms（默认）或 chat，risk 模式下按该场景的风险评估结果选择难度
 func CaptchaHandler(c *gin.Context) {
-	captdata.GetClickBasicCaptData(c.Writer, c.Request)
+	scene := c.DefaultQuery("scene", constants.RiskSceneSMS)
+	if scene != constants.RiskSceneSMS && scene != constants.RiskSceneChat {
+		c.JSON(http.StatusBadRequest, CaptchaResponse{
+			Code:    1,
+			Message: "不支持的验证场景",
+			Success: false,
+		})
+		return
+	}
+	assessment := assessRisk(c, scene, false)
+
+	captchaService := services.NewCaptchaService(kvService)
+	captchaType, err := captchaService.ChooseType(c.Query("type"), assessment.Decision)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrCaptchaTypeUnsupported) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, CaptchaResponse{
+			Code:    1,
+			Message: err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	// 调用原始生成函数并捕获响应，记录验证码类型后返回
+	responseBuffer := &bytes.Buffer{}
+	captchaGenerators[captchaType](&responseWriter{Buffer: responseBuffer}, c.Request)
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(responseBuffer.Bytes(), &data); err != nil {
+		c.JSON(http.StatusInternalServerError, CaptchaResponse{
+			Code:    1,
+			Message: "生成验证码失败",
+			Success: false,
+		})
+		return
+	}
+	if key, ok := data["captcha_key"].(string); ok {
+		if err := captchaService.BindType(key, captchaType); err != nil {
+			c.JSON(http.StatusInternalServerError, CaptchaResponse{
+				Code:    1,
+				Message: err.Error(),
+				Success: false,
+			})
+			return
+		}
+		data["captcha_type"] = captchaType
+	}
+	c.JSON(http.StatusOK, data)
 }
 
 // CaptchaCheckHandler 验证码检查处理器 - 验证码通过后发送短信
@@ -71,12 +176,27 @@ func CaptchaCheckHandler(c *gin.Context) {
 		return
 	}
 
-	// 验证验证码 - 使用现有的CheckClickData函数
-	isValid := validateCaptchaWithExisting(req.Dots, req.Key)
-	if !isValid {
-		c.JSON(http.StatusBadRequest, CaptchaResponse{
+	// 风险评估决定人机验证的难度，发送短信的评估结果至少为 easy
+	assessment := assessRisk(c, constants.RiskSceneSMS, true)
+	if req.Key == "" {
+		if assessment.Decision != constants.RiskDecisionSkip {
+			c.JSON(http.StatusForbidden, CaptchaResponse{
+				Code:    1,
+				Message: "请先完成人机验证",
+				Success: false,
+				Level:   assessment.Decision,
+			})
+			return
+		}
+	} else if !verifyCaptcha(c, req.Key, req.CaptchaAnswer, assessment.Decision) {
+		return
+	}
+
+	// 检查发送频率限制
+	if err := services.NewSMSPolicy(kvService).AllowSend(extraData.Phone, middleware.GetRealClientIP(c)); err != nil {
+		c.JSON(smsPolicyErrorStatus(err), CaptchaResponse{
 			Code:    1,
-			Message: "验证码验证失败",
+			Message: err.Error(),
 			Success: false,
 		})
 		return
@@ -85,8 +205,18 @@ func CaptchaCheckHandler(c *gin.Context) {
 	// 生成6位随机验证码
 	smsCode := utils.GenerateRandomCode(6)
 
-	// 创建SMS服务并发送短信
-	smsService := services.NewSMSService(config.AppConfig.SMS)
+	// 先存储验证码再发送短信，存储失败时不发送，避免用户收到无法使用的验证码
+	userService := services.NewUserService(kvService)
+	if err := userService.SetSMSCode(extraData.Phone, smsCode); err != nil {
+		c.JSON(http.StatusInternalServerError, CaptchaResponse{
+			Code:    1,
+			Message: "验证码存储失败: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	// 发送短信
 	if err := smsService.SendSMS(extraData.Phone, smsCode); err != nil {
 		c.JSON(http.StatusInternalServerError, CaptchaResponse{
 			Code:    1,
@@ -96,14 +226,35 @@ func CaptchaCheckHandler(c *gin.Context) {
 		return
 	}
 
-	// 创建用户服务来存储验证码
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+	c.JSON(http.StatusOK, CaptchaResponse{
+		Code:    0,
+		Message: "验证码验证通过，短信已发送",
+		Success: true,
+	})
+}
+
+// CaptchaVerifyHandler 人机验证处理器 - 匿名聊天要求人机验证时调用，通过后一段时间内不再要求验证
+func CaptchaVerifyHandler(c *gin.Context) {
+	var req CaptchaVerifyRequest
+
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, CaptchaResponse{
+			Code:    1,
+			Message: "请求参数无效: " + err.Error(),
+			Success: false,
+		})
+		return
+	}
+
+	assessment := assessRisk(c, constants.RiskSceneChat, false)
+	if !verifyCaptcha(c, req.Key, req.CaptchaAnswer, assessment.Decision) {
+		return
+	}
 
-	// 将验证码存储到缓存中，用于后续登录验证
-	if err := userService.SetSMSCode(extraData.Phone, smsCode); err != nil {
+	if err := services.NewRiskService(kvService).MarkVerified(constants.RiskSceneChat, middleware.GetRiskSignals(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, CaptchaResponse{
 			Code:    1,
-			Message: "验证码存储失败: " + err.Error(),
+			Message: err.Error(),
 			Success: false,
 		})
 		return
@@ -111,27 +262,96 @@ func CaptchaCheckHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, CaptchaResponse{
 		Code:    0,
-		Message: "验证码验证通过，短信已发送",
+		Message: "验证通过",
 		Success: true,
 	})
 }
 
-// validateCaptchaWithExisting 使用现有的CheckClickData函数验证验证码
-func validateCaptchaWithExisting(dots, key string) bool {
-	// 创建一个模拟的请求来调用CheckClickData
-	formData := "dots=" + dots + "&key=" + key
+// assessRisk 评估请求风险，record 为 true 时记录本次请求
+// 评估失败时按需要简单验证处理，不因存储故障放过请求
+func assessRisk(c *gin.Context, scene string, record bool) services.RiskAssessment {
+	riskService := services.NewRiskService(kvService)
+	evaluate := riskService.Evaluate
+	if record {
+		evaluate = riskService.Assess
+	}
+	assessment, err := evaluate(scene, middleware.GetRiskSignals(c))
+	if err != nil {
+		log.Printf("风险评估失败: %v", err)
+		return services.RiskAssessment{Decision: constants.RiskDecisionEasy}
+	}
+	if assessment.Decision != constants.RiskDecisionSkip {
+		log.Printf("风险评估 scene=%s ip=%s score=%d reasons=%v", scene, middleware.GetRealClientIP(c), assessment.Score, assessment.Reasons)
+	}
+	return assessment
+}
+
+// verifyCaptcha 按生成时记录的类型校验验证码并记录校验结果，验证码只能校验一次
+// 验证码难度低于风险评估要求时视为未通过；未通过时写入错误响应并返回 false
+func verifyCaptcha(c *gin.Context, key string, answer CaptchaAnswer, decision string) bool {
+	captchaService := services.NewCaptchaService(kvService)
+	captchaType, err := captchaService.TakeType(key)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrCaptchaExpired) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, CaptchaResponse{
+			Code:    1,
+			Message: err.Error(),
+			Success: false,
+		})
+		return false
+	}
+	if !captchaService.Satisfies(captchaType, decision) {
+		c.JSON(http.StatusForbidden, CaptchaResponse{
+			Code:    1,
+			Message: "请完成更高难度的人机验证",
+			Success: false,
+			Level:   decision,
+		})
+		return false
+	}
+
+	isValid := validateCaptchaWithExisting(c.Request, captchaType, answer.answer(captchaType), key)
+	if err := services.NewRiskService(kvService).RecordCaptchaResult(middleware.GetRiskSignals(c), isValid); err != nil {
+		log.Printf("记录验证码校验结果失败: %v", err)
+	}
+	if !isValid {
+		c.JSON(http.StatusBadRequest, CaptchaResponse{
+			Code:    1,
+			Message: "验证码验证失败",
+			Success: false,
+		})
+		return false
+	}
+	return true
+}
+
+// validateCaptchaWithExisting 使用验证码类型对应的现有校验函数验证验证码
+// 模拟请求沿用原请求的请求头和连接地址，验证码答案绑定了客户端指纹
+func validateCaptchaWithExisting(original *http.Request, captchaType, answer, key string) bool {
+	verifier, ok := captchaVerifiers[captchaType]
+	if !ok || answer == "" {
+		return false
+	}
+
+	// 创建一个模拟的请求来调用校验函数
+	formData := url.Values{verifier.param: {answer}, "key": {key}}.Encode()
 	req, err := http.NewRequest("POST", "/captcha/check", bytes.NewBufferString(formData))
 	if err != nil {
 		return false
 	}
+	req.Header = original.Header.Clone()
+	req.RemoteAddr = original.RemoteAddr
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// 创建一个ResponseWriter来捕获响应
 	responseBuffer := &bytes.Buffer{}
 	writer := &responseWriter{Buffer: responseBuffer}
 
-	// 调用现有的CheckClickData函数
-	checkdata.CheckClickData(writer, req)
+	// 调用现有的校验函数
+	verifier.check(writer, req)
 
 	// 解析响应
 	var response map[string]interface{}
@@ -160,3 +380,19 @@ func (w *responseWriter) Header() http.Header {
 func (w *responseWriter) WriteHeader(statusCode int) {
 	w.statusCode = statusCode
 }
+
+// smsPolicyErrorStatus 根据短信发送频率限制错误返回对应的HTTP状态码
+func smsPolicyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrInvalidPhone):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrSMSTooFrequent),
+		errors.Is(err, services.ErrSMSPhoneDailyLimit),
+		errors.Is(err, services.ErrSMSIPDailyLimit):
+		return http.StatusTooManyRequests
+	case errors.Is(err, services.ErrSMSCircuitOpen):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"net/http"
+	"project/src/models"
+	"project/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCategoriesHandler 获取公开目录的群组分类
+func GetCategoriesHandler(c *gin.Context) {
+	directoryService := services.NewDirectoryService()
+	c.JSON(http.StatusOK, models.GroupCategoryListResponse{
+		Success: true,
+		Message: "获取分类列表成功",
+		Data:    directoryService.Categories(),
+	})
+}
+
+// SearchDirectoryHandler 搜索公开目录中的群组
+// q 按名称或描述搜索，category、tag 过滤，sort=popular（默认）或 recent
+func SearchDirectoryHandler(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+	query := &services.DirectoryQuery{
+		Keyword:  c.Query("q"),
+		Category: c.Query("category"),
+		Tag:      c.Query("tag"),
+		Sort:     c.Query("sort"),
+	}
+
+	directoryService := services.NewDirectoryService()
+	groups, total, err := directoryService.SearchGroups(query, page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupListResponse{
+			Success: false,
+			Message: "搜索群组失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LlmGroupListResponse{
+		Success: true,
+		Message: "搜索群组成功",
+		Data:    groups,
+		Total:   total,
+	})
+}
+
+// GetFavoritesHandler 获取当前用户收藏的群组
+func GetFavoritesHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.LlmGroupListResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	page, pageSize := parsePagination(c)
+
+	directoryService := services.NewDirectoryService()
+	groups, total, err := directoryService.ListFavorites(user.ID, page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupListResponse{
+			Success: false,
+			Message: "获取收藏列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LlmGroupListResponse{
+		Success: true,
+		Message: "获取收藏列表成功",
+		Data:    groups,
+		Total:   total,
+	})
+}
+
+// GetGroupForksHandler 获取克隆自指定群组的公开群组
+func GetGroupForksHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.LlmGroupListResponse{
+			Success: false,
+			Message: "无效的群组ID",
+		})
+		return
+	}
+
+	page, pageSize := parsePagination(c)
+
+	directoryService := services.NewDirectoryService()
+	groups, total, err := directoryService.ListForks(currentUserID(c), uint(id), page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupListResponse{
+			Success: false,
+			Message: "获取克隆列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LlmGroupListResponse{
+		Success: true,
+		Message: "获取克隆列表成功",
+		Data:    groups,
+		Total:   total,
+	})
+}
+
+// GetGroupReactionsHandler 获取当前用户对群组的点赞/收藏状态
+func GetGroupReactionsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupReactionResponse{
+			Success: false,
+			Message: "无效的群组ID",
+		})
+		return
+	}
+
+	directoryService := services.NewDirectoryService()
+	state, err := directoryService.GetReactions(currentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupReactionResponse{
+			Success: false,
+			Message: "获取互动状态失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GroupReactionResponse{
+		Success: true,
+		Message: "获取互动状态成功",
+		Data:    state,
+	})
+}
+
+// AddGroupReactionHandler 点赞或收藏群组，type=like|favorite
+func AddGroupReactionHandler(c *gin.Context) {
+	setGroupReaction(c, true)
+}
+
+// RemoveGroupReactionHandler 取消点赞或收藏，type=like|favorite
+func RemoveGroupReactionHandler(c *gin.Context) {
+	setGroupReaction(c, false)
+}
+
+// setGroupReaction 设置当前用户对群组的点赞/收藏状态
+func setGroupReaction(c *gin.Context, active bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.GroupReactionResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupReactionResponse{
+			Success: false,
+			Message: "无效的群组ID",
+		})
+		return
+	}
+
+	directoryService := services.NewDirectoryService()
+	state, err := directoryService.SetReaction(user.ID, uint(id), c.Param("type"), active)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupReactionResponse{
+			Success: false,
+			Message: "操作失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GroupReactionResponse{
+		Success: true,
+		Message: "操作成功",
+		Data:    state,
+	})
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/constants"
+	"project/src/models"
+	"project/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailRegisterHandler 邮箱注册，发送验证邮件，点击邮件中的链接后完成注册
+func EmailRegisterHandler(c *gin.Context) {
+	var req models.EmailRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.EmailResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.RegisterWithEmail(req.Email, req.Password, req.Nickname); err != nil {
+		c.JSON(emailErrorStatus(err), models.EmailResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.EmailResponse{
+		Success: true,
+		Message: "验证邮件已发送，请查收邮件完成注册",
+	})
+}
+
+// EmailVerifyHandler 校验注册验证链接中的令牌，完成注册并登录
+func EmailVerifyHandler(c *gin.Context) {
+	var req models.EmailTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.UserLoginResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	userData, err := userService.VerifyEmail(req.Token, loginClient(c, constants.LoginTypeEmail))
+	if err != nil {
+		c.JSON(emailErrorStatus(err), models.UserLoginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserLoginResponse{
+		Success: true,
+		Message: "注册成功",
+		Data:    userData,
+	})
+}
+
+// EmailLoginHandler 邮箱密码登录
+func EmailLoginHandler(c *gin.Context) {
+	var req models.EmailLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.UserLoginResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	userData, err := userService.LoginWithPassword(req.Email, req.Password, loginClient(c, constants.LoginTypeEmail))
+	if err != nil {
+		c.JSON(emailErrorStatus(err), models.UserLoginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserLoginResponse{
+		Success: true,
+		Message: "登录成功",
+		Data:    userData,
+	})
+}
+
+// ForgotPasswordHandler 发送重置密码邮件，无论邮箱是否注册都返回成功
+func ForgotPasswordHandler(c *gin.Context) {
+	var req models.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.EmailResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.RequestPasswordReset(req.Email); err != nil {
+		c.JSON(emailErrorStatus(err), models.EmailResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.EmailResponse{
+		Success: true,
+		Message: "如果该邮箱已注册，您将收到重置密码的邮件",
+	})
+}
+
+// ResetPasswordHandler 使用重置密码链接中的令牌设置新密码，所有设备需要重新登录
+func ResetPasswordHandler(c *gin.Context) {
+	var req models.PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.EmailResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.ResetPassword(req.Token, req.Password); err != nil {
+		c.JSON(emailErrorStatus(err), models.EmailResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.EmailResponse{
+		Success: true,
+		Message: "密码已重置，请使用新密码登录",
+	})
+}
+
+// MagicLinkHandler 发送免密登录邮件
+func MagicLinkHandler(c *gin.Context) {
+	var req models.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.EmailResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.SendMagicLink(req.Email); err != nil {
+		c.JSON(emailErrorStatus(err), models.EmailResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.EmailResponse{
+		Success: true,
+		Message: "登录链接已发送，请查收邮件",
+	})
+}
+
+// MagicLinkLoginHandler 使用免密登录链接中的令牌登录
+func MagicLinkLoginHandler(c *gin.Context) {
+	var req models.EmailTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.UserLoginResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	userData, err := userService.LoginWithMagicLink(req.Token, loginClient(c, constants.LoginTypeEmail))
+	if err != nil {
+		c.JSON(emailErrorStatus(err), models.UserLoginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserLoginResponse{
+		Success: true,
+		Message: "登录成功",
+		Data:    userData,
+	})
+}
+
+// emailErrorStatus 根据邮箱登录相关错误返回对应的HTTP状态码
+func emailErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidEmail),
+		errors.Is(err, services.ErrInvalidPassword),
+		errors.Is(err, services.ErrInvalidEmailToken):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrUserDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrEmailTooFrequent):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,55 +1,45 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
-	"project/src/config"
+	"net/url"
 	"project/src/models"
+	"project/src/services"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+// maxCharacterCardSize 角色卡文件大小上限
+const maxCharacterCardSize = 5 << 20
+
 // CreateCharacterHandler 创建群组角色
 func CreateCharacterHandler(c *gin.Context) {
-	var req models.GroupCharacterCreateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.GroupCharacterResponse{
 			Success: false,
-			Message: "请求参数错误: " + err.Error(),
+			Message: "用户认证失败",
 		})
 		return
 	}
 
-	// 验证群组是否存在
-	var group models.LlmGroup
-	if err := config.DB.First(&group, req.GID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
-				Success: false,
-				Message: "指定的群组不存在",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
+	var req models.GroupCharacterCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
 			Success: false,
-			Message: "验证群组失败: " + err.Error(),
+			Message: "请求参数错误: " + err.Error(),
 		})
 		return
 	}
 
-	// 创建角色
-	character := models.GroupCharacter{
-		GID:          req.GID,
-		Name:         req.Name,
-		Personality:  req.Personality,
-		Model:        req.Model,
-		Avatar:       req.Avatar,
-		CustomPrompt: req.CustomPrompt,
-	}
-
-	if err := config.DB.Create(&character).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
+	groupService := services.NewGroupService()
+	character, err := groupService.CreateCharacter(user.ID, &req)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterResponse{
 			Success: false,
 			Message: "创建角色失败: " + err.Error(),
 		})
@@ -59,55 +49,37 @@ func CreateCharacterHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, models.GroupCharacterResponse{
 		Success: true,
 		Message: "创建角色成功",
-		Data:    &character,
+		Data:    character,
 	})
 }
 
 // GetCharactersHandler 获取角色列表
+// scope=mine（默认）返回当前用户的角色，scope=public 返回公开群组中的角色
 func GetCharactersHandler(c *gin.Context) {
-	var characters []models.GroupCharacter
-	var total int64
-
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
-	offset := (page - 1) * pageSize
+	page, pageSize := parsePagination(c)
 
 	// 搜索参数
-	gid := c.Query("gid")
-	name := c.Query("name")
-	model := c.Query("model")
-
-	// 构建查询
-	query := config.DB.Model(&models.GroupCharacter{})
-	if gid != "" {
-		query = query.Where("gid = ?", gid)
-	}
-	if name != "" {
-		query = query.Where("name LIKE ?", "%"+name+"%")
-	}
-	if model != "" {
-		query = query.Where("model = ?", model)
+	gid, _ := strconv.ParseUint(c.Query("gid"), 10, 32)
+	query := &services.CharacterQuery{
+		Scope: c.DefaultQuery("scope", services.GroupScopeMine),
+		GID:   uint(gid),
+		Name:  c.Query("name"),
+		Model: c.Query("model"),
 	}
 
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterListResponse{
+	userID := currentUserID(c)
+	if query.Scope == services.GroupScopeMine && userID == 0 {
+		c.JSON(http.StatusUnauthorized, models.GroupCharacterListResponse{
 			Success: false,
-			Message: "获取角色总数失败: " + err.Error(),
+			Message: "用户认证失败",
 		})
 		return
 	}
 
-	// 获取分页数据，预加载群组信息
-	if err := query.Preload("Group").Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&characters).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterListResponse{
+	groupService := services.NewGroupService()
+	characters, total, err := groupService.ListCharacters(userID, query, page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterListResponse{
 			Success: false,
 			Message: "获取角色列表失败: " + err.Error(),
 		})
@@ -122,7 +94,7 @@ func GetCharactersHandler(c *gin.Context) {
 	})
 }
 
-// GetCharactersByGroupHandler 根据群组获取角色列表
+// GetCharactersByGroupHandler 根据群组ID获取角色列表
 func GetCharactersByGroupHandler(c *gin.Context) {
 	gid, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -133,61 +105,65 @@ func GetCharactersByGroupHandler(c *gin.Context) {
 		return
 	}
 
-	// 验证群组是否存在
-	var group models.LlmGroup
-	if err := config.DB.First(&group, gid).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, models.GroupCharactersByGroupResponse{
-				Success: false,
-				Message: "群组不存在",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.GroupCharactersByGroupResponse{
+	page, pageSize := parsePagination(c)
+
+	groupService := services.NewGroupService()
+	group, characters, total, err := groupService.ListCharactersByGroup(currentUserID(c), uint(gid), page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharactersByGroupResponse{
 			Success: false,
-			Message: "查询群组失败: " + err.Error(),
+			Message: "获取角色列表失败: " + err.Error(),
 		})
 		return
 	}
 
-	var characters []models.GroupCharacter
-	var total int64
+	c.JSON(http.StatusOK, models.GroupCharactersByGroupResponse{
+		Success: true,
+		Message: "获取群组角色列表成功",
+		Group:   group,
+		Data:    characters,
+		Total:   total,
+	})
+}
 
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
+// BatchCharactersHandler 在一个事务中批量新建、更新、删除和排序群组内的角色
+func BatchCharactersHandler(c *gin.Context) {
+	gid, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharactersByGroupResponse{
+			Success: false,
+			Message: "无效的群组ID",
+		})
+		return
 	}
-	offset := (page - 1) * pageSize
 
-	// 获取该群组的角色总数
-	if err := config.DB.Model(&models.GroupCharacter{}).Where("gid = ?", gid).Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.GroupCharactersByGroupResponse{
+	var req models.GroupCharacterBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharactersByGroupResponse{
 			Success: false,
-			Message: "获取角色总数失败: " + err.Error(),
+			Message: "请求参数错误: " + err.Error(),
 		})
 		return
 	}
 
-	// 获取分页数据
-	if err := config.DB.Where("gid = ?", gid).Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&characters).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.GroupCharactersByGroupResponse{
+	groupService := services.NewGroupService()
+	group, err := groupService.BatchCharacters(currentUserID(c), uint(gid), &req)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharactersByGroupResponse{
 			Success: false,
-			Message: "获取角色列表失败: " + err.Error(),
+			Message: "批量操作角色失败: " + err.Error(),
 		})
 		return
 	}
 
+	characters := group.Characters
+	group.Characters = nil
 	c.JSON(http.StatusOK, models.GroupCharactersByGroupResponse{
 		Success: true,
-		Message: "获取群组角色列表成功",
-		Group:   &group,
+		Message: "批量操作角色成功",
+		Group:   group,
 		Data:    characters,
-		Total:   total,
+		Total:   int64(len(characters)),
 	})
 }
 
@@ -202,16 +178,10 @@ func GetCharacterHandler(c *gin.Context) {
 		return
 	}
 
-	var character models.GroupCharacter
-	if err := config.DB.Preload("Group").First(&character, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, models.GroupCharacterResponse{
-				Success: false,
-				Message: "角色不存在",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
+	groupService := services.NewGroupService()
+	character, err := groupService.GetCharacter(currentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterResponse{
 			Success: false,
 			Message: "获取角色详情失败: " + err.Error(),
 		})
@@ -221,11 +191,11 @@ func GetCharacterHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, models.GroupCharacterResponse{
 		Success: true,
 		Message: "获取角色详情成功",
-		Data:    &character,
+		Data:    character,
 	})
 }
 
-// UpdateCharacterHandler 更新角色
+// UpdateCharacterHandler 更新角色，PUT/PATCH 均按 JSON Merge Patch 语义处理
 func UpdateCharacterHandler(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -245,76 +215,124 @@ func UpdateCharacterHandler(c *gin.Context) {
 		return
 	}
 
-	// 检查角色是否存在
-	var character models.GroupCharacter
-	if err := config.DB.First(&character, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, models.GroupCharacterResponse{
-				Success: false,
-				Message: "角色不存在",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
+	groupService := services.NewGroupService()
+	character, err := groupService.UpdateCharacter(currentUserID(c), uint(id), &req)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterResponse{
+			Success: false,
+			Message: "更新角色失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GroupCharacterResponse{
+		Success: true,
+		Message: "更新角色成功",
+		Data:    character,
+	})
+}
+
+// DeleteCharacterHandler 删除角色
+func DeleteCharacterHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
 			Success: false,
-			Message: "查询角色失败: " + err.Error(),
+			Message: "无效的角色ID",
 		})
 		return
 	}
 
-	// 更新字段
-	updates := make(map[string]interface{})
-	if req.Name != "" {
-		updates["name"] = req.Name
+	groupService := services.NewGroupService()
+	if err := groupService.DeleteCharacter(currentUserID(c), uint(id)); err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterResponse{
+			Success: false,
+			Message: "删除角色失败: " + err.Error(),
+		})
+		return
 	}
-	if req.Personality != "" {
-		updates["personality"] = req.Personality
+
+	c.JSON(http.StatusOK, models.GroupCharacterResponse{
+		Success: true,
+		Message: "删除角色成功",
+	})
+}
+
+// ImportCharacterCardHandler 导入角色卡（Character Card V2 JSON 或嵌入 chara 文本块的PNG）
+// 表单字段：file 角色卡文件，gid 目标群组ID，model 模型（可选），avatar 头像（可选）
+func ImportCharacterCardHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.GroupCharacterResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
 	}
-	if req.Model != "" {
-		updates["model"] = req.Model
+
+	gid, err := strconv.ParseUint(c.PostForm("gid"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
+			Success: false,
+			Message: "无效的群组ID",
+		})
+		return
 	}
-	if req.Avatar != "" {
-		updates["avatar"] = req.Avatar
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
+			Success: false,
+			Message: "请上传角色卡文件",
+		})
+		return
 	}
-	if req.CustomPrompt != "" {
-		updates["custom_prompt"] = req.CustomPrompt
+	if fileHeader.Size > maxCharacterCardSize {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
+			Success: false,
+			Message: "角色卡文件不能超过5MB",
+		})
+		return
 	}
 
-	if len(updates) == 0 {
+	file, err := fileHeader.Open()
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
 			Success: false,
-			Message: "至少需要提供一个更新字段",
+			Message: "读取角色卡文件失败: " + err.Error(),
 		})
 		return
 	}
+	defer file.Close()
 
-	// 执行更新
-	if err := config.DB.Model(&character).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
+	data, err := io.ReadAll(io.LimitReader(file, maxCharacterCardSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
 			Success: false,
-			Message: "更新角色失败: " + err.Error(),
+			Message: "读取角色卡文件失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 重新获取更新后的数据
-	if err := config.DB.Preload("Group").First(&character, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
+	groupService := services.NewGroupService()
+	character, err := groupService.ImportCharacterCard(user.ID, uint(gid), data, c.PostForm("model"), c.PostForm("avatar"))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterResponse{
 			Success: false,
-			Message: "获取更新后的角色信息失败: " + err.Error(),
+			Message: "导入角色卡失败: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, models.GroupCharacterResponse{
 		Success: true,
-		Message: "更新角色成功",
-		Data:    &character,
+		Message: "导入角色卡成功",
+		Data:    character,
 	})
 }
 
-// DeleteCharacterHandler 删除角色
-func DeleteCharacterHandler(c *gin.Context) {
+// ExportCharacterCardHandler 导出角色卡，format=json（默认）或 png
+func ExportCharacterCardHandler(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
@@ -324,34 +342,44 @@ func DeleteCharacterHandler(c *gin.Context) {
 		return
 	}
 
-	// 检查角色是否存在
-	var character models.GroupCharacter
-	if err := config.DB.First(&character, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, models.GroupCharacterResponse{
-				Success: false,
-				Message: "角色不存在",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "png" {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
+			Success: false,
+			Message: "无效的format参数，可选值：json、png",
+		})
+		return
+	}
+
+	groupService := services.NewGroupService()
+	card, character, err := groupService.ExportCharacterCard(currentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterResponse{
 			Success: false,
-			Message: "查询角色失败: " + err.Error(),
+			Message: "导出角色卡失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 删除角色
-	if err := config.DB.Delete(&character).Error; err != nil {
+	var data []byte
+	var contentType string
+	if format == "png" {
+		data, err = services.EncodeCharacterCardPNG(card, services.LoadAvatarImage(character.Avatar))
+		contentType = "image/png"
+	} else {
+		data, err = json.MarshalIndent(card, "", "  ")
+		contentType = "application/json; charset=utf-8"
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.GroupCharacterResponse{
 			Success: false,
-			Message: "删除角色失败: " + err.Error(),
+			Message: "导出角色卡失败: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.GroupCharacterResponse{
-		Success: true,
-		Message: "删除角色成功",
-	})
+	filename := fmt.Sprintf("%s.%s", character.Name, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"character_%d.%s\"; filename*=UTF-8''%s",
+		character.ID, format, url.PathEscape(filename)))
+	c.Data(http.StatusOK, contentType, data)
 }
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"log"
 	"net/http"
 
 	"project/src/config"
+	"project/src/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,9 +14,20 @@ import (
 func InitHandler(c *gin.Context) {
 	// 从配置中获取需要暴露给前端的配置信息
 	initData := map[string]interface{}{
-		"models":     config.AppConfig.LLMModels,
-		"groups":     config.AppConfig.LLMGroups,
-		"characters": config.AppConfig.LLMCharacters,
+		"models": config.AppConfig.LLMModels,
+	}
+
+	// 群组目录包含系统群组和当前用户自己的群组
+	catalogService := services.NewCatalogService()
+	catalog, err := catalogService.GetCatalog(currentUserID(c))
+	if err != nil {
+		// 数据库不可用时退回到配置文件中的群组，保证前端可用
+		log.Printf("获取群组目录失败，使用配置文件中的群组: %v", err)
+		initData["groups"] = config.AppConfig.LLMGroups
+		initData["characters"] = config.AppConfig.LLMCharacters
+	} else {
+		initData["groups"] = catalog.Groups
+		initData["characters"] = catalog.Characters
 	}
 
 	// 如果存在用户信息，则添加到响应中
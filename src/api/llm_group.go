@@ -1,17 +1,31 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"project/src/config"
+	"net/url"
 	"project/src/models"
+	"project/src/repository"
+	"project/src/services"
+	"project/src/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // CreateGroupHandler 创建群组
 func CreateGroupHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.LlmGroupResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
 	var req models.LlmGroupCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
@@ -21,14 +35,10 @@ func CreateGroupHandler(c *gin.Context) {
 		return
 	}
 
-	// 创建群组
-	group := models.LlmGroup{
-		Name:        req.Name,
-		Description: req.Description,
-	}
-
-	if err := config.DB.Create(&group).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.LlmGroupResponse{
+	groupService := services.NewGroupService()
+	group, err := groupService.CreateGroup(user.ID, &req)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
 			Success: false,
 			Message: "创建群组失败: " + err.Error(),
 		})
@@ -38,47 +48,29 @@ func CreateGroupHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, models.LlmGroupResponse{
 		Success: true,
 		Message: "创建群组成功",
-		Data:    &group,
+		Data:    group,
 	})
 }
 
 // GetGroupsHandler 获取群组列表
+// scope=mine（默认）返回当前用户的群组，scope=public 返回所有公开群组
 func GetGroupsHandler(c *gin.Context) {
-	var groups []models.LlmGroup
-	var total int64
-
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
-	offset := (page - 1) * pageSize
-
-	// 搜索参数
-	name := c.Query("name")
+	page, pageSize := parsePagination(c)
+	scope := c.DefaultQuery("scope", services.GroupScopeMine)
 
-	// 构建查询
-	query := config.DB.Model(&models.LlmGroup{})
-	if name != "" {
-		query = query.Where("name LIKE ?", "%"+name+"%")
-	}
-
-	// 获取总数
-	if err := query.Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.LlmGroupListResponse{
+	userID := currentUserID(c)
+	if scope == services.GroupScopeMine && userID == 0 {
+		c.JSON(http.StatusUnauthorized, models.LlmGroupListResponse{
 			Success: false,
-			Message: "获取群组总数失败: " + err.Error(),
+			Message: "用户认证失败",
 		})
 		return
 	}
 
-	// 获取分页数据
-	if err := query.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&groups).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.LlmGroupListResponse{
+	groupService := services.NewGroupService()
+	groups, total, err := groupService.ListGroups(userID, scope, c.Query("name"), page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupListResponse{
 			Success: false,
 			Message: "获取群组列表失败: " + err.Error(),
 		})
@@ -104,16 +96,10 @@ func GetGroupHandler(c *gin.Context) {
 		return
 	}
 
-	var group models.LlmGroup
-	if err := config.DB.Preload("Characters").First(&group, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, models.LlmGroupResponse{
-				Success: false,
-				Message: "群组不存在",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.LlmGroupResponse{
+	groupService := services.NewGroupService()
+	group, err := groupService.GetGroup(currentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
 			Success: false,
 			Message: "获取群组详情失败: " + err.Error(),
 		})
@@ -123,11 +109,11 @@ func GetGroupHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, models.LlmGroupResponse{
 		Success: true,
 		Message: "获取群组详情成功",
-		Data:    &group,
+		Data:    group,
 	})
 }
 
-// UpdateGroupHandler 更新群组
+// UpdateGroupHandler 更新群组，PUT/PATCH 均按 JSON Merge Patch 语义处理
 func UpdateGroupHandler(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -147,67 +133,134 @@ func UpdateGroupHandler(c *gin.Context) {
 		return
 	}
 
-	// 检查群组是否存在
-	var group models.LlmGroup
-	if err := config.DB.First(&group, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, models.LlmGroupResponse{
-				Success: false,
-				Message: "群组不存在",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, models.LlmGroupResponse{
+	groupService := services.NewGroupService()
+	group, err := groupService.UpdateGroup(currentUserID(c), uint(id), &req)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
 			Success: false,
-			Message: "查询群组失败: " + err.Error(),
+			Message: "更新群组失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 更新字段
-	updates := make(map[string]interface{})
-	if req.Name != "" {
-		updates["name"] = req.Name
+	c.JSON(http.StatusOK, models.LlmGroupResponse{
+		Success: true,
+		Message: "更新群组成功",
+		Data:    group,
+	})
+}
+
+// DeleteGroupHandler 删除群组
+func DeleteGroupHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
+			Success: false,
+			Message: "无效的群组ID",
+		})
+		return
 	}
-	if req.Description != "" {
-		updates["description"] = req.Description
+
+	groupService := services.NewGroupService()
+	if err := groupService.DeleteGroup(currentUserID(c), uint(id)); err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
+			Success: false,
+			Message: "删除群组失败: " + err.Error(),
+		})
+		return
 	}
 
-	if len(updates) == 0 {
+	c.JSON(http.StatusOK, models.LlmGroupResponse{
+		Success: true,
+		Message: "删除群组成功",
+	})
+}
+
+// ExportGroupHandler 导出群组为JSON导出包（包含角色和定时发言设置）
+func ExportGroupHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
 			Success: false,
-			Message: "至少需要提供一个更新字段",
+			Message: "无效的群组ID",
 		})
 		return
 	}
 
-	// 执行更新
-	if err := config.DB.Model(&group).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.LlmGroupResponse{
+	groupService := services.NewGroupService()
+	bundle, err := groupService.ExportGroup(currentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
 			Success: false,
-			Message: "更新群组失败: " + err.Error(),
+			Message: "导出群组失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 重新获取更新后的数据
-	if err := config.DB.First(&group, id).Error; err != nil {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.LlmGroupResponse{
 			Success: false,
-			Message: "获取更新后的群组信息失败: " + err.Error(),
+			Message: "导出群组失败: " + err.Error(),
+		})
+		return
+	}
+
+	filename := bundle.Group.Name + ".json"
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"group_%d.json\"; filename*=UTF-8''%s",
+		id, url.PathEscape(filename)))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// ImportGroupHandler 从JSON导出包导入群组
+// on_conflict 指定同名群组的处理方式：rename（默认，自动重命名）、replace（覆盖）、fail（返回409）
+func ImportGroupHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.LlmGroupResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	var bundle models.GroupBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
+			Success: false,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	groupService := services.NewGroupService()
+	group, err := groupService.ImportGroup(user.ID, &bundle, c.Query("on_conflict"))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
+			Success: false,
+			Message: "导入群组失败: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, models.LlmGroupResponse{
 		Success: true,
-		Message: "更新群组成功",
-		Data:    &group,
+		Message: "导入群组成功",
+		Data:    group,
 	})
 }
 
-// DeleteGroupHandler 删除群组
-func DeleteGroupHandler(c *gin.Context) {
+// CloneGroupHandler 克隆群组及其角色到当前用户名下
+func CloneGroupHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.LlmGroupResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
@@ -217,35 +270,66 @@ func DeleteGroupHandler(c *gin.Context) {
 		return
 	}
 
-	// 检查群组是否存在
-	var group models.LlmGroup
-	if err := config.DB.First(&group, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, models.LlmGroupResponse{
+	// 请求体可选
+	var req models.GroupCloneRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
 				Success: false,
-				Message: "群组不存在",
+				Message: "请求参数错误: " + err.Error(),
 			})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, models.LlmGroupResponse{
-			Success: false,
-			Message: "查询群组失败: " + err.Error(),
-		})
-		return
 	}
 
-	// 删除群组（会级联删除相关角色）
-	if err := config.DB.Delete(&group).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.LlmGroupResponse{
+	groupService := services.NewGroupService()
+	group, err := groupService.CloneGroup(user.ID, uint(id), &req)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
 			Success: false,
-			Message: "删除群组失败: " + err.Error(),
+			Message: "克隆群组失败: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, models.LlmGroupResponse{
 		Success: true,
-		Message: "删除群组成功",
+		Message: "克隆群组成功",
+		Data:    group,
 	})
 }
 
+// groupErrorStatus 根据群组/角色业务错误返回对应的HTTP状态码
+func groupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrGroupNotFound),
+		errors.Is(err, repository.ErrCharacterNotFound),
+		errors.Is(err, repository.ErrRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGroupForbidden),
+		errors.Is(err, services.ErrCharacterForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrGroupNameExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrTooManyCharacters),
+		errors.Is(err, services.ErrInvalidModel),
+		errors.Is(err, services.ErrInvalidScope),
+		errors.Is(err, services.ErrEmptyUpdate),
+		errors.Is(err, services.ErrTargetGroupNotFound),
+		errors.Is(err, services.ErrInvalidCharacterCard),
+		errors.Is(err, services.ErrInvalidGroupBundle),
+		errors.Is(err, services.ErrInvalidImportConflict),
+		errors.Is(err, services.ErrTaskLimitExceeded),
+		errors.Is(err, utils.ErrInvalidCronExpr),
+		errors.Is(err, services.ErrInvalidCategory),
+		errors.Is(err, services.ErrInvalidSort),
+		errors.Is(err, repository.ErrInvalidReactionType),
+		errors.Is(err, services.ErrInvalidRevisionEntity),
+		errors.Is(err, services.ErrGroupDeleted),
+		errors.Is(err, services.ErrNameRequired),
+		errors.Is(err, services.ErrInvalidBatch):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
-	"project/src/config"
+	"project/src/constants"
+	"project/src/middleware"
 	"project/src/models"
 	"project/src/services"
 	"project/src/utils"
@@ -26,10 +27,10 @@ func LoginHandler(c *gin.Context) {
 	}
 
 	// 创建用户服务
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+	userService := services.NewUserService(kvService)
 
 	// 执行登录
-	userData, err := userService.Login(req.Phone, req.Code)
+	userData, err := userService.Login(req.Phone, req.Code, loginClient(c, constants.LoginTypePhone))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.UserLoginResponse{
 			Success: false,
@@ -55,9 +56,6 @@ type SendCodeRequest struct {
 type SendCodeResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
-	Data    *struct {
-		Code string `json:"code,omitempty"` // 仅在开发环境返回
-	} `json:"data,omitempty"`
 }
 
 // SendCodeHandler 发送验证码处理器
@@ -81,6 +79,15 @@ func SendCodeHandler(c *gin.Context) {
 		return
 	}
 
+	// 检查发送频率限制
+	if err := services.NewSMSPolicy(kvService).AllowSend(req.Phone, middleware.GetRealClientIP(c)); err != nil {
+		c.JSON(smsPolicyErrorStatus(err), SendCodeResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	// 生成6位随机验证码
 	verificationCode, err := generateVerificationCode()
 	if err != nil {
@@ -91,45 +98,29 @@ func SendCodeHandler(c *gin.Context) {
 		return
 	}
 
-	// 创建短信服务
-	smsService := services.NewSMSService(config.AppConfig.SMS)
-
-	// 发送短信验证码
-	if err := smsService.SendSMS(req.Phone, verificationCode); err != nil {
+	// 先将验证码存储到KV中再发送，存储失败时不发送
+	userService := services.NewUserService(kvService)
+	if err := userService.SetSMSCode(req.Phone, verificationCode); err != nil {
 		c.JSON(http.StatusInternalServerError, SendCodeResponse{
 			Success: false,
-			Message: "短信发送失败: " + err.Error(),
+			Message: "存储验证码失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 创建用户服务并将验证码存储到KV中
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
-	if err := userService.SetSMSCode(req.Phone, verificationCode); err != nil {
+	// 发送短信验证码
+	if err := smsService.SendSMS(req.Phone, verificationCode); err != nil {
 		c.JSON(http.StatusInternalServerError, SendCodeResponse{
 			Success: false,
-			Message: "存储验证码失败: " + err.Error(),
+			Message: "短信发送失败: " + err.Error(),
 		})
 		return
 	}
 
-	// 构建响应
-	response := SendCodeResponse{
+	c.JSON(http.StatusOK, SendCodeResponse{
 		Success: true,
 		Message: "验证码发送成功",
-	}
-
-	// 在开发环境下返回验证码（仅用于测试）
-	// 可以通过环境变量或配置来控制
-	if config.AppConfig.Server.Port == "8080" { // 简单的开发环境判断，可以改为更精确的判断
-		response.Data = &struct {
-			Code string `json:"code,omitempty"`
-		}{
-			Code: verificationCode,
-		}
-	}
-
-	c.JSON(http.StatusOK, response)
+	})
 }
 
 // generateVerificationCode 生成6位随机验证码
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/models"
+	"project/src/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthProvidersHandler 获取已启用的第三方登录方式
+func OAuthProvidersHandler(c *gin.Context) {
+	oauthService := services.NewOAuthService(kvService)
+	c.JSON(http.StatusOK, models.OAuthProviderListResponse{
+		Success: true,
+		Message: "获取第三方登录方式成功",
+		Data:    oauthService.Providers(),
+	})
+}
+
+// OAuthAuthorizeHandler 生成第三方登录授权地址，前端跳转到该地址完成授权
+func OAuthAuthorizeHandler(c *gin.Context) {
+	oauthService := services.NewOAuthService(kvService)
+	data, err := oauthService.AuthorizeURL(c.Param("provider"))
+	if err != nil {
+		c.JSON(oauthErrorStatus(err), models.OAuthAuthorizeResponse{
+			Success: false,
+			Message: "生成授权地址失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OAuthAuthorizeResponse{
+		Success: true,
+		Message: "生成授权地址成功",
+		Data:    data,
+	})
+}
+
+// OAuthCallbackHandler 第三方登录回调，前端回调页将地址中的 code 和 state 提交到此接口换取登录令牌
+func OAuthCallbackHandler(c *gin.Context) {
+	var req models.OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.UserLoginResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	provider := c.Param("provider")
+	oauthService := services.NewOAuthService(kvService)
+	userData, err := oauthService.Login(provider, req.Code, req.State, loginClient(c, provider))
+	if err != nil {
+		c.JSON(oauthErrorStatus(err), models.UserLoginResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserLoginResponse{
+		Success: true,
+		Message: "登录成功",
+		Data:    userData,
+	})
+}
+
+// oauthErrorStatus 根据第三方登录相关错误返回对应的HTTP状态码
+func oauthErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrOAuthProviderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidOAuthState):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOAuthFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, services.ErrUserDisabled):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+	"project/src/models"
+	"project/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListGroupRevisionsHandler 获取群组的修订记录
+func ListGroupRevisionsHandler(c *gin.Context) {
+	listRevisions(c, models.RevisionEntityGroup)
+}
+
+// DiffGroupRevisionsHandler 比较群组的两个版本，from、to 为版本号
+func DiffGroupRevisionsHandler(c *gin.Context) {
+	diffRevisions(c, models.RevisionEntityGroup)
+}
+
+// RollbackGroupRevisionHandler 将群组回滚到指定版本
+func RollbackGroupRevisionHandler(c *gin.Context) {
+	rollbackRevision(c, models.RevisionEntityGroup)
+}
+
+// ListCharacterRevisionsHandler 获取角色的修订记录
+func ListCharacterRevisionsHandler(c *gin.Context) {
+	listRevisions(c, models.RevisionEntityCharacter)
+}
+
+// DiffCharacterRevisionsHandler 比较角色的两个版本，from、to 为版本号
+func DiffCharacterRevisionsHandler(c *gin.Context) {
+	diffRevisions(c, models.RevisionEntityCharacter)
+}
+
+// RollbackCharacterRevisionHandler 将角色回滚到指定版本
+func RollbackCharacterRevisionHandler(c *gin.Context) {
+	rollbackRevision(c, models.RevisionEntityCharacter)
+}
+
+// listRevisions 获取群组或角色的修订记录
+func listRevisions(c *gin.Context, entityType string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.RevisionListResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	page, pageSize := parsePagination(c)
+
+	groupService := services.NewGroupService()
+	revisions, total, err := groupService.ListRevisions(currentUserID(c), entityType, uint(id), page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.RevisionListResponse{
+			Success: false,
+			Message: "获取修订记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevisionListResponse{
+		Success: true,
+		Message: "获取修订记录成功",
+		Data:    revisions,
+		Total:   total,
+	})
+}
+
+// diffRevisions 比较群组或角色的两个版本
+func diffRevisions(c *gin.Context, entityType string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.RevisionDiffResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	from, fromErr := strconv.Atoi(c.Query("from"))
+	to, toErr := strconv.Atoi(c.Query("to"))
+	if fromErr != nil || toErr != nil {
+		c.JSON(http.StatusBadRequest, models.RevisionDiffResponse{
+			Success: false,
+			Message: "请指定要比较的版本号from和to",
+		})
+		return
+	}
+
+	groupService := services.NewGroupService()
+	diff, err := groupService.DiffRevisions(currentUserID(c), entityType, uint(id), from, to)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.RevisionDiffResponse{
+			Success: false,
+			Message: "比较版本失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevisionDiffResponse{
+		Success: true,
+		Message: "比较版本成功",
+		Data:    diff,
+	})
+}
+
+// rollbackRevision 将群组或角色回滚到指定版本
+func rollbackRevision(c *gin.Context, entityType string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.RevisionResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.RevisionResponse{
+			Success: false,
+			Message: "无效的版本号",
+		})
+		return
+	}
+
+	groupService := services.NewGroupService()
+	revision, err := groupService.RollbackRevision(currentUserID(c), entityType, uint(id), version)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.RevisionResponse{
+			Success: false,
+			Message: "回滚失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevisionResponse{
+		Success: true,
+		Message: "回滚成功",
+		Data:    revision,
+	})
+}
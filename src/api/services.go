@@ -0,0 +1,19 @@
+package api
+
+import "project/src/services"
+
+// 应用共享的服务实例，由 main 在注册路由前注入
+var (
+	kvService  services.KVService
+	smsService services.SMSService
+)
+
+// SetKVService 注入应用共享的KV存储服务，所有处理函数共用同一个连接池
+func SetKVService(kv services.KVService) {
+	kvService = kv
+}
+
+// SetSMSService 注入应用共享的短信服务，所有处理函数共用同一组服务商实例
+func SetSMSService(sms services.SMSService) {
+	smsService = sms
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"project/src/config"
+	"project/src/models"
 	"project/src/services"
 	"project/src/utils"
 
@@ -58,9 +60,6 @@ func SendSMSHandler(c *gin.Context) {
 		return
 	}
 
-	// 创建SMS服务
-	smsService := services.NewSMSService(config.AppConfig.SMS)
-
 	// 发送短信
 	if err := smsService.SendSMS(req.Phone, req.Code); err != nil {
 		c.JSON(http.StatusInternalServerError, SMSResponse{
@@ -97,9 +96,6 @@ func SendSMSWithTemplateHandler(c *gin.Context) {
 		return
 	}
 
-	// 创建SMS服务
-	smsService := services.NewSMSService(config.AppConfig.SMS)
-
 	// 发送短信
 	if err := smsService.SendSMSWithTemplate(req.Phone, req.TemplateCode, req.TemplateParam); err != nil {
 		c.JSON(http.StatusInternalServerError, SMSResponse{
@@ -114,3 +110,34 @@ func SendSMSWithTemplateHandler(c *gin.Context) {
 		Message: "短信发送成功",
 	})
 }
+
+// SMSInboxHandler 开发环境短信收件箱，查看 mock 服务商保存的短信，只在开发模式下注册
+// 查询参数 phone 为空时返回所有手机号的短信
+func SMSInboxHandler(c *gin.Context) {
+	if !config.AppConfig.DevMode {
+		c.JSON(http.StatusNotFound, models.SMSInboxResponse{
+			Success: false,
+			Message: "开发模式未开启",
+		})
+		return
+	}
+
+	inbox, err := services.NewSMSInbox(kvService).List(c.Query("phone"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, utils.ErrInvalidPhone) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.SMSInboxResponse{
+			Success: false,
+			Message: "读取短信收件箱失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SMSInboxResponse{
+		Success: true,
+		Message: "获取成功",
+		Data:    inbox,
+	})
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/services"
+	"project/src/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTaskHandler 创建定时任务
+func CreateTaskHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.TaskResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	var req models.TaskCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.TaskResponse{
+			Success: false,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	taskService := services.NewTaskService()
+	task, err := taskService.CreateTask(user.ID, &req)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), models.TaskResponse{
+			Success: false,
+			Message: "创建定时任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TaskResponse{
+		Success: true,
+		Message: "创建定时任务成功",
+		Data:    task,
+	})
+}
+
+// GetTasksHandler 获取当前用户的定时任务列表
+func GetTasksHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.TaskListResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	// 分页参数
+	page, pageSize := parsePagination(c)
+
+	taskService := services.NewTaskService()
+	tasks, total, err := taskService.ListTasks(user.ID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.TaskListResponse{
+			Success: false,
+			Message: "获取定时任务列表失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TaskListResponse{
+		Success: true,
+		Message: "获取定时任务列表成功",
+		Data:    tasks,
+		Total:   total,
+	})
+}
+
+// GetTaskHandler 获取单个定时任务详情
+func GetTaskHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.TaskResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TaskResponse{
+			Success: false,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	taskService := services.NewTaskService()
+	task, err := taskService.GetTask(user.ID, uint(id))
+	if err != nil {
+		c.JSON(taskErrorStatus(err), models.TaskResponse{
+			Success: false,
+			Message: "获取定时任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TaskResponse{
+		Success: true,
+		Message: "获取定时任务成功",
+		Data:    task,
+	})
+}
+
+// UpdateTaskHandler 更新定时任务
+func UpdateTaskHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.TaskResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TaskResponse{
+			Success: false,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	var req models.TaskUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.TaskResponse{
+			Success: false,
+			Message: "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	taskService := services.NewTaskService()
+	task, err := taskService.UpdateTask(user.ID, uint(id), &req)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), models.TaskResponse{
+			Success: false,
+			Message: "更新定时任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TaskResponse{
+		Success: true,
+		Message: "更新定时任务成功",
+		Data:    task,
+	})
+}
+
+// DeleteTaskHandler 删除定时任务
+func DeleteTaskHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.TaskResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TaskResponse{
+			Success: false,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	taskService := services.NewTaskService()
+	if err := taskService.DeleteTask(user.ID, uint(id)); err != nil {
+		c.JSON(taskErrorStatus(err), models.TaskResponse{
+			Success: false,
+			Message: "删除定时任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TaskResponse{
+		Success: true,
+		Message: "删除定时任务成功",
+	})
+}
+
+// GetTaskExecutionsHandler 获取定时任务执行记录
+func GetTaskExecutionsHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.TaskExecutionListResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TaskExecutionListResponse{
+			Success: false,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	// 分页参数
+	page, pageSize := parsePagination(c)
+
+	taskService := services.NewTaskService()
+	executions, total, err := taskService.ListExecutions(user.ID, uint(id), page, pageSize)
+	if err != nil {
+		c.JSON(taskErrorStatus(err), models.TaskExecutionListResponse{
+			Success: false,
+			Message: "获取执行记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TaskExecutionListResponse{
+		Success: true,
+		Message: "获取执行记录成功",
+		Data:    executions,
+		Total:   total,
+	})
+}
+
+// RunTaskHandler 立即执行一次定时任务
+func RunTaskHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.TaskExecutionResponse{
+			Success: false,
+			Message: "用户认证失败",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TaskExecutionResponse{
+			Success: false,
+			Message: "无效的任务ID",
+		})
+		return
+	}
+
+	taskService := services.NewTaskService()
+	task, err := taskService.GetTask(user.ID, uint(id))
+	if err != nil {
+		c.JSON(taskErrorStatus(err), models.TaskExecutionResponse{
+			Success: false,
+			Message: "获取定时任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	runner := services.NewTaskRunner(kvService)
+	execution, err := runner.ExecuteTask(task, models.TaskTriggerManual)
+	if execution == nil {
+		c.JSON(http.StatusInternalServerError, models.TaskExecutionResponse{
+			Success: false,
+			Message: "执行定时任务失败: " + err.Error(),
+		})
+		return
+	}
+
+	// 执行失败时同样返回执行记录，便于前端展示错误日志
+	message := "执行定时任务成功"
+	if err != nil {
+		message = "执行定时任务失败: " + err.Error()
+	}
+	c.JSON(http.StatusOK, models.TaskExecutionResponse{
+		Success: err == nil,
+		Message: message,
+		Data:    execution,
+	})
+}
+
+// taskErrorStatus 根据定时任务业务错误返回对应的HTTP状态码
+func taskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrTaskForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrTaskLimitExceeded),
+		errors.Is(err, services.ErrTaskGroupNotFound),
+		errors.Is(err, services.ErrTaskCharacterNotFit),
+		errors.Is(err, utils.ErrInvalidCronExpr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"project/src/models"
+	"project/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeletedGroupsHandler 获取当前用户回收站中的群组
+func GetDeletedGroupsHandler(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	groupService := services.NewGroupService()
+	groups, total, err := groupService.ListDeletedGroups(currentUserID(c), page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupListResponse{
+			Success: false,
+			Message: "获取回收站群组失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LlmGroupListResponse{
+		Success: true,
+		Message: "获取回收站群组成功",
+		Data:    groups,
+		Total:   total,
+	})
+}
+
+// GetDeletedCharactersHandler 获取当前用户回收站中单独删除的角色
+func GetDeletedCharactersHandler(c *gin.Context) {
+	page, pageSize := parsePagination(c)
+
+	groupService := services.NewGroupService()
+	characters, total, err := groupService.ListDeletedCharacters(currentUserID(c), page, pageSize)
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterListResponse{
+			Success: false,
+			Message: "获取回收站角色失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GroupCharacterListResponse{
+		Success: true,
+		Message: "获取回收站角色成功",
+		Data:    characters,
+		Total:   total,
+	})
+}
+
+// RestoreGroupHandler 从回收站恢复群组及随其删除的角色
+func RestoreGroupHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.LlmGroupResponse{
+			Success: false,
+			Message: "无效的群组ID",
+		})
+		return
+	}
+
+	groupService := services.NewGroupService()
+	group, err := groupService.RestoreGroup(currentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.LlmGroupResponse{
+			Success: false,
+			Message: "恢复群组失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LlmGroupResponse{
+		Success: true,
+		Message: "恢复群组成功",
+		Data:    group,
+	})
+}
+
+// RestoreCharacterHandler 从回收站恢复角色
+func RestoreCharacterHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.GroupCharacterResponse{
+			Success: false,
+			Message: "无效的角色ID",
+		})
+		return
+	}
+
+	groupService := services.NewGroupService()
+	character, err := groupService.RestoreCharacter(currentUserID(c), uint(id))
+	if err != nil {
+		c.JSON(groupErrorStatus(err), models.GroupCharacterResponse{
+			Success: false,
+			Message: "恢复角色失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GroupCharacterResponse{
+		Success: true,
+		Message: "恢复角色成功",
+		Data:    character,
+	})
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"net/http"
-	"project/src/config"
 	"project/src/models"
 	"project/src/services"
 
@@ -29,6 +28,27 @@ type UserUpdateResponse struct {
 	Data    *models.User `json:"data,omitempty"`
 }
 
+// currentUser 从认证中间件设置的上下文中获取当前登录用户
+func currentUser(c *gin.Context) (*models.User, bool) {
+	userInterface, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+	user, ok := userInterface.(*models.User)
+	if !ok || user == nil {
+		return nil, false
+	}
+	return user, true
+}
+
+// currentUserID 获取当前登录用户ID，未登录时返回0
+func currentUserID(c *gin.Context) uint {
+	if user, ok := currentUser(c); ok {
+		return user.ID
+	}
+	return 0
+}
+
 // UserInfoHandler 获取用户信息处理器
 func UserInfoHandler(c *gin.Context) {
 	// 从认证中间件中获取用户信息
@@ -101,7 +121,7 @@ func UserUpdateHandler(c *gin.Context) {
 	}
 
 	// 创建用户服务
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+	userService := services.NewUserService(kvService)
 
 	// 更新昵称（如果提供了）
 	if req.Nickname != "" {
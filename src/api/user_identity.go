@@ -0,0 +1,206 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/services"
+	"project/src/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListIdentitiesHandler 获取当前用户绑定的登录身份（手机号、微信）
+func ListIdentitiesHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.UserIdentityListResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	identities, err := userService.ListIdentities(user.ID)
+	if err != nil {
+		c.JSON(identityErrorStatus(err), models.UserIdentityListResponse{
+			Success: false,
+			Message: "获取登录身份失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserIdentityListResponse{
+		Success: true,
+		Message: "获取登录身份成功",
+		Data:    identities,
+	})
+}
+
+// BindPhoneHandler 使用短信验证码为当前用户绑定手机号
+func BindPhoneHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.UserIdentityResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	var req models.BindPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.UserIdentityResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+	if !utils.IsValidPhone(req.Phone) {
+		c.JSON(http.StatusBadRequest, models.UserIdentityResponse{
+			Success: false,
+			Message: "手机号格式无效",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	identity, err := userService.BindPhone(user.ID, req.Phone, req.Code)
+	if err != nil {
+		c.JSON(identityErrorStatus(err), models.UserIdentityResponse{
+			Success: false,
+			Message: "绑定手机号失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserIdentityResponse{
+		Success: true,
+		Message: "绑定手机号成功",
+		Data:    identity,
+	})
+}
+
+// BindWechatQRCodeHandler 生成绑定微信的二维码，扫码结果通过 /api/auth/wechat/status/:session_id 查询
+func BindWechatQRCodeHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.WechatLoginResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	sessionService := services.NewSessionService(kvService)
+	qrService := services.NewWechatQRService(sessionService, kvService)
+
+	qrData, err := qrService.GenerateBindQRCode(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.WechatLoginResponse{
+			Success: false,
+			Message: "生成二维码失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WechatLoginResponse{
+		Success: true,
+		Message: "二维码生成成功",
+		Data:    qrData,
+	})
+}
+
+// UnbindIdentityHandler 解绑当前用户的登录身份
+func UnbindIdentityHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.UserIdentityResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.UserIdentityResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.UnbindIdentity(user.ID, uint(id)); err != nil {
+		c.JSON(identityErrorStatus(err), models.UserIdentityResponse{
+			Success: false,
+			Message: "解绑登录身份失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserIdentityResponse{
+		Success: true,
+		Message: "解绑登录身份成功",
+	})
+}
+
+// MergeAccountHandler 将另一个账号合并到当前账号，需提供另一个账号的访问令牌
+func MergeAccountHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.AccountMergeResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	var req models.MergeAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.AccountMergeResponse{
+			Success: false,
+			Message: "请求参数无效: " + err.Error(),
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	result, err := userService.MergeAccount(user.ID, req.SourceToken)
+	if err != nil {
+		c.JSON(identityErrorStatus(err), models.AccountMergeResponse{
+			Success: false,
+			Message: "合并账号失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AccountMergeResponse{
+		Success: true,
+		Message: "合并账号成功",
+		Data:    result,
+	})
+}
+
+// identityErrorStatus 根据登录身份和账号合并相关错误返回对应的HTTP状态码
+func identityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrIdentityNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrIdentityBoundToOther),
+		errors.Is(err, services.ErrIdentityAlreadyBound):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrLastIdentity),
+		errors.Is(err, services.ErrMergeSameAccount),
+		errors.Is(err, services.ErrInvalidSMSCode),
+		errors.Is(err, services.ErrSMSCodeLocked),
+		errors.Is(err, services.ErrInvalidMergeToken):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
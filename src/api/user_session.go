@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentSessionID 获取当前请求所属的登录会话ID，升级前签发的令牌没有会话ID
+func currentSessionID(c *gin.Context) string {
+	return c.GetString("session_id")
+}
+
+// ListSessionsHandler 获取当前用户已登录的设备（登录会话）列表
+func ListSessionsHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.UserSessionListResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	sessions, err := userService.ListSessions(user.ID, currentSessionID(c))
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), models.UserSessionListResponse{
+			Success: false,
+			Message: "获取登录会话失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserSessionListResponse{
+		Success: true,
+		Message: "获取登录会话成功",
+		Data:    sessions,
+	})
+}
+
+// RevokeSessionHandler 吊销指定登录会话，该设备需要重新登录
+func RevokeSessionHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.UserSessionRevokeResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.UserSessionRevokeResponse{
+			Success: false,
+			Message: "无效的ID",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	if err := userService.RevokeUserSession(user.ID, uint(id)); err != nil {
+		c.JSON(sessionErrorStatus(err), models.UserSessionRevokeResponse{
+			Success: false,
+			Message: "吊销登录会话失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserSessionRevokeResponse{
+		Success: true,
+		Message: "吊销登录会话成功",
+		Revoked: 1,
+	})
+}
+
+// RevokeOtherSessionsHandler 吊销当前设备以外的全部登录会话
+func RevokeOtherSessionsHandler(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.UserSessionRevokeResponse{
+			Success: false,
+			Message: "用户未认证",
+		})
+		return
+	}
+
+	userService := services.NewUserService(kvService)
+	revoked, err := userService.RevokeOtherSessions(user.ID, currentSessionID(c))
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), models.UserSessionRevokeResponse{
+			Success: false,
+			Message: "吊销登录会话失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserSessionRevokeResponse{
+		Success: true,
+		Message: "已退出其他设备",
+		Revoked: revoked,
+	})
+}
+
+// sessionErrorStatus 根据登录会话相关错误返回对应的HTTP状态码
+func sessionErrorStatus(err error) int {
+	if errors.Is(err, repository.ErrUserSessionNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"reflect"
+
+	"project/src/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterValidators 注册请求参数校验规则
+// PatchField 字段按其新值校验，未提供或为 null 时视为空值，由 omitempty 跳过
+func RegisterValidators() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterCustomTypeFunc(patchFieldValue,
+		models.PatchField[string]{},
+		models.PatchField[[]string]{},
+		models.PatchField[int]{},
+	)
+}
+
+// patchFieldValue 返回 PatchField 中待校验的值
+func patchFieldValue(field reflect.Value) interface{} {
+	switch f := field.Interface().(type) {
+	case models.PatchField[string]:
+		if f.Set && !f.Null {
+			return f.Value
+		}
+	case models.PatchField[[]string]:
+		if f.Set && !f.Null {
+			return f.Value
+		}
+	case models.PatchField[int]:
+		if f.Set && !f.Null {
+			return f.Value
+		}
+	}
+	return nil
+}
@@ -3,7 +3,7 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"project/src/config"
+	"project/src/constants"
 	"project/src/models"
 	"project/src/services"
 
@@ -20,9 +20,6 @@ func WechatQRCodeHandler(c *gin.Context) {
 		req = models.WechatLoginRequest{}
 	}
 
-	// 创建Redis服务
-	kvService := services.NewKVService(config.AppConfig.Redis)
-
 	// 创建会话服务
 	sessionService := services.NewSessionService(kvService)
 
@@ -72,9 +69,8 @@ func handleWechatVerification(c *gin.Context) {
 	echostr := c.Query("echostr")
 
 	// 创建服务
-	kvService := services.NewKVService(config.AppConfig.Redis)
 	sessionService := services.NewSessionService(kvService)
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+	userService := services.NewUserService(kvService)
 	callbackService := services.NewWechatCallbackService(sessionService, userService)
 
 	// 验证签名
@@ -95,9 +91,8 @@ func handleWechatCallback(c *gin.Context) {
 	nonce := c.Query("nonce")
 
 	// 创建服务
-	kvService := services.NewKVService(config.AppConfig.Redis)
 	sessionService := services.NewSessionService(kvService)
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+	userService := services.NewUserService(kvService)
 	callbackService := services.NewWechatCallbackService(sessionService, userService)
 
 	// 验证签名
@@ -135,7 +130,6 @@ func WechatLoginStatusHandler(c *gin.Context) {
 	}
 
 	// 创建服务
-	kvService := services.NewKVService(config.AppConfig.Redis)
 	sessionService := services.NewSessionService(kvService)
 
 	// 获取会话信息
@@ -157,8 +151,18 @@ func WechatLoginStatusHandler(c *gin.Context) {
 			"message": "等待扫码",
 		})
 	case "success":
+		// 绑定微信的二维码不签发令牌，网页端已登录
+		if session.BindUserID != 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"success": true,
+				"status":  "success",
+				"message": "绑定成功",
+			})
+			return
+		}
+
 		// 获取用户信息
-		userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+		userService := services.NewUserService(kvService)
 		user, err := userService.GetUserByID(session.UserID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -168,12 +172,12 @@ func WechatLoginStatusHandler(c *gin.Context) {
 			return
 		}
 
-		// 生成新的JWT token
-		token, expiresIn, err := userService.GenerateJWTToken(user.ID)
+		// 签发访问令牌和刷新令牌
+		userData, err := userService.IssueTokens(user, loginClient(c, constants.LoginTypeWechat))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
-				"message": "生成token失败",
+				"message": "生成token失败: " + err.Error(),
 			})
 			return
 		}
@@ -188,8 +192,9 @@ func WechatLoginStatusHandler(c *gin.Context) {
 					"nickname": user.Nickname,
 					"avatar":   user.AvatarURL,
 				},
-				"token":      token,
-				"expires_in": expiresIn,
+				"token":         userData.Token,
+				"refresh_token": userData.RefreshToken,
+				"expires_in":    userData.ExpiresIn,
 			},
 		})
 	case "expired":
@@ -198,6 +203,12 @@ func WechatLoginStatusHandler(c *gin.Context) {
 			"status":  "expired",
 			"message": "会话已过期，请重新扫码",
 		})
+	case constants.SessionStatusFailed:
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"status":  constants.SessionStatusFailed,
+			"message": session.Message,
+		})
 	default:
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -216,17 +227,8 @@ func WebSocketHandler(c *gin.Context) {
 	wsService.HandleWebSocket(c.Writer, c.Request)
 }
 
-// WechatLoginTestHandler 测试微信登录流程（仅开发环境使用）
+// WechatLoginTestHandler 测试微信登录流程（仅管理员）
 func WechatLoginTestHandler(c *gin.Context) {
-	// 检查是否为开发环境
-	if config.AppConfig.Server.Port != "8080" { // 生产环境通常不使用8080端口
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "此接口仅在开发环境可用",
-		})
-		return
-	}
-
 	// 获取参数
 	sessionID := c.Query("session_id")
 	openID := c.Query("openid")
@@ -240,12 +242,11 @@ func WechatLoginTestHandler(c *gin.Context) {
 	}
 
 	// 创建服务
-	kvService := services.NewKVService(config.AppConfig.Redis)
 	sessionService := services.NewSessionService(kvService)
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+	userService := services.NewUserService(kvService)
 
 	// 模拟微信登录
-	userData, err := userService.LoginWithWechat(openID, "测试用户", "", "test_scene")
+	userData, err := userService.LoginWithWechat(openID, "测试用户", "", "test_scene", loginClient(c, constants.LoginTypeWechat))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -274,19 +275,9 @@ func WechatLoginTestHandler(c *gin.Context) {
 	})
 }
 
-// WechatTokenDebugHandler 微信Token调试接口（仅开发环境）
+// WechatTokenDebugHandler 微信Token调试接口（仅管理员）
 func WechatTokenDebugHandler(c *gin.Context) {
-	// 检查是否为开发环境
-	if config.AppConfig.Server.Port != "8080" { // 生产环境通常不使用8080端口
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "此接口仅在开发环境可用",
-		})
-		return
-	}
-
 	// 创建服务
-	kvService := services.NewKVService(config.AppConfig.Redis)
 	sessionService := services.NewSessionService(kvService)
 	qrService := services.NewWechatQRService(sessionService, kvService)
 
@@ -323,17 +314,8 @@ func WechatTokenDebugHandler(c *gin.Context) {
 	})
 }
 
-// WechatCallbackSimulateHandler 模拟微信回调接口（仅开发环境）
+// WechatCallbackSimulateHandler 模拟微信回调接口（仅管理员）
 func WechatCallbackSimulateHandler(c *gin.Context) {
-	// 检查是否为开发环境
-	if config.AppConfig.Server.Port != "8080" {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"message": "此接口仅在开发环境可用",
-		})
-		return
-	}
-
 	var req struct {
 		QRScene  string `json:"qr_scene" binding:"required"`
 		OpenID   string `json:"openid"`
@@ -358,9 +340,8 @@ func WechatCallbackSimulateHandler(c *gin.Context) {
 	}
 
 	// 创建服务
-	kvService := services.NewKVService(config.AppConfig.Redis)
 	sessionService := services.NewSessionService(kvService)
-	userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+	userService := services.NewUserService(kvService)
 	wsService := services.NewWebSocketService()
 
 	// 查找会话
@@ -382,7 +363,7 @@ func WechatCallbackSimulateHandler(c *gin.Context) {
 	}
 
 	// 执行登录
-	userData, err := userService.LoginWithWechat(req.OpenID, req.Nickname, req.Avatar, req.QRScene)
+	userData, err := userService.LoginWithWechat(req.OpenID, req.Nickname, req.Avatar, req.QRScene, loginClient(c, constants.LoginTypeWechat))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"project/src/config"
+)
+
+// 令牌校验错误
+var (
+	ErrTokenMalformed   = errors.New("token格式错误")
+	ErrTokenAlgorithm   = errors.New("token签名算法不受支持")
+	ErrTokenUnknownKey  = errors.New("token签名密钥不存在")
+	ErrTokenSignature   = errors.New("token签名无效")
+	ErrTokenExpired     = errors.New("token已过期")
+	ErrTokenNotYetValid = errors.New("token尚未生效")
+	ErrTokenIssuer      = errors.New("token签发者不匹配")
+	ErrTokenAudience    = errors.New("token受众不匹配")
+)
+
+// TokenIssuer 访问令牌签发和校验接口
+type TokenIssuer interface {
+	// Issue 签发令牌，claims 中的 sub、sid 由调用方填写，iss、aud、jti、iat、exp 自动填充
+	Issue(claims Claims, ttl time.Duration) (string, error)
+	// Validate 校验令牌的签名、算法、签发者、受众和有效期
+	Validate(token string) (*Claims, error)
+	// Parse 与 Validate 相同但不检查有效期，用于退出登录等需要识别已过期令牌的场景
+	Parse(token string) (*Claims, error)
+	// JWKS 返回可公开的验证公钥（仅 RS256/EdDSA 密钥，HMAC 密钥不会公开）
+	JWKS() JWKSet
+}
+
+// Claims 令牌声明
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+	SessionID string   `json:"sid,omitempty"` // 登录会话ID，退出登录后该会话签发的访问令牌失效
+	IssuedAt  int64    `json:"iat"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// Audience 令牌受众，按 RFC 7519 既可以是单个字符串也可以是字符串数组
+type Audience []string
+
+// MarshalJSON 只有一个受众时输出为字符串
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON 同时支持字符串和字符串数组
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// Contains 判断受众中是否包含指定值
+func (a Audience) Contains(audience string) bool {
+	for _, v := range a {
+		if v == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenIssuer 全局令牌签发器
+var tokenIssuer TokenIssuer
+
+// InitTokenIssuer 按应用配置初始化全局令牌签发器，密钥配置有误时返回错误
+func InitTokenIssuer() error {
+	issuer, err := NewTokenIssuer(config.AppConfig.Auth, config.AppConfig.JWTSecret)
+	if err != nil {
+		return err
+	}
+	tokenIssuer = issuer
+	return nil
+}
+
+// GetTokenIssuer 获取全局令牌签发器
+func GetTokenIssuer() TokenIssuer {
+	return tokenIssuer
+}
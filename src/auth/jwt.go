@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"project/src/config"
+)
+
+// header JWT 头部
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// legacyClaims 引入 kid 之前签发的令牌负载
+type legacyClaims struct {
+	UserID    string `json:"userId"`
+	SessionID string `json:"sid,omitempty"`
+	Exp       int64  `json:"exp"`
+	Iat       int64  `json:"iat"`
+}
+
+// jwtIssuer 基于 JWT 的令牌签发器，按 kid 选择校验密钥，支持多个密钥同时生效以便轮换
+type jwtIssuer struct {
+	issuer   string
+	audience string
+	signing  *key
+	keys     map[string]*key
+	ordered  []*key
+	legacy   *key // 校验不带 kid 的旧令牌，未开启兼容时为 nil
+}
+
+// NewTokenIssuer 按配置创建令牌签发器
+// 未配置 keys 时使用 jwtSecret 作为唯一的 HS256 密钥；开启 accept_legacy_tokens 时，
+// 不带 kid 的旧令牌使用 jwtSecret 校验
+func NewTokenIssuer(cfg config.AuthConfig, jwtSecret string) (TokenIssuer, error) {
+	keyConfigs := cfg.Keys
+	if len(keyConfigs) == 0 {
+		if jwtSecret == "" {
+			return nil, fmt.Errorf("未配置令牌签名密钥")
+		}
+		keyConfigs = []config.AuthKeyConfig{{ID: "default", Algorithm: AlgHS256, Secret: jwtSecret}}
+	}
+
+	i := &jwtIssuer{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		keys:     make(map[string]*key, len(keyConfigs)),
+	}
+	for _, keyConfig := range keyConfigs {
+		k, err := loadKey(keyConfig)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := i.keys[k.id]; ok {
+			return nil, fmt.Errorf("签名密钥 %s 重复", k.id)
+		}
+		i.keys[k.id] = k
+		i.ordered = append(i.ordered, k)
+	}
+
+	signingKeyID := cfg.SigningKey
+	if signingKeyID == "" {
+		signingKeyID = i.ordered[0].id
+	}
+	signing, ok := i.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("签发密钥 %s 不存在", signingKeyID)
+	}
+	if !signing.canSign() {
+		return nil, fmt.Errorf("签发密钥 %s 未配置私钥", signingKeyID)
+	}
+	i.signing = signing
+
+	if cfg.AcceptLegacyTokens && jwtSecret != "" {
+		i.legacy = &key{alg: AlgHS256, secret: []byte(jwtSecret)}
+	}
+	return i, nil
+}
+
+// Issue 使用当前签发密钥签发令牌
+func (i *jwtIssuer) Issue(claims Claims, ttl time.Duration) (string, error) {
+	id, err := randomID()
+	if err != nil {
+		return "", fmt.Errorf("生成令牌ID失败: %v", err)
+	}
+
+	now := time.Now()
+	claims.Issuer = i.issuer
+	claims.Audience = nil
+	if i.audience != "" {
+		claims.Audience = Audience{i.audience}
+	}
+	claims.ID = id
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+
+	headerJSON, err := json.Marshal(header{Alg: i.signing.alg, Typ: "JWT", Kid: i.signing.id})
+	if err != nil {
+		return "", err
+	}
+	payloadJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(headerJSON) + "." + encodeSegment(payloadJSON)
+	signature, err := i.signing.sign([]byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("签名失败: %v", err)
+	}
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Validate 校验令牌并检查有效期
+func (i *jwtIssuer) Validate(token string) (*Claims, error) {
+	claims, err := i.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	if now > claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return nil, ErrTokenNotYetValid
+	}
+	return claims, nil
+}
+
+// Parse 校验令牌的签名、算法、签发者和受众，不检查有效期
+func (i *jwtIssuer) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var h header
+	if err := decodeSegmentJSON(parts[0], &h); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	if h.Kid == "" {
+		return i.parseLegacy(h, parts, signature)
+	}
+
+	k, ok := i.keys[h.Kid]
+	if !ok {
+		return nil, ErrTokenUnknownKey
+	}
+	// 算法以密钥为准，拒绝 alg 与密钥不一致（包括 none）的令牌
+	if h.Alg != k.alg {
+		return nil, ErrTokenAlgorithm
+	}
+	if !k.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrTokenSignature
+	}
+
+	var claims Claims
+	if err := decodeSegmentJSON(parts[1], &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if i.issuer != "" && claims.Issuer != i.issuer {
+		return nil, ErrTokenIssuer
+	}
+	if i.audience != "" && !claims.Audience.Contains(i.audience) {
+		return nil, ErrTokenAudience
+	}
+	return &claims, nil
+}
+
+// JWKS 返回所有非对称密钥的公钥
+func (i *jwtIssuer) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range i.ordered {
+		if jwk, ok := k.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// parseLegacy 校验不带 kid 的旧令牌，旧令牌没有签发者和受众，用户ID保存在 userId 中
+func (i *jwtIssuer) parseLegacy(h header, parts []string, signature []byte) (*Claims, error) {
+	if i.legacy == nil {
+		return nil, ErrTokenUnknownKey
+	}
+	if h.Alg != AlgHS256 {
+		return nil, ErrTokenAlgorithm
+	}
+	if !i.legacy.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrTokenSignature
+	}
+
+	var legacy legacyClaims
+	if err := decodeSegmentJSON(parts[1], &legacy); err != nil || legacy.UserID == "" {
+		return nil, ErrTokenMalformed
+	}
+	return &Claims{
+		Subject:   legacy.UserID,
+		SessionID: legacy.SessionID,
+		IssuedAt:  legacy.Iat,
+		ExpiresAt: legacy.Exp,
+	}, nil
+}
+
+// encodeSegment Base64URL 编码（无填充）
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSegment Base64URL 解码（无填充）
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(segment)
+}
+
+// decodeSegmentJSON 解码 Base64URL 编码的 JSON
+func decodeSegmentJSON(segment string, v interface{}) error {
+	data, err := decodeSegment(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// randomID 生成令牌ID
+func randomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return encodeSegment(buf), nil
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"project/src/config"
+)
+
+func mustTokenIssuer(t *testing.T, cfg config.AuthConfig) TokenIssuer {
+	t.Helper()
+	issuer, err := NewTokenIssuer(cfg, "legacy-secret")
+	if err != nil {
+		t.Fatalf("创建令牌签发器失败: %v", err)
+	}
+	return issuer
+}
+
+func mustIssue(t *testing.T, issuer TokenIssuer, ttl time.Duration) string {
+	t.Helper()
+	token, err := issuer.Issue(Claims{Subject: "42", SessionID: "s1"}, ttl)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	return token
+}
+
+// writePEM 将密钥写入临时 PEM 文件
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("写入密钥文件失败: %v", err)
+	}
+	return path
+}
+
+// signSegments 用指定头部和负载手工构造 HS256 令牌
+func signSegments(t *testing.T, h, payload interface{}, secret string) string {
+	t.Helper()
+	headerJSON, _ := json.Marshal(h)
+	payloadJSON, _ := json.Marshal(payload)
+	input := encodeSegment(headerJSON) + "." + encodeSegment(payloadJSON)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + encodeSegment(mac.Sum(nil))
+}
+
+func TestIssueAndValidate(t *testing.T) {
+	issuer := mustTokenIssuer(t, config.AuthConfig{
+		Issuer:   "botgroup-chat",
+		Audience: "botgroup-chat",
+		Keys:     []config.AuthKeyConfig{{ID: "k1", Algorithm: AlgHS256, Secret: "secret-1"}},
+	})
+	token := mustIssue(t, issuer, time.Minute)
+
+	claims, err := issuer.Validate(token)
+	if err != nil {
+		t.Fatalf("校验令牌失败: %v", err)
+	}
+	if claims.Subject != "42" || claims.SessionID != "s1" || claims.Issuer != "botgroup-chat" ||
+		!claims.Audience.Contains("botgroup-chat") || claims.ID == "" {
+		t.Errorf("令牌声明不完整: %+v", claims)
+	}
+
+	other := mustIssue(t, issuer, time.Minute)
+	otherClaims, _ := issuer.Validate(other)
+	if otherClaims.ID == claims.ID {
+		t.Errorf("每个令牌的jti应不同")
+	}
+
+	// 篡改负载后签名校验失败
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(Claims{Subject: "1", Issuer: "botgroup-chat", Audience: Audience{"botgroup-chat"}, ExpiresAt: claims.ExpiresAt})
+	if _, err := issuer.Validate(parts[0] + "." + encodeSegment(forged) + "." + parts[2]); !errors.Is(err, ErrTokenSignature) {
+		t.Errorf("篡改负载应返回ErrTokenSignature，实际为%v", err)
+	}
+	if _, err := issuer.Validate("not-a-token"); !errors.Is(err, ErrTokenMalformed) {
+		t.Errorf("格式错误应返回ErrTokenMalformed，实际为%v", err)
+	}
+}
+
+func TestValidateRejectsAlgorithmMismatch(t *testing.T) {
+	issuer := mustTokenIssuer(t, config.AuthConfig{
+		Keys: []config.AuthKeyConfig{{ID: "k1", Algorithm: AlgHS256, Secret: "secret-1"}},
+	})
+	payload := Claims{Subject: "42", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+
+	none := signSegments(t, header{Alg: "none", Kid: "k1"}, payload, "secret-1")
+	if _, err := issuer.Validate(none); !errors.Is(err, ErrTokenAlgorithm) {
+		t.Errorf("alg=none应被拒绝，实际为%v", err)
+	}
+	unknown := signSegments(t, header{Alg: AlgHS256, Kid: "k9"}, payload, "secret-1")
+	if _, err := issuer.Validate(unknown); !errors.Is(err, ErrTokenUnknownKey) {
+		t.Errorf("未知kid应被拒绝，实际为%v", err)
+	}
+}
+
+func TestValidateClaims(t *testing.T) {
+	keys := []config.AuthKeyConfig{{ID: "k1", Algorithm: AlgHS256, Secret: "secret-1"}}
+	issuer := mustTokenIssuer(t, config.AuthConfig{Issuer: "a", Audience: "web", Keys: keys})
+
+	expired := mustIssue(t, issuer, -time.Minute)
+	if _, err := issuer.Validate(expired); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("过期令牌应返回ErrTokenExpired，实际为%v", err)
+	}
+	if _, err := issuer.Parse(expired); err != nil {
+		t.Errorf("Parse不应检查有效期，实际为%v", err)
+	}
+
+	otherIssuer := mustTokenIssuer(t, config.AuthConfig{Issuer: "b", Audience: "web", Keys: keys})
+	if _, err := issuer.Validate(mustIssue(t, otherIssuer, time.Minute)); !errors.Is(err, ErrTokenIssuer) {
+		t.Errorf("签发者不匹配应被拒绝，实际为%v", err)
+	}
+	otherAudience := mustTokenIssuer(t, config.AuthConfig{Issuer: "a", Audience: "admin", Keys: keys})
+	if _, err := issuer.Validate(mustIssue(t, otherAudience, time.Minute)); !errors.Is(err, ErrTokenAudience) {
+		t.Errorf("受众不匹配应被拒绝，实际为%v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldIssuer := mustTokenIssuer(t, config.AuthConfig{
+		Keys: []config.AuthKeyConfig{{ID: "k1", Algorithm: AlgHS256, Secret: "secret-1"}},
+	})
+	oldToken := mustIssue(t, oldIssuer, time.Minute)
+
+	// 加入新密钥并切换签发密钥，旧密钥签发的令牌仍然有效
+	rotated := mustTokenIssuer(t, config.AuthConfig{
+		SigningKey: "k2",
+		Keys: []config.AuthKeyConfig{
+			{ID: "k1", Algorithm: AlgHS256, Secret: "secret-1"},
+			{ID: "k2", Algorithm: AlgHS256, Secret: "secret-2"},
+		},
+	})
+	if _, err := rotated.Validate(oldToken); err != nil {
+		t.Errorf("轮换后旧密钥签发的令牌应仍然有效: %v", err)
+	}
+	newToken := mustIssue(t, rotated, time.Minute)
+	if _, err := oldIssuer.Validate(newToken); !errors.Is(err, ErrTokenUnknownKey) {
+		t.Errorf("新令牌应使用新密钥签发，实际为%v", err)
+	}
+
+	// 移除旧密钥后旧令牌失效
+	retired := mustTokenIssuer(t, config.AuthConfig{
+		Keys: []config.AuthKeyConfig{{ID: "k2", Algorithm: AlgHS256, Secret: "secret-2"}},
+	})
+	if _, err := retired.Validate(oldToken); !errors.Is(err, ErrTokenUnknownKey) {
+		t.Errorf("移除旧密钥后旧令牌应失效，实际为%v", err)
+	}
+}
+
+func TestAsymmetricKeysAndJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成Ed25519密钥失败: %v", err)
+	}
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	edPublicDER, _ := x509.MarshalPKIXPublicKey(edKey.Public())
+
+	keys := []config.AuthKeyConfig{
+		{ID: "hs", Algorithm: AlgHS256, Secret: "secret-1"},
+		{ID: "rs", Algorithm: AlgRS256, PrivateKeyFile: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))},
+		{ID: "ed", Algorithm: AlgEdDSA, PrivateKeyFile: writePEM(t, "PRIVATE KEY", edDER)},
+	}
+	for _, signingKey := range []string{"rs", "ed"} {
+		issuer := mustTokenIssuer(t, config.AuthConfig{SigningKey: signingKey, Keys: keys})
+		claims, err := issuer.Validate(mustIssue(t, issuer, time.Minute))
+		if err != nil || claims.Subject != "42" {
+			t.Errorf("%s 签发的令牌校验失败: %v", signingKey, err)
+		}
+
+		jwks := issuer.JWKS()
+		if len(jwks.Keys) != 2 || jwks.Keys[0].KeyID != "rs" || jwks.Keys[0].N == "" || jwks.Keys[1].Curve != "Ed25519" {
+			t.Errorf("JWKS应只包含非对称公钥，实际为%+v", jwks)
+		}
+	}
+
+	// 只有公钥的密钥可以校验但不能签发
+	verifyOnly := []config.AuthKeyConfig{
+		{ID: "hs", Algorithm: AlgHS256, Secret: "secret-1"},
+		{ID: "ed", Algorithm: AlgEdDSA, PublicKeyFile: writePEM(t, "PUBLIC KEY", edPublicDER)},
+	}
+	edIssuer := mustTokenIssuer(t, config.AuthConfig{SigningKey: "ed", Keys: keys})
+	verifier := mustTokenIssuer(t, config.AuthConfig{Keys: verifyOnly})
+	if _, err := verifier.Validate(mustIssue(t, edIssuer, time.Minute)); err != nil {
+		t.Errorf("公钥应可校验令牌: %v", err)
+	}
+	if _, err := NewTokenIssuer(config.AuthConfig{SigningKey: "ed", Keys: verifyOnly}, ""); err == nil {
+		t.Errorf("只有公钥的密钥不能作为签发密钥")
+	}
+
+	// 密钥与算法不一致时拒绝加载
+	mismatch := []config.AuthKeyConfig{{ID: "rs", Algorithm: AlgEdDSA, PrivateKeyFile: keys[1].PrivateKeyFile}}
+	if _, err := NewTokenIssuer(config.AuthConfig{Keys: mismatch}, ""); err == nil {
+		t.Errorf("RSA密钥配置为EdDSA算法应加载失败")
+	}
+}
+
+func TestLegacyTokens(t *testing.T) {
+	legacy := signSegments(t, header{Alg: AlgHS256, Typ: "JWT"}, legacyClaims{
+		UserID: "42",
+		Exp:    time.Now().Add(time.Minute).Unix(),
+		Iat:    time.Now().Unix(),
+	}, "legacy-secret")
+
+	accepting := mustTokenIssuer(t, config.AuthConfig{Issuer: "a", Audience: "web", AcceptLegacyTokens: true})
+	claims, err := accepting.Validate(legacy)
+	if err != nil || claims.Subject != "42" {
+		t.Fatalf("开启兼容时应接受旧令牌: %+v, %v", claims, err)
+	}
+	forged := signSegments(t, header{Alg: AlgHS256}, legacyClaims{UserID: "1", Exp: claims.ExpiresAt}, "wrong")
+	if _, err := accepting.Validate(forged); !errors.Is(err, ErrTokenSignature) {
+		t.Errorf("签名错误的旧令牌应被拒绝，实际为%v", err)
+	}
+
+	rejecting := mustTokenIssuer(t, config.AuthConfig{})
+	if _, err := rejecting.Validate(legacy); !errors.Is(err, ErrTokenUnknownKey) {
+		t.Errorf("未开启兼容时应拒绝旧令牌，实际为%v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"project/src/config"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minRSAKeyBits RS256 密钥的最小长度
+const minRSAKeyBits = 2048
+
+// JWK 单个验证公钥（RFC 7517）
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet 验证公钥集合
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// key 签名密钥，HS256 使用 secret，RS256/EdDSA 使用 private/public
+// 只配置了公钥的非对称密钥仅用于校验轮换前签发的令牌
+type key struct {
+	id      string
+	alg     string
+	secret  []byte
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// canSign 是否可以用于签发令牌
+func (k *key) canSign() bool {
+	return k.alg == AlgHS256 || k.private != nil
+}
+
+// sign 对签名输入计算签名
+func (k *key) sign(data []byte) ([]byte, error) {
+	switch k.alg {
+	case AlgHS256:
+		h := hmac.New(sha256.New, k.secret)
+		h.Write(data)
+		return h.Sum(nil), nil
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return k.private.Sign(rand.Reader, digest[:], crypto.SHA256)
+	case AlgEdDSA:
+		return k.private.Sign(rand.Reader, data, crypto.Hash(0))
+	default:
+		return nil, ErrTokenAlgorithm
+	}
+}
+
+// verify 校验签名，HMAC 使用常量时间比较
+func (k *key) verify(data, signature []byte) bool {
+	switch k.alg {
+	case AlgHS256:
+		expected, _ := k.sign(data)
+		return hmac.Equal(signature, expected)
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k.public.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	case AlgEdDSA:
+		return ed25519.Verify(k.public.(ed25519.PublicKey), data, signature)
+	default:
+		return false
+	}
+}
+
+// jwk 返回可公开的公钥，HMAC 密钥不公开
+func (k *key) jwk() (JWK, bool) {
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType:   "RSA",
+			KeyID:     k.id,
+			Use:       "sig",
+			Algorithm: k.alg,
+			N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType:   "OKP",
+			KeyID:     k.id,
+			Use:       "sig",
+			Algorithm: k.alg,
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(public),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// loadKey 按配置加载签名密钥
+func loadKey(cfg config.AuthKeyConfig) (*key, error) {
+	if cfg.ID == "" {
+		return nil, fmt.Errorf("签名密钥缺少kid")
+	}
+	k := &key{id: cfg.ID, alg: cfg.Algorithm}
+
+	switch cfg.Algorithm {
+	case AlgHS256:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("签名密钥 %s 缺少secret", cfg.ID)
+		}
+		k.secret = []byte(cfg.Secret)
+		return k, nil
+	case AlgRS256, AlgEdDSA:
+	default:
+		return nil, fmt.Errorf("签名密钥 %s 的算法 %q 不受支持，可选 HS256、RS256、EdDSA", cfg.ID, cfg.Algorithm)
+	}
+
+	switch {
+	case cfg.PrivateKeyFile != "":
+		private, err := readPrivateKey(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取签名密钥 %s 失败: %v", cfg.ID, err)
+		}
+		k.private = private
+		k.public = private.Public()
+	case cfg.PublicKeyFile != "":
+		public, err := readPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取签名密钥 %s 失败: %v", cfg.ID, err)
+		}
+		k.public = public
+	default:
+		return nil, fmt.Errorf("签名密钥 %s 缺少private_key_file或public_key_file", cfg.ID)
+	}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		if k.alg != AlgRS256 {
+			return nil, fmt.Errorf("签名密钥 %s 是RSA密钥，算法应为RS256", cfg.ID)
+		}
+		if public.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("签名密钥 %s 长度不足%d位", cfg.ID, minRSAKeyBits)
+		}
+	case ed25519.PublicKey:
+		if k.alg != AlgEdDSA {
+			return nil, fmt.Errorf("签名密钥 %s 是Ed25519密钥，算法应为EdDSA", cfg.ID)
+		}
+	default:
+		return nil, fmt.Errorf("签名密钥 %s 的类型不受支持", cfg.ID)
+	}
+	return k, nil
+}
+
+// readPrivateKey 读取 PEM 格式私钥（PKCS#1 或 PKCS#8）
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if private, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return private, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("私钥类型不受支持")
+	}
+	return signer, nil
+}
+
+// readPublicKey 读取 PEM 格式公钥（PKIX 或 PKCS#1）
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if public, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return public, nil
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %v", err)
+	}
+	return public, nil
+}
+
+// readPEM 读取 PEM 文件中的第一个块
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s 不是PEM格式", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// ErrTokenNonce ID Token 的 nonce 与授权请求不一致
+var ErrTokenNonce = errors.New("token nonce不匹配")
+
+// IDTokenClaims 第三方 OIDC 提供商签发的 ID Token 声明
+type IDTokenClaims struct {
+	Subject       string   `json:"sub"`
+	Issuer        string   `json:"iss"`
+	Audience      Audience `json:"aud"`
+	Nonce         string   `json:"nonce,omitempty"`
+	IssuedAt      int64    `json:"iat"`
+	ExpiresAt     int64    `json:"exp"`
+	Name          string   `json:"name,omitempty"`
+	Picture       string   `json:"picture,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+}
+
+// VerifyIDToken 使用提供商公布的 JWKS 校验 ID Token 的签名、签发者、受众、nonce 和有效期
+// 目前只支持 RS256；JWKS 中找不到 kid 时返回 ErrTokenUnknownKey，调用方可以重新获取 JWKS 后重试
+func VerifyIDToken(token string, keys JWKSet, issuer, clientID, nonce string, now time.Time) (*IDTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var h header
+	if err := decodeSegmentJSON(parts[0], &h); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if h.Alg != AlgRS256 {
+		return nil, ErrTokenAlgorithm
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	k, err := keys.rsaKey(h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if !k.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrTokenSignature
+	}
+
+	var claims IDTokenClaims
+	if err := decodeSegmentJSON(parts[1], &claims); err != nil || claims.Subject == "" {
+		return nil, ErrTokenMalformed
+	}
+	if claims.Issuer != issuer {
+		return nil, ErrTokenIssuer
+	}
+	if !claims.Audience.Contains(clientID) {
+		return nil, ErrTokenAudience
+	}
+	if claims.Nonce != nonce {
+		return nil, ErrTokenNonce
+	}
+	if now.Unix() > claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+// rsaKey 按 kid 查找 RSA 公钥，kid 为空且只有一个 RSA 公钥时使用该公钥
+func (s JWKSet) rsaKey(kid string) (*key, error) {
+	var found *JWK
+	for i := range s.Keys {
+		jwk := &s.Keys[i]
+		if jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		if jwk.KeyID == kid {
+			found = jwk
+			break
+		}
+		if kid == "" {
+			if found != nil {
+				return nil, ErrTokenUnknownKey
+			}
+			found = jwk
+		}
+	}
+	if found == nil {
+		return nil, ErrTokenUnknownKey
+	}
+
+	n, err := decodeSegment(found.N)
+	if err != nil {
+		return nil, ErrTokenUnknownKey
+	}
+	e, err := decodeSegment(found.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, ErrTokenUnknownKey
+	}
+	public := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+	if public.N.BitLen() < minRSAKeyBits {
+		return nil, ErrTokenUnknownKey
+	}
+	return &key{id: found.KeyID, alg: AlgRS256, public: public}, nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"project/src/config"
+)
+
+func TestVerifyIDToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+	keys := []config.AuthKeyConfig{
+		{ID: "rs", Algorithm: AlgRS256, PrivateKeyFile: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))},
+	}
+	// 用本服务的签发器模拟第三方提供商签发 ID Token
+	provider := mustTokenIssuer(t, config.AuthConfig{Issuer: "https://idp.example.com", Audience: "client-1", Keys: keys})
+	token := mustIssue(t, provider, time.Minute)
+	now := time.Now()
+
+	claims, err := VerifyIDToken(token, provider.JWKS(), "https://idp.example.com", "client-1", "", now)
+	if err != nil || claims.Subject != "42" {
+		t.Fatalf("ID Token校验失败: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		jwks     JWKSet
+		issuer   string
+		clientID string
+		nonce    string
+		now      time.Time
+		want     error
+	}{
+		{"签发者不匹配", provider.JWKS(), "https://other.example.com", "client-1", "", now, ErrTokenIssuer},
+		{"受众不匹配", provider.JWKS(), "https://idp.example.com", "client-2", "", now, ErrTokenAudience},
+		{"nonce不匹配", provider.JWKS(), "https://idp.example.com", "client-1", "nonce", now, ErrTokenNonce},
+		{"已过期", provider.JWKS(), "https://idp.example.com", "client-1", "", now.Add(time.Hour), ErrTokenExpired},
+		{"公钥不存在", JWKSet{}, "https://idp.example.com", "client-1", "", now, ErrTokenUnknownKey},
+	}
+	for _, tc := range cases {
+		if _, err := VerifyIDToken(token, tc.jwks, tc.issuer, tc.clientID, tc.nonce, tc.now); !errors.Is(err, tc.want) {
+			t.Errorf("%s: 期望%v，实际为%v", tc.name, tc.want, err)
+		}
+	}
+
+	// HS256 令牌不能作为第三方 ID Token
+	hmacIssuer := mustTokenIssuer(t, config.AuthConfig{Issuer: "https://idp.example.com", Audience: "client-1"})
+	if _, err := VerifyIDToken(mustIssue(t, hmacIssuer, time.Minute), provider.JWKS(), "https://idp.example.com", "client-1", "", now); !errors.Is(err, ErrTokenAlgorithm) {
+		t.Errorf("HS256令牌应返回ErrTokenAlgorithm，实际为%v", err)
+	}
+}
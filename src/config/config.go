@@ -44,12 +44,83 @@ type AliyunSMSConfig struct {
 	TemplateCode    string `mapstructure:"template_code" json:"template_code"`
 }
 
+// SMSConfig 短信配置结构，阿里云配置沿用 sms 下原有的键
+// 发送时按手机号国家码匹配 routes 中最长的前缀，依次尝试其中的服务商，前一个失败时自动切换到下一个；
+// 没有匹配的路由时使用 providers
+type SMSConfig struct {
+	AliyunSMSConfig `mapstructure:",squash"`
+	Providers       []string         `mapstructure:"providers" json:"providers"` // 默认服务商顺序：aliyun、tencent、twilio、console
+	Routes          []SMSRouteConfig `mapstructure:"routes" json:"routes"`
+	Tencent         TencentSMSConfig `mapstructure:"tencent" json:"tencent"`
+	Twilio          TwilioSMSConfig  `mapstructure:"twilio" json:"twilio"`
+	Policy          SMSPolicyConfig  `mapstructure:"policy" json:"policy"`
+}
+
+// SMSPolicyConfig 短信发送频率和验证码校验次数限制
+type SMSPolicyConfig struct {
+	ResendInterval    int `mapstructure:"resend_interval" json:"resend_interval"`         // 同一手机号重新发送的间隔(秒)
+	PhoneDailyLimit   int `mapstructure:"phone_daily_limit" json:"phone_daily_limit"`     // 每个手机号每天最多发送条数
+	IPDailyLimit      int `mapstructure:"ip_daily_limit" json:"ip_daily_limit"`           // 每个IP每天最多发送条数
+	GlobalHourlyLimit int `mapstructure:"global_hourly_limit" json:"global_hourly_limit"` // 全站每小时最多发送条数，超过后暂停发送直到下一小时
+	CodeMaxAttempts   int `mapstructure:"code_max_attempts" json:"code_max_attempts"`     // 验证码最多校验次数，错误次数达到后验证码失效
+}
+
+// SMSRouteConfig 按国家码路由的短信服务商配置
+type SMSRouteConfig struct {
+	Prefixes  []string `mapstructure:"prefixes" json:"prefixes"`   // 国家码前缀，如 "+86"、"+852"
+	Providers []string `mapstructure:"providers" json:"providers"` // 按顺序尝试的服务商
+}
+
+// TencentSMSConfig 腾讯云短信配置结构
+type TencentSMSConfig struct {
+	SecretID   string `mapstructure:"secret_id" json:"secret_id"`
+	SecretKey  string `mapstructure:"secret_key" json:"secret_key"`
+	Region     string `mapstructure:"region" json:"region"`
+	SdkAppID   string `mapstructure:"sdk_app_id" json:"sdk_app_id"`
+	SignName   string `mapstructure:"sign_name" json:"sign_name"`
+	TemplateID string `mapstructure:"template_id" json:"template_id"` // 验证码模板ID，模板第一个参数为验证码
+}
+
+// TwilioSMSConfig Twilio短信配置结构
+type TwilioSMSConfig struct {
+	AccountSID          string `mapstructure:"account_sid" json:"account_sid"`
+	AuthToken           string `mapstructure:"auth_token" json:"auth_token"`
+	From                string `mapstructure:"from" json:"from"`                                   // 发送号码，与 messaging_service_sid 二选一
+	MessagingServiceSID string `mapstructure:"messaging_service_sid" json:"messaging_service_sid"` // 消息服务SID
+	CodeTemplate        string `mapstructure:"code_template" json:"code_template"`                 // 验证码短信正文，${code} 替换为验证码
+}
+
+// CaptchaConfig 人机验证码配置结构
+type CaptchaConfig struct {
+	Mode      string   `mapstructure:"mode" json:"mode"`             // random 从 types 中随机选择；risk 按风险评估结果从 easy_types 或 hard_types 中选择
+	Types     []string `mapstructure:"types" json:"types"`           // random 模式可用的类型：click、click_shape、slide、drag、rotate
+	EasyTypes []string `mapstructure:"easy_types" json:"easy_types"` // risk 模式要求简单验证时使用的类型
+	HardTypes []string `mapstructure:"hard_types" json:"hard_types"` // risk 模式要求困难验证时使用的类型
+}
+
+// RiskConfig 风险评估配置结构，按IP请求频率、人机验证失败率、User-Agent 和设备指纹计算风险分
+// 风险分低于 easy_score 时不需要人机验证，达到 hard_score 时需要困难的人机验证
+type RiskConfig struct {
+	Enabled           bool   `mapstructure:"enabled" json:"enabled"`                         // 关闭后发送短信始终需要人机验证，匿名聊天不需要
+	EasyScore         int    `mapstructure:"easy_score" json:"easy_score"`                   // 风险分达到该值时需要简单的人机验证
+	HardScore         int    `mapstructure:"hard_score" json:"hard_score"`                   // 风险分达到该值时需要困难的人机验证
+	VelocityWindow    int    `mapstructure:"velocity_window" json:"velocity_window"`         // 请求频率统计窗口(秒)
+	SMSVelocityLimit  int    `mapstructure:"sms_velocity_limit" json:"sms_velocity_limit"`   // 窗口内每个IP发送短信验证码的请求数上限
+	ChatVelocityLimit int    `mapstructure:"chat_velocity_limit" json:"chat_velocity_limit"` // 窗口内每个IP匿名聊天的请求数上限
+	DeviceHeader      string `mapstructure:"device_header" json:"device_header"`             // 前端上报设备指纹的请求头
+	DeviceIPLimit     int    `mapstructure:"device_ip_limit" json:"device_ip_limit"`         // 每个IP一小时内出现的设备数上限
+	VerifiedTTL       int    `mapstructure:"verified_ttl" json:"verified_ttl"`               // 匿名聊天通过人机验证后免验证的时间(秒)
+}
+
 // RedisConfig Redis配置结构
 type RedisConfig struct {
-	Host     string `mapstructure:"host" json:"host"`
-	Port     string `mapstructure:"port" json:"port"`
-	Password string `mapstructure:"password" json:"password"`
-	DB       int    `mapstructure:"db" json:"db"`
+	Host         string `mapstructure:"host" json:"host"`
+	Port         string `mapstructure:"port" json:"port"`
+	Password     string `mapstructure:"password" json:"password"`
+	DB           int    `mapstructure:"db" json:"db"`
+	PoolSize     int    `mapstructure:"pool_size" json:"pool_size"`           // 连接池最大连接数
+	MinIdleConns int    `mapstructure:"min_idle_conns" json:"min_idle_conns"` // 连接池保持的最少空闲连接数
+	DegradedMode string `mapstructure:"degraded_mode" json:"degraded_mode"`   // Redis不可用时的降级策略：memory 或 fail_closed
 }
 
 // CloudflareConfig Cloudflare配置结构
@@ -76,6 +147,77 @@ type WebSocketConfig struct {
 	CheckOrigin     bool `mapstructure:"check_origin" json:"check_origin"`
 }
 
+// SchedulerConfig 定时任务调度配置结构
+type SchedulerConfig struct {
+	Enabled         bool `mapstructure:"enabled" json:"enabled"`                       // 是否启用定时任务调度
+	TickInterval    int  `mapstructure:"tick_interval" json:"tick_interval"`           // 调度轮询间隔(秒)
+	LockTTL         int  `mapstructure:"lock_ttl" json:"lock_ttl"`                     // 调度主节点锁过期时间(秒)
+	MaxTasksPerUser int  `mapstructure:"max_tasks_per_user" json:"max_tasks_per_user"` // 每个用户最多可创建的任务数
+}
+
+// GroupConfig 用户自建群组配置结构
+type GroupConfig struct {
+	MaxCharactersPerGroup int      `mapstructure:"max_characters_per_group" json:"max_characters_per_group"` // 每个群组最多可添加的角色数
+	Categories            []string `mapstructure:"categories" json:"categories"`                             // 公开目录的群组分类，为空时不限制
+	TrashRetentionDays    int      `mapstructure:"trash_retention_days" json:"trash_retention_days"`         // 回收站保留天数，超期后彻底删除，0表示不清理
+	TrashPurgeInterval    int      `mapstructure:"trash_purge_interval" json:"trash_purge_interval"`         // 回收站清理检查间隔(分钟)
+}
+
+// AuthConfig 访问令牌签发配置结构
+type AuthConfig struct {
+	Issuer             string          `mapstructure:"issuer" json:"issuer"`                             // 令牌签发者(iss)，为空时不校验
+	Audience           string          `mapstructure:"audience" json:"audience"`                         // 令牌受众(aud)，为空时不校验
+	SigningKey         string          `mapstructure:"signing_key" json:"signing_key"`                   // 当前用于签发的密钥kid，为空时使用第一个密钥
+	Keys               []AuthKeyConfig `mapstructure:"keys" json:"keys"`                                 // 签名密钥，为空时使用 jwt_secret
+	AcceptLegacyTokens bool            `mapstructure:"accept_legacy_tokens" json:"accept_legacy_tokens"` // 是否接受不带kid、由 jwt_secret 签发的旧令牌
+}
+
+// AuthKeyConfig 令牌签名密钥配置结构
+// 轮换密钥时先加入新密钥并切换 signing_key，旧密钥保留到其签发的令牌全部过期后再移除
+type AuthKeyConfig struct {
+	ID             string `mapstructure:"kid" json:"kid"`
+	Algorithm      string `mapstructure:"alg" json:"alg"`                           // HS256、RS256 或 EdDSA
+	Secret         string `mapstructure:"secret" json:"-"`                          // HS256 密钥
+	PrivateKeyFile string `mapstructure:"private_key_file" json:"private_key_file"` // RS256/EdDSA 私钥文件(PEM)
+	PublicKeyFile  string `mapstructure:"public_key_file" json:"public_key_file"`   // 只用于校验的 RS256/EdDSA 公钥文件(PEM)
+}
+
+// OAuthConfig 第三方登录（OAuth2/OIDC）配置结构
+type OAuthConfig struct {
+	StateTTL  int                   `mapstructure:"state_ttl" json:"state_ttl"` // 授权请求 state 有效期(秒)
+	Providers []OAuthProviderConfig `mapstructure:"providers" json:"providers"` // 登录提供商，为空时不启用第三方登录
+}
+
+// OAuthProviderConfig 第三方登录提供商配置结构
+// type 为 github 时使用 GitHub 的固定端点；google 和 oidc 通过 issuer 的 /.well-known/openid-configuration 发现端点
+type OAuthProviderConfig struct {
+	Name         string   `mapstructure:"name" json:"name"`                   // 提供商标识，用于接口路径和登录身份类型，如 github、google、keycloak
+	Type         string   `mapstructure:"type" json:"type"`                   // github、google 或 oidc
+	DisplayName  string   `mapstructure:"display_name" json:"display_name"`   // 登录按钮上显示的名称
+	ClientID     string   `mapstructure:"client_id" json:"client_id"`         // 客户端ID
+	ClientSecret string   `mapstructure:"client_secret" json:"client_secret"` // 客户端密钥
+	Issuer       string   `mapstructure:"issuer" json:"issuer"`               // OIDC 签发者，google 默认 https://accounts.google.com
+	RedirectURL  string   `mapstructure:"redirect_url" json:"redirect_url"`   // 授权回调地址，需与提供商后台登记的一致
+	Scopes       []string `mapstructure:"scopes" json:"scopes"`               // 申请的权限范围，为空时使用提供商默认值
+}
+
+// EmailConfig 邮件发送配置结构
+type EmailConfig struct {
+	Driver      string     `mapstructure:"driver" json:"driver"`               // smtp、file 或 log，默认 log（只打印到日志，用于开发环境）
+	From        string     `mapstructure:"from" json:"from"`                   // 发件人，如 "群聊 <noreply@example.com>"
+	LinkBaseURL string     `mapstructure:"link_base_url" json:"link_base_url"` // 邮件中链接指向的前端地址，如 https://your-domain.com
+	OutputDir   string     `mapstructure:"output_dir" json:"output_dir"`       // file 驱动保存邮件的目录
+	SMTP        SMTPConfig `mapstructure:"smtp" json:"smtp"`
+}
+
+// SMTPConfig SMTP服务器配置结构，465 端口使用 TLS 直连，其他端口在服务器支持时使用 STARTTLS
+type SMTPConfig struct {
+	Host     string `mapstructure:"host" json:"host"`
+	Port     int    `mapstructure:"port" json:"port"`
+	Username string `mapstructure:"username" json:"username"`
+	Password string `mapstructure:"password" json:"password"`
+}
+
 // Config 应用配置结构
 type Config struct {
 	Server struct {
@@ -89,18 +231,71 @@ type Config struct {
 	LLMModels       map[string]string      `mapstructure:"llm_models"`
 	LLMGroups       []*LLMGroup            `mapstructure:"llm_groups"`
 	LLMCharacters   []*LLMCharacter        `mapstructure:"llm_characters"`
-	SMS             AliyunSMSConfig        `mapstructure:"sms" json:"sms"`
+	SMS             SMSConfig              `mapstructure:"sms" json:"sms"`
+	Captcha         CaptchaConfig          `mapstructure:"captcha" json:"captcha"`
+	Risk            RiskConfig             `mapstructure:"risk" json:"risk"`
 	Redis           RedisConfig            `mapstructure:"redis" json:"redis"`
 	JWTSecret       string                 `mapstructure:"jwt_secret" json:"jwt_secret"`
+	AccessTokenTTL  int                    `mapstructure:"access_token_ttl" json:"access_token_ttl"`   // 访问令牌有效期(秒)
+	RefreshTokenTTL int                    `mapstructure:"refresh_token_ttl" json:"refresh_token_ttl"` // 刷新令牌有效期(秒)
+	Auth            AuthConfig             `mapstructure:"auth" json:"auth"`
+	DevMode         bool                   `mapstructure:"dev_mode" json:"dev_mode"` // 开发模式，开启后提供 /api/dev 下的调试接口，生产环境必须关闭
 	AuthAccess      int                    `mapstructure:"auth_access" json:"auth_access"`
 	ChatRateLimit   int                    `mapstructure:"chat_rate_limit" json:"chat_rate_limit"`
 	Cloudflare      CloudflareConfig       `mapstructure:"cloudflare" json:"cloudflare"`
 	Wechat          WechatConfig           `mapstructure:"wechat" json:"wechat"`
 	WebSocket       WebSocketConfig        `mapstructure:"websocket" json:"websocket"`
+	Scheduler       SchedulerConfig        `mapstructure:"scheduler" json:"scheduler"`
+	Group           GroupConfig            `mapstructure:"group" json:"group"`
+	OAuth           OAuthConfig            `mapstructure:"oauth" json:"oauth"`
+	Email           EmailConfig            `mapstructure:"email" json:"email"`
 }
 
 var AppConfig Config
 
+// redactedValue 脱敏后的占位值
+const redactedValue = "******"
+
+// Redacted 返回脱敏后的配置副本，用于管理后台查看运行配置，密钥、密码和数据库连接串不会返回原文
+func (c Config) Redacted() Config {
+	redacted := c
+	redacted.Database.DSN = redact(c.Database.DSN)
+	redacted.SMS.AccessKeySecret = redact(c.SMS.AccessKeySecret)
+	redacted.SMS.Tencent.SecretKey = redact(c.SMS.Tencent.SecretKey)
+	redacted.SMS.Twilio.AuthToken = redact(c.SMS.Twilio.AuthToken)
+	redacted.Redis.Password = redact(c.Redis.Password)
+	redacted.JWTSecret = redact(c.JWTSecret)
+	redacted.Cloudflare.APIToken = redact(c.Cloudflare.APIToken)
+	redacted.Wechat.AppSecret = redact(c.Wechat.AppSecret)
+	redacted.Wechat.Token = redact(c.Wechat.Token)
+	redacted.Email.SMTP.Password = redact(c.Email.SMTP.Password)
+
+	redacted.LLMProviders = make(map[string]LLMProvider, len(c.LLMProviders))
+	for name, provider := range c.LLMProviders {
+		provider.APIKey = redact(provider.APIKey)
+		redacted.LLMProviders[name] = provider
+	}
+	redacted.OAuth.Providers = make([]OAuthProviderConfig, len(c.OAuth.Providers))
+	for i, provider := range c.OAuth.Providers {
+		provider.ClientSecret = redact(provider.ClientSecret)
+		redacted.OAuth.Providers[i] = provider
+	}
+	redacted.Auth.Keys = make([]AuthKeyConfig, len(c.Auth.Keys))
+	for i, key := range c.Auth.Keys {
+		key.Secret = redact(key.Secret)
+		redacted.Auth.Keys[i] = key
+	}
+	return redacted
+}
+
+// redact 非空值替换为占位值，便于确认是否已配置
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
 // LoadConfig 加载配置文件
 func LoadConfig() {
 	viper.SetConfigName("config")
@@ -109,6 +304,46 @@ func LoadConfig() {
 
 	// 设置默认值
 	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("access_token_ttl", 900)
+	viper.SetDefault("refresh_token_ttl", 30*24*3600)
+	viper.SetDefault("auth.issuer", "botgroup-chat")
+	viper.SetDefault("auth.audience", "botgroup-chat")
+	viper.SetDefault("auth.accept_legacy_tokens", true)
+	viper.SetDefault("scheduler.enabled", true)
+	viper.SetDefault("scheduler.tick_interval", 30)
+	viper.SetDefault("scheduler.lock_ttl", 90)
+	viper.SetDefault("scheduler.max_tasks_per_user", 20)
+	viper.SetDefault("group.max_characters_per_group", 20)
+	viper.SetDefault("group.categories", []string{"闲聊", "学习", "工作", "技术", "娱乐", "角色扮演", "其他"})
+	viper.SetDefault("group.trash_retention_days", 30)
+	viper.SetDefault("group.trash_purge_interval", 60)
+	viper.SetDefault("oauth.state_ttl", 600)
+	viper.SetDefault("sms.providers", []string{"aliyun"})
+	viper.SetDefault("sms.tencent.region", "ap-guangzhou")
+	viper.SetDefault("sms.policy.resend_interval", 60)
+	viper.SetDefault("sms.policy.phone_daily_limit", 10)
+	viper.SetDefault("sms.policy.ip_daily_limit", 20)
+	viper.SetDefault("sms.policy.global_hourly_limit", 1000)
+	viper.SetDefault("sms.policy.code_max_attempts", 5)
+	viper.SetDefault("sms.twilio.code_template", "Your verification code is ${code}. It expires in 5 minutes.")
+	viper.SetDefault("captcha.mode", "random")
+	viper.SetDefault("captcha.types", []string{"click"})
+	viper.SetDefault("captcha.easy_types", []string{"slide", "rotate"})
+	viper.SetDefault("captcha.hard_types", []string{"click", "click_shape"})
+	viper.SetDefault("risk.enabled", true)
+	viper.SetDefault("risk.easy_score", 20)
+	viper.SetDefault("risk.hard_score", 50)
+	viper.SetDefault("risk.velocity_window", 60)
+	viper.SetDefault("risk.sms_velocity_limit", 3)
+	viper.SetDefault("risk.chat_velocity_limit", 20)
+	viper.SetDefault("risk.device_header", "X-Device-Id")
+	viper.SetDefault("risk.device_ip_limit", 5)
+	viper.SetDefault("risk.verified_ttl", 1800)
+	viper.SetDefault("redis.pool_size", 20)
+	viper.SetDefault("redis.min_idle_conns", 2)
+	viper.SetDefault("redis.degraded_mode", "memory")
+	viper.SetDefault("email.output_dir", "./data/mail")
+	viper.SetDefault("email.smtp.port", 587)
 
 	// 设置环境变量自动绑定
 	viper.AutomaticEnv()
@@ -119,13 +354,24 @@ func LoadConfig() {
 	viper.BindEnv("sms.access_key_secret", "ALIYUN_SMS_ACCESS_KEY_SECRET")
 	viper.BindEnv("sms.sign_name", "ALIYUN_SMS_SIGN_NAME")
 	viper.BindEnv("sms.template_code", "ALIYUN_SMS_TEMPLATE_CODE")
+	viper.BindEnv("sms.tencent.secret_id", "TENCENT_SMS_SECRET_ID")
+	viper.BindEnv("sms.tencent.secret_key", "TENCENT_SMS_SECRET_KEY")
+	viper.BindEnv("sms.tencent.sdk_app_id", "TENCENT_SMS_SDK_APP_ID")
+	viper.BindEnv("sms.tencent.sign_name", "TENCENT_SMS_SIGN_NAME")
+	viper.BindEnv("sms.tencent.template_id", "TENCENT_SMS_TEMPLATE_ID")
+	viper.BindEnv("sms.twilio.account_sid", "TWILIO_ACCOUNT_SID")
+	viper.BindEnv("sms.twilio.auth_token", "TWILIO_AUTH_TOKEN")
+	viper.BindEnv("sms.twilio.from", "TWILIO_FROM")
+	viper.BindEnv("sms.twilio.messaging_service_sid", "TWILIO_MESSAGING_SERVICE_SID")
 
 	viper.BindEnv("redis.host", "REDIS_HOST")
 	viper.BindEnv("redis.port", "REDIS_PORT")
 	viper.BindEnv("redis.password", "REDIS_PASSWORD")
 	viper.BindEnv("redis.db", "REDIS_DB")
+	viper.BindEnv("redis.degraded_mode", "REDIS_DEGRADED_MODE")
 
 	viper.BindEnv("jwt_secret", "JWT_SECRET")
+	viper.BindEnv("auth.signing_key", "AUTH_SIGNING_KEY")
 	viper.BindEnv("cloudflare.account_id", "CF_ACCOUNT_ID")
 	viper.BindEnv("cloudflare.api_token", "CF_API_TOKEN")
 	viper.BindEnv("cloudflare.image_prefix", "CF_IMAGE_PREFIX")
@@ -138,11 +384,27 @@ func LoadConfig() {
 	viper.BindEnv("wechat.qr_expires_in", "WECHAT_QR_EXPIRES_IN")
 	viper.BindEnv("wechat.session_expires_in", "WECHAT_SESSION_EXPIRES_IN")
 
+	// 邮件配置环境变量绑定
+	viper.BindEnv("email.driver", "EMAIL_DRIVER")
+	viper.BindEnv("email.from", "EMAIL_FROM")
+	viper.BindEnv("email.link_base_url", "EMAIL_LINK_BASE_URL")
+	viper.BindEnv("email.smtp.host", "SMTP_HOST")
+	viper.BindEnv("email.smtp.port", "SMTP_PORT")
+	viper.BindEnv("email.smtp.username", "SMTP_USERNAME")
+	viper.BindEnv("email.smtp.password", "SMTP_PASSWORD")
+
 	// WebSocket配置环境变量绑定
 	viper.BindEnv("websocket.read_buffer_size", "WS_READ_BUFFER_SIZE")
 	viper.BindEnv("websocket.write_buffer_size", "WS_WRITE_BUFFER_SIZE")
 	viper.BindEnv("websocket.check_origin", "WS_CHECK_ORIGIN")
 
+	// 定时任务调度配置环境变量绑定
+	viper.BindEnv("scheduler.enabled", "SCHEDULER_ENABLED")
+	viper.BindEnv("scheduler.tick_interval", "SCHEDULER_TICK_INTERVAL")
+
+	// 开发模式
+	viper.BindEnv("dev_mode", "DEV_MODE")
+
 	//是否登录检测
 	viper.BindEnv("auth_access", "AUTH_ACCESS")
 
@@ -167,8 +429,9 @@ func LoadConfig() {
 	}
 
 	// 调试日志：检查环境变量读取情况
-	log.Printf("SMS配置: AccessKeyID=%s, SignName=%s, TemplateCode=%s",
-		AppConfig.SMS.AccessKeyID, AppConfig.SMS.SignName, AppConfig.SMS.TemplateCode)
+	log.Printf("SMS配置: AccessKeyID=%s, SignName=%s, TemplateCode=%s, Providers=%v, Routes=%d",
+		AppConfig.SMS.AccessKeyID, AppConfig.SMS.SignName, AppConfig.SMS.TemplateCode,
+		AppConfig.SMS.Providers, len(AppConfig.SMS.Routes))
 	log.Printf("Redis配置: Host=%s, Port=%s, Password=%s, DB=%d",
 		AppConfig.Redis.Host, AppConfig.Redis.Port, AppConfig.Redis.Password, AppConfig.Redis.DB)
 	log.Printf("JWT Secret: %s", AppConfig.JWTSecret)
@@ -179,6 +442,8 @@ func LoadConfig() {
 		AppConfig.Wechat.QRExpiresIn, AppConfig.Wechat.SessionExpiresIn)
 	log.Printf("WebSocket配置: ReadBufferSize=%d, WriteBufferSize=%d, CheckOrigin=%t",
 		AppConfig.WebSocket.ReadBufferSize, AppConfig.WebSocket.WriteBufferSize, AppConfig.WebSocket.CheckOrigin)
+	log.Printf("定时任务配置: Enabled=%t, TickInterval=%ds, LockTTL=%ds",
+		AppConfig.Scheduler.Enabled, AppConfig.Scheduler.TickInterval, AppConfig.Scheduler.LockTTL)
 
 	log.Println("AppConfig:", AppConfig.LLMModels)
 
@@ -0,0 +1,19 @@
+package constants
+
+// 登录令牌相关常量
+const (
+	// 刷新令牌随机字节数
+	RefreshTokenBytes = 32
+)
+
+// API密钥相关常量
+const (
+	// API密钥前缀，用于与JWT访问令牌区分
+	APIKeyPrefix = "bgk_"
+	// API密钥随机字节数
+	APIKeyBytes = 32
+	// 列表中展示的密钥前缀长度（含 bgk_）
+	APIKeyDisplayLength = 12
+	// 每个用户最多可创建的API密钥数量
+	MaxAPIKeysPerUser = 20
+)
@@ -0,0 +1,20 @@
+package constants
+
+// 人机验证码相关常量
+const (
+	// Redis Key 前缀
+	CaptchaPrefix     = "captcha:"      // 验证码答案
+	CaptchaUsedPrefix = "captcha_used:" // 已取出的答案，保证多实例下每个答案只能使用一次
+	CaptchaTypePrefix = "captcha_type:" // 验证码类型，校验时按类型选择校验方式
+
+	// 验证码类型
+	CaptchaTypeClick      = "click"       // 按顺序点击文字
+	CaptchaTypeClickShape = "click_shape" // 按顺序点击图形
+	CaptchaTypeSlide      = "slide"       // 滑动拼图
+	CaptchaTypeDrag       = "drag"        // 拖拽拼图到任意位置
+	CaptchaTypeRotate     = "rotate"      // 旋转图片
+
+	// 验证码类型选择方式
+	CaptchaModeRandom = "random" // 从可用类型中随机选择
+	CaptchaModeRisk   = "risk"   // 按风险评估结果选择
+)
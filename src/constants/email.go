@@ -0,0 +1,31 @@
+package constants
+
+import "time"
+
+// 邮箱登录相关常量
+const (
+	// Redis Key 前缀，链接令牌以SHA-256摘要保存
+	EmailVerifyPrefix   = "email_verify:" // 待验证的注册信息
+	PasswordResetPrefix = "email_reset:"  // 重置密码
+	MagicLinkPrefix     = "email_magic:"  // 免密登录
+	EmailSendLockPrefix = "email_send:"   // 同一邮箱的发送间隔
+
+	// 链接有效期
+	EmailVerifyTTL   = 24 * time.Hour
+	PasswordResetTTL = 30 * time.Minute
+	MagicLinkTTL     = 15 * time.Minute
+
+	// 同一邮箱两次发送的最小间隔
+	EmailSendInterval = time.Minute
+
+	// 邮件中链接指向的前端页面，前端读取 token 参数后提交到对应接口
+	EmailVerifyPath   = "/auth/email/verify"
+	PasswordResetPath = "/auth/email/reset"
+	MagicLinkPath     = "/auth/email/magic"
+
+	// 链接令牌随机字节数
+	EmailTokenBytes = 32
+	// bcrypt 只使用密码的前72字节
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
@@ -0,0 +1,19 @@
+package constants
+
+import "time"
+
+// KV存储相关常量
+const (
+	// Redis不可用时的降级策略
+	KVDegradedMemory     = "memory"      // 使用进程内共享的内存存储，多实例之间不共享，Redis恢复后切回
+	KVDegradedFailClosed = "fail_closed" // 依赖存储的操作直接返回错误，限流和验证码等请求被拒绝
+
+	// Redis连接参数
+	KVDialTimeout  = 3 * time.Second
+	KVReadTimeout  = 2 * time.Second
+	KVWriteTimeout = 2 * time.Second
+
+	// Redis断线重连的退避间隔，每次失败后翻倍
+	KVReconnectMinBackoff = time.Second
+	KVReconnectMaxBackoff = 30 * time.Second
+)
@@ -0,0 +1,23 @@
+package constants
+
+// 第三方登录（OAuth2/OIDC）相关常量
+const (
+	// Redis Key 前缀，保存授权请求的 state、PKCE code_verifier 和 nonce
+	OAuthStatePrefix = "oauth_state:"
+
+	// 提供商类型
+	OAuthTypeGitHub = "github"
+	OAuthTypeGoogle = "google"
+	OAuthTypeOIDC   = "oidc"
+
+	// Google 的 OIDC 签发者
+	GoogleIssuer = "https://accounts.google.com"
+
+	// GitHub OAuth 端点
+	GitHubAuthURL     = "https://github.com/login/oauth/authorize"
+	GitHubTokenURL    = "https://github.com/login/oauth/access_token"
+	GitHubUserInfoURL = "https://api.github.com/user"
+
+	// state、code_verifier 和 nonce 的随机字节数
+	OAuthStateBytes = 32
+)
@@ -0,0 +1,54 @@
+package constants
+
+import "time"
+
+// 风险评估相关常量
+const (
+	// Redis Key 前缀
+	RiskVelocityPrefix     = "risk:velocity:"      // 每个IP在各场景下的请求次数
+	RiskCaptchaTotalPrefix = "risk:captcha_total:" // 每个IP的人机验证次数
+	RiskCaptchaFailPrefix  = "risk:captcha_fail:"  // 每个IP的人机验证失败次数
+	RiskDeviceSeenPrefix   = "risk:device_seen:"   // IP上出现过的设备
+	RiskDeviceCountPrefix  = "risk:devices:"       // 每个IP上出现的设备数
+	RiskVerifiedPrefix     = "risk:verified:"      // 人机验证通过的客户端
+
+	// 评估场景
+	RiskSceneSMS  = "sms"  // 发送短信验证码
+	RiskSceneChat = "chat" // 匿名用户聊天
+
+	// 评估结果
+	RiskDecisionSkip = "skip" // 不需要人机验证
+	RiskDecisionEasy = "easy" // 需要简单的人机验证
+	RiskDecisionHard = "hard" // 需要困难的人机验证
+
+	// 各项风险分
+	RiskScoreVelocity       = 30 // 请求频率超过上限
+	RiskScoreVelocityDouble = 50 // 请求频率超过上限的两倍
+	RiskScoreCaptchaFail    = 30 // 人机验证失败率达到一半
+	RiskScoreCaptchaFailAll = 50 // 人机验证失败率达到80%
+	RiskScoreEmptyUA        = 30 // 没有User-Agent
+	RiskScoreBotUA          = 40 // User-Agent 为脚本或爬虫
+	RiskScoreNoDevice       = 10 // 没有设备指纹请求头
+	RiskScoreManyDevices    = 20 // 同一IP上的设备数超过上限
+	RiskScoreMax            = 100
+
+	// RiskCaptchaMinAttempts 人机验证次数达到该值后才计算失败率
+	RiskCaptchaMinAttempts = 3
+	// RiskCounterWindow 人机验证次数和设备数的统计时间窗口
+	RiskCounterWindow = time.Hour
+
+	// 未配置时的默认值
+	DefaultRiskEasyScore         = 20
+	DefaultRiskHardScore         = 50
+	DefaultRiskVelocityWindow    = time.Minute
+	DefaultRiskSMSVelocityLimit  = 3
+	DefaultRiskChatVelocityLimit = 20
+	DefaultRiskDeviceIPLimit     = 5
+	DefaultRiskVerifiedTTL       = 30 * time.Minute
+)
+
+// RiskBotUserAgents 视为脚本或爬虫的 User-Agent 关键字，匹配时不区分大小写
+var RiskBotUserAgents = []string{
+	"curl", "wget", "python", "go-http-client", "java/", "okhttp", "httpclient",
+	"postman", "scrapy", "headless", "phantomjs", "selenium", "bot", "spider", "crawler",
+}
@@ -0,0 +1,40 @@
+package constants
+
+import "time"
+
+// 短信相关常量
+const (
+	// 短信服务商
+	SMSProviderAliyun  = "aliyun"
+	SMSProviderTencent = "tencent"
+	SMSProviderTwilio  = "twilio"
+	SMSProviderConsole = "console" // 只打印到日志，用于开发环境
+	SMSProviderMock    = "mock"    // 保存到KV存储，通过开发环境收件箱查看，用于开发和测试
+
+	// 服务商 API 端点
+	AliyunSMSURL    = "https://dysmsapi.aliyuncs.com/"
+	TencentSMSHost  = "sms.tencentcloudapi.com"
+	TwilioSMSAPIURL = "https://api.twilio.com/2010-04-01/Accounts/"
+
+	// 验证码有效期
+	SMSCodeTTL = 5 * time.Minute
+
+	// Redis Key 前缀，发送频率限制和验证码校验次数
+	SMSCooldownPrefix     = "sms_policy:cooldown:" // 重新发送间隔，按手机号
+	SMSPhoneCountPrefix   = "sms_policy:phone:"    // 每日发送条数，按手机号和日期
+	SMSIPCountPrefix      = "sms_policy:ip:"       // 每日发送条数，按IP和日期
+	SMSGlobalCountPrefix  = "sms_policy:global:"   // 全站每小时发送条数，按小时
+	SMSCodeAttemptsPrefix = "sms_attempts:"        // 验证码校验次数，按手机号
+	SMSInboxPrefix        = "sms_inbox:"           // mock 服务商保存的短信，按手机号
+
+	// mock 服务商每个手机号保留的短信条数和保留时间
+	SMSInboxSize = 20
+	SMSInboxTTL  = 24 * time.Hour
+
+	// 限制默认值，配置为0时使用
+	DefaultSMSResendInterval    = 60 * time.Second
+	DefaultSMSPhoneDailyLimit   = 10
+	DefaultSMSIPDailyLimit      = 20
+	DefaultSMSGlobalHourlyLimit = 1000
+	DefaultSMSCodeMaxAttempts   = 5
+)
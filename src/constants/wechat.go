@@ -9,6 +9,7 @@ const (
 	SessionStatusPending = "pending" // 等待扫码
 	SessionStatusSuccess = "success" // 登录成功
 	SessionStatusExpired = "expired" // 已过期
+	SessionStatusFailed  = "failed"  // 处理失败，如绑定微信时该微信已属于其他账号
 
 	// 默认过期时间（秒）
 	SessionDefaultExpireTime = 600 // 10分钟
@@ -20,5 +21,6 @@ const (
 	// 登录类型
 	LoginTypeWechat = "wechat"
 	LoginTypePhone  = "phone"
+	LoginTypeEmail  = "email"
 	LoginTypeBoth   = "both"
 )
@@ -6,8 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"project/src/api"
+	"project/src/auth"
 	"project/src/config"
 	"project/src/middleware"
+	"project/src/models"
+	"project/src/services"
+	"project/src/services/go-captcha/cache"
 )
 
 func main() {
@@ -17,18 +21,77 @@ func main() {
 	// 初始化数据库
 	config.InitDatabase()
 
+	// 初始化KV存储，所有请求共用一个Redis连接池
+	if err := services.ValidateRedisConfig(config.AppConfig.Redis); err != nil {
+		log.Fatalf("Redis配置有误: %v", err)
+	}
+	kvService := services.NewKVService(config.AppConfig.Redis)
+	defer kvService.Close()
+
+	// 初始化访问令牌签发器
+	if err := auth.InitTokenIssuer(); err != nil {
+		log.Fatalf("初始化令牌签名密钥失败: %v", err)
+	}
+
+	// 检查第三方登录配置
+	if err := services.ValidateOAuthConfig(config.AppConfig.OAuth); err != nil {
+		log.Fatalf("第三方登录配置有误: %v", err)
+	}
+
+	// 验证码答案保存到Redis，多实例部署时生成和校验请求可以落在不同实例上
+	cache.SetStore(services.NewCaptchaStore(kvService))
+	api.SetKVService(kvService)
+
+	// 检查人机验证码配置
+	if err := services.ValidateCaptchaConfig(config.AppConfig.Captcha); err != nil {
+		log.Fatalf("人机验证码配置有误: %v", err)
+	}
+
+	// 检查邮件配置
+	if err := services.ValidateEmailConfig(config.AppConfig.Email, config.AppConfig.DevMode); err != nil {
+		log.Fatalf("邮件配置有误: %v", err)
+	}
+
+	// 检查短信路由配置
+	if err := services.ValidateSMSConfig(config.AppConfig.SMS, config.AppConfig.DevMode); err != nil {
+		log.Fatalf("短信配置有误: %v", err)
+	}
+	api.SetSMSService(services.NewSMSService(config.AppConfig.SMS, kvService))
+
+	// 同步配置文件中的群组为系统群组
+	if err := services.NewCatalogService().SyncSystemGroups(); err != nil {
+		log.Printf("同步系统群组失败: %v", err)
+	}
+
+	// 启动定时任务调度（角色定时发言）
+	if config.AppConfig.Scheduler.Enabled {
+		taskRunner := services.NewTaskRunner(kvService)
+		taskRunner.Start()
+		defer taskRunner.Stop()
+	}
+
+	// 启动回收站清理（彻底删除超过保留期的群组和角色）
+	if config.AppConfig.Group.TrashRetentionDays > 0 {
+		trashPurger := services.NewTrashPurger()
+		trashPurger.Start()
+		defer trashPurger.Stop()
+	}
+
 	// 创建Gin引擎
 	r := gin.Default()
 
 	// 设置信任代理
 	r.SetTrustedProxies(nil)
 
+	// 注册请求参数校验规则
+	api.RegisterValidators()
+
 	// 注册中间件
 	r.Use(middleware.Logger())
 	r.Use(middleware.Cors())
 
 	// 注册路由
-	registerRoutes(r)
+	registerRoutes(r, kvService)
 
 	// 启动服务器
 	if err := r.Run(":8080"); err != nil {
@@ -36,7 +99,7 @@ func main() {
 	}
 }
 
-func registerRoutes(r *gin.Engine) {
+func registerRoutes(r *gin.Engine, kvService services.KVService) {
 	// 根路径响应
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -54,6 +117,9 @@ func registerRoutes(r *gin.Engine) {
 		})
 	})
 
+	// 访问令牌验证公钥，供其他服务校验 RS256/EdDSA 令牌
+	r.GET("/.well-known/jwks.json", api.JWKSHandler)
+
 	// 详细健康检查端点（包含数据库检查）
 	r.GET("/health/detailed", func(c *gin.Context) {
 		// 检查数据库连接
@@ -96,11 +162,44 @@ func registerRoutes(r *gin.Engine) {
 		//apiGroup.POST("/sendcode", api.SendCodeHandler) // 测试用接口
 		apiGroup.GET("/captcha", api.CaptchaHandler)
 		apiGroup.POST("/captcha/check", api.CaptchaCheckHandler)
+		apiGroup.POST("/captcha/verify", api.CaptchaVerifyHandler)
+
+		// 开发模式调试接口，生产环境不注册
+		if config.AppConfig.DevMode {
+			log.Println("开发模式已开启，注册 /api/dev 调试接口")
+			devGroup := apiGroup.Group("/dev")
+			devGroup.GET("/sms-inbox", api.SMSInboxHandler) // 查看 mock 短信服务商保存的短信
+		}
 		// 微信登录相关接口（无需认证）
 
 		authGroup := apiGroup.Group("/auth")
 		authGroup.Use(middleware.SecurityHeaders()) // 安全头
 		{
+			// 刷新令牌与退出登录
+			authGroup.POST("/refresh", api.RefreshTokenHandler)
+			authGroup.POST("/logout", api.LogoutHandler)
+
+			// 邮箱注册、密码登录与免密登录
+			emailGroup := authGroup.Group("/email")
+			emailGroup.Use(middleware.LoginRateLimit())
+			{
+				emailGroup.POST("/register", api.EmailRegisterHandler)
+				emailGroup.POST("/verify", api.EmailVerifyHandler)
+				emailGroup.POST("/login", api.EmailLoginHandler)
+				emailGroup.POST("/password/forgot", api.ForgotPasswordHandler)
+				emailGroup.POST("/password/reset", api.ResetPasswordHandler)
+				emailGroup.POST("/magic-link", api.MagicLinkHandler)
+				emailGroup.POST("/magic-link/login", api.MagicLinkLoginHandler)
+			}
+
+			// 第三方登录（OAuth2/OIDC）
+			oauthGroup := authGroup.Group("/oauth")
+			{
+				oauthGroup.GET("/providers", api.OAuthProvidersHandler)
+				oauthGroup.GET("/:provider/authorize", middleware.LoginRateLimit(), api.OAuthAuthorizeHandler)
+				oauthGroup.POST("/:provider/callback", middleware.LoginRateLimit(), api.OAuthCallbackHandler)
+			}
+
 			// 微信扫码登录
 			wechatGroup := authGroup.Group("/wechat")
 			wechatGroup.Use(middleware.WechatCORS()) // 微信专用CORS
@@ -117,60 +216,148 @@ func registerRoutes(r *gin.Engine) {
 
 				wechatGroup.POST("/callback",
 					middleware.WechatCallbackRateLimit(),
-					middleware.WechatSignatureVerify(),
+					middleware.WechatSignatureVerify(kvService),
 					api.WechatCallbackHandler)
 
 				// 状态查询（需要限流）
 				wechatGroup.GET("/status/:session_id",
 					middleware.WechatStatusRateLimit(),
 					api.WechatLoginStatusHandler)
-
-				// 测试接口（仅开发环境，需要限流）
-				wechatGroup.GET("/test",
-					middleware.WechatQRCodeRateLimit(),
-					api.WechatLoginTestHandler)
-
-				// 调试接口（仅开发环境）
-				wechatGroup.GET("/debug/token", api.WechatTokenDebugHandler)
 			}
 		}
 		// 匿名Chat接口（带限流）
-		apiGroup.GET("/init", middleware.ChatRateLimitMiddleware(), api.InitHandler)
-		apiGroup.POST("/chat", middleware.ChatRateLimitMiddleware(), api.ChatHandler)
+		apiGroup.GET("/init", middleware.ChatRateLimitMiddleware(kvService), api.InitHandler)
+		apiGroup.POST("/chat", middleware.ChatRateLimitMiddleware(kvService), api.ChatHandler)
 
 		// 需要认证的用户接口
 		userGroup := apiGroup.Group("/")
-		userGroup.Use(middleware.AuthMiddleware())
+		userGroup.Use(middleware.AuthMiddleware(kvService))
 		{
 			// // 初始化接口
 			// userGroup.GET("/init", api.InitHandler)
 			// 调度相关接口
 			userGroup.POST("/scheduler", api.SchedulerHandler)
+
+			// 定时任务接口（角色定时发言）
+			tasksGroup := userGroup.Group("/tasks")
+			{
+				tasksGroup.POST("/", api.CreateTaskHandler)                     // 创建定时任务
+				tasksGroup.GET("/", api.GetTasksHandler)                        // 获取定时任务列表
+				tasksGroup.GET("/:id", api.GetTaskHandler)                      // 获取单个定时任务
+				tasksGroup.PUT("/:id", api.UpdateTaskHandler)                   // 更新定时任务
+				tasksGroup.DELETE("/:id", api.DeleteTaskHandler)                // 删除定时任务
+				tasksGroup.GET("/:id/executions", api.GetTaskExecutionsHandler) // 获取执行记录
+				tasksGroup.POST("/:id/run", api.RunTaskHandler)                 // 立即执行一次
+			}
 			// 用户相关接口
 			userGroup.GET("/user/info", api.UserInfoHandler)
 			userGroup.POST("/user/update", api.UserUpdateHandler)
+			userGroup.GET("/user/sessions", api.ListSessionsHandler)                       // 已登录设备列表
+			userGroup.POST("/user/sessions/revoke-others", api.RevokeOtherSessionsHandler) // 退出其他设备
+			userGroup.DELETE("/user/sessions/:id", api.RevokeSessionHandler)               // 退出指定设备
+			userGroup.GET("/user/identities", api.ListIdentitiesHandler)                   // 已绑定的登录身份
+			userGroup.POST("/user/identities/phone", api.BindPhoneHandler)                 // 绑定手机号
+			userGroup.DELETE("/user/identities/:id", api.UnbindIdentityHandler)            // 解绑登录身份
+			userGroup.POST("/user/merge", api.MergeAccountHandler)                         // 合并另一个账号
+			userGroup.GET("/user/api-keys", api.ListAPIKeysHandler)                        // 个人API密钥列表
+			userGroup.POST("/user/api-keys", api.CreateAPIKeyHandler)                      // 创建个人API密钥
+			userGroup.DELETE("/user/api-keys/:id", api.RevokeAPIKeyHandler)                // 吊销个人API密钥
+			// 生成绑定微信的二维码（需要限流）
+			userGroup.POST("/user/identities/wechat/qr-code",
+				middleware.WechatQRCodeRateLimit(),
+				api.BindWechatQRCodeHandler)
 			// 上传相关接口
 			userGroup.POST("/user/upload", api.UploadHandler)
 
 			// 群组管理接口
 			groupsGroup := userGroup.Group("/groups")
 			{
-				groupsGroup.POST("/", api.CreateGroupHandler)                       // 创建群组
-				groupsGroup.GET("/", api.GetGroupsHandler)                          // 获取群组列表
-				groupsGroup.GET("/:id", api.GetGroupHandler)                        // 获取单个群组详情
-				groupsGroup.PUT("/:id", api.UpdateGroupHandler)                     // 更新群组
-				groupsGroup.DELETE("/:id", api.DeleteGroupHandler)                  // 删除群组
-				groupsGroup.GET("/:id/characters", api.GetCharactersByGroupHandler) // 获取群组下的角色列表
+				groupsGroup.POST("/", api.CreateGroupHandler)                                          // 创建群组
+				groupsGroup.GET("/", api.GetGroupsHandler)                                             // 获取群组列表
+				groupsGroup.GET("/:id", api.GetGroupHandler)                                           // 获取单个群组详情
+				groupsGroup.PUT("/:id", api.UpdateGroupHandler)                                        // 更新群组
+				groupsGroup.PATCH("/:id", api.UpdateGroupHandler)                                      // 部分更新群组
+				groupsGroup.DELETE("/:id", api.DeleteGroupHandler)                                     // 删除群组
+				groupsGroup.GET("/:id/characters", api.GetCharactersByGroupHandler)                    // 获取群组下的角色列表
+				groupsGroup.POST("/:id/characters/batch", api.BatchCharactersHandler)                  // 批量新建/更新/删除/排序角色
+				groupsGroup.POST("/import", api.ImportGroupHandler)                                    // 导入群组
+				groupsGroup.GET("/:id/export", api.ExportGroupHandler)                                 // 导出群组
+				groupsGroup.POST("/:id/clone", api.CloneGroupHandler)                                  // 克隆群组
+				groupsGroup.GET("/:id/forks", api.GetGroupForksHandler)                                // 获取克隆自该群组的公开群组
+				groupsGroup.GET("/:id/reactions", api.GetGroupReactionsHandler)                        // 获取点赞/收藏状态
+				groupsGroup.PUT("/:id/reactions/:type", api.AddGroupReactionHandler)                   // 点赞/收藏
+				groupsGroup.DELETE("/:id/reactions/:type", api.RemoveGroupReactionHandler)             // 取消点赞/收藏
+				groupsGroup.GET("/:id/revisions", api.ListGroupRevisionsHandler)                       // 获取修订记录
+				groupsGroup.GET("/:id/revisions/diff", api.DiffGroupRevisionsHandler)                  // 比较两个版本
+				groupsGroup.POST("/:id/revisions/:version/rollback", api.RollbackGroupRevisionHandler) // 回滚到指定版本
+			}
+
+			// 公开群组目录接口
+			directoryGroup := userGroup.Group("/directory")
+			{
+				directoryGroup.GET("/categories", api.GetCategoriesHandler) // 获取分类列表
+				directoryGroup.GET("/groups", api.SearchDirectoryHandler)   // 搜索公开群组
+				directoryGroup.GET("/favorites", api.GetFavoritesHandler)   // 获取我的收藏
 			}
 
 			// 角色管理接口
 			charactersGroup := userGroup.Group("/characters")
 			{
-				charactersGroup.POST("/", api.CreateCharacterHandler)      // 创建角色
-				charactersGroup.GET("/", api.GetCharactersHandler)         // 获取角色列表
-				charactersGroup.GET("/:id", api.GetCharacterHandler)       // 获取单个角色详情
-				charactersGroup.PUT("/:id", api.UpdateCharacterHandler)    // 更新角色
-				charactersGroup.DELETE("/:id", api.DeleteCharacterHandler) // 删除角色
+				charactersGroup.POST("/", api.CreateCharacterHandler)                                          // 创建角色
+				charactersGroup.GET("/", api.GetCharactersHandler)                                             // 获取角色列表
+				charactersGroup.GET("/:id", api.GetCharacterHandler)                                           // 获取单个角色详情
+				charactersGroup.PUT("/:id", api.UpdateCharacterHandler)                                        // 更新角色
+				charactersGroup.PATCH("/:id", api.UpdateCharacterHandler)                                      // 部分更新角色
+				charactersGroup.DELETE("/:id", api.DeleteCharacterHandler)                                     // 删除角色
+				charactersGroup.POST("/import", api.ImportCharacterCardHandler)                                // 导入角色卡
+				charactersGroup.GET("/:id/export", api.ExportCharacterCardHandler)                             // 导出角色卡
+				charactersGroup.GET("/:id/revisions", api.ListCharacterRevisionsHandler)                       // 获取修订记录
+				charactersGroup.GET("/:id/revisions/diff", api.DiffCharacterRevisionsHandler)                  // 比较两个版本
+				charactersGroup.POST("/:id/revisions/:version/rollback", api.RollbackCharacterRevisionHandler) // 回滚到指定版本
+			}
+
+			// 回收站接口
+			trashGroup := userGroup.Group("/trash")
+			{
+				trashGroup.GET("/groups", api.GetDeletedGroupsHandler)                  // 获取回收站中的群组
+				trashGroup.POST("/groups/:id/restore", api.RestoreGroupHandler)         // 恢复群组
+				trashGroup.GET("/characters", api.GetDeletedCharactersHandler)          // 获取回收站中的角色
+				trashGroup.POST("/characters/:id/restore", api.RestoreCharacterHandler) // 恢复角色
+			}
+		}
+
+		// 管理后台接口，始终需要认证（不受 auth_access 影响），按角色权限控制访问
+		adminGroup := apiGroup.Group("/admin")
+		adminGroup.Use(middleware.RequireAuth(kvService), middleware.RequireRole(models.RoleAdmin, models.RoleModerator))
+		{
+			// 用户管理
+			adminUsersGroup := adminGroup.Group("/users")
+			adminUsersGroup.Use(middleware.RequirePermission(models.PermissionUserManage))
+			{
+				adminUsersGroup.GET("/", api.AdminSearchUsersHandler)             // 搜索用户
+				adminUsersGroup.GET("/:id", api.AdminGetUserHandler)              // 用户详情
+				adminUsersGroup.PUT("/:id/status", api.AdminSetUserStatusHandler) // 启用/禁用用户
+				adminUsersGroup.PUT("/:id/role", api.AdminSetUserRoleHandler)     // 设置用户角色
+			}
+
+			// 群组审核
+			adminGroupsGroup := adminGroup.Group("/groups")
+			adminGroupsGroup.Use(middleware.RequirePermission(models.PermissionGroupModerate))
+			{
+				adminGroupsGroup.GET("/", api.AdminListGroupsHandler)                  // 获取全部群组
+				adminGroupsGroup.PUT("/:id/moderation", api.AdminModerateGroupHandler) // 下架/恢复群组
+			}
+
+			adminGroup.GET("/stats", middleware.RequirePermission(models.PermissionStatsView), api.AdminStatsHandler)    // 使用统计
+			adminGroup.GET("/config", middleware.RequirePermission(models.PermissionConfigView), api.AdminConfigHandler) // 运行配置（已脱敏）
+
+			// 微信登录调试与测试接口
+			adminWechatGroup := adminGroup.Group("/wechat")
+			adminWechatGroup.Use(middleware.RequirePermission(models.PermissionSystemDebug))
+			{
+				adminWechatGroup.GET("/test", api.WechatLoginTestHandler)                      // 模拟扫码登录
+				adminWechatGroup.POST("/callback/simulate", api.WechatCallbackSimulateHandler) // 模拟微信回调
+				adminWechatGroup.GET("/debug/token", api.WechatTokenDebugHandler)              // access_token 状态
 			}
 		}
 	}
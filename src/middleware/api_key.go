@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"project/src/constants"
+	"project/src/models"
+	"project/src/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyRoutes API密钥可访问的接口及所需权限范围，未列出的接口（如用户资料、密钥管理、管理后台）不接受API密钥
+var apiKeyRoutes = []struct {
+	prefix string
+	read   string // GET 请求所需权限范围
+	write  string // 其他请求所需权限范围
+}{
+	{"/api/chat", models.APIKeyScopeChat, models.APIKeyScopeChat},
+	{"/api/init", models.APIKeyScopeChat, models.APIKeyScopeChat},
+	{"/api/scheduler", models.APIKeyScopeChat, models.APIKeyScopeChat},
+	{"/api/groups/", models.APIKeyScopeGroupsRead, models.APIKeyScopeGroupsWrite},
+	{"/api/characters/", models.APIKeyScopeGroupsRead, models.APIKeyScopeGroupsWrite},
+	{"/api/directory/", models.APIKeyScopeGroupsRead, models.APIKeyScopeGroupsWrite},
+}
+
+// isAPIKey 判断 Bearer 令牌是否为个人API密钥
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, constants.APIKeyPrefix)
+}
+
+// apiKeyScope 返回当前请求所需的API密钥权限范围，接口不接受API密钥时返回 false
+func apiKeyScope(c *gin.Context) (string, bool) {
+	path := c.FullPath()
+	for _, route := range apiKeyRoutes {
+		if strings.HasPrefix(path, route.prefix) {
+			if c.Request.Method == http.MethodGet {
+				return route.read, true
+			}
+			return route.write, true
+		}
+	}
+	return "", false
+}
+
+// authenticateAPIKey 校验API密钥及其对当前接口的权限范围，并将用户信息和密钥存储到上下文中
+// 校验失败时写入错误响应并中止请求，返回 false
+func authenticateAPIKey(c *gin.Context, kvService services.KVService, token string) bool {
+	userService := services.NewUserService(kvService)
+	user, key, err := userService.ValidateAPIKey(token)
+	if err != nil {
+		fmt.Println("ValidateAPIKey error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "认证失败: " + err.Error(),
+		})
+		c.Abort()
+		return false
+	}
+
+	scope, ok := apiKeyScope(c)
+	if !ok || !key.HasScope(scope) {
+		message := services.ErrAPIKeyScopeDenied.Error()
+		if ok {
+			message += ": " + scope
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": message,
+		})
+		c.Abort()
+		return false
+	}
+
+	c.Set("user", user)
+	c.Set("api_key", key)
+	return true
+}
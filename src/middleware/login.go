@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginLimiter 第三方登录、邮箱登录等登录接口：每分钟20次
+var loginLimiter = newRateLimiter(20, time.Minute)
+
+// LoginRateLimit 登录接口限流中间件，用于第三方登录和邮箱登录
+func LoginRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if !loginLimiter.isAllowed(ip) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"message": "请求频率过高，请稍后再试",
+				"code":    "RATE_LIMIT_EXCEEDED",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"project/src/config"
+	"project/src/constants"
 	"project/src/services"
 	"strconv"
 	"strings"
@@ -56,8 +57,12 @@ func Cors() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		allowHeaders := "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With"
+		if deviceHeader := config.AppConfig.Risk.DeviceHeader; deviceHeader != "" {
+			allowHeaders += ", " + deviceHeader
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -69,59 +74,80 @@ func Cors() gin.HandlerFunc {
 }
 
 // AuthMiddleware JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(kvService services.KVService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 如果auth_access为0，则不进行认证
 		if config.AppConfig.AuthAccess == 0 {
 			c.Next()
 			return
 		}
-		// 获取Authorization头部
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "缺少认证信息",
-			})
-			c.Abort()
-			return
-		}
+		authenticate(c, kvService)
+	}
+}
 
-		// 验证Bearer token格式
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "认证格式错误",
-			})
-			c.Abort()
-			return
-		}
+// RequireAuth 强制认证中间件，不受 auth_access 开关影响，用于管理后台等必须识别用户的接口
+func RequireAuth(kvService services.KVService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticate(c, kvService)
+	}
+}
 
-		// 提取token
-		token := strings.TrimPrefix(authHeader, "Bearer ")
+// authenticate 校验访问令牌或个人API密钥并将用户信息和登录会话ID存储到上下文中
+func authenticate(c *gin.Context, kvService services.KVService) {
+	// 获取Authorization头部
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "缺少认证信息",
+		})
+		c.Abort()
+		return
+	}
 
-		// 创建用户服务并验证token
-		userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
-		user, err := userService.ValidateToken(token)
-		if err != nil {
-			fmt.Println("ValidateToken error", err)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "认证失败: " + err.Error(),
-			})
-			c.Abort()
-			return
+	// 验证Bearer token格式
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "认证格式错误",
+		})
+		c.Abort()
+		return
+	}
+
+	// 提取token
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// 个人API密钥
+	if isAPIKey(token) {
+		if authenticateAPIKey(c, kvService, token) {
+			c.Next()
 		}
+		return
+	}
 
-		// 将用户信息存储到上下文中
-		c.Set("user", user)
-		c.Next()
+	// 创建用户服务并验证token
+	userService := services.NewUserService(kvService)
+	user, sessionID, err := userService.ValidateSession(token)
+	if err != nil {
+		fmt.Println("ValidateToken error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "认证失败: " + err.Error(),
+		})
+		c.Abort()
+		return
 	}
+
+	// 将用户信息和登录会话ID存储到上下文中
+	c.Set("user", user)
+	c.Set("session_id", sessionID)
+	c.Next()
 }
 
 // ChatRateLimitMiddleware Chat接口限流中间件
 // 允许匿名用户每个IP每天调用指定次数的chat接口，超过后要求登录
-func ChatRateLimitMiddleware() gin.HandlerFunc {
+func ChatRateLimitMiddleware(kvService services.KVService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 如果全局关闭认证，直接跳过
 		if config.AppConfig.AuthAccess == 0 {
@@ -134,7 +160,14 @@ func ChatRateLimitMiddleware() gin.HandlerFunc {
 		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 			// 有token，尝试验证
 			token := strings.TrimPrefix(authHeader, "Bearer ")
-			userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+			// 个人API密钥必须有效且拥有 chat 权限，不回退到匿名限流
+			if isAPIKey(token) {
+				if authenticateAPIKey(c, kvService, token) {
+					c.Next()
+				}
+				return
+			}
+			userService := services.NewUserService(kvService)
 			if user, err := userService.ValidateToken(token); err == nil {
 				// token有效，用户已登录，跳过限流
 				c.Set("user", user)
@@ -145,22 +178,28 @@ func ChatRateLimitMiddleware() gin.HandlerFunc {
 		}
 
 		// 获取客户端真实IP（考虑反向代理）
-		clientIP := getRealClientIP(c)
+		clientIP := GetRealClientIP(c)
 
 		// 调试日志：显示IP获取信息
 		fmt.Printf("ChatRateLimitMiddleware Debug - ClientIP: %s, X-Real-IP: %s, X-Forwarded-For: %s, RemoteAddr: %s\n",
 			clientIP, c.GetHeader("X-Real-IP"), c.GetHeader("X-Forwarded-For"), c.Request.RemoteAddr)
 
-		// 创建KV服务实例
-		kvService := services.NewKVService(config.AppConfig.Redis)
-
 		// 构造Redis key
 		rateLimitKey := fmt.Sprintf("chat_rate_limit:%s:%s", clientIP, time.Now().Format("2006-01-02"))
 
-		// 获取当前访问次数
+		// 获取当前访问次数，存储不可用时拒绝匿名请求
 		countStr, err := kvService.Get(rateLimitKey)
+		if err != nil {
+			fmt.Println("ChatRateLimitMiddleware", "读取访问次数失败: ", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"message": "服务暂不可用，请稍后再试",
+			})
+			c.Abort()
+			return
+		}
 		var count int = 0
-		if err == nil && countStr != "" {
+		if countStr != "" {
 			if parsedCount, parseErr := strconv.Atoi(countStr); parseErr == nil {
 				count = parsedCount
 			}
@@ -184,17 +223,49 @@ func ChatRateLimitMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 增加访问次数
-		count++
-		countStr = strconv.Itoa(count)
+		// 风险较高的匿名请求需要先通过人机验证，评估失败时按需要简单验证处理，不因存储故障放过请求
+		assessment, err := services.NewRiskService(kvService).Assess(constants.RiskSceneChat, GetRiskSignals(c))
+		if err != nil {
+			fmt.Println("ChatRateLimitMiddleware", "风险评估失败: ", err)
+			assessment = services.RiskAssessment{Decision: constants.RiskDecisionEasy}
+		}
+		if assessment.Decision != constants.RiskDecisionSkip {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "请先完成人机验证",
+				"code":    "CAPTCHA_REQUIRED",
+				"level":   assessment.Decision,
+			})
+			c.Abort()
+			return
+		}
 
 		// 设置过期时间为当天结束（第二天0点）
 		now := time.Now()
 		tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
-		ttl := tomorrow.Sub(now)
 
-		// 更新Redis中的计数
-		kvService.Set(rateLimitKey, countStr, ttl)
+		// 原子地增加访问次数，并发请求不会读到同一个计数而同时放行
+		newCount, err := kvService.Incr(rateLimitKey, tomorrow.Sub(now))
+		if err != nil {
+			fmt.Println("ChatRateLimitMiddleware", "更新访问次数失败: ", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"message": "服务暂不可用，请稍后再试",
+			})
+			c.Abort()
+			return
+		}
+		// 并发请求可能同时通过上面的预检查，以自增后的计数为准
+		count = int(newCount)
+		if count > maxAttempts {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("匿名用户每日chat调用次数已达上限(%d次)，请登录后继续使用", maxAttempts),
+				"code":    "CHAT_RATE_LIMIT_EXCEEDED",
+			})
+			c.Abort()
+			return
+		}
 
 		// 在响应头中添加剩余次数信息
 		remaining := maxAttempts - count
@@ -205,9 +276,18 @@ func ChatRateLimitMiddleware() gin.HandlerFunc {
 	}
 }
 
-// getRealClientIP 获取客户端真实IP地址
+// GetRiskSignals 获取风险评估使用的客户端信息
+func GetRiskSignals(c *gin.Context) services.RiskSignals {
+	return services.RiskSignals{
+		IP:        GetRealClientIP(c),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  strings.TrimSpace(c.GetHeader(config.AppConfig.Risk.DeviceHeader)),
+	}
+}
+
+// GetRealClientIP 获取客户端真实IP地址
 // 优先级：X-Real-IP > X-Forwarded-For > RemoteAddr
-func getRealClientIP(c *gin.Context) string {
+func GetRealClientIP(c *gin.Context) string {
 	// 1. 尝试从 X-Real-IP 获取（nginx设置的真实IP）
 	if realIP := c.GetHeader("X-Real-IP"); realIP != "" {
 		return realIP
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"project/src/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 角色校验中间件，需放在 RequireAuth 之后，用户拥有任一指定角色时放行
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := contextUser(c)
+		if !ok {
+			return
+		}
+		if !user.HasRole(roles...) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "没有访问权限",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 权限校验中间件，需放在 RequireAuth 之后，用户的角色拥有指定权限时放行
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := contextUser(c)
+		if !ok {
+			return
+		}
+		if !user.HasPermission(permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "没有访问权限: " + permission,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// contextUser 获取认证中间件写入的用户，未认证时返回401并中止请求
+func contextUser(c *gin.Context) (*models.User, bool) {
+	if value, exists := c.Get("user"); exists {
+		if user, ok := value.(*models.User); ok && user != nil {
+			return user, true
+		}
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"success": false,
+		"message": "用户未认证",
+	})
+	c.Abort()
+	return nil, false
+}
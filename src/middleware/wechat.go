@@ -2,7 +2,6 @@ package middleware
 
 import (
 	"net/http"
-	"project/src/config"
 	"project/src/services"
 	"sync"
 	"time"
@@ -147,7 +146,7 @@ func WechatStatusRateLimit() gin.HandlerFunc {
 }
 
 // WechatSignatureVerify 微信签名验证中间件（仅用于回调接口）
-func WechatSignatureVerify() gin.HandlerFunc {
+func WechatSignatureVerify(kvService services.KVService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 只对POST请求进行签名验证（事件回调）
 		// GET请求的签名验证在处理函数中进行（服务器验证）
@@ -163,9 +162,8 @@ func WechatSignatureVerify() gin.HandlerFunc {
 			}
 
 			// 创建回调服务进行签名验证
-			kvService := services.NewKVService(config.AppConfig.Redis)
 			sessionService := services.NewSessionService(kvService)
-			userService := services.NewUserService(config.AppConfig.JWTSecret, config.AppConfig.Redis)
+			userService := services.NewUserService(kvService)
 			callbackService := services.NewWechatCallbackService(sessionService, userService)
 
 			if !callbackService.VerifySignature(signature, timestamp, nonce) {
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// UserStatusRequest 启用/禁用用户请求（管理员）
+type UserStatusRequest struct {
+	Status *int `json:"status" binding:"required,oneof=0 1"` // 0 禁用，1 启用
+}
+
+// UserRoleRequest 设置用户角色请求（管理员）
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+// AdminUserDetail 管理后台的用户详情
+type AdminUserDetail struct {
+	User
+	Identities []UserIdentity `json:"identities"`
+}
+
+// UsageStats 使用统计
+type UsageStats struct {
+	Users       UserUsageStats  `json:"users"`
+	Groups      GroupUsageStats `json:"groups"`
+	Characters  int64           `json:"characters"` // 未删除的角色数
+	Tasks       TaskUsageStats  `json:"tasks"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// UserUsageStats 用户统计
+type UserUsageStats struct {
+	Total     int64 `json:"total"`      // 不含已合并的账号
+	Active    int64 `json:"active"`     // 状态正常
+	Disabled  int64 `json:"disabled"`   // 已禁用
+	New24h    int64 `json:"new_24h"`    // 24小时内注册
+	New7d     int64 `json:"new_7d"`     // 7天内注册
+	Online24h int64 `json:"online_24h"` // 24小时内有活跃登录会话
+}
+
+// GroupUsageStats 群组统计
+type GroupUsageStats struct {
+	Total   int64 `json:"total"`   // 未删除的群组数
+	Public  int64 `json:"public"`  // 公开群组
+	Hidden  int64 `json:"hidden"`  // 被管理员下架
+	Deleted int64 `json:"deleted"` // 回收站中
+}
+
+// TaskUsageStats 定时任务统计
+type TaskUsageStats struct {
+	Total         int64 `json:"total"`
+	Enabled       int64 `json:"enabled"`
+	Runs24h       int64 `json:"runs_24h"`        // 24小时内执行次数
+	FailedRuns24h int64 `json:"failed_runs_24h"` // 24小时内执行失败次数
+}
+
+// AdminUserListResponse 用户列表响应（管理员）
+type AdminUserListResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    []User `json:"data,omitempty"`
+	Total   int64  `json:"total"`
+}
+
+// AdminUserResponse 用户详情响应（管理员）
+type AdminUserResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    *AdminUserDetail `json:"data,omitempty"`
+}
+
+// UsageStatsResponse 使用统计响应
+type UsageStatsResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    *UsageStats `json:"data,omitempty"`
+}
+
+// AdminConfigResponse 运行配置响应，敏感字段已脱敏
+type AdminConfigResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
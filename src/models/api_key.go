@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+// API密钥权限范围
+const (
+	APIKeyScopeChat        = "chat"         // 调用 /api/chat、/api/init、/api/scheduler
+	APIKeyScopeGroupsRead  = "groups:read"  // 读取群组、角色和公开目录
+	APIKeyScopeGroupsWrite = "groups:write" // 创建、修改、删除群组和角色，包含 groups:read
+)
+
+// IsValidAPIKeyScope 检查权限范围是否有效
+func IsValidAPIKeyScope(scope string) bool {
+	switch scope {
+	case APIKeyScopeChat, APIKeyScopeGroupsRead, APIKeyScopeGroupsWrite:
+		return true
+	}
+	return false
+}
+
+// APIKey 个人API密钥，只保存密钥的SHA-256摘要，明文仅在创建时返回一次
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index;comment:用户ID"`
+	Name       string     `json:"name" gorm:"size:100;not null;comment:密钥名称"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null;comment:密钥明文前缀，用于识别密钥"`
+	KeyHash    string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:密钥SHA-256摘要"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json;type:text;comment:权限范围"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"comment:过期时间，为空表示永不过期"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" gorm:"comment:最近使用时间"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"comment:吊销时间"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 设置表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope 检查密钥是否拥有指定权限范围，groups:write 包含 groups:read
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || (s == APIKeyScopeGroupsWrite && scope == APIKeyScopeGroupsRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired 检查密钥在指定时间是否已过期
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// APIKeyCreateRequest 创建API密钥请求
+type APIKeyCreateRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=chat groups:read groups:write"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=3650"` // 有效天数，0 表示永不过期
+}
+
+// APIKeyCreateResult 创建API密钥结果，Key 为密钥明文，只返回这一次
+type APIKeyCreateResult struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
+
+// APIKeyListResponse API密钥列表响应
+type APIKeyListResponse struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Data    []APIKey `json:"data,omitempty"`
+}
+
+// APIKeyCreateResponse 创建API密钥响应
+type APIKeyCreateResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    *APIKeyCreateResult `json:"data,omitempty"`
+}
+
+// APIKeyResponse API密钥操作响应
+type APIKeyResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
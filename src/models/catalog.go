@@ -0,0 +1,33 @@
+package models
+
+// CatalogGroup 目录中的群组，字段与配置文件中的群组结构保持一致，便于前端统一处理
+type CatalogGroup struct {
+	ID                    string   `json:"id"`  // 系统群组为配置中的id，用户群组为 group_<数据库ID>
+	GID                   uint     `json:"gid"` // 数据库中的群组ID
+	Name                  string   `json:"name"`
+	Description           string   `json:"description"`
+	Members               []string `json:"members"`
+	IsGroupDiscussionMode bool     `json:"isGroupDiscussionMode"`
+	IsSystem              bool     `json:"isSystem"`
+	OwnerID               uint     `json:"owner_id"`
+	Visibility            string   `json:"visibility"`
+}
+
+// CatalogCharacter 目录中的角色，字段与配置文件中的角色结构保持一致
+type CatalogCharacter struct {
+	ID           string   `json:"id"` // 系统角色为配置中的id，用户角色为 char_<数据库ID>
+	Name         string   `json:"name"`
+	Personality  string   `json:"personality"`
+	Model        string   `json:"model"`
+	Avatar       string   `json:"avatar"`
+	CustomPrompt string   `json:"custom_prompt"`
+	Tags         []string `json:"tags"`
+	RAG          bool     `json:"rag"`
+	Knowledge    string   `json:"knowledge"`
+}
+
+// Catalog 群组与角色目录
+type Catalog struct {
+	Groups     []CatalogGroup     `json:"groups"`
+	Characters []CatalogCharacter `json:"characters"`
+}
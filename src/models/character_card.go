@@ -0,0 +1,55 @@
+package models
+
+import "encoding/json"
+
+// 角色卡规范标识
+const (
+	CharacterCardSpecV2    = "chara_card_v2"
+	CharacterCardVersionV2 = "2.0"
+	// CharacterCardPNGKeyword PNG角色卡中保存角色数据的 tEXt 关键字
+	CharacterCardPNGKeyword = "chara"
+)
+
+// CharacterCardV2 Character Card V2 角色卡（SillyTavern / TavernAI）
+type CharacterCardV2 struct {
+	Spec        string            `json:"spec"`
+	SpecVersion string            `json:"spec_version"`
+	Data        CharacterCardData `json:"data"`
+}
+
+// CharacterCardData 角色卡数据，V1角色卡的字段与其相同但位于顶层
+type CharacterCardData struct {
+	Name                    string                 `json:"name"`
+	Description             string                 `json:"description"`
+	Personality             string                 `json:"personality"`
+	Scenario                string                 `json:"scenario"`
+	FirstMes                string                 `json:"first_mes"`
+	MesExample              string                 `json:"mes_example"`
+	CreatorNotes            string                 `json:"creator_notes"`
+	SystemPrompt            string                 `json:"system_prompt"`
+	PostHistoryInstructions string                 `json:"post_history_instructions"`
+	AlternateGreetings      []string               `json:"alternate_greetings"`
+	CharacterBook           json.RawMessage        `json:"character_book,omitempty"`
+	Tags                    []string               `json:"tags"`
+	Creator                 string                 `json:"creator"`
+	CharacterVersion        string                 `json:"character_version"`
+	Extensions              map[string]interface{} `json:"extensions"`
+}
+
+// CharacterExtension 角色扩展信息，保存导入角色卡中无法映射到角色字段的内容，导出时原样写回
+type CharacterExtension struct {
+	Description             string                 `json:"description,omitempty"`
+	Personality             string                 `json:"personality,omitempty"` // 完整的性格描述，角色的personality字段有长度限制
+	Scenario                string                 `json:"scenario,omitempty"`
+	FirstMes                string                 `json:"first_mes,omitempty"`
+	MesExample              string                 `json:"mes_example,omitempty"`
+	CreatorNotes            string                 `json:"creator_notes,omitempty"`
+	SystemPrompt            string                 `json:"system_prompt,omitempty"`
+	PostHistoryInstructions string                 `json:"post_history_instructions,omitempty"`
+	AlternateGreetings      []string               `json:"alternate_greetings,omitempty"`
+	CharacterBook           json.RawMessage        `json:"character_book,omitempty"`
+	Tags                    []string               `json:"tags,omitempty"`
+	Creator                 string                 `json:"creator,omitempty"`
+	CharacterVersion        string                 `json:"character_version,omitempty"`
+	Extensions              map[string]interface{} `json:"extensions,omitempty"`
+}
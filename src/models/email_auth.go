@@ -0,0 +1,43 @@
+package models
+
+// PendingEmailRegistration 待验证的邮箱注册信息，以验证链接令牌的摘要为键保存在KV存储中
+type PendingEmailRegistration struct {
+	Email        string `json:"email"`
+	PasswordHash string `json:"password_hash"`
+	Nickname     string `json:"nickname"`
+}
+
+// EmailRegisterRequest 邮箱注册请求
+type EmailRegisterRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Nickname string `json:"nickname" binding:"max=50"`
+}
+
+// EmailLoginRequest 邮箱密码登录请求
+type EmailLoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// EmailRequest 只包含邮箱的请求，用于找回密码和发送免密登录链接
+type EmailRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// EmailTokenRequest 邮件链接中的令牌，用于验证邮箱和免密登录
+type EmailTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PasswordResetRequest 重置密码请求
+type PasswordResetRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// EmailResponse 邮件发送等不返回数据的操作响应
+type EmailResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// 群组导出包格式标识与当前版本
+const (
+	GroupBundleFormat  = "botgroup.group"
+	GroupBundleVersion = 1
+)
+
+// 导入群组时的同名冲突处理方式
+const (
+	ImportConflictRename  = "rename"  // 自动重命名，如“周末闲聊 (2)”
+	ImportConflictReplace = "replace" // 覆盖自己的同名群组
+	ImportConflictFail    = "fail"    // 返回冲突错误
+)
+
+// GroupBundle 群组导出包，包含群组信息、角色及定时发言设置，用于在账号或环境之间迁移群组
+type GroupBundle struct {
+	Format     string                 `json:"format"`
+	Version    int                    `json:"version"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Group      GroupBundleGroup       `json:"group"`
+	Characters []GroupBundleCharacter `json:"characters"`
+	Tasks      []GroupBundleTask      `json:"tasks"`
+}
+
+// GroupBundleGroup 导出包中的群组信息，导入后的群组可见性固定为私有
+type GroupBundleGroup struct {
+	Name                  string   `json:"name"`
+	Description           string   `json:"description"`
+	IsGroupDiscussionMode bool     `json:"is_group_discussion_mode"`
+	Category              string   `json:"category,omitempty"`
+	Tags                  []string `json:"tags,omitempty"`
+}
+
+// GroupBundleCharacter 导出包中的角色，Ref 为包内唯一标识，供定时任务引用
+type GroupBundleCharacter struct {
+	Ref          string              `json:"ref"`
+	Name         string              `json:"name"`
+	Personality  string              `json:"personality"`
+	Model        string              `json:"model"`
+	Avatar       string              `json:"avatar"` // 完整的头像URL
+	CustomPrompt string              `json:"custom_prompt"`
+	Tags         []string            `json:"tags"`
+	RAG          bool                `json:"rag"`
+	Knowledge    string              `json:"knowledge"`
+	Extension    *CharacterExtension `json:"extension,omitempty"`
+}
+
+// GroupBundleTask 导出包中的定时发言设置
+type GroupBundleTask struct {
+	CharacterRef string `json:"character_ref"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Prompt       string `json:"prompt"`
+	CronExpr     string `json:"cron_expr"`
+	Enabled      bool   `json:"enabled"`
+}
+
+// GroupCloneRequest 克隆群组请求，未指定名称时沿用原群组名称，重名时自动重命名
+type GroupCloneRequest struct {
+	Name string `json:"name" binding:"max=100"`
+}
@@ -11,15 +11,23 @@ import (
 
 // GroupCharacter 群组角色模型
 type GroupCharacter struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	GID          uint      `json:"gid" gorm:"not null;index;comment:群组ID，关联llm_groups表的id字段"`
-	Name         string    `json:"name" gorm:"size:100;not null;index;comment:角色名称"`
-	Personality  string    `json:"personality" gorm:"size:100;not null;default:'';comment:角色性格描述"`
-	Model        string    `json:"model" gorm:"size:50;index;comment:AI模型名称"`
-	Avatar       string    `json:"avatar" gorm:"type:text;comment:角色头像URL"`
-	CustomPrompt string    `json:"custom_prompt" gorm:"type:text;comment:自定义提示词"`
-	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	GID          uint                `json:"gid" gorm:"column:gid;not null;index;uniqueIndex:idx_gid_system_key;comment:群组ID，关联llm_groups表的id字段"`
+	OwnerID      uint                `json:"owner_id" gorm:"not null;default:0;index;comment:所有者用户ID"`
+	SystemKey    *string             `json:"system_key,omitempty" gorm:"size:100;uniqueIndex:idx_gid_system_key;comment:系统角色标识，对应配置文件中的角色id"`
+	Name         string              `json:"name" gorm:"size:100;not null;index;comment:角色名称"`
+	Personality  string              `json:"personality" gorm:"size:100;not null;default:'';comment:角色性格描述"`
+	Model        string              `json:"model" gorm:"size:50;index;comment:AI模型名称"`
+	Avatar       string              `json:"avatar" gorm:"type:text;comment:角色头像URL"`
+	CustomPrompt string              `json:"custom_prompt" gorm:"type:text;comment:自定义提示词"`
+	Tags         []string            `json:"tags" gorm:"serializer:json;type:text;comment:角色标签"`
+	RAG          bool                `json:"rag" gorm:"not null;default:false;comment:是否启用知识库"`
+	Knowledge    string              `json:"knowledge" gorm:"size:255;comment:知识库文件"`
+	Extension    *CharacterExtension `json:"extension,omitempty" gorm:"serializer:json;type:text;comment:角色扩展信息(JSON)"` // 导入角色卡时无法映射的字段
+	SortOrder    int                 `json:"sort_order" gorm:"not null;default:0;comment:群组内排序，越小越靠前"`
+	CreatedAt    time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt    gorm.DeletedAt      `json:"deleted_at" gorm:"index;comment:删除时间，非空表示已移入回收站"`
 
 	// 关联关系
 	Group *LlmGroup `json:"group,omitempty" gorm:"foreignKey:GID;references:ID"`
@@ -31,10 +39,12 @@ func (GroupCharacter) TableName() string {
 }
 
 // AfterFind GORM Hook: 查询后自动添加头像URL前缀
+// 系统角色的头像是前端静态资源路径，不添加前缀；导入或克隆的角色可能保存完整URL或静态资源路径，同样不添加
 func (gc *GroupCharacter) AfterFind(tx *gorm.DB) error {
-	if gc.Avatar != "" && config.AppConfig.Cloudflare.ImagePrefix != "" {
+	if gc.Avatar != "" && gc.SystemKey == nil && config.AppConfig.Cloudflare.ImagePrefix != "" {
 		// 检查URL是否已经包含前缀，避免重复添加
-		if !strings.HasPrefix(gc.Avatar, config.AppConfig.Cloudflare.ImagePrefix) {
+		if !strings.HasPrefix(gc.Avatar, config.AppConfig.Cloudflare.ImagePrefix) &&
+			!strings.HasPrefix(gc.Avatar, "/") && !strings.Contains(gc.Avatar, "://") {
 			gc.Avatar = fmt.Sprintf(config.AppConfig.Cloudflare.ImagePrefix, gc.Avatar)
 		}
 	}
@@ -51,15 +61,42 @@ type GroupCharacterCreateRequest struct {
 	CustomPrompt string `json:"custom_prompt" binding:"max=2000"`
 }
 
-// GroupCharacterUpdateRequest 更新群组角色请求
+// GroupCharacterUpdateRequest 更新群组角色请求，按 JSON Merge Patch 语义处理：
+// 未提供的字段不修改，null 清除字段（名称不可清除）
 type GroupCharacterUpdateRequest struct {
-	Name         string `json:"name" binding:"max=100"`
+	Name         PatchField[string] `json:"name" binding:"omitempty,max=100"`
+	Personality  PatchField[string] `json:"personality" binding:"omitempty,max=100"`
+	Model        PatchField[string] `json:"model" binding:"omitempty,max=50"`
+	Avatar       PatchField[string] `json:"avatar" binding:"omitempty,max=500"`
+	CustomPrompt PatchField[string] `json:"custom_prompt" binding:"omitempty,max=2000"`
+	SortOrder    PatchField[int]    `json:"sort_order"`
+}
+
+// GroupCharacterBatchCreate 批量操作中新建的角色
+type GroupCharacterBatchCreate struct {
+	Name         string `json:"name" binding:"required,max=100"`
 	Personality  string `json:"personality" binding:"max=100"`
 	Model        string `json:"model" binding:"max=50"`
 	Avatar       string `json:"avatar" binding:"max=500"`
 	CustomPrompt string `json:"custom_prompt" binding:"max=2000"`
 }
 
+// GroupCharacterBatchUpdate 批量操作中更新的角色
+type GroupCharacterBatchUpdate struct {
+	ID uint `json:"id" binding:"required"`
+	GroupCharacterUpdateRequest
+}
+
+// GroupCharacterBatchRequest 在一个事务中批量新建、更新、删除和排序群组内的角色
+// order 为群组内保留角色的ID（不含本次新建和删除的角色）按新顺序排列，需包含全部保留角色；
+// 新建的角色按提交顺序排在最后
+type GroupCharacterBatchRequest struct {
+	Create []GroupCharacterBatchCreate `json:"create" binding:"max=50,dive"`
+	Update []GroupCharacterBatchUpdate `json:"update" binding:"max=100,dive"`
+	Delete []uint                      `json:"delete" binding:"max=100"`
+	Order  []uint                      `json:"order" binding:"max=200"`
+}
+
 // GroupCharacterResponse 群组角色响应
 type GroupCharacterResponse struct {
 	Success bool            `json:"success"`
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// 群组互动类型
+const (
+	ReactionLike     = "like"     // 点赞
+	ReactionFavorite = "favorite" // 收藏
+)
+
+// GroupReaction 用户对群组的点赞/收藏记录，每个用户对同一群组的同一类型只有一条记录
+type GroupReaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_gid_type;comment:用户ID"`
+	GID       uint      `json:"gid" gorm:"column:gid;not null;uniqueIndex:idx_user_gid_type;index;comment:群组ID"`
+	Type      string    `json:"type" gorm:"size:20;not null;uniqueIndex:idx_user_gid_type;comment:类型：like|favorite"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 设置表名
+func (GroupReaction) TableName() string {
+	return "group_reactions"
+}
+
+// GroupReactionState 当前用户对群组的互动状态及计数
+type GroupReactionState struct {
+	Liked         bool `json:"liked"`
+	Favorited     bool `json:"favorited"`
+	LikeCount     int  `json:"like_count"`
+	FavoriteCount int  `json:"favorite_count"`
+}
+
+// GroupReactionResponse 群组互动响应
+type GroupReactionResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    *GroupReactionState `json:"data,omitempty"`
+}
+
+// GroupModerationRequest 群组审核请求（管理员）
+type GroupModerationRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved hidden"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// GroupCategoryListResponse 群组分类列表响应
+type GroupCategoryListResponse struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Data    []string `json:"data"`
+}
@@ -2,15 +2,44 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// 群组可见性
+const (
+	VisibilityPrivate  = "private"  // 仅所有者可见
+	VisibilityUnlisted = "unlisted" // 知道ID即可查看，但不出现在公开列表中
+	VisibilityPublic   = "public"   // 所有人可见，出现在公开列表中
+)
+
+// 群组审核状态，仅影响公开目录的展示，不影响通过ID访问
+const (
+	ModerationStatusApproved = "approved" // 正常展示
+	ModerationStatusHidden   = "hidden"   // 被管理员下架，不出现在公开目录中
 )
 
 // LlmGroup 群组模型
 type LlmGroup struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"size:100;not null;index;comment:群组名称"`
-	Description string    `json:"description" gorm:"type:text;comment:群组描述"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                    uint           `json:"id" gorm:"primaryKey"`
+	OwnerID               uint           `json:"owner_id" gorm:"not null;default:0;index;comment:所有者用户ID"`
+	SystemKey             *string        `json:"system_key,omitempty" gorm:"size:100;uniqueIndex;comment:系统群组标识，对应配置文件中的群组id"`
+	Name                  string         `json:"name" gorm:"size:100;not null;index;comment:群组名称"`
+	Description           string         `json:"description" gorm:"type:text;comment:群组描述"`
+	Visibility            string         `json:"visibility" gorm:"size:20;not null;default:'private';index;comment:可见性：private|unlisted|public"`
+	IsGroupDiscussionMode bool           `json:"is_group_discussion_mode" gorm:"not null;default:false;comment:是否群聊讨论模式"`
+	Category              string         `json:"category" gorm:"size:50;not null;default:'';index;comment:分类"`
+	Tags                  []string       `json:"tags" gorm:"serializer:json;type:text;comment:标签"`
+	ForkedFrom            *uint          `json:"forked_from,omitempty" gorm:"index;comment:克隆来源群组ID"`
+	LikeCount             int            `json:"like_count" gorm:"not null;default:0;comment:点赞数"`
+	FavoriteCount         int            `json:"favorite_count" gorm:"not null;default:0;comment:收藏数"`
+	ForkCount             int            `json:"fork_count" gorm:"not null;default:0;comment:被克隆次数"`
+	ModerationStatus      string         `json:"moderation_status" gorm:"size:20;not null;default:'approved';index;comment:审核状态：approved|hidden"`
+	ModerationReason      string         `json:"moderation_reason,omitempty" gorm:"size:255;not null;default:'';comment:审核说明"`
+	PublishedAt           *time.Time     `json:"published_at,omitempty" gorm:"index;comment:首次公开时间"`
+	CreatedAt             time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt             time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt             gorm.DeletedAt `json:"deleted_at" gorm:"index;comment:删除时间，非空表示已移入回收站"`
 
 	// 关联关系
 	Characters []GroupCharacter `json:"characters,omitempty" gorm:"foreignKey:GID;references:ID"`
@@ -21,16 +50,38 @@ func (LlmGroup) TableName() string {
 	return "llm_groups"
 }
 
+// IsSystem 判断是否为配置文件同步的系统群组
+func (g *LlmGroup) IsSystem() bool {
+	return g.SystemKey != nil
+}
+
+// IsOwnedBy 判断群组是否属于指定用户
+func (g *LlmGroup) IsOwnedBy(userID uint) bool {
+	return userID != 0 && g.OwnerID == userID
+}
+
+// CanView 判断指定用户是否可以查看群组（userID为0表示未登录）
+func (g *LlmGroup) CanView(userID uint) bool {
+	return g.IsOwnedBy(userID) || g.Visibility == VisibilityPublic || g.Visibility == VisibilityUnlisted
+}
+
 // LlmGroupCreateRequest 创建群组请求
 type LlmGroupCreateRequest struct {
-	Name        string `json:"name" binding:"required,max=100"`
-	Description string `json:"description" binding:"max=1000"`
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description string   `json:"description" binding:"max=1000"`
+	Visibility  string   `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
+	Category    string   `json:"category" binding:"max=50"`
+	Tags        []string `json:"tags" binding:"max=10,dive,max=20"`
 }
 
-// LlmGroupUpdateRequest 更新群组请求
+// LlmGroupUpdateRequest 更新群组请求，按 JSON Merge Patch 语义处理：
+// 未提供的字段不修改，null 清除字段（名称不可清除，可见性恢复为私有）
 type LlmGroupUpdateRequest struct {
-	Name        string `json:"name" binding:"max=100"`
-	Description string `json:"description" binding:"max=1000"`
+	Name        PatchField[string]   `json:"name" binding:"omitempty,max=100"`
+	Description PatchField[string]   `json:"description" binding:"omitempty,max=1000"`
+	Visibility  PatchField[string]   `json:"visibility" binding:"omitempty,oneof=private unlisted public"`
+	Category    PatchField[string]   `json:"category" binding:"omitempty,max=50"`
+	Tags        PatchField[[]string] `json:"tags" binding:"omitempty,max=10,dive,max=20"`
 }
 
 // LlmGroupResponse 群组响应
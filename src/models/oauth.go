@@ -0,0 +1,50 @@
+package models
+
+// OAuthProviderInfo 已启用的第三方登录提供商
+type OAuthProviderInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	DisplayName string `json:"display_name"`
+}
+
+// OAuthAuthorizeData 第三方登录授权地址，前端跳转到 AuthURL 完成授权
+type OAuthAuthorizeData struct {
+	AuthURL   string `json:"auth_url"`
+	State     string `json:"state"`
+	ExpiresIn int    `json:"expires_in"` // state 有效期(秒)
+}
+
+// OAuthState 授权请求的服务端状态，以 state 为键保存在KV存储中，回调时一次性取出
+type OAuthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`   // PKCE code_verifier
+	Nonce        string `json:"nonce,omitempty"` // OIDC nonce，GitHub 不使用
+}
+
+// OAuthUserInfo 第三方账号信息
+type OAuthUserInfo struct {
+	Subject   string `json:"subject"` // 第三方账号的唯一标识，GitHub 为用户ID，OIDC 为 sub
+	Nickname  string `json:"nickname"`
+	AvatarURL string `json:"avatar_url"`
+	Email     string `json:"email"`
+}
+
+// OAuthCallbackRequest 第三方登录回调请求，code 和 state 由提供商回调时附带在地址中
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// OAuthProviderListResponse 第三方登录提供商列表响应
+type OAuthProviderListResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    []OAuthProviderInfo `json:"data"`
+}
+
+// OAuthAuthorizeResponse 第三方登录授权地址响应
+type OAuthAuthorizeResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    *OAuthAuthorizeData `json:"data,omitempty"`
+}
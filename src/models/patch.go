@@ -0,0 +1,39 @@
+package models
+
+import "encoding/json"
+
+// PatchField JSON Merge Patch（RFC 7396）语义的更新字段
+// 请求中未出现该字段时 Set 为 false，表示不修改；值为 null 时 Null 为 true，表示清除（恢复默认值）；
+// 其他情况 Value 为新值
+type PatchField[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON 记录字段是否出现以及是否为 null
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	f.Null = string(data) == "null"
+	if f.Null {
+		var zero T
+		f.Value = zero
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Get 返回字段的新值，null 时返回零值；ok 为 false 表示请求中未出现该字段
+func (f PatchField[T]) Get() (value T, ok bool) {
+	return f.Value, f.Set
+}
+
+// PatchValue 创建设置为指定值的更新字段
+func PatchValue[T any](value T) PatchField[T] {
+	return PatchField[T]{Set: true, Value: value}
+}
+
+// PatchNull 创建值为 null 的更新字段
+func PatchNull[T any]() PatchField[T] {
+	return PatchField[T]{Set: true, Null: true}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// RefreshToken 刷新令牌，数据库中只保存令牌的SHA-256摘要
+// 同一次登录产生的刷新令牌共享 SessionID（令牌族），每次刷新后旧令牌作废并签发新令牌；
+// 已作废的令牌被再次使用时视为泄露，整个令牌族随即吊销
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index;comment:用户ID"`
+	SessionID string     `json:"session_id" gorm:"size:64;not null;index;comment:登录会话ID，同一次登录轮换产生的令牌相同"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:令牌SHA-256摘要"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;comment:过期时间"`
+	UsedAt    *time.Time `json:"used_at,omitempty" gorm:"comment:轮换时间，非空表示已换发新令牌"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" gorm:"comment:吊销时间"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 设置表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 退出登录请求，可同时提交刷新令牌以吊销其所属会话
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"project/src/utils"
+)
+
+// 修订记录所属对象类型
+const (
+	RevisionEntityGroup     = "group"
+	RevisionEntityCharacter = "character"
+)
+
+// 修订记录产生方式
+const (
+	RevisionActionInitial  = "initial"  // 首次修改前补记的原始内容
+	RevisionActionCreate   = "create"   // 创建
+	RevisionActionUpdate   = "update"   // 更新
+	RevisionActionRollback = "rollback" // 回滚到历史版本
+)
+
+// Revision 群组/角色的修订记录，每次修改后保存一份完整快照，记录写入后不再修改
+type Revision struct {
+	ID            uint            `json:"id" gorm:"primaryKey"`
+	EntityType    string          `json:"entity_type" gorm:"size:20;not null;uniqueIndex:idx_entity_version;comment:对象类型：group|character"`
+	EntityID      uint            `json:"entity_id" gorm:"not null;uniqueIndex:idx_entity_version;comment:群组或角色ID"`
+	Version       int             `json:"version" gorm:"not null;uniqueIndex:idx_entity_version;comment:版本号，从1开始递增"`
+	Action        string          `json:"action" gorm:"size:20;not null;comment:产生方式：initial|create|update|rollback"`
+	SourceVersion int             `json:"source_version,omitempty" gorm:"not null;default:0;comment:回滚时的目标版本号"`
+	AuthorID      uint            `json:"author_id" gorm:"not null;default:0;index;comment:修改人用户ID"`
+	Snapshot      json.RawMessage `json:"snapshot" gorm:"type:mediumtext;comment:修改后的完整内容(JSON)"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 设置表名
+func (Revision) TableName() string {
+	return "revisions"
+}
+
+// GroupSnapshot 群组修订快照
+type GroupSnapshot struct {
+	Name                  string   `json:"name"`
+	Description           string   `json:"description"`
+	Visibility            string   `json:"visibility"`
+	IsGroupDiscussionMode bool     `json:"is_group_discussion_mode"`
+	Category              string   `json:"category"`
+	Tags                  []string `json:"tags"`
+}
+
+// CharacterSnapshot 角色修订快照
+type CharacterSnapshot struct {
+	Name         string              `json:"name"`
+	Personality  string              `json:"personality"`
+	Model        string              `json:"model"`
+	Avatar       string              `json:"avatar"`
+	CustomPrompt string              `json:"custom_prompt"`
+	Tags         []string            `json:"tags"`
+	RAG          bool                `json:"rag"`
+	Knowledge    string              `json:"knowledge"`
+	Extension    *CharacterExtension `json:"extension,omitempty"`
+}
+
+// RevisionFieldChange 两个版本之间单个字段的差异，多行文本字段附带逐行差异
+type RevisionFieldChange struct {
+	Field string           `json:"field"`
+	Old   interface{}      `json:"old"`
+	New   interface{}      `json:"new"`
+	Lines []utils.DiffLine `json:"lines,omitempty"`
+}
+
+// RevisionDiff 两个版本之间的差异
+type RevisionDiff struct {
+	From    int                   `json:"from"`
+	To      int                   `json:"to"`
+	Changes []RevisionFieldChange `json:"changes"`
+}
+
+// RevisionListResponse 修订记录列表响应
+type RevisionListResponse struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message"`
+	Data    []Revision `json:"data,omitempty"`
+	Total   int64      `json:"total,omitempty"`
+}
+
+// RevisionDiffResponse 修订差异响应
+type RevisionDiffResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    *RevisionDiff `json:"data,omitempty"`
+}
+
+// RevisionResponse 修订记录响应
+type RevisionResponse struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Data    *Revision `json:"data,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+// 用户角色
+const (
+	RoleUser      = "user"      // 普通用户
+	RoleModerator = "moderator" // 运营，可审核群组和查看统计
+	RoleAdmin     = "admin"     // 管理员，拥有全部权限
+)
+
+// 管理后台权限
+const (
+	PermissionUserManage    = "user:manage"    // 搜索用户、启用/禁用用户、设置角色
+	PermissionGroupModerate = "group:moderate" // 审核公开目录中的群组
+	PermissionStatsView     = "stats:view"     // 查看使用统计
+	PermissionConfigView    = "config:view"    // 查看运行配置（敏感字段已脱敏）
+	PermissionSystemDebug   = "system:debug"   // 微信登录调试与测试接口
+)
+
+// rolePermissions 角色拥有的权限，普通用户没有管理后台权限
+var rolePermissions = map[string][]string{
+	RoleModerator: {PermissionGroupModerate, PermissionStatsView},
+	RoleAdmin: {
+		PermissionUserManage,
+		PermissionGroupModerate,
+		PermissionStatsView,
+		PermissionConfigView,
+		PermissionSystemDebug,
+	},
+}
+
+// IsValidRole 判断是否为有效的角色
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
+// HasRole 判断用户是否拥有任一指定角色
+func (u *User) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission 判断用户的角色是否拥有指定权限
+func (u *User) HasPermission(permission string) bool {
+	for _, p := range rolePermissions[u.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions 获取用户角色拥有的全部权限
+func (u *User) Permissions() []string {
+	permissions := rolePermissions[u.Role]
+	if permissions == nil {
+		return []string{}
+	}
+	return append([]string(nil), permissions...)
+}
@@ -4,23 +4,119 @@ import (
 	"time"
 )
 
-// Task 任务模型
+// 任务状态
+const (
+	TaskStatusPending   = "pending"   // 等待执行
+	TaskStatusRunning   = "running"   // 执行中
+	TaskStatusCompleted = "completed" // 最近一次执行成功
+	TaskStatusFailed    = "failed"    // 最近一次执行失败
+)
+
+// 执行记录状态
+const (
+	ExecutionStatusRunning = "running"
+	ExecutionStatusSuccess = "success"
+	ExecutionStatusFailed  = "failed"
+)
+
+// 执行触发方式
+const (
+	TaskTriggerSchedule = "schedule" // 按Cron表达式自动触发
+	TaskTriggerManual   = "manual"   // 手动触发
+)
+
+// Task 定时任务模型（群组角色定时发言）
 type Task struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CronExpr    string    `json:"cron_expr"`
-	Status      string    `json:"status"` // pending, running, completed, failed
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	OwnerID     uint       `json:"owner_id" gorm:"not null;index;comment:创建者用户ID"`
+	GID         uint       `json:"gid" gorm:"column:gid;not null;index;comment:群组ID"`
+	CharacterID uint       `json:"character_id" gorm:"not null;index;comment:发言角色ID"`
+	Name        string     `json:"name" gorm:"size:100;not null;comment:任务名称"`
+	Description string     `json:"description" gorm:"type:text;comment:任务描述"`
+	Prompt      string     `json:"prompt" gorm:"type:text;comment:发言指令，如：总结今天的科技新闻"`
+	CronExpr    string     `json:"cron_expr" gorm:"size:100;not null;comment:Cron表达式"`
+	Enabled     bool       `json:"enabled" gorm:"not null;default:true;index;comment:是否启用"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:'pending';comment:任务状态"` // pending, running, completed, failed
+	LastRunAt   *time.Time `json:"last_run_at" gorm:"comment:最近一次执行时间"`
+	NextRunAt   *time.Time `json:"next_run_at" gorm:"index;comment:下一次执行时间"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// 关联关系
+	Group     *LlmGroup       `json:"group,omitempty" gorm:"foreignKey:GID;references:ID"`
+	Character *GroupCharacter `json:"character,omitempty" gorm:"foreignKey:CharacterID;references:ID"`
+}
+
+// TableName 设置表名
+func (Task) TableName() string {
+	return "scheduled_tasks"
 }
 
 // TaskExecution 任务执行记录
 type TaskExecution struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	TaskID    uint      `json:"task_id"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
-	Status    string    `json:"status"` // success, failed
-	Log       string    `json:"log"`
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TaskID    uint       `json:"task_id" gorm:"not null;index;comment:任务ID"`
+	Trigger   string     `json:"trigger" gorm:"size:20;not null;default:'schedule';comment:触发方式"` // schedule, manual
+	Instance  string     `json:"instance" gorm:"size:100;comment:执行实例标识"`
+	StartTime time.Time  `json:"start_time" gorm:"index;comment:开始时间"`
+	EndTime   *time.Time `json:"end_time" gorm:"comment:结束时间"`
+	Status    string     `json:"status" gorm:"size:20;not null;comment:执行状态"` // running, success, failed
+	Output    string     `json:"output" gorm:"type:text;comment:角色发言内容"`
+	Log       string     `json:"log" gorm:"type:text;comment:执行日志或错误信息"`
+}
+
+// TableName 设置表名
+func (TaskExecution) TableName() string {
+	return "task_executions"
+}
+
+// TaskCreateRequest 创建定时任务请求
+type TaskCreateRequest struct {
+	GID         uint   `json:"gid" binding:"required"`
+	CharacterID uint   `json:"character_id" binding:"required"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+	Prompt      string `json:"prompt" binding:"required,max=2000"`
+	CronExpr    string `json:"cron_expr" binding:"required,max=100"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+// TaskUpdateRequest 更新定时任务请求，description 为空字符串或 null 时清空描述
+type TaskUpdateRequest struct {
+	CharacterID uint               `json:"character_id"`
+	Name        string             `json:"name" binding:"max=100"`
+	Description PatchField[string] `json:"description" binding:"omitempty,max=1000"`
+	Prompt      string             `json:"prompt" binding:"max=2000"`
+	CronExpr    string             `json:"cron_expr" binding:"max=100"`
+	Enabled     *bool              `json:"enabled"`
+}
+
+// TaskResponse 定时任务响应
+type TaskResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    *Task  `json:"data,omitempty"`
+}
+
+// TaskListResponse 定时任务列表响应
+type TaskListResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    []Task `json:"data,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+}
+
+// TaskExecutionResponse 任务执行记录响应
+type TaskExecutionResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    *TaskExecution `json:"data,omitempty"`
+}
+
+// TaskExecutionListResponse 任务执行记录列表响应
+type TaskExecutionListResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    []TaskExecution `json:"data,omitempty"`
+	Total   int64           `json:"total,omitempty"`
 }
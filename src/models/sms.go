@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SMSMessage mock 短信服务商记录的短信，用于开发环境查看验证码
+type SMSMessage struct {
+	Phone         string            `json:"phone"`
+	Code          string            `json:"code,omitempty"`           // 验证码短信的验证码
+	TemplateCode  string            `json:"template_code,omitempty"`  // 模板短信的模板编号
+	TemplateParam map[string]string `json:"template_param,omitempty"` // 模板短信的参数
+	SentAt        time.Time         `json:"sent_at"`
+}
+
+// SMSInboxResponse 开发环境短信收件箱响应，按手机号分组，每组按发送时间倒序
+type SMSInboxResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message"`
+	Data    map[string][]SMSMessage `json:"data,omitempty"`
+}
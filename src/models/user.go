@@ -11,17 +11,25 @@ import (
 
 // User 用户模型
 type User struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Phone       string    `json:"phone" gorm:"size:11;charset:utf8mb4;collation:utf8mb4_unicode_ci"`
-	OpenID      *string   `json:"openid" gorm:"column:openid;uniqueIndex;size:100;charset:utf8mb4;collation:utf8mb4_unicode_ci"`
-	Nickname    string    `json:"nickname" gorm:"size:50;charset:utf8mb4;collation:utf8mb4_unicode_ci"`
-	AvatarURL   string    `json:"avatar_url" gorm:"column:avatar_url;type:text;charset:utf8mb4;collation:utf8mb4_unicode_ci"`
-	Status      int       `json:"status" gorm:"default:1"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-	LastLoginAt time.Time `json:"last_login_at" gorm:"autoCreateTime"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Phone        string    `json:"phone" gorm:"size:16;charset:utf8mb4;collation:utf8mb4_unicode_ci"` // 展示用的手机号（E.164格式），登录身份以 user_identities 为准
+	Nickname     string    `json:"nickname" gorm:"size:50;charset:utf8mb4;collation:utf8mb4_unicode_ci"`
+	AvatarURL    string    `json:"avatar_url" gorm:"column:avatar_url;type:text;charset:utf8mb4;collation:utf8mb4_unicode_ci"`
+	Status       int       `json:"status" gorm:"default:1"`
+	Role         string    `json:"role" gorm:"size:20;not null;default:'user';index;comment:角色：user|moderator|admin"`
+	PasswordHash string    `json:"-" gorm:"size:100;not null;default:'';comment:邮箱登录密码的bcrypt摘要，为空表示未设置密码"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	LastLoginAt  time.Time `json:"last_login_at" gorm:"autoCreateTime"`
 }
 
+// 用户状态
+const (
+	UserStatusDisabled = 0 // 已禁用
+	UserStatusActive   = 1 // 正常
+	UserStatusMerged   = 2 // 已合并到其他账号
+)
+
 // TableName 设置表名
 func (User) TableName() string {
 	return "users"
@@ -53,6 +61,8 @@ type UserLoginResponse struct {
 
 // UserData 用户数据
 type UserData struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token        string `json:"token"`                   // 访问令牌
+	RefreshToken string `json:"refresh_token,omitempty"` // 刷新令牌，用于换取新的访问令牌
+	ExpiresIn    int    `json:"expires_in,omitempty"`    // 访问令牌有效期(秒)
+	User         *User  `json:"user"`
 }
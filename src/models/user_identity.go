@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// UserIdentity 用户的登录身份，一个用户可以绑定多个手机号或微信，同一身份只能属于一个用户
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index;comment:用户ID"`
+	Provider  string    `json:"provider" gorm:"size:20;not null;uniqueIndex:idx_provider_subject;comment:身份类型，与登录方式一致：phone|wechat|email|第三方登录提供商标识"`
+	Subject   string    `json:"subject" gorm:"size:100;not null;uniqueIndex:idx_provider_subject;comment:手机号（E.164格式）、邮箱、微信OpenID或第三方账号ID"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 设置表名
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// BindPhoneRequest 绑定手机号请求
+type BindPhoneRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// MergeAccountRequest 合并账号请求，source_token 为待合并账号的访问令牌（登录该账号后获得）
+type MergeAccountRequest struct {
+	SourceToken string `json:"source_token" binding:"required"`
+}
+
+// AccountMergeResult 账号合并结果
+type AccountMergeResult struct {
+	SourceUserID uint  `json:"source_user_id"`
+	Groups       int64 `json:"groups"`     // 转移的群组数
+	Characters   int64 `json:"characters"` // 转移的角色数
+	Tasks        int64 `json:"tasks"`      // 转移的定时任务数
+	Identities   int64 `json:"identities"` // 转移的登录身份数
+}
+
+// UserIdentityListResponse 登录身份列表响应
+type UserIdentityListResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    []UserIdentity `json:"data,omitempty"`
+}
+
+// UserIdentityResponse 登录身份响应
+type UserIdentityResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    *UserIdentity `json:"data,omitempty"`
+}
+
+// AccountMergeResponse 账号合并响应
+type AccountMergeResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    *AccountMergeResult `json:"data,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// UserSession 登录会话，每次登录创建一条，SessionID 与访问令牌中的 sid 及刷新令牌的 session_id 一致
+type UserSession struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	SessionID  string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:登录会话ID"`
+	UserID     uint       `json:"user_id" gorm:"not null;index;comment:用户ID"`
+	LoginType  string     `json:"login_type" gorm:"size:20;not null;default:'';comment:登录方式：phone|wechat|email|第三方登录提供商标识"`
+	Device     string     `json:"device" gorm:"size:100;not null;default:'';comment:由User-Agent识别的设备描述"`
+	UserAgent  string     `json:"user_agent" gorm:"size:512;not null;default:'';comment:登录时的User-Agent"`
+	IP         string     `json:"ip" gorm:"column:ip;size:64;not null;default:'';comment:登录IP"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"not null;comment:最近活跃时间"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null;comment:过期时间，与最新刷新令牌一致"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"comment:吊销时间"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	Current    bool       `json:"current" gorm:"-"` // 是否为当前请求所属的会话
+}
+
+// TableName 设置表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// LoginClient 登录时的客户端信息，用于记录登录会话
+type LoginClient struct {
+	LoginType string
+	UserAgent string
+	IP        string
+}
+
+// UserSessionListResponse 登录会话列表响应
+type UserSessionListResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    []UserSession `json:"data,omitempty"`
+}
+
+// UserSessionRevokeResponse 吊销登录会话响应
+type UserSessionRevokeResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Revoked int64  `json:"revoked"` // 被吊销的会话数量
+}
@@ -4,10 +4,9 @@ import (
 	"time"
 )
 
-// WechatUser 微信用户模型
+// WechatUser 微信用户资料，与用户的关联见 UserIdentity
 type WechatUser struct {
 	ID             uint      `json:"id" gorm:"primaryKey"`
-	UID            uint      `json:"uid" gorm:"index;comment:关联用户ID"` // 关联users表的id
 	OpenID         string    `json:"openid" gorm:"uniqueIndex;size:64;not null;comment:微信OpenID"`
 	Nickname       string    `json:"nickname" gorm:"size:100;comment:微信昵称"`
 	AvatarURL      string    `json:"avatar_url" gorm:"column:avatar_url;type:text;comment:微信头像URL"`
@@ -17,9 +16,6 @@ type WechatUser struct {
 	LastLoginAt    time.Time `json:"last_login_at" gorm:"autoCreateTime;comment:最后登录时间"`
 	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt      time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-
-	// 关联关系
-	User *User `json:"user,omitempty" gorm:"foreignKey:UID;references:ID"`
 }
 
 // TableName 设置表名
@@ -29,13 +25,15 @@ func (WechatUser) TableName() string {
 
 // LoginSession Redis中存储的登录会话结构
 type LoginSession struct {
-	SessionID string `json:"session_id"` // 会话ID
-	QRScene   string `json:"qr_scene"`   // 二维码场景值
-	Status    string `json:"status"`     // pending|success|expired
-	UserID    uint   `json:"user_id"`    // 登录成功后的用户ID
-	OpenID    string `json:"openid"`     // 微信openid
-	CreatedAt int64  `json:"created_at"` // 创建时间戳
-	ExpiresAt int64  `json:"expires_at"` // 过期时间戳
+	SessionID  string `json:"session_id"`             // 会话ID
+	QRScene    string `json:"qr_scene"`               // 二维码场景值
+	Status     string `json:"status"`                 // pending|success|expired|failed
+	UserID     uint   `json:"user_id"`                // 登录成功后的用户ID
+	OpenID     string `json:"openid"`                 // 微信openid
+	BindUserID uint   `json:"bind_user_id,omitempty"` // 非0时为绑定微信的二维码，扫码后将微信绑定到该用户而不是登录
+	Message    string `json:"message,omitempty"`      // 失败原因
+	CreatedAt  int64  `json:"created_at"`             // 创建时间戳
+	ExpiresAt  int64  `json:"expires_at"`             // 过期时间戳
 }
 
 // WechatLoginRequest 微信登录请求
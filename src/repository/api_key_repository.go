@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrAPIKeyNotFound API密钥不存在
+var ErrAPIKeyNotFound = errors.New("API密钥不存在")
+
+// APIKeyRepository API密钥仓库接口
+type APIKeyRepository interface {
+	CreateAPIKey(key *models.APIKey) error
+	GetAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	GetAPIKeyByID(id uint) (*models.APIKey, error)
+	GetUserAPIKeys(userID uint) ([]models.APIKey, error)
+	CountUserAPIKeys(userID uint) (int64, error)
+	TouchAPIKey(id uint, usedAt time.Time) error
+	RevokeAPIKey(id uint) error
+}
+
+// apiKeyRepository API密钥仓库实现
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建API密钥仓库实例
+func NewAPIKeyRepository() APIKeyRepository {
+	return &apiKeyRepository{
+		db: config.GetDB(),
+	}
+}
+
+// CreateAPIKey 创建API密钥
+func (r *apiKeyRepository) CreateAPIKey(key *models.APIKey) error {
+	if err := r.db.Create(key).Error; err != nil {
+		return fmt.Errorf("创建API密钥失败: %v", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash 根据密钥摘要获取API密钥
+func (r *apiKeyRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("查询API密钥失败: %v", err)
+	}
+	return &key, nil
+}
+
+// GetAPIKeyByID 根据主键获取API密钥
+func (r *apiKeyRepository) GetAPIKeyByID(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.First(&key, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("查询API密钥失败: %v", err)
+	}
+	return &key, nil
+}
+
+// GetUserAPIKeys 获取用户未吊销的API密钥（含已过期），按创建时间倒序
+func (r *apiKeyRepository) GetUserAPIKeys(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("id DESC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("获取API密钥失败: %v", err)
+	}
+	return keys, nil
+}
+
+// CountUserAPIKeys 统计用户未吊销的API密钥数量
+func (r *apiKeyRepository) CountUserAPIKeys(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("统计API密钥失败: %v", err)
+	}
+	return count, nil
+}
+
+// TouchAPIKey 更新最近使用时间，一分钟内重复调用不写库
+func (r *apiKeyRepository) TouchAPIKey(id uint, usedAt time.Time) error {
+	if err := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-time.Minute)).
+		Update("last_used_at", usedAt).Error; err != nil {
+		return fmt.Errorf("更新API密钥失败: %v", err)
+	}
+	return nil
+}
+
+// RevokeAPIKey 吊销API密钥
+func (r *apiKeyRepository) RevokeAPIKey(id uint) error {
+	if err := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("吊销API密钥失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrCharacterNotFound 角色不存在
+var ErrCharacterNotFound = errors.New("角色不存在")
+
+// CharacterListFilter 角色列表查询条件
+type CharacterListFilter struct {
+	OwnerID    uint   // 所有者ID，为0时不限制
+	PublicOnly bool   // 仅返回公开目录中群组（公开且审核通过）的角色
+	GID        uint   // 所属群组ID，为0时不限制
+	Name       string // 名称模糊搜索
+	Model      string // 模型名称
+}
+
+// GroupCharacterRepository 群组角色仓库接口
+type GroupCharacterRepository interface {
+	CreateCharacter(character *models.GroupCharacter) error
+	GetCharacterByID(id uint) (*models.GroupCharacter, error)
+	GetCharacterIncludingDeleted(id uint) (*models.GroupCharacter, error)
+	GetCharacters(filter CharacterListFilter, page, pageSize int) ([]models.GroupCharacter, int64, error)
+	GetCharactersByGroup(gid uint, page, pageSize int) ([]models.GroupCharacter, int64, error)
+	CountCharactersByGroup(gid uint) (int64, error)
+	GetCharacterIDsByGroup(gid uint) ([]uint, error)
+	GetMaxSortOrder(gid uint) (int, error)
+	BatchUpdateCharacters(gid uint, creates []models.GroupCharacter, updates map[uint]map[string]interface{}, deleteIDs []uint) error
+	UpdateCharacter(id uint, updates map[string]interface{}) error
+	DeleteCharacter(id uint) error
+	GetDeletedCharacters(ownerID uint, page, pageSize int) ([]models.GroupCharacter, int64, error)
+	GetDeletedCharacterByID(id uint) (*models.GroupCharacter, error)
+	RestoreCharacter(id uint) error
+	PurgeDeletedCharacters(before time.Time) (int64, error)
+}
+
+// groupCharacterRepository 群组角色仓库实现
+type groupCharacterRepository struct {
+	db *gorm.DB
+}
+
+// NewGroupCharacterRepository 创建群组角色仓库实例
+func NewGroupCharacterRepository() GroupCharacterRepository {
+	return &groupCharacterRepository{
+		db: config.GetDB(),
+	}
+}
+
+// CreateCharacter 创建角色
+func (r *groupCharacterRepository) CreateCharacter(character *models.GroupCharacter) error {
+	if err := r.db.Create(character).Error; err != nil {
+		return fmt.Errorf("创建角色失败: %v", err)
+	}
+	return nil
+}
+
+// GetCharacterByID 根据ID获取角色，预加载所属群组
+func (r *groupCharacterRepository) GetCharacterByID(id uint) (*models.GroupCharacter, error) {
+	var character models.GroupCharacter
+	if err := r.db.Preload("Group").First(&character, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCharacterNotFound
+		}
+		return nil, fmt.Errorf("查询角色失败: %v", err)
+	}
+	return &character, nil
+}
+
+// GetCharacterIncludingDeleted 根据ID获取角色，包括回收站中的角色及已删除的群组
+// 用于历史对话中展示已删除角色的名称和头像
+func (r *groupCharacterRepository) GetCharacterIncludingDeleted(id uint) (*models.GroupCharacter, error) {
+	var character models.GroupCharacter
+	err := r.db.Unscoped().Preload("Group", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).First(&character, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCharacterNotFound
+		}
+		return nil, fmt.Errorf("查询角色失败: %v", err)
+	}
+	return &character, nil
+}
+
+// GetCharacters 分页获取角色列表，预加载所属群组
+func (r *groupCharacterRepository) GetCharacters(filter CharacterListFilter, page, pageSize int) ([]models.GroupCharacter, int64, error) {
+	var characters []models.GroupCharacter
+	var total int64
+
+	query := r.db.Model(&models.GroupCharacter{})
+	if filter.OwnerID != 0 {
+		query = query.Where("group_characters.owner_id = ?", filter.OwnerID)
+	}
+	if filter.PublicOnly {
+		query = query.Joins("JOIN llm_groups ON llm_groups.id = group_characters.gid").
+			Where("llm_groups.visibility = ? AND llm_groups.moderation_status = ?", models.VisibilityPublic, models.ModerationStatusApproved)
+	}
+	if filter.GID != 0 {
+		query = query.Where("group_characters.gid = ?", filter.GID)
+	}
+	if filter.Name != "" {
+		query = query.Where("group_characters.name LIKE ?", "%"+filter.Name+"%")
+	}
+	if filter.Model != "" {
+		query = query.Where("group_characters.model = ?", filter.Model)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取角色总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Group").Offset(offset).Limit(pageSize).Order("group_characters.created_at DESC").Find(&characters).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取角色列表失败: %v", err)
+	}
+
+	return characters, total, nil
+}
+
+// GetCharactersByGroup 分页获取群组下的角色列表
+func (r *groupCharacterRepository) GetCharactersByGroup(gid uint, page, pageSize int) ([]models.GroupCharacter, int64, error) {
+	var characters []models.GroupCharacter
+	var total int64
+
+	query := r.db.Model(&models.GroupCharacter{}).Where("gid = ?", gid)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取角色总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("sort_order ASC, id ASC").Find(&characters).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取角色列表失败: %v", err)
+	}
+
+	return characters, total, nil
+}
+
+// CountCharactersByGroup 统计群组下的角色数量
+func (r *groupCharacterRepository) CountCharactersByGroup(gid uint) (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.GroupCharacter{}).Where("gid = ?", gid).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计角色数量失败: %v", err)
+	}
+	return total, nil
+}
+
+// GetCharacterIDsByGroup 获取群组下所有角色的ID，按排序顺序
+func (r *groupCharacterRepository) GetCharacterIDsByGroup(gid uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&models.GroupCharacter{}).Where("gid = ?", gid).
+		Order("sort_order ASC, id ASC").Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("获取群组角色失败: %v", err)
+	}
+	return ids, nil
+}
+
+// GetMaxSortOrder 获取群组内角色的最大排序值，群组没有角色时返回0
+func (r *groupCharacterRepository) GetMaxSortOrder(gid uint) (int, error) {
+	var maxSortOrder int
+	if err := r.db.Model(&models.GroupCharacter{}).Where("gid = ?", gid).
+		Select("COALESCE(MAX(sort_order), 0)").Scan(&maxSortOrder).Error; err != nil {
+		return 0, fmt.Errorf("查询角色排序失败: %v", err)
+	}
+	return maxSortOrder, nil
+}
+
+// BatchUpdateCharacters 在一个事务中批量新建、更新和删除群组内的角色，任一操作失败则全部回滚
+// 新建的角色会回填ID
+func (r *groupCharacterRepository) BatchUpdateCharacters(gid uint, creates []models.GroupCharacter, updates map[uint]map[string]interface{}, deleteIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(deleteIDs) > 0 {
+			if err := tx.Where("gid = ? AND id IN ?", gid, deleteIDs).Delete(&models.GroupCharacter{}).Error; err != nil {
+				return fmt.Errorf("删除角色失败: %v", err)
+			}
+		}
+		for id, fields := range updates {
+			if err := tx.Model(&models.GroupCharacter{}).Where("gid = ? AND id = ?", gid, id).
+				Updates(fields).Error; err != nil {
+				return fmt.Errorf("更新角色失败: %v", err)
+			}
+		}
+		for i := range creates {
+			creates[i].GID = gid
+			if err := tx.Omit("Group").Create(&creates[i]).Error; err != nil {
+				return fmt.Errorf("创建角色失败: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateCharacter 更新角色字段
+func (r *groupCharacterRepository) UpdateCharacter(id uint, updates map[string]interface{}) error {
+	if err := r.db.Model(&models.GroupCharacter{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新角色失败: %v", err)
+	}
+	return nil
+}
+
+// DeleteCharacter 将角色移入回收站（软删除）
+func (r *groupCharacterRepository) DeleteCharacter(id uint) error {
+	if err := r.db.Delete(&models.GroupCharacter{}, id).Error; err != nil {
+		return fmt.Errorf("删除角色失败: %v", err)
+	}
+	return nil
+}
+
+// GetDeletedCharacters 分页获取用户回收站中单独删除的角色（所属群组未被删除），按删除时间倒序
+// 用户自己创建的角色及其群组中的角色都会返回
+func (r *groupCharacterRepository) GetDeletedCharacters(ownerID uint, page, pageSize int) ([]models.GroupCharacter, int64, error) {
+	var characters []models.GroupCharacter
+	var total int64
+
+	query := r.db.Unscoped().Model(&models.GroupCharacter{}).
+		Joins("JOIN llm_groups ON llm_groups.id = group_characters.gid AND llm_groups.deleted_at IS NULL").
+		Where("group_characters.deleted_at IS NOT NULL").
+		Where("group_characters.owner_id = ? OR llm_groups.owner_id = ?", ownerID, ownerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取回收站角色总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Group").Order("group_characters.deleted_at DESC").
+		Offset(offset).Limit(pageSize).Find(&characters).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取回收站角色失败: %v", err)
+	}
+	return characters, total, nil
+}
+
+// GetDeletedCharacterByID 根据ID获取回收站中的角色，预加载所属群组（包括已删除的群组）
+func (r *groupCharacterRepository) GetDeletedCharacterByID(id uint) (*models.GroupCharacter, error) {
+	var character models.GroupCharacter
+	err := r.db.Unscoped().Preload("Group", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Where("deleted_at IS NOT NULL").First(&character, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCharacterNotFound
+		}
+		return nil, fmt.Errorf("查询角色失败: %v", err)
+	}
+	return &character, nil
+}
+
+// RestoreCharacter 从回收站恢复角色
+func (r *groupCharacterRepository) RestoreCharacter(id uint) error {
+	if err := r.db.Unscoped().Model(&models.GroupCharacter{}).Where("id = ?", id).
+		Update("deleted_at", nil).Error; err != nil {
+		return fmt.Errorf("恢复角色失败: %v", err)
+	}
+	return nil
+}
+
+// PurgeDeletedCharacters 彻底删除在指定时间之前单独移入回收站的角色，连同其定时任务和修订记录
+// 随群组一起删除的角色由 PurgeDeletedGroups 清理
+func (r *groupCharacterRepository) PurgeDeletedCharacters(before time.Time) (int64, error) {
+	var ids []uint
+	if err := r.db.Unscoped().Model(&models.GroupCharacter{}).
+		Joins("JOIN llm_groups ON llm_groups.id = group_characters.gid AND llm_groups.deleted_at IS NULL").
+		Where("group_characters.deleted_at IS NOT NULL AND group_characters.deleted_at < ?", before).
+		Pluck("group_characters.id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("查询待清理角色失败: %v", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := r.db.Transaction(func(tx *gorm.DB) error {
+		return purgeCharacters(tx, ids)
+	}); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+// purgeCharacters 在事务中彻底删除角色及引用它的定时任务、执行记录和修订记录
+func purgeCharacters(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	taskIDs := tx.Model(&models.Task{}).Select("id").Where("character_id IN ?", ids)
+	if err := tx.Where("task_id IN (?)", taskIDs).Delete(&models.TaskExecution{}).Error; err != nil {
+		return fmt.Errorf("清理任务执行记录失败: %v", err)
+	}
+	if err := tx.Where("character_id IN ?", ids).Delete(&models.Task{}).Error; err != nil {
+		return fmt.Errorf("清理定时任务失败: %v", err)
+	}
+	if err := tx.Where("entity_type = ? AND entity_id IN ?", models.RevisionEntityCharacter, ids).
+		Delete(&models.Revision{}).Error; err != nil {
+		return fmt.Errorf("清理修订记录失败: %v", err)
+	}
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.GroupCharacter{}).Error; err != nil {
+		return fmt.Errorf("清理角色失败: %v", err)
+	}
+	return nil
+}
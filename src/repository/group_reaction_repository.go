@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// reactionCountColumns 互动类型对应的群组计数字段
+var reactionCountColumns = map[string]string{
+	models.ReactionLike:     "like_count",
+	models.ReactionFavorite: "favorite_count",
+}
+
+// ErrInvalidReactionType 无效的互动类型
+var ErrInvalidReactionType = errors.New("无效的互动类型")
+
+// GroupReactionRepository 群组点赞/收藏仓库接口
+type GroupReactionRepository interface {
+	AddReaction(userID, gid uint, reactionType string) error
+	RemoveReaction(userID, gid uint, reactionType string) error
+	GetUserReactionTypes(userID, gid uint) ([]string, error)
+	GetFavoriteGroups(userID uint, page, pageSize int) ([]models.LlmGroup, int64, error)
+}
+
+// groupReactionRepository 群组点赞/收藏仓库实现
+type groupReactionRepository struct {
+	db *gorm.DB
+}
+
+// NewGroupReactionRepository 创建群组点赞/收藏仓库实例
+func NewGroupReactionRepository() GroupReactionRepository {
+	return &groupReactionRepository{
+		db: config.GetDB(),
+	}
+}
+
+// AddReaction 添加互动记录并增加群组计数，重复添加不产生影响
+func (r *groupReactionRepository) AddReaction(userID, gid uint, reactionType string) error {
+	column, ok := reactionCountColumns[reactionType]
+	if !ok {
+		return ErrInvalidReactionType
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.GroupReaction{}).
+			Where("user_id = ? AND gid = ? AND type = ?", userID, gid, reactionType).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("查询互动记录失败: %v", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		reaction := &models.GroupReaction{UserID: userID, GID: gid, Type: reactionType}
+		if err := tx.Create(reaction).Error; err != nil {
+			return fmt.Errorf("创建互动记录失败: %v", err)
+		}
+		if err := tx.Model(&models.LlmGroup{}).Where("id = ?", gid).
+			UpdateColumn(column, gorm.Expr(column+" + 1")).Error; err != nil {
+			return fmt.Errorf("更新群组计数失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// RemoveReaction 删除互动记录并减少群组计数，记录不存在时不产生影响
+func (r *groupReactionRepository) RemoveReaction(userID, gid uint, reactionType string) error {
+	column, ok := reactionCountColumns[reactionType]
+	if !ok {
+		return ErrInvalidReactionType
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND gid = ? AND type = ?", userID, gid, reactionType).
+			Delete(&models.GroupReaction{})
+		if result.Error != nil {
+			return fmt.Errorf("删除互动记录失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.LlmGroup{}).Where("id = ? AND "+column+" > 0", gid).
+			UpdateColumn(column, gorm.Expr(column+" - 1")).Error; err != nil {
+			return fmt.Errorf("更新群组计数失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// GetUserReactionTypes 获取用户对群组的互动类型
+func (r *groupReactionRepository) GetUserReactionTypes(userID, gid uint) ([]string, error) {
+	var types []string
+	if err := r.db.Model(&models.GroupReaction{}).
+		Where("user_id = ? AND gid = ?", userID, gid).
+		Pluck("type", &types).Error; err != nil {
+		return nil, fmt.Errorf("查询互动记录失败: %v", err)
+	}
+	return types, nil
+}
+
+// GetFavoriteGroups 分页获取用户收藏的群组，按收藏时间倒序
+// 收藏后被设为私有的他人群组不再返回
+func (r *groupReactionRepository) GetFavoriteGroups(userID uint, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	var groups []models.LlmGroup
+	var total int64
+
+	query := r.db.Model(&models.LlmGroup{}).
+		Joins("JOIN group_reactions ON group_reactions.gid = llm_groups.id").
+		Where("group_reactions.user_id = ? AND group_reactions.type = ?", userID, models.ReactionFavorite).
+		Where("llm_groups.owner_id = ? OR llm_groups.visibility <> ?", userID, models.VisibilityPrivate)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取收藏总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("group_reactions.created_at DESC").Order("group_reactions.id DESC").
+		Offset(offset).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取收藏列表失败: %v", err)
+	}
+	return groups, total, nil
+}
@@ -0,0 +1,449 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrGroupNotFound 群组不存在
+var ErrGroupNotFound = errors.New("群组不存在")
+
+// 群组列表排序方式
+const (
+	GroupSortRecent  = "recent"  // 按公开时间倒序
+	GroupSortPopular = "popular" // 按点赞、收藏、克隆数加权倒序
+)
+
+// GroupListFilter 群组列表查询条件
+type GroupListFilter struct {
+	OwnerID    uint   // 所有者ID，为0时不限制
+	Visibility string // 可见性，为空时不限制
+	Listed     bool   // 仅公开目录中展示的群组（公开且审核通过）
+	Moderation string // 审核状态，为空时不限制
+	ForkedFrom uint   // 克隆来源群组ID，为0时不限制
+	Name       string // 名称模糊搜索
+	Keyword    string // 名称或描述模糊搜索
+	Category   string // 分类
+	Tag        string // 标签
+	Sort       string // 排序方式，为空时按创建时间倒序
+}
+
+// LlmGroupRepository 群组仓库接口
+type LlmGroupRepository interface {
+	CreateGroup(group *models.LlmGroup) error
+	GetGroupByID(id uint) (*models.LlmGroup, error)
+	GetGroupWithCharacters(id uint) (*models.LlmGroup, error)
+	GetGroups(filter GroupListFilter, page, pageSize int) ([]models.LlmGroup, int64, error)
+	GetGroupByName(ownerID uint, name string) (*models.LlmGroup, error)
+	ExistsGroupName(ownerID uint, name string, excludeID uint) (bool, error)
+	UpdateGroup(id uint, updates map[string]interface{}) error
+	DeleteGroup(id uint) error
+	GetDeletedGroups(ownerID uint, page, pageSize int) ([]models.LlmGroup, int64, error)
+	GetDeletedGroupByID(id uint) (*models.LlmGroup, error)
+	RestoreGroup(group *models.LlmGroup) error
+	PurgeDeletedGroups(before time.Time) (int64, error)
+	SaveGroupWithMembers(group *models.LlmGroup, characters []models.GroupCharacter, tasks []models.Task) error
+
+	GetCatalogGroups(ownerID uint) ([]models.LlmGroup, error)
+	SyncSystemGroup(group *models.LlmGroup, characters []models.GroupCharacter) error
+	HideStaleSystemGroups(activeKeys []string) error
+}
+
+// llmGroupRepository 群组仓库实现
+type llmGroupRepository struct {
+	db *gorm.DB
+}
+
+// NewLlmGroupRepository 创建群组仓库实例
+func NewLlmGroupRepository() LlmGroupRepository {
+	return &llmGroupRepository{
+		db: config.GetDB(),
+	}
+}
+
+// CreateGroup 创建群组
+func (r *llmGroupRepository) CreateGroup(group *models.LlmGroup) error {
+	if err := r.db.Create(group).Error; err != nil {
+		return fmt.Errorf("创建群组失败: %v", err)
+	}
+	return nil
+}
+
+// GetGroupByID 根据ID获取群组
+func (r *llmGroupRepository) GetGroupByID(id uint) (*models.LlmGroup, error) {
+	var group models.LlmGroup
+	if err := r.db.First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("查询群组失败: %v", err)
+	}
+	return &group, nil
+}
+
+// GetGroupWithCharacters 根据ID获取群组并预加载角色
+func (r *llmGroupRepository) GetGroupWithCharacters(id uint) (*models.LlmGroup, error) {
+	var group models.LlmGroup
+	if err := r.db.Preload("Characters", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	}).First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("查询群组失败: %v", err)
+	}
+	return &group, nil
+}
+
+// GetGroups 分页获取群组列表
+func (r *llmGroupRepository) GetGroups(filter GroupListFilter, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	var groups []models.LlmGroup
+	var total int64
+
+	query := r.db.Model(&models.LlmGroup{})
+	if filter.OwnerID != 0 {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+	if filter.Visibility != "" {
+		query = query.Where("visibility = ?", filter.Visibility)
+	}
+	if filter.Listed {
+		query = query.Where("visibility = ? AND moderation_status = ?", models.VisibilityPublic, models.ModerationStatusApproved)
+	}
+	if filter.Moderation != "" {
+		query = query.Where("moderation_status = ?", filter.Moderation)
+	}
+	if filter.ForkedFrom != 0 {
+		query = query.Where("forked_from = ?", filter.ForkedFrom)
+	}
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", "%"+filter.Name+"%")
+	}
+	if filter.Keyword != "" {
+		query = query.Where("name LIKE ? OR description LIKE ?", "%"+filter.Keyword+"%", "%"+filter.Keyword+"%")
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.Tag != "" {
+		// 标签以JSON数组存储，按带引号的完整标签匹配
+		query = query.Where("tags LIKE ?", "%\""+filter.Tag+"\"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取群组总数失败: %v", err)
+	}
+
+	switch filter.Sort {
+	case GroupSortPopular:
+		query = query.Order("like_count + favorite_count * 2 + fork_count * 3 DESC").Order("id DESC")
+	case GroupSortRecent:
+		query = query.Order("published_at DESC").Order("id DESC")
+	default:
+		query = query.Order("created_at DESC")
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取群组列表失败: %v", err)
+	}
+
+	return groups, total, nil
+}
+
+// GetGroupByName 根据名称获取用户的群组
+func (r *llmGroupRepository) GetGroupByName(ownerID uint, name string) (*models.LlmGroup, error) {
+	var group models.LlmGroup
+	if err := r.db.Where("owner_id = ? AND name = ?", ownerID, name).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("查询群组失败: %v", err)
+	}
+	return &group, nil
+}
+
+// ExistsGroupName 检查用户是否已有同名群组，excludeID用于更新时排除自身
+func (r *llmGroupRepository) ExistsGroupName(ownerID uint, name string, excludeID uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.LlmGroup{}).Where("owner_id = ? AND name = ?", ownerID, name)
+	if excludeID != 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return false, fmt.Errorf("检查群组名称失败: %v", err)
+	}
+	return count > 0, nil
+}
+
+// UpdateGroup 更新群组字段
+func (r *llmGroupRepository) UpdateGroup(id uint, updates map[string]interface{}) error {
+	if err := r.db.Model(&models.LlmGroup{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新群组失败: %v", err)
+	}
+	return nil
+}
+
+// DeleteGroup 将群组及其角色移入回收站（软删除）
+// 角色与群组使用相同的删除时间，恢复群组时据此只恢复随群组一起删除的角色
+func (r *llmGroupRepository) DeleteGroup(id uint) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.GroupCharacter{}).Where("gid = ?", id).Update("deleted_at", now).Error; err != nil {
+			return fmt.Errorf("删除群组角色失败: %v", err)
+		}
+		if err := tx.Model(&models.LlmGroup{}).Where("id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return fmt.Errorf("删除群组失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// GetDeletedGroups 分页获取用户回收站中的群组，按删除时间倒序
+func (r *llmGroupRepository) GetDeletedGroups(ownerID uint, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	var groups []models.LlmGroup
+	var total int64
+
+	query := r.db.Unscoped().Model(&models.LlmGroup{}).Where("owner_id = ? AND deleted_at IS NOT NULL", ownerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取回收站群组总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("deleted_at DESC").Offset(offset).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取回收站群组失败: %v", err)
+	}
+	return groups, total, nil
+}
+
+// GetDeletedGroupByID 根据ID获取回收站中的群组
+func (r *llmGroupRepository) GetDeletedGroupByID(id uint) (*models.LlmGroup, error) {
+	var group models.LlmGroup
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, fmt.Errorf("查询群组失败: %v", err)
+	}
+	return &group, nil
+}
+
+// RestoreGroup 从回收站恢复群组及随其一起删除的角色
+func (r *llmGroupRepository) RestoreGroup(group *models.LlmGroup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.GroupCharacter{}).
+			Where("gid = ? AND deleted_at = ?", group.ID, group.DeletedAt.Time).
+			Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("恢复群组角色失败: %v", err)
+		}
+		if err := tx.Unscoped().Model(&models.LlmGroup{}).Where("id = ?", group.ID).
+			Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("恢复群组失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedGroups 彻底删除在指定时间之前移入回收站的群组，连同其角色、定时任务、点赞收藏和修订记录
+func (r *llmGroupRepository) PurgeDeletedGroups(before time.Time) (int64, error) {
+	var ids []uint
+	if err := r.db.Unscoped().Model(&models.LlmGroup{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("查询待清理群组失败: %v", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var characterIDs []uint
+		if err := tx.Unscoped().Model(&models.GroupCharacter{}).Where("gid IN ?", ids).
+			Pluck("id", &characterIDs).Error; err != nil {
+			return fmt.Errorf("查询待清理角色失败: %v", err)
+		}
+		if err := purgeCharacters(tx, characterIDs); err != nil {
+			return err
+		}
+
+		taskIDs := tx.Model(&models.Task{}).Select("id").Where("gid IN ?", ids)
+		if err := tx.Where("task_id IN (?)", taskIDs).Delete(&models.TaskExecution{}).Error; err != nil {
+			return fmt.Errorf("清理任务执行记录失败: %v", err)
+		}
+		if err := tx.Where("gid IN ?", ids).Delete(&models.Task{}).Error; err != nil {
+			return fmt.Errorf("清理定时任务失败: %v", err)
+		}
+		if err := tx.Where("gid IN ?", ids).Delete(&models.GroupReaction{}).Error; err != nil {
+			return fmt.Errorf("清理点赞收藏失败: %v", err)
+		}
+		if err := tx.Where("entity_type = ? AND entity_id IN ?", models.RevisionEntityGroup, ids).
+			Delete(&models.Revision{}).Error; err != nil {
+			return fmt.Errorf("清理修订记录失败: %v", err)
+		}
+		// 克隆出的群组保留，只清除对已清理群组的引用
+		if err := tx.Unscoped().Model(&models.LlmGroup{}).Where("forked_from IN ?", ids).
+			Update("forked_from", nil).Error; err != nil {
+			return fmt.Errorf("清除克隆来源失败: %v", err)
+		}
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.LlmGroup{}).Error; err != nil {
+			return fmt.Errorf("清理群组失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+// SaveGroupWithMembers 在一个事务中保存群组及其角色和定时任务
+// group.ID为0时创建群组，否则更新群组信息并替换其原有的角色和定时任务；
+// 定时任务通过 Character 字段引用 characters 中的角色，保存后回填 GID 和 CharacterID
+func (r *llmGroupRepository) SaveGroupWithMembers(group *models.LlmGroup, characters []models.GroupCharacter, tasks []models.Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if group.ID == 0 {
+			if err := tx.Omit("Characters").Create(group).Error; err != nil {
+				return fmt.Errorf("创建群组失败: %v", err)
+			}
+			if group.ForkedFrom != nil {
+				if err := tx.Model(&models.LlmGroup{}).Where("id = ?", *group.ForkedFrom).
+					UpdateColumn("fork_count", gorm.Expr("fork_count + 1")).Error; err != nil {
+					return fmt.Errorf("更新群组克隆数失败: %v", err)
+				}
+			}
+		} else {
+			if err := tx.Model(group).
+				Select("Name", "Description", "Visibility", "IsGroupDiscussionMode", "Category", "Tags").
+				Updates(group).Error; err != nil {
+				return fmt.Errorf("更新群组失败: %v", err)
+			}
+			taskIDs := tx.Model(&models.Task{}).Select("id").Where("gid = ?", group.ID)
+			if err := tx.Where("task_id IN (?)", taskIDs).Delete(&models.TaskExecution{}).Error; err != nil {
+				return fmt.Errorf("删除任务执行记录失败: %v", err)
+			}
+			if err := tx.Where("gid = ?", group.ID).Delete(&models.Task{}).Error; err != nil {
+				return fmt.Errorf("删除群组定时任务失败: %v", err)
+			}
+			// 原有角色移入回收站，引用它们的历史对话仍可展示
+			if err := tx.Where("gid = ?", group.ID).Delete(&models.GroupCharacter{}).Error; err != nil {
+				return fmt.Errorf("删除群组角色失败: %v", err)
+			}
+		}
+
+		for i := range characters {
+			characters[i].GID = group.ID
+			if err := tx.Omit("Group").Create(&characters[i]).Error; err != nil {
+				return fmt.Errorf("创建角色失败: %v", err)
+			}
+		}
+
+		for i := range tasks {
+			task := &tasks[i]
+			task.GID = group.ID
+			if task.Character != nil {
+				task.CharacterID = task.Character.ID
+			}
+			if err := tx.Omit("Group", "Character").Create(task).Error; err != nil {
+				return fmt.Errorf("创建定时任务失败: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetCatalogGroups 获取目录中的群组：公开的系统群组及指定用户自己的群组，预加载角色
+func (r *llmGroupRepository) GetCatalogGroups(ownerID uint) ([]models.LlmGroup, error) {
+	var groups []models.LlmGroup
+
+	query := r.db.Preload("Characters", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, id ASC")
+	})
+	if ownerID != 0 {
+		query = query.Where("(system_key IS NOT NULL AND visibility = ?) OR owner_id = ?", models.VisibilityPublic, ownerID)
+	} else {
+		query = query.Where("system_key IS NOT NULL AND visibility = ?", models.VisibilityPublic)
+	}
+
+	// 系统群组（owner_id为0）排在前面，其余按创建顺序
+	if err := query.Order("owner_id ASC, id ASC").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("获取目录群组失败: %v", err)
+	}
+	return groups, nil
+}
+
+// SyncSystemGroup 按系统标识同步系统群组及其角色，不存在则创建，存在则更新，
+// 已从配置中移除的角色会被删除
+func (r *llmGroupRepository) SyncSystemGroup(group *models.LlmGroup, characters []models.GroupCharacter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.LlmGroup
+		err := tx.Where("system_key = ?", *group.SystemKey).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(group).Error; err != nil {
+				return fmt.Errorf("创建系统群组失败: %v", err)
+			}
+		case err != nil:
+			return fmt.Errorf("查询系统群组失败: %v", err)
+		default:
+			group.ID = existing.ID
+			if err := tx.Model(&existing).
+				Select("Name", "Description", "Visibility", "IsGroupDiscussionMode").
+				Updates(group).Error; err != nil {
+				return fmt.Errorf("更新系统群组失败: %v", err)
+			}
+		}
+
+		keys := make([]string, 0, len(characters))
+		for i := range characters {
+			character := &characters[i]
+			character.GID = group.ID
+			keys = append(keys, *character.SystemKey)
+
+			var existingCharacter models.GroupCharacter
+			err := tx.Where("gid = ? AND system_key = ?", group.ID, *character.SystemKey).First(&existingCharacter).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(character).Error; err != nil {
+					return fmt.Errorf("创建系统角色失败: %v", err)
+				}
+			case err != nil:
+				return fmt.Errorf("查询系统角色失败: %v", err)
+			default:
+				character.ID = existingCharacter.ID
+				if err := tx.Model(&existingCharacter).
+					Select("Name", "Personality", "Model", "Avatar", "CustomPrompt", "Tags", "RAG", "Knowledge", "SortOrder").
+					Updates(character).Error; err != nil {
+					return fmt.Errorf("更新系统角色失败: %v", err)
+				}
+			}
+		}
+
+		// 彻底删除已从配置中移除的系统角色，避免软删除的记录占用 (gid, system_key) 唯一索引
+		query := tx.Unscoped().Where("gid = ? AND system_key IS NOT NULL", group.ID)
+		if len(keys) > 0 {
+			query = query.Where("system_key NOT IN ?", keys)
+		}
+		if err := query.Delete(&models.GroupCharacter{}).Error; err != nil {
+			return fmt.Errorf("删除过期系统角色失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// HideStaleSystemGroups 将已从配置中移除的系统群组设为私有，保留数据以免破坏已有引用
+func (r *llmGroupRepository) HideStaleSystemGroups(activeKeys []string) error {
+	query := r.db.Model(&models.LlmGroup{}).Where("system_key IS NOT NULL")
+	if len(activeKeys) > 0 {
+		query = query.Where("system_key NOT IN ?", activeKeys)
+	}
+	if err := query.Update("visibility", models.VisibilityPrivate).Error; err != nil {
+		return fmt.Errorf("隐藏过期系统群组失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// 刷新令牌错误
+var (
+	ErrRefreshTokenNotFound = errors.New("刷新令牌不存在")
+	ErrRefreshTokenUsed     = errors.New("刷新令牌已被使用")
+)
+
+// RefreshTokenRepository 刷新令牌仓库接口
+type RefreshTokenRepository interface {
+	GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(old *models.RefreshToken, next *models.RefreshToken) error
+}
+
+// refreshTokenRepository 刷新令牌仓库实现
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository 创建刷新令牌仓库实例
+func NewRefreshTokenRepository() RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db: config.GetDB(),
+	}
+}
+
+// GetRefreshTokenByHash 根据令牌摘要获取刷新令牌
+func (r *refreshTokenRepository) GetRefreshTokenByHash(tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("查询刷新令牌失败: %v", err)
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken 将旧令牌标记为已使用并保存新令牌
+// 旧令牌已被使用或吊销时返回 ErrRefreshTokenUsed，并发刷新时只有一个请求能成功
+func (r *refreshTokenRepository) RotateRefreshToken(old *models.RefreshToken, next *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", old.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("更新刷新令牌失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenUsed
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return fmt.Errorf("保存刷新令牌失败: %v", err)
+		}
+		return nil
+	})
+}
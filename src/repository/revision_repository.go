@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrRevisionNotFound 修订记录不存在
+var ErrRevisionNotFound = errors.New("修订记录不存在")
+
+// RevisionRepository 修订记录仓库接口
+type RevisionRepository interface {
+	CreateRevision(revision *models.Revision) error
+	CountRevisions(entityType string, entityID uint) (int64, error)
+	GetRevisions(entityType string, entityID uint, page, pageSize int) ([]models.Revision, int64, error)
+	GetRevision(entityType string, entityID uint, version int) (*models.Revision, error)
+}
+
+// revisionRepository 修订记录仓库实现
+type revisionRepository struct {
+	db *gorm.DB
+}
+
+// NewRevisionRepository 创建修订记录仓库实例
+func NewRevisionRepository() RevisionRepository {
+	return &revisionRepository{
+		db: config.GetDB(),
+	}
+}
+
+// CreateRevision 创建修订记录，版本号为该对象当前最大版本号加1
+// 并发写入时由 (entity_type, entity_id, version) 唯一索引保证版本号不重复
+func (r *revisionRepository) CreateRevision(revision *models.Revision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var maxVersion int
+		if err := tx.Model(&models.Revision{}).
+			Where("entity_type = ? AND entity_id = ?", revision.EntityType, revision.EntityID).
+			Select("COALESCE(MAX(version), 0)").Scan(&maxVersion).Error; err != nil {
+			return fmt.Errorf("查询修订版本号失败: %v", err)
+		}
+
+		revision.Version = maxVersion + 1
+		if err := tx.Create(revision).Error; err != nil {
+			return fmt.Errorf("创建修订记录失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// CountRevisions 统计对象的修订记录数量
+func (r *revisionRepository) CountRevisions(entityType string, entityID uint) (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Revision{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计修订记录失败: %v", err)
+	}
+	return total, nil
+}
+
+// GetRevisions 分页获取对象的修订记录，按版本号倒序
+func (r *revisionRepository) GetRevisions(entityType string, entityID uint, page, pageSize int) ([]models.Revision, int64, error) {
+	var revisions []models.Revision
+	var total int64
+
+	query := r.db.Model(&models.Revision{}).Where("entity_type = ? AND entity_id = ?", entityType, entityID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取修订记录总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("version DESC").Offset(offset).Limit(pageSize).Find(&revisions).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取修订记录失败: %v", err)
+	}
+	return revisions, total, nil
+}
+
+// GetRevision 获取对象的指定版本
+func (r *revisionRepository) GetRevision(entityType string, entityID uint, version int) (*models.Revision, error) {
+	var revision models.Revision
+	err := r.db.Where("entity_type = ? AND entity_id = ? AND version = ?", entityType, entityID, version).
+		First(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("查询修订记录失败: %v", err)
+	}
+	return &revision, nil
+}
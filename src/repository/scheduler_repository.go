@@ -2,77 +2,211 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
+	"project/src/config"
 	"project/src/models"
+
+	"gorm.io/gorm"
 )
 
+// ErrTaskNotFound 任务不存在
+var ErrTaskNotFound = errors.New("任务不存在")
+
 // SchedulerRepository 调度仓库接口
 type SchedulerRepository interface {
-	SaveTask(task models.Task) (uint, error)
-	GetAllTasks() ([]models.Task, error)
-	GetTaskByID(id uint) (models.Task, error)
-	UpdateTask(task models.Task) error
+	SaveTask(task *models.Task) error
+	GetTasksByOwner(ownerID uint, page, pageSize int) ([]models.Task, int64, error)
+	CountTasksByOwner(ownerID uint) (int64, error)
+	GetTasksByGroup(gid uint) ([]models.Task, error)
+	GetTaskByID(id uint) (*models.Task, error)
+	UpdateTask(id uint, updates map[string]interface{}) error
 	DeleteTask(id uint) error
+	GetDueTasks(now time.Time, limit int) ([]models.Task, error)
+	// ClaimTask 抢占一次到期触发，返回 false 表示已被其他实例抢占或任务已被修改
+	ClaimTask(id uint, dueAt, now time.Time, nextRunAt *time.Time, staleBefore time.Time) (bool, error)
+	UpdateTaskRunState(id uint, status string, lastRunAt, nextRunAt *time.Time) error
+
+	SaveExecution(execution *models.TaskExecution) error
+	UpdateExecution(execution *models.TaskExecution) error
+	GetExecutionsByTask(taskID uint, page, pageSize int) ([]models.TaskExecution, int64, error)
 }
 
 // schedulerRepository 调度仓库实现
 type schedulerRepository struct {
-	// 这里可以添加数据库连接等
-	tasks []models.Task // 临时存储，实际应使用数据库
+	db *gorm.DB
 }
 
 // NewSchedulerRepository 创建调度仓库实例
 func NewSchedulerRepository() SchedulerRepository {
 	return &schedulerRepository{
-		tasks: make([]models.Task, 0),
+		db: config.GetDB(),
 	}
 }
 
 // SaveTask 保存任务
-func (r *schedulerRepository) SaveTask(task models.Task) (uint, error) {
-	// 模拟ID自增
-	task.ID = uint(len(r.tasks) + 1)
-	r.tasks = append(r.tasks, task)
-	return task.ID, nil
+func (r *schedulerRepository) SaveTask(task *models.Task) error {
+	if err := r.db.Create(task).Error; err != nil {
+		return fmt.Errorf("创建任务失败: %v", err)
+	}
+	return nil
 }
 
-// GetAllTasks 获取所有任务
-func (r *schedulerRepository) GetAllTasks() ([]models.Task, error) {
-	return r.tasks, nil
+// GetTasksByOwner 分页获取用户的任务列表
+func (r *schedulerRepository) GetTasksByOwner(ownerID uint, page, pageSize int) ([]models.Task, int64, error) {
+	var tasks []models.Task
+	var total int64
+
+	query := r.db.Model(&models.Task{}).Where("owner_id = ?", ownerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取任务总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&tasks).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取任务列表失败: %v", err)
+	}
+
+	return tasks, total, nil
 }
 
-// GetTaskByID 根据ID获取任务
-func (r *schedulerRepository) GetTaskByID(id uint) (models.Task, error) {
-	for _, task := range r.tasks {
-		if task.ID == id {
-			return task, nil
-		}
+// CountTasksByOwner 统计用户的任务数量
+func (r *schedulerRepository) CountTasksByOwner(ownerID uint) (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Task{}).Where("owner_id = ?", ownerID).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计任务数量失败: %v", err)
 	}
+	return total, nil
+}
 
-	return models.Task{}, errors.New("任务不存在")
+// GetTasksByGroup 获取群组下的全部任务
+func (r *schedulerRepository) GetTasksByGroup(gid uint) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := r.db.Where("gid = ?", gid).Order("id ASC").Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("获取群组任务失败: %v", err)
+	}
+	return tasks, nil
 }
 
-// UpdateTask 更新任务
-func (r *schedulerRepository) UpdateTask(task models.Task) error {
-	for i, t := range r.tasks {
-		if t.ID == task.ID {
-			r.tasks[i] = task
-			return nil
+// GetTaskByID 根据ID获取任务
+func (r *schedulerRepository) GetTaskByID(id uint) (*models.Task, error) {
+	var task models.Task
+	err := r.db.First(&task, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
 		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
 	}
+	return &task, nil
+}
 
-	return errors.New("任务不存在")
+// UpdateTask 更新任务字段，只写入指定的列，不覆盖执行器同时写入的运行状态
+func (r *schedulerRepository) UpdateTask(id uint, updates map[string]interface{}) error {
+	if err := r.db.Model(&models.Task{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新任务失败: %v", err)
+	}
+	return nil
 }
 
-// DeleteTask 删除任务
+// DeleteTask 删除任务及其执行记录
 func (r *schedulerRepository) DeleteTask(id uint) error {
-	for i, task := range r.tasks {
-		if task.ID == id {
-			// 从切片中删除元素
-			r.tasks = append(r.tasks[:i], r.tasks[i+1:]...)
-			return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", id).Delete(&models.TaskExecution{}).Error; err != nil {
+			return fmt.Errorf("删除任务执行记录失败: %v", err)
 		}
+		result := tx.Delete(&models.Task{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("删除任务失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrTaskNotFound
+		}
+		return nil
+	})
+}
+
+// GetDueTasks 获取已到执行时间的启用任务
+func (r *schedulerRepository) GetDueTasks(now time.Time, limit int) ([]models.Task, error) {
+	var tasks []models.Task
+	// 群组或角色已移入回收站的任务暂不执行，恢复后继续
+	err := r.db.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Where("EXISTS (SELECT 1 FROM llm_groups WHERE llm_groups.id = scheduled_tasks.gid AND llm_groups.deleted_at IS NULL)").
+		Where("EXISTS (SELECT 1 FROM group_characters WHERE group_characters.id = scheduled_tasks.character_id AND group_characters.deleted_at IS NULL)").
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取待执行任务失败: %v", err)
+	}
+	return tasks, nil
+}
+
+// ClaimTask 仅当 next_run_at 仍为读取时的 dueAt 且任务不在执行中时，标记为执行中并推进下一次执行时间
+// 状态为执行中但开始时间早于 staleBefore 的任务视为实例崩溃遗留，允许重新抢占
+func (r *schedulerRepository) ClaimTask(id uint, dueAt, now time.Time, nextRunAt *time.Time, staleBefore time.Time) (bool, error) {
+	result := r.db.Model(&models.Task{}).
+		Where("id = ? AND next_run_at = ?", id, dueAt).
+		Where("status <> ? OR last_run_at IS NULL OR last_run_at < ?", models.TaskStatusRunning, staleBefore).
+		Updates(map[string]interface{}{
+			"status":      models.TaskStatusRunning,
+			"last_run_at": now,
+			"next_run_at": nextRunAt,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("抢占任务失败: %v", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateTaskRunState 更新任务的运行状态和执行时间
+func (r *schedulerRepository) UpdateTaskRunState(id uint, status string, lastRunAt, nextRunAt *time.Time) error {
+	updates := map[string]interface{}{
+		"status":      status,
+		"next_run_at": nextRunAt,
+	}
+	if lastRunAt != nil {
+		updates["last_run_at"] = lastRunAt
+	}
+
+	err := r.db.Model(&models.Task{}).Where("id = ?", id).Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("更新任务运行状态失败: %v", err)
+	}
+	return nil
+}
+
+// SaveExecution 保存执行记录
+func (r *schedulerRepository) SaveExecution(execution *models.TaskExecution) error {
+	if err := r.db.Create(execution).Error; err != nil {
+		return fmt.Errorf("保存执行记录失败: %v", err)
+	}
+	return nil
+}
+
+// UpdateExecution 更新执行记录
+func (r *schedulerRepository) UpdateExecution(execution *models.TaskExecution) error {
+	if err := r.db.Save(execution).Error; err != nil {
+		return fmt.Errorf("更新执行记录失败: %v", err)
+	}
+	return nil
+}
+
+// GetExecutionsByTask 分页获取任务的执行记录
+func (r *schedulerRepository) GetExecutionsByTask(taskID uint, page, pageSize int) ([]models.TaskExecution, int64, error) {
+	var executions []models.TaskExecution
+	var total int64
+
+	query := r.db.Model(&models.TaskExecution{}).Where("task_id = ?", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取执行记录总数失败: %v", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("start_time DESC").Find(&executions).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取执行记录失败: %v", err)
 	}
 
-	return errors.New("任务不存在")
+	return executions, total, nil
 }
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// StatsRepository 使用统计仓库接口
+type StatsRepository interface {
+	GetUsageStats(now time.Time) (*models.UsageStats, error)
+}
+
+// statsRepository 使用统计仓库实现
+type statsRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRepository 创建使用统计仓库实例
+func NewStatsRepository() StatsRepository {
+	return &statsRepository{
+		db: config.GetDB(),
+	}
+}
+
+// GetUsageStats 统计用户、群组、角色和定时任务的使用情况
+func (r *statsRepository) GetUsageStats(now time.Time) (*models.UsageStats, error) {
+	stats := &models.UsageStats{GeneratedAt: now}
+	dayAgo := now.Add(-24 * time.Hour)
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+
+	counts := []struct {
+		target *int64
+		query  *gorm.DB
+	}{
+		{&stats.Users.Total, r.db.Model(&models.User{}).Where("status <> ?", models.UserStatusMerged)},
+		{&stats.Users.Active, r.db.Model(&models.User{}).Where("status = ?", models.UserStatusActive)},
+		{&stats.Users.Disabled, r.db.Model(&models.User{}).Where("status = ?", models.UserStatusDisabled)},
+		{&stats.Users.New24h, r.db.Model(&models.User{}).Where("status <> ? AND created_at >= ?", models.UserStatusMerged, dayAgo)},
+		{&stats.Users.New7d, r.db.Model(&models.User{}).Where("status <> ? AND created_at >= ?", models.UserStatusMerged, weekAgo)},
+		{&stats.Users.Online24h, r.db.Model(&models.UserSession{}).Distinct("user_id").Where("revoked_at IS NULL AND last_seen_at >= ?", dayAgo)},
+		{&stats.Groups.Total, r.db.Model(&models.LlmGroup{})},
+		{&stats.Groups.Public, r.db.Model(&models.LlmGroup{}).Where("visibility = ?", models.VisibilityPublic)},
+		{&stats.Groups.Hidden, r.db.Model(&models.LlmGroup{}).Where("moderation_status = ?", models.ModerationStatusHidden)},
+		{&stats.Groups.Deleted, r.db.Unscoped().Model(&models.LlmGroup{}).Where("deleted_at IS NOT NULL")},
+		{&stats.Characters, r.db.Model(&models.GroupCharacter{})},
+		{&stats.Tasks.Total, r.db.Model(&models.Task{})},
+		{&stats.Tasks.Enabled, r.db.Model(&models.Task{}).Where("enabled = ?", true)},
+		{&stats.Tasks.Runs24h, r.db.Model(&models.TaskExecution{}).Where("start_time >= ?", dayAgo)},
+		{&stats.Tasks.FailedRuns24h, r.db.Model(&models.TaskExecution{}).Where("start_time >= ? AND status = ?", dayAgo, models.ExecutionStatusFailed)},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.target).Error; err != nil {
+			return nil, fmt.Errorf("统计使用情况失败: %v", err)
+		}
+	}
+	return stats, nil
+}
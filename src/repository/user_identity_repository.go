@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrIdentityNotFound 登录身份不存在
+var ErrIdentityNotFound = errors.New("登录身份不存在")
+
+// UserIdentityRepository 登录身份仓库接口
+type UserIdentityRepository interface {
+	GetIdentity(provider, subject string) (*models.UserIdentity, error)
+	GetIdentityByID(id uint) (*models.UserIdentity, error)
+	GetUserIdentities(userID uint) ([]models.UserIdentity, error)
+	CreateIdentity(identity *models.UserIdentity) error
+	DeleteIdentity(identity *models.UserIdentity) error
+}
+
+// userIdentityRepository 登录身份仓库实现
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository 创建登录身份仓库实例
+func NewUserIdentityRepository() UserIdentityRepository {
+	return &userIdentityRepository{
+		db: config.GetDB(),
+	}
+}
+
+// GetIdentity 根据身份类型和标识获取登录身份
+func (r *userIdentityRepository) GetIdentity(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("查询登录身份失败: %v", err)
+	}
+	return &identity, nil
+}
+
+// GetIdentityByID 根据主键获取登录身份
+func (r *userIdentityRepository) GetIdentityByID(id uint) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.First(&identity, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("查询登录身份失败: %v", err)
+	}
+	return &identity, nil
+}
+
+// GetUserIdentities 获取用户绑定的全部登录身份
+func (r *userIdentityRepository) GetUserIdentities(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	if err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("获取登录身份失败: %v", err)
+	}
+	return identities, nil
+}
+
+// CreateIdentity 绑定登录身份，手机号同时作为用户的展示手机号（用户尚未设置时）
+func (r *userIdentityRepository) CreateIdentity(identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(identity).Error; err != nil {
+			return fmt.Errorf("绑定登录身份失败: %v", err)
+		}
+		if identity.Provider != constants.LoginTypePhone {
+			return nil
+		}
+		if err := tx.Model(&models.User{}).
+			Where("id = ? AND (phone = '' OR phone IS NULL)", identity.UserID).
+			Update("phone", identity.Subject).Error; err != nil {
+			return fmt.Errorf("更新用户手机号失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// DeleteIdentity 解绑登录身份，解绑的是展示手机号时改用其他已绑定的手机号
+func (r *userIdentityRepository) DeleteIdentity(identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.UserIdentity{}, identity.ID).Error; err != nil {
+			return fmt.Errorf("解绑登录身份失败: %v", err)
+		}
+		if identity.Provider != constants.LoginTypePhone {
+			return nil
+		}
+
+		var phones []string
+		if err := tx.Model(&models.UserIdentity{}).
+			Where("user_id = ? AND provider = ?", identity.UserID, constants.LoginTypePhone).
+			Order("id ASC").Limit(1).Pluck("subject", &phones).Error; err != nil {
+			return fmt.Errorf("查询登录身份失败: %v", err)
+		}
+		phone := ""
+		if len(phones) > 0 {
+			phone = phones[0]
+		}
+		if err := tx.Model(&models.User{}).
+			Where("id = ? AND phone = ?", identity.UserID, identity.Subject).
+			Update("phone", phone).Error; err != nil {
+			return fmt.Errorf("更新用户手机号失败: %v", err)
+		}
+		return nil
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"project/src/config"
+	"project/src/constants"
 	"project/src/models"
 	"strconv"
 	"time"
@@ -11,16 +12,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrUserNotFound 用户不存在
+var ErrUserNotFound = errors.New("user not found")
+
+// UserListFilter 用户列表查询条件（管理员）
+type UserListFilter struct {
+	Keyword string // 匹配用户ID、昵称、手机号或已绑定的登录身份
+	Status  *int   // 用户状态，为nil时不限制（已合并的账号除外）
+	Role    string // 角色，为空时不限制
+}
+
 // UserRepository 用户仓库接口
 type UserRepository interface {
-	GetUserByPhone(phone string) (*models.User, error)
-	GetUserByOpenID(openID string) (*models.User, error)
-	CreateUser(phone, openID, nickname string) (*models.User, error)
+	CreateUser(nickname string, identity *models.UserIdentity) (*models.User, error)
+	CreateUserWithPassword(nickname, passwordHash string, identity *models.UserIdentity) (*models.User, error)
 	UpdateLastLoginTime(userID uint) error
 	GetUserByID(userID uint) (*models.User, error)
 	GetUserByIDString(userIDStr string) (*models.User, error)
 	UpdateUserNickname(userID uint, nickname string) error
 	UpdateUserAvatar(userID uint, avatarURL string) error
+	MergeUsers(sourceID, targetID uint) (*models.AccountMergeResult, error)
+	SearchUsers(filter UserListFilter, page, pageSize int) ([]models.User, int64, error)
+	UpdateUserStatus(userID uint, status int) error
+	UpdateUserRole(userID uint, role string) error
+	UpdateUserPassword(userID uint, passwordHash string) error
 }
 
 // userRepository 用户仓库实现
@@ -35,82 +50,50 @@ func NewUserRepository() UserRepository {
 	}
 }
 
-// GetUserByPhone 根据手机号获取用户
-func (r *userRepository) GetUserByPhone(phone string) (*models.User, error) {
-	var user models.User
-	err := r.db.Where("phone = ?", phone).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, fmt.Errorf("查询用户失败: %v", err)
-	}
-	return &user, nil
+// CreateUser 创建新用户并绑定其首个登录身份
+func (r *userRepository) CreateUser(nickname string, identity *models.UserIdentity) (*models.User, error) {
+	return r.CreateUserWithPassword(nickname, "", identity)
 }
 
-// GetUserByOpenID 根据OpenID获取用户
-func (r *userRepository) GetUserByOpenID(openID string) (*models.User, error) {
-	if openID == "" {
-		return nil, errors.New("user not found")
-	}
-	var user models.User
-	err := r.db.Where("openid = ?", openID).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, fmt.Errorf("查询用户失败: %v", err)
-	}
-	return &user, nil
-}
-
-// CreateUser 创建新用户
-func (r *userRepository) CreateUser(phone, openID, nickname string) (*models.User, error) {
-	// 检查用户是否已存在（通过 OpenID）
-	if openID != "" {
-		if existingUser, _ := r.GetUserByOpenID(openID); existingUser != nil {
-			return nil, errors.New("user already exists")
-		}
-	}
-
-	// 如果有手机号，也检查手机号是否已存在
-	if phone != "" {
-		if existingUser, _ := r.GetUserByPhone(phone); existingUser != nil {
-			return nil, errors.New("user already exists")
-		}
-	}
-
+// CreateUserWithPassword 在同一个事务中创建带登录密码的新用户并绑定其首个登录身份，passwordHash 为空时不设置密码
+func (r *userRepository) CreateUserWithPassword(nickname, passwordHash string, identity *models.UserIdentity) (*models.User, error) {
 	now := time.Now()
 	user := models.User{
-		Phone:       phone,
-		OpenID:      nil, // 先设为 nil
-		Nickname:    nickname,
-		Status:      1,
-		CreatedAt:   now,
-		UpdatedAt:   now,
-		LastLoginAt: now,
+		Nickname:     nickname,
+		PasswordHash: passwordHash,
+		Status:       models.UserStatusActive,
+		Role:         models.RoleUser,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		LastLoginAt:  now,
 	}
-
-	// 如果 openID 不为空，设置指针
-	if openID != "" {
-		user.OpenID = &openID
+	if identity.Provider == constants.LoginTypePhone {
+		user.Phone = identity.Subject
 	}
 
-	// 创建用户
-	err := r.db.Create(&user).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("创建用户失败: %v", err)
+		}
+		identity.UserID = user.ID
+		if err := tx.Create(identity).Error; err != nil {
+			return fmt.Errorf("绑定登录身份失败: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("创建用户失败: %v", err)
+		return nil, err
 	}
-
 	return &user, nil
 }
 
 // UpdateLastLoginTime 更新最后登录时间
 func (r *userRepository) UpdateLastLoginTime(userID uint) error {
+	now := time.Now()
 	err := r.db.Model(&models.User{}).Where("id = ?", userID).
 		Updates(map[string]interface{}{
-			"last_login_at": gorm.Expr("NOW()"),
-			"updated_at":    gorm.Expr("NOW()"),
+			"last_login_at": now,
+			"updated_at":    now,
 		}).Error
 
 	if err != nil {
@@ -126,7 +109,7 @@ func (r *userRepository) GetUserByID(userID uint) (*models.User, error) {
 	err := r.db.Where("id = ?", userID).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("查询用户失败: %v", err)
 	}
@@ -171,3 +154,191 @@ func (r *userRepository) UpdateUserAvatar(userID uint, avatarURL string) error {
 
 	return nil
 }
+
+// SearchUsers 分页搜索用户，按注册时间倒序
+func (r *userRepository) SearchUsers(filter UserListFilter, page, pageSize int) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+
+	query := r.db.Model(&models.User{})
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	} else {
+		query = query.Where("status <> ?", models.UserStatusMerged)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Keyword != "" {
+		like := "%" + filter.Keyword + "%"
+		identities := r.db.Model(&models.UserIdentity{}).Select("user_id").Where("subject = ?", filter.Keyword)
+		if id, err := strconv.ParseUint(filter.Keyword, 10, 64); err == nil {
+			query = query.Where("id = ? OR nickname LIKE ? OR phone LIKE ? OR id IN (?)", id, like, like, identities)
+		} else {
+			query = query.Where("nickname LIKE ? OR phone LIKE ? OR id IN (?)", like, like, identities)
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取用户总数失败: %v", err)
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取用户列表失败: %v", err)
+	}
+	return users, total, nil
+}
+
+// UpdateUserStatus 更新用户状态
+func (r *userRepository) UpdateUserStatus(userID uint, status int) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", userID).Update("status", status).Error; err != nil {
+		return fmt.Errorf("更新用户状态失败: %v", err)
+	}
+	return nil
+}
+
+// UpdateUserRole 更新用户角色
+func (r *userRepository) UpdateUserRole(userID uint, role string) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error; err != nil {
+		return fmt.Errorf("更新用户角色失败: %v", err)
+	}
+	return nil
+}
+
+// UpdateUserPassword 更新用户的登录密码摘要
+func (r *userRepository) UpdateUserPassword(userID uint, passwordHash string) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", userID).Update("password_hash", passwordHash).Error; err != nil {
+		return fmt.Errorf("更新用户密码失败: %v", err)
+	}
+	return nil
+}
+
+// MergeUsers 将来源账号合并到目标账号：转移群组、角色、定时任务、点赞收藏和登录身份，
+// 吊销来源账号的全部登录会话并将其标记为已合并。目标账号已有同名群组时，来源群组自动改名
+func (r *userRepository) MergeUsers(sourceID, targetID uint) (*models.AccountMergeResult, error) {
+	result := &models.AccountMergeResult{SourceUserID: sourceID}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 群组（包括回收站中的群组），逐个检查重名
+		var groups []models.LlmGroup
+		if err := tx.Unscoped().Select("id", "name").Where("owner_id = ?", sourceID).Find(&groups).Error; err != nil {
+			return fmt.Errorf("查询群组失败: %v", err)
+		}
+		for _, group := range groups {
+			name, err := uniqueMergedGroupName(tx, targetID, group.Name)
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.LlmGroup{}).Where("id = ?", group.ID).
+				Updates(map[string]interface{}{"owner_id": targetID, "name": name}).Error; err != nil {
+				return fmt.Errorf("转移群组失败: %v", err)
+			}
+		}
+		result.Groups = int64(len(groups))
+
+		characters := tx.Unscoped().Model(&models.GroupCharacter{}).Where("owner_id = ?", sourceID).Update("owner_id", targetID)
+		if characters.Error != nil {
+			return fmt.Errorf("转移角色失败: %v", characters.Error)
+		}
+		result.Characters = characters.RowsAffected
+
+		tasks := tx.Model(&models.Task{}).Where("owner_id = ?", sourceID).Update("owner_id", targetID)
+		if tasks.Error != nil {
+			return fmt.Errorf("转移定时任务失败: %v", tasks.Error)
+		}
+		result.Tasks = tasks.RowsAffected
+
+		if err := mergeReactions(tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Revision{}).Where("author_id = ?", sourceID).Update("author_id", targetID).Error; err != nil {
+			return fmt.Errorf("转移修订记录失败: %v", err)
+		}
+
+		identities := tx.Model(&models.UserIdentity{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if identities.Error != nil {
+			return fmt.Errorf("转移登录身份失败: %v", identities.Error)
+		}
+		result.Identities = identities.RowsAffected
+
+		// 吊销来源账号的登录会话
+		now := time.Now()
+		if err := tx.Model(&models.UserSession{}).Where("user_id = ? AND revoked_at IS NULL", sourceID).
+			Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("吊销登录会话失败: %v", err)
+		}
+		if err := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", sourceID).
+			Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("吊销刷新令牌失败: %v", err)
+		}
+
+		// 目标账号没有展示手机号时沿用来源账号的手机号
+		var source models.User
+		if err := tx.Select("id", "phone").First(&source, sourceID).Error; err != nil {
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+		if source.Phone != "" {
+			if err := tx.Model(&models.User{}).Where("id = ? AND (phone = '' OR phone IS NULL)", targetID).
+				Update("phone", source.Phone).Error; err != nil {
+				return fmt.Errorf("更新用户手机号失败: %v", err)
+			}
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", sourceID).
+			Updates(map[string]interface{}{"status": models.UserStatusMerged, "phone": ""}).Error; err != nil {
+			return fmt.Errorf("更新用户状态失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// uniqueMergedGroupName 合并账号时为来源群组生成目标账号下不重复的名称，重名时依次追加“ (2)”、“ (3)”……
+func uniqueMergedGroupName(tx *gorm.DB, ownerID uint, name string) (string, error) {
+	candidate := name
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Model(&models.LlmGroup{}).Where("owner_id = ? AND name = ?", ownerID, candidate).
+			Count(&count).Error; err != nil {
+			return "", fmt.Errorf("检查群组名称失败: %v", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix := fmt.Sprintf(" (%d)", i)
+		runes := []rune(name)
+		if limit := 100 - len(suffix); len(runes) > limit {
+			runes = runes[:limit]
+		}
+		candidate = string(runes) + suffix
+	}
+}
+
+// mergeReactions 转移点赞和收藏，两个账号对同一群组的重复互动只保留一条并修正计数
+func mergeReactions(tx *gorm.DB, sourceID, targetID uint) error {
+	var duplicates []models.GroupReaction
+	if err := tx.Where("user_id = ? AND EXISTS (SELECT 1 FROM group_reactions t WHERE t.user_id = ? AND t.gid = group_reactions.gid AND t.type = group_reactions.type)",
+		sourceID, targetID).Find(&duplicates).Error; err != nil {
+		return fmt.Errorf("查询点赞收藏失败: %v", err)
+	}
+	for _, reaction := range duplicates {
+		if err := tx.Delete(&models.GroupReaction{}, reaction.ID).Error; err != nil {
+			return fmt.Errorf("删除重复的点赞收藏失败: %v", err)
+		}
+		column, ok := reactionCountColumns[reaction.Type]
+		if !ok {
+			continue
+		}
+		if err := tx.Unscoped().Model(&models.LlmGroup{}).Where("id = ? AND "+column+" > 0", reaction.GID).
+			Update(column, gorm.Expr(column+" - 1")).Error; err != nil {
+			return fmt.Errorf("更新群组计数失败: %v", err)
+		}
+	}
+
+	if err := tx.Model(&models.GroupReaction{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+		return fmt.Errorf("转移点赞收藏失败: %v", err)
+	}
+	return nil
+}
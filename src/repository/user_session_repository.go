@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrUserSessionNotFound 登录会话不存在
+var ErrUserSessionNotFound = errors.New("登录会话不存在")
+
+// UserSessionRepository 登录会话仓库接口
+type UserSessionRepository interface {
+	CreateSession(session *models.UserSession, token *models.RefreshToken) error
+	GetSessionBySessionID(sessionID string) (*models.UserSession, error)
+	GetSessionByID(id uint) (*models.UserSession, error)
+	GetActiveSessions(userID uint) ([]models.UserSession, error)
+	TouchSession(sessionID string, seenAt time.Time) error
+	ExtendSession(sessionID string, expiresAt time.Time) error
+	RevokeSession(sessionID string) error
+	RevokeOtherSessions(userID uint, keepSessionID string) (int64, error)
+}
+
+// userSessionRepository 登录会话仓库实现
+type userSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSessionRepository 创建登录会话仓库实例
+func NewUserSessionRepository() UserSessionRepository {
+	return &userSessionRepository{
+		db: config.GetDB(),
+	}
+}
+
+// CreateSession 创建登录会话及其首个刷新令牌
+func (r *userSessionRepository) CreateSession(session *models.UserSession, token *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return fmt.Errorf("创建登录会话失败: %v", err)
+		}
+		if err := tx.Create(token).Error; err != nil {
+			return fmt.Errorf("保存刷新令牌失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// GetSessionBySessionID 根据会话ID获取登录会话
+func (r *userSessionRepository) GetSessionBySessionID(sessionID string) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := r.db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserSessionNotFound
+		}
+		return nil, fmt.Errorf("查询登录会话失败: %v", err)
+	}
+	return &session, nil
+}
+
+// GetSessionByID 根据主键获取登录会话
+func (r *userSessionRepository) GetSessionByID(id uint) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := r.db.First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserSessionNotFound
+		}
+		return nil, fmt.Errorf("查询登录会话失败: %v", err)
+	}
+	return &session, nil
+}
+
+// GetActiveSessions 获取用户未吊销且未过期的登录会话，按最近活跃时间倒序
+func (r *userSessionRepository) GetActiveSessions(userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC, id DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("获取登录会话失败: %v", err)
+	}
+	return sessions, nil
+}
+
+// TouchSession 更新最近活跃时间，一分钟内重复调用不写库
+func (r *userSessionRepository) TouchSession(sessionID string, seenAt time.Time) error {
+	if err := r.db.Model(&models.UserSession{}).
+		Where("session_id = ? AND last_seen_at < ?", sessionID, seenAt.Add(-time.Minute)).
+		Update("last_seen_at", seenAt).Error; err != nil {
+		return fmt.Errorf("更新登录会话失败: %v", err)
+	}
+	return nil
+}
+
+// ExtendSession 刷新令牌轮换后延长会话有效期并更新最近活跃时间
+func (r *userSessionRepository) ExtendSession(sessionID string, expiresAt time.Time) error {
+	if err := r.db.Model(&models.UserSession{}).
+		Where("session_id = ?", sessionID).
+		Updates(map[string]interface{}{
+			"expires_at":   expiresAt,
+			"last_seen_at": time.Now(),
+		}).Error; err != nil {
+		return fmt.Errorf("更新登录会话失败: %v", err)
+	}
+	return nil
+}
+
+// RevokeSession 吊销登录会话及其全部刷新令牌
+func (r *userSessionRepository) RevokeSession(sessionID string) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserSession{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("吊销登录会话失败: %v", err)
+		}
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("吊销刷新令牌失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// RevokeOtherSessions 吊销用户除指定会话外的全部登录会话，返回吊销的会话数量
+func (r *userSessionRepository) RevokeOtherSessions(userID uint, keepSessionID string) (int64, error) {
+	now := time.Now()
+	var revoked int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserSession{}).
+			Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("吊销登录会话失败: %v", result.Error)
+		}
+		revoked = result.RowsAffected
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+			Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("吊销刷新令牌失败: %v", err)
+		}
+		return nil
+	})
+	return revoked, err
+}
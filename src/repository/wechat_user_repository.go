@@ -13,7 +13,6 @@ type WechatUserRepository interface {
 	GetWechatUserByOpenID(openID string) (*models.WechatUser, error)
 	CreateWechatUser(wechatUser *models.WechatUser) error
 	UpdateWechatUser(wechatUser *models.WechatUser) error
-	GetWechatUserByUID(uid uint) (*models.WechatUser, error)
 	UpdateLastLoginTime(openID string) error
 	DeleteWechatUser(openID string) error
 }
@@ -50,16 +49,6 @@ func (r *wechatUserRepository) UpdateWechatUser(wechatUser *models.WechatUser) e
 	return r.db.Save(wechatUser).Error
 }
 
-// GetWechatUserByUID 根据用户ID获取微信用户
-func (r *wechatUserRepository) GetWechatUserByUID(uid uint) (*models.WechatUser, error) {
-	var wechatUser models.WechatUser
-	err := r.db.Where("uid = ?", uid).First(&wechatUser).Error
-	if err == gorm.ErrRecordNotFound {
-		return nil, nil // 用户不存在
-	}
-	return &wechatUser, err
-}
-
 // UpdateLastLoginTime 更新最后登录时间
 func (r *wechatUserRepository) UpdateLastLoginTime(openID string) error {
 	return r.db.Model(&models.WechatUser{}).
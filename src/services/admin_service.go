@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+)
+
+// 管理后台错误
+var (
+	ErrModifySelf  = errors.New("不能修改自己的状态或角色")
+	ErrUserMerged  = errors.New("该账号已合并到其他账号，不能修改")
+	ErrInvalidRole = errors.New("无效的角色，可选值：user、moderator、admin")
+)
+
+// AdminService 管理后台服务接口
+type AdminService interface {
+	SearchUsers(filter repository.UserListFilter, page, pageSize int) ([]models.User, int64, error)
+	GetUser(userID uint) (*models.AdminUserDetail, error)
+	SetUserStatus(operatorID, userID uint, status int) (*models.User, error)
+	SetUserRole(operatorID, userID uint, role string) (*models.User, error)
+	ListGroups(filter repository.GroupListFilter, page, pageSize int) ([]models.LlmGroup, int64, error)
+	ModerateGroup(groupID uint, req *models.GroupModerationRequest) (*models.LlmGroup, error)
+	UsageStats() (*models.UsageStats, error)
+	Config() config.Config
+}
+
+// adminService 管理后台服务实现
+type adminService struct {
+	userRepo         repository.UserRepository
+	identityRepo     repository.UserIdentityRepository
+	sessionRepo      repository.UserSessionRepository
+	groupRepo        repository.LlmGroupRepository
+	statsRepo        repository.StatsRepository
+	directoryService DirectoryService
+}
+
+// NewAdminService 创建管理后台服务实例
+func NewAdminService() AdminService {
+	return &adminService{
+		userRepo:         repository.NewUserRepository(),
+		identityRepo:     repository.NewUserIdentityRepository(),
+		sessionRepo:      repository.NewUserSessionRepository(),
+		groupRepo:        repository.NewLlmGroupRepository(),
+		statsRepo:        repository.NewStatsRepository(),
+		directoryService: NewDirectoryService(),
+	}
+}
+
+// SearchUsers 搜索用户
+func (s *adminService) SearchUsers(filter repository.UserListFilter, page, pageSize int) ([]models.User, int64, error) {
+	return s.userRepo.SearchUsers(filter, page, pageSize)
+}
+
+// GetUser 获取用户详情及其登录身份
+func (s *adminService) GetUser(userID uint) (*models.AdminUserDetail, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identityRepo.GetUserIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AdminUserDetail{User: *user, Identities: identities}, nil
+}
+
+// SetUserStatus 启用或禁用用户，禁用时同时吊销其全部登录会话
+func (s *adminService) SetUserStatus(operatorID, userID uint, status int) (*models.User, error) {
+	user, err := s.modifiableUser(operatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateUserStatus(user.ID, status); err != nil {
+		return nil, err
+	}
+	if status == models.UserStatusDisabled {
+		if _, err := s.sessionRepo.RevokeOtherSessions(user.ID, ""); err != nil {
+			return nil, err
+		}
+	}
+	return s.userRepo.GetUserByID(user.ID)
+}
+
+// SetUserRole 设置用户角色
+func (s *adminService) SetUserRole(operatorID, userID uint, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	user, err := s.modifiableUser(operatorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateUserRole(user.ID, role); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetUserByID(user.ID)
+}
+
+// ListGroups 分页获取全部用户的群组，用于审核
+func (s *adminService) ListGroups(filter repository.GroupListFilter, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	return s.groupRepo.GetGroups(filter, page, pageSize)
+}
+
+// ModerateGroup 下架或恢复公开目录中的群组
+func (s *adminService) ModerateGroup(groupID uint, req *models.GroupModerationRequest) (*models.LlmGroup, error) {
+	return s.directoryService.ModerateGroup(groupID, req)
+}
+
+// UsageStats 获取使用统计
+func (s *adminService) UsageStats() (*models.UsageStats, error) {
+	return s.statsRepo.GetUsageStats(time.Now())
+}
+
+// Config 获取脱敏后的运行配置
+func (s *adminService) Config() config.Config {
+	return config.AppConfig.Redacted()
+}
+
+// modifiableUser 获取可由管理员修改的用户，管理员不能修改自己，已合并的账号不能修改
+func (s *adminService) modifiableUser(operatorID, userID uint) (*models.User, error) {
+	if operatorID == userID {
+		return nil, ErrModifySelf
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == models.UserStatusMerged {
+		return nil, ErrUserMerged
+	}
+	return user, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+)
+
+func TestRolePermissions(t *testing.T) {
+	user := &models.User{Role: models.RoleUser}
+	moderator := &models.User{Role: models.RoleModerator}
+	admin := &models.User{Role: models.RoleAdmin}
+
+	if user.HasPermission(models.PermissionStatsView) || len(user.Permissions()) != 0 {
+		t.Errorf("普通用户不应有管理权限")
+	}
+	if !moderator.HasPermission(models.PermissionGroupModerate) || moderator.HasPermission(models.PermissionUserManage) {
+		t.Errorf("运营只能审核群组和查看统计，实际为%v", moderator.Permissions())
+	}
+	for _, permission := range []string{models.PermissionUserManage, models.PermissionConfigView, models.PermissionSystemDebug} {
+		if !admin.HasPermission(permission) {
+			t.Errorf("管理员应拥有%s权限", permission)
+		}
+	}
+	if !admin.HasRole(models.RoleModerator, models.RoleAdmin) || user.HasRole(models.RoleModerator, models.RoleAdmin) {
+		t.Errorf("角色判断不正确")
+	}
+}
+
+func TestAdminUserManagement(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	operator := mustIssueTokens(t, s, "13800000011")
+	target := mustIssueTokens(t, s, "13800000012")
+	admin := NewAdminService()
+
+	if _, err := admin.SetUserRole(operator.User.ID, operator.User.ID, models.RoleUser); !errors.Is(err, ErrModifySelf) {
+		t.Errorf("修改自己的角色应返回ErrModifySelf，实际为%v", err)
+	}
+	if _, err := admin.SetUserRole(operator.User.ID, target.User.ID, "root"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("无效的角色应返回ErrInvalidRole，实际为%v", err)
+	}
+	if _, err := admin.SetUserStatus(operator.User.ID, 9999, models.UserStatusDisabled); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("用户不存在应返回ErrUserNotFound，实际为%v", err)
+	}
+
+	user, err := admin.SetUserRole(operator.User.ID, target.User.ID, models.RoleModerator)
+	if err != nil || user.Role != models.RoleModerator {
+		t.Fatalf("设置角色失败: %+v, %v", user, err)
+	}
+
+	// 禁用后登录会话失效，重新启用后需要重新登录
+	if _, err := admin.SetUserStatus(operator.User.ID, target.User.ID, models.UserStatusDisabled); err != nil {
+		t.Fatalf("禁用用户失败: %v", err)
+	}
+	if _, err := s.ValidateToken(target.Token); err == nil {
+		t.Errorf("禁用用户的访问令牌应失效")
+	}
+	if _, err := admin.SetUserStatus(operator.User.ID, target.User.ID, models.UserStatusActive); err != nil {
+		t.Fatalf("启用用户失败: %v", err)
+	}
+	if _, err := s.ValidateToken(target.Token); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("禁用时吊销的会话在启用后不应恢复，实际为%v", err)
+	}
+
+	users, total, err := admin.SearchUsers(repository.UserListFilter{Keyword: "13800000012"}, 1, 10)
+	if err != nil || total != 1 || users[0].ID != target.User.ID {
+		t.Errorf("按手机号搜索应返回1个用户: %d, %v", total, err)
+	}
+	users, total, _ = admin.SearchUsers(repository.UserListFilter{Role: models.RoleModerator}, 1, 10)
+	if total != 1 || users[0].ID != target.User.ID {
+		t.Errorf("按角色过滤应返回1个用户，实际为%d", total)
+	}
+
+	detail, err := admin.GetUser(target.User.ID)
+	if err != nil || len(detail.Identities) != 1 || detail.Identities[0].Subject != "13800000012" {
+		t.Errorf("用户详情应包含登录身份: %+v, %v", detail, err)
+	}
+}
+
+func TestAdminUsageStatsAndConfig(t *testing.T) {
+	s, db := setupUserTestService(t)
+	mustIssueTokens(t, s, "13800000013")
+	mustIssueTokens(t, s, "13800000014")
+	groups := []models.LlmGroup{
+		{Name: "公开群组", Visibility: models.VisibilityPublic},
+		{Name: "下架群组", Visibility: models.VisibilityPublic, ModerationStatus: models.ModerationStatusHidden},
+		{Name: "私有群组", Visibility: models.VisibilityPrivate},
+	}
+	if err := db.Create(&groups).Error; err != nil {
+		t.Fatalf("创建群组失败: %v", err)
+	}
+	db.Delete(&groups[2])
+
+	stats, err := NewAdminService().UsageStats()
+	if err != nil {
+		t.Fatalf("获取使用统计失败: %v", err)
+	}
+	if stats.Users.Total != 2 || stats.Users.New24h != 2 || stats.Users.Online24h != 2 {
+		t.Errorf("用户统计不正确: %+v", stats.Users)
+	}
+	if stats.Groups.Total != 2 || stats.Groups.Public != 2 || stats.Groups.Hidden != 1 || stats.Groups.Deleted != 1 {
+		t.Errorf("群组统计不正确: %+v", stats.Groups)
+	}
+
+	config.AppConfig.JWTSecret = "secret"
+	config.AppConfig.LLMProviders = map[string]config.LLMProvider{"openai": {APIKey: "sk-1", BaseURL: "https://api.openai.com"}}
+	redacted := NewAdminService().Config()
+	if redacted.JWTSecret != "******" || redacted.LLMProviders["openai"].APIKey != "******" || redacted.LLMProviders["openai"].BaseURL == "" {
+		t.Errorf("运行配置应脱敏: %+v", redacted.LLMProviders)
+	}
+	if config.AppConfig.LLMProviders["openai"].APIKey != "sk-1" {
+		t.Errorf("脱敏不应修改原配置")
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"project/src/constants"
+	"project/src/models"
+	"project/src/repository"
+)
+
+// API密钥错误
+var (
+	ErrInvalidAPIKey     = errors.New("API密钥无效、已过期或已被吊销")
+	ErrInvalidAPIScope   = errors.New("无效的API密钥权限范围")
+	ErrTooManyAPIKeys    = fmt.Errorf("每个用户最多创建%d个API密钥", constants.MaxAPIKeysPerUser)
+	ErrAPIKeyScopeDenied = errors.New("API密钥没有访问该接口的权限")
+)
+
+// CreateAPIKey 为用户创建API密钥，返回的明文密钥只在创建时出现一次
+func (s *userService) CreateAPIKey(userID uint, req models.APIKeyCreateRequest) (*models.APIKeyCreateResult, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !models.IsValidAPIKeyScope(scope) {
+			return nil, ErrInvalidAPIScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidAPIScope
+	}
+
+	count, err := s.apiKeyRepo.CountUserAPIKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= constants.MaxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	secret, err := randomToken(constants.APIKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("生成API密钥失败: %v", err)
+	}
+	plain := constants.APIKeyPrefix + secret
+
+	key := &models.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  plain[:constants.APIKeyDisplayLength],
+		KeyHash: hashRefreshToken(plain),
+		Scopes:  scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(key); err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyCreateResult{APIKey: key, Key: plain}, nil
+}
+
+// ListAPIKeys 获取用户未吊销的API密钥
+func (s *userService) ListAPIKeys(userID uint) ([]models.APIKey, error) {
+	return s.apiKeyRepo.GetUserAPIKeys(userID)
+}
+
+// RevokeAPIKey 吊销用户的指定API密钥
+func (s *userService) RevokeAPIKey(userID, id uint) error {
+	key, err := s.apiKeyRepo.GetAPIKeyByID(id)
+	if err != nil {
+		return err
+	}
+	// 不暴露其他用户的密钥是否存在
+	if key.UserID != userID || key.RevokedAt != nil {
+		return repository.ErrAPIKeyNotFound
+	}
+	return s.apiKeyRepo.RevokeAPIKey(id)
+}
+
+// ValidateAPIKey 验证API密钥，返回密钥所属用户，并记录最近使用时间
+func (s *userService) ValidateAPIKey(plain string) (*models.User, *models.APIKey, error) {
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(hashRefreshToken(plain))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || key.IsExpired(now) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetUserByID(key.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("用户不存在: %v", err)
+	}
+	if user.Status != models.UserStatusActive {
+		return nil, nil, ErrUserDisabled
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKey(key.ID, now); err != nil {
+		// 更新失败不影响本次请求，只记录错误
+		fmt.Printf("更新API密钥使用时间失败: %v\n", err)
+	}
+
+	return user, key, nil
+}
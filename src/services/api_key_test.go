@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project/src/constants"
+	"project/src/models"
+	"project/src/repository"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	s, db := setupUserTestService(t)
+	owner := mustIssueTokens(t, s, "13800138000")
+	other := mustIssueTokens(t, s, "13900139000")
+
+	if _, err := s.CreateAPIKey(owner.User.ID, models.APIKeyCreateRequest{Name: "脚本", Scopes: []string{"admin"}}); !errors.Is(err, ErrInvalidAPIScope) {
+		t.Errorf("无效的权限范围应返回ErrInvalidAPIScope，实际为%v", err)
+	}
+
+	created, err := s.CreateAPIKey(owner.User.ID, models.APIKeyCreateRequest{
+		Name:   "脚本",
+		Scopes: []string{models.APIKeyScopeChat, models.APIKeyScopeGroupsWrite, models.APIKeyScopeChat},
+	})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, constants.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.APIKey.Prefix) {
+		t.Errorf("密钥明文格式不正确: %s", created.Key)
+	}
+	if len(created.APIKey.Scopes) != 2 || !created.APIKey.HasScope(models.APIKeyScopeGroupsRead) {
+		t.Errorf("权限范围应去重且 groups:write 包含 groups:read: %v", created.APIKey.Scopes)
+	}
+
+	// 数据库只保存摘要
+	var stored models.APIKey
+	db.First(&stored, created.APIKey.ID)
+	if stored.KeyHash == created.Key || strings.Contains(stored.KeyHash, created.Key[len(constants.APIKeyPrefix):]) {
+		t.Errorf("数据库不应保存密钥明文")
+	}
+
+	user, key, err := s.ValidateAPIKey(created.Key)
+	if err != nil || user.ID != owner.User.ID || key.ID != created.APIKey.ID {
+		t.Fatalf("验证API密钥失败: %v", err)
+	}
+	db.First(&stored, created.APIKey.ID)
+	if stored.LastUsedAt == nil {
+		t.Errorf("验证后应记录最近使用时间")
+	}
+	if _, _, err := s.ValidateAPIKey(created.Key + "x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("错误的密钥应返回ErrInvalidAPIKey，实际为%v", err)
+	}
+
+	// 其他用户不能吊销
+	if err := s.RevokeAPIKey(other.User.ID, created.APIKey.ID); !errors.Is(err, repository.ErrAPIKeyNotFound) {
+		t.Errorf("吊销其他用户的密钥应返回ErrAPIKeyNotFound，实际为%v", err)
+	}
+	if err := s.RevokeAPIKey(owner.User.ID, created.APIKey.ID); err != nil {
+		t.Fatalf("吊销API密钥失败: %v", err)
+	}
+	if _, _, err := s.ValidateAPIKey(created.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("已吊销的密钥应失效，实际为%v", err)
+	}
+	keys, _ := s.ListAPIKeys(owner.User.ID)
+	if len(keys) != 0 {
+		t.Errorf("已吊销的密钥不应出现在列表中，实际为%d个", len(keys))
+	}
+}
+
+func TestAPIKeyExpiryAndDisabledUser(t *testing.T) {
+	s, db := setupUserTestService(t)
+	owner := mustIssueTokens(t, s, "13800138000")
+
+	created, err := s.CreateAPIKey(owner.User.ID, models.APIKeyCreateRequest{
+		Name:          "临时",
+		Scopes:        []string{models.APIKeyScopeGroupsRead},
+		ExpiresInDays: 7,
+	})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+	if created.APIKey.ExpiresAt == nil || created.APIKey.HasScope(models.APIKeyScopeGroupsWrite) {
+		t.Errorf("密钥应有过期时间且只读: %+v", created.APIKey)
+	}
+
+	db.Model(&models.APIKey{}).Where("id = ?", created.APIKey.ID).Update("expires_at", time.Now().Add(-time.Minute))
+	if _, _, err := s.ValidateAPIKey(created.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("已过期的密钥应返回ErrInvalidAPIKey，实际为%v", err)
+	}
+
+	active, err := s.CreateAPIKey(owner.User.ID, models.APIKeyCreateRequest{Name: "长期", Scopes: []string{models.APIKeyScopeChat}})
+	if err != nil {
+		t.Fatalf("创建API密钥失败: %v", err)
+	}
+	db.Model(&models.User{}).Where("id = ?", owner.User.ID).Update("status", models.UserStatusDisabled)
+	if _, _, err := s.ValidateAPIKey(active.Key); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("禁用用户的密钥应返回ErrUserDisabled，实际为%v", err)
+	}
+}
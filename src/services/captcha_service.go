@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/services/go-captcha/cache"
+)
+
+// 人机验证码错误
+var (
+	ErrCaptchaTypeUnsupported = errors.New("不支持的验证码类型")
+	ErrCaptchaExpired         = errors.New("验证码已失效，请重新获取")
+)
+
+// captchaTypes 支持的验证码类型
+var captchaTypes = []string{
+	constants.CaptchaTypeClick,
+	constants.CaptchaTypeClickShape,
+	constants.CaptchaTypeSlide,
+	constants.CaptchaTypeDrag,
+	constants.CaptchaTypeRotate,
+}
+
+// CaptchaService 人机验证码服务接口，负责选择验证码类型并记录每个验证码的类型
+type CaptchaService interface {
+	// ChooseType 按风险评估结果选择验证码类型，客户端指定的类型不在可选范围内时按配置选择
+	ChooseType(requested, decision string) (string, error)
+	// Satisfies 验证码类型是否满足风险评估要求的难度
+	Satisfies(captchaType, decision string) bool
+	BindType(key, captchaType string) error
+	// TakeType 取出验证码的类型，每个验证码只能取出一次
+	TakeType(key string) (string, error)
+}
+
+// captchaService 基于KV存储的人机验证码服务实现
+type captchaService struct {
+	kvService KVService
+	config    config.CaptchaConfig
+}
+
+// NewCaptchaService 创建人机验证码服务实例
+func NewCaptchaService(kvService KVService) CaptchaService {
+	return newCaptchaService(kvService)
+}
+
+func newCaptchaService(kvService KVService) *captchaService {
+	return &captchaService{kvService: kvService, config: config.AppConfig.Captcha}
+}
+
+// ValidateCaptchaConfig 检查人机验证码配置，启动时调用，配置有误时返回错误
+func ValidateCaptchaConfig(cfg config.CaptchaConfig) error {
+	switch cfg.Mode {
+	case constants.CaptchaModeRandom:
+		if len(cfg.Types) == 0 {
+			return fmt.Errorf("验证码配置缺少可用类型types")
+		}
+	case constants.CaptchaModeRisk:
+		if len(cfg.EasyTypes) == 0 || len(cfg.HardTypes) == 0 {
+			return fmt.Errorf("risk 模式必须同时配置easy_types和hard_types")
+		}
+	default:
+		return fmt.Errorf("验证码选择方式 %q 不受支持，可选 random、risk", cfg.Mode)
+	}
+	for _, types := range [][]string{cfg.Types, cfg.EasyTypes, cfg.HardTypes} {
+		for _, captchaType := range types {
+			if !slices.Contains(captchaTypes, captchaType) {
+				return fmt.Errorf("验证码类型 %q 不受支持，可选 click、click_shape、slide、drag、rotate", captchaType)
+			}
+		}
+	}
+	return nil
+}
+
+// ChooseType 选择验证码类型，random 模式从 types 中选择，risk 模式按风险评估结果选择
+func (s *captchaService) ChooseType(requested, decision string) (string, error) {
+	if requested != "" && !slices.Contains(captchaTypes, requested) {
+		return "", ErrCaptchaTypeUnsupported
+	}
+
+	candidates := s.config.Types
+	if s.config.Mode == constants.CaptchaModeRisk {
+		candidates = s.config.EasyTypes
+		if decision == constants.RiskDecisionHard {
+			candidates = s.config.HardTypes
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = []string{constants.CaptchaTypeClick}
+	}
+
+	if slices.Contains(candidates, requested) {
+		return requested, nil
+	}
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// Satisfies risk 模式下要求困难验证时只接受 hard_types 中的类型，其他情况接受任意类型
+func (s *captchaService) Satisfies(captchaType, decision string) bool {
+	if s.config.Mode != constants.CaptchaModeRisk || decision != constants.RiskDecisionHard {
+		return true
+	}
+	return slices.Contains(s.config.HardTypes, captchaType)
+}
+
+// BindType 记录验证码的类型，有效期与验证码答案相同
+func (s *captchaService) BindType(key, captchaType string) error {
+	if err := s.kvService.Set(constants.CaptchaTypePrefix+key, captchaType, cache.ExpirationTime); err != nil {
+		return fmt.Errorf("保存验证码类型失败: %v", err)
+	}
+	return nil
+}
+
+// TakeType 取出并删除验证码的类型，验证码不存在或已过期时返回 ErrCaptchaExpired
+func (s *captchaService) TakeType(key string) (string, error) {
+	captchaType, err := s.kvService.Get(constants.CaptchaTypePrefix + key)
+	if err != nil {
+		return "", fmt.Errorf("读取验证码类型失败: %v", err)
+	}
+	if captchaType == "" {
+		return "", ErrCaptchaExpired
+	}
+	if err := s.kvService.Delete(constants.CaptchaTypePrefix + key); err != nil {
+		return "", fmt.Errorf("删除验证码类型失败: %v", err)
+	}
+	return captchaType, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"project/src/config"
+	"project/src/constants"
+)
+
+func TestCaptchaChooseType(t *testing.T) {
+	s := &captchaService{kvService: newMemoryKVService(), config: config.CaptchaConfig{
+		Mode: constants.CaptchaModeRandom, Types: []string{"slide", "rotate"},
+	}}
+
+	// 指定的类型在可用范围内时使用指定的类型，否则从可用类型中选择
+	if captchaType, err := s.ChooseType("rotate", ""); err != nil || captchaType != "rotate" {
+		t.Errorf("应使用指定的类型rotate，实际为%q, %v", captchaType, err)
+	}
+	for i := 0; i < 20; i++ {
+		if captchaType, _ := s.ChooseType("click", ""); captchaType != "slide" && captchaType != "rotate" {
+			t.Fatalf("未启用的类型不应被选择: %q", captchaType)
+		}
+	}
+	if _, err := s.ChooseType("puzzle", ""); !errors.Is(err, ErrCaptchaTypeUnsupported) {
+		t.Errorf("未知类型应返回ErrCaptchaTypeUnsupported，实际为%v", err)
+	}
+}
+
+func TestCaptchaRiskDecision(t *testing.T) {
+	s := &captchaService{kvService: newMemoryKVService(), config: config.CaptchaConfig{
+		Mode: constants.CaptchaModeRisk, EasyTypes: []string{"slide"}, HardTypes: []string{"click_shape"},
+	}}
+
+	for _, decision := range []string{constants.RiskDecisionSkip, constants.RiskDecisionEasy} {
+		if captchaType, _ := s.ChooseType("", decision); captchaType != "slide" {
+			t.Errorf("%s 应使用easy_types，实际为%q", decision, captchaType)
+		}
+	}
+	// 要求困难验证时不能通过 type 参数指定简单的类型
+	if captchaType, _ := s.ChooseType("slide", constants.RiskDecisionHard); captchaType != "click_shape" {
+		t.Errorf("hard 应使用hard_types，实际为%q", captchaType)
+	}
+
+	if s.Satisfies("slide", constants.RiskDecisionHard) || !s.Satisfies("click_shape", constants.RiskDecisionHard) {
+		t.Errorf("要求困难验证时只接受hard_types中的类型")
+	}
+	if !s.Satisfies("slide", constants.RiskDecisionEasy) {
+		t.Errorf("要求简单验证时应接受任意类型")
+	}
+	s.config.Mode = constants.CaptchaModeRandom
+	if !s.Satisfies("slide", constants.RiskDecisionHard) {
+		t.Errorf("random 模式应接受任意类型")
+	}
+}
+
+func TestCaptchaTypeBinding(t *testing.T) {
+	s := &captchaService{kvService: newMemoryKVService()}
+
+	if err := s.BindType("key1", "rotate"); err != nil {
+		t.Fatalf("保存验证码类型失败: %v", err)
+	}
+	if captchaType, err := s.TakeType("key1"); err != nil || captchaType != "rotate" {
+		t.Errorf("应取出rotate，实际为%q, %v", captchaType, err)
+	}
+	if _, err := s.TakeType("key1"); !errors.Is(err, ErrCaptchaExpired) {
+		t.Errorf("验证码类型只能取出一次，实际为%v", err)
+	}
+}
+
+func TestValidateCaptchaConfig(t *testing.T) {
+	valid := []config.CaptchaConfig{
+		{Mode: "random", Types: []string{"click", "drag"}},
+		{Mode: "risk", EasyTypes: []string{"slide"}, HardTypes: []string{"click", "click_shape"}},
+	}
+	for _, cfg := range valid {
+		if err := ValidateCaptchaConfig(cfg); err != nil {
+			t.Errorf("有效配置不应报错: %v", err)
+		}
+	}
+
+	invalid := []config.CaptchaConfig{
+		{},
+		{Mode: "random"},
+		{Mode: "risk", EasyTypes: []string{"slide"}},
+		{Mode: "random", Types: []string{"puzzle"}},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateCaptchaConfig(cfg); err == nil {
+			t.Errorf("无效配置应报错: %+v", cfg)
+		}
+	}
+}
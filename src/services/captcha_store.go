@@ -0,0 +1,49 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"project/src/constants"
+	"project/src/services/go-captcha/cache"
+)
+
+// kvCaptchaStore 基于KV存储的验证码答案存储，Redis可用时多个实例共享验证码
+type kvCaptchaStore struct {
+	kvService KVService
+}
+
+// NewCaptchaStore 创建验证码答案存储实例，启动时通过 cache.SetStore 替换默认的进程内存储
+func NewCaptchaStore(kvService KVService) cache.Store {
+	return &kvCaptchaStore{kvService: kvService}
+}
+
+// Set 保存验证码答案
+func (s *kvCaptchaStore) Set(key string, value []byte, ttl time.Duration) error {
+	if err := s.kvService.Set(constants.CaptchaPrefix+key, string(value), ttl); err != nil {
+		return fmt.Errorf("保存验证码失败: %v", err)
+	}
+	return nil
+}
+
+// Take 取出并删除验证码答案，先设置已使用标记，并发请求中只有一个能取出
+func (s *kvCaptchaStore) Take(key string) ([]byte, error) {
+	ok, err := s.kvService.SetNX(constants.CaptchaUsedPrefix+key, "1", cache.ExpirationTime)
+	if err != nil {
+		return nil, fmt.Errorf("读取验证码失败: %v", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	value, err := s.kvService.Get(constants.CaptchaPrefix + key)
+	if err != nil {
+		return nil, fmt.Errorf("读取验证码失败: %v", err)
+	}
+	if err := s.kvService.Delete(constants.CaptchaPrefix + key); err != nil {
+		return nil, fmt.Errorf("删除验证码失败: %v", err)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return []byte(value), nil
+}
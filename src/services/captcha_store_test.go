@@ -0,0 +1,54 @@
+package services
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"project/src/services/go-captcha/cache"
+)
+
+func TestKVCaptchaStore(t *testing.T) {
+	// 两个实例共享同一个KV存储
+	kv := newMemoryKVService()
+	first, second := &kvCaptchaStore{kvService: kv}, &kvCaptchaStore{kvService: kv}
+	t.Cleanup(func() { cache.SetStore(cache.NewMemoryStore()) })
+
+	client := httptest.NewRequest("GET", "/api/captcha", nil)
+	client.Header.Set("User-Agent", "test-browser")
+	client.Header.Set("X-Real-IP", "1.1.1.1")
+	other := httptest.NewRequest("POST", "/api/captcha/check", nil)
+	other.Header.Set("User-Agent", "test-browser")
+	other.Header.Set("X-Real-IP", "2.2.2.2")
+
+	// 在一个实例生成，在另一个实例校验，答案只能取出一次
+	cache.SetStore(first)
+	if err := cache.WriteCache(client, "key-1", []byte("answer")); err != nil {
+		t.Fatalf("保存验证码失败: %v", err)
+	}
+	cache.SetStore(second)
+	if got := string(cache.ReadCache(client, "key-1")); got != "answer" {
+		t.Errorf("另一个实例应能读取验证码答案，实际为%q", got)
+	}
+	if got := cache.ReadCache(client, "key-1"); len(got) != 0 {
+		t.Errorf("验证码答案只能使用一次，实际为%q", got)
+	}
+
+	// 客户端指纹不一致时读取失败，答案同时作废
+	cache.WriteCache(client, "key-2", []byte("answer"))
+	if got := cache.ReadCache(other, "key-2"); len(got) != 0 {
+		t.Errorf("其他客户端不应读取验证码答案，实际为%q", got)
+	}
+	if got := cache.ReadCache(client, "key-2"); len(got) != 0 {
+		t.Errorf("指纹不一致的尝试后答案应作废，实际为%q", got)
+	}
+
+	// 校验通过标记同样绑定客户端且只能使用一次
+	cache.SetCacheOk(client, "key-3")
+	if !cache.HasCacheOk(client, "key-3") || cache.HasCacheOk(client, "key-3") {
+		t.Errorf("校验通过标记应只能使用一次")
+	}
+	cache.SetCacheOk(client, "key-4")
+	if cache.HasCacheOk(other, "key-4") {
+		t.Errorf("其他客户端不应使用校验通过标记")
+	}
+}
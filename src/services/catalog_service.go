@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+)
+
+// CatalogService 群组目录服务接口
+// 配置文件中的群组和角色会同步到数据库作为系统群组，与用户自建群组统一对外提供
+type CatalogService interface {
+	SyncSystemGroups() error
+	GetCatalog(userID uint) (*models.Catalog, error)
+}
+
+// catalogService 群组目录服务实现
+type catalogService struct {
+	groupRepo repository.LlmGroupRepository
+}
+
+// NewCatalogService 创建群组目录服务实例
+func NewCatalogService() CatalogService {
+	return &catalogService{
+		groupRepo: repository.NewLlmGroupRepository(),
+	}
+}
+
+// SyncSystemGroups 将配置文件中的群组及其成员角色同步为系统群组
+// 以配置中的id作为稳定标识，重复执行不会产生重复数据
+func (s *catalogService) SyncSystemGroups() error {
+	characters := make(map[string]*config.LLMCharacter, len(config.AppConfig.LLMCharacters))
+	for _, character := range config.AppConfig.LLMCharacters {
+		characters[character.ID] = character
+	}
+
+	keys := make([]string, 0, len(config.AppConfig.LLMGroups))
+	for _, groupConfig := range config.AppConfig.LLMGroups {
+		key := groupConfig.ID
+		group := &models.LlmGroup{
+			SystemKey:             &key,
+			Name:                  groupConfig.Name,
+			Description:           groupConfig.Description,
+			Visibility:            models.VisibilityPublic,
+			IsGroupDiscussionMode: groupConfig.IsGroupDiscussionMode,
+		}
+
+		members := make([]models.GroupCharacter, 0, len(groupConfig.Members))
+		for _, memberID := range groupConfig.Members {
+			characterConfig, ok := characters[memberID]
+			if !ok {
+				log.Printf("系统群组[%s]的成员[%s]未在llm_characters中定义，已跳过", groupConfig.ID, memberID)
+				continue
+			}
+			characterKey := characterConfig.ID
+			members = append(members, models.GroupCharacter{
+				SystemKey:    &characterKey,
+				Name:         characterConfig.Name,
+				Personality:  characterConfig.Personality,
+				Model:        characterConfig.Model,
+				Avatar:       characterConfig.Avatar,
+				CustomPrompt: characterConfig.CustomPrompt,
+				Tags:         characterConfig.Tags,
+				RAG:          characterConfig.RAG,
+				Knowledge:    characterConfig.Knowledge,
+				SortOrder:    len(members) + 1,
+			})
+		}
+
+		if err := s.groupRepo.SyncSystemGroup(group, members); err != nil {
+			return fmt.Errorf("同步系统群组[%s]失败: %w", groupConfig.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return s.groupRepo.HideStaleSystemGroups(keys)
+}
+
+// GetCatalog 获取群组目录：系统群组、当前用户自己的群组及其角色
+// 未加入任何系统群组的配置角色（如调度器）不入库，直接从配置中返回
+func (s *catalogService) GetCatalog(userID uint) (*models.Catalog, error) {
+	groups, err := s.groupRepo.GetCatalogGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &models.Catalog{
+		Groups:     make([]models.CatalogGroup, 0, len(groups)),
+		Characters: make([]models.CatalogCharacter, 0),
+	}
+	seen := make(map[string]bool)
+
+	for _, group := range groups {
+		catalogGroup := models.CatalogGroup{
+			ID:                    catalogGroupID(&group),
+			GID:                   group.ID,
+			Name:                  group.Name,
+			Description:           group.Description,
+			Members:               make([]string, 0, len(group.Characters)),
+			IsGroupDiscussionMode: group.IsGroupDiscussionMode,
+			IsSystem:              group.IsSystem(),
+			OwnerID:               group.OwnerID,
+			Visibility:            group.Visibility,
+		}
+
+		for _, character := range group.Characters {
+			id := catalogCharacterID(&character)
+			catalogGroup.Members = append(catalogGroup.Members, id)
+			// 同一个系统角色可能属于多个系统群组，只返回一次
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			catalog.Characters = append(catalog.Characters, models.CatalogCharacter{
+				ID:           id,
+				Name:         character.Name,
+				Personality:  character.Personality,
+				Model:        character.Model,
+				Avatar:       character.Avatar,
+				CustomPrompt: character.CustomPrompt,
+				Tags:         character.Tags,
+				RAG:          character.RAG,
+				Knowledge:    character.Knowledge,
+			})
+		}
+
+		catalog.Groups = append(catalog.Groups, catalogGroup)
+	}
+
+	for _, character := range config.AppConfig.LLMCharacters {
+		if seen[character.ID] {
+			continue
+		}
+		seen[character.ID] = true
+		catalog.Characters = append(catalog.Characters, models.CatalogCharacter{
+			ID:           character.ID,
+			Name:         character.Name,
+			Personality:  character.Personality,
+			Model:        character.Model,
+			Avatar:       character.Avatar,
+			CustomPrompt: character.CustomPrompt,
+			Tags:         character.Tags,
+			RAG:          character.RAG,
+			Knowledge:    character.Knowledge,
+		})
+	}
+
+	return catalog, nil
+}
+
+// catalogGroupID 目录中的群组标识，系统群组沿用配置中的id
+func catalogGroupID(group *models.LlmGroup) string {
+	if group.SystemKey != nil {
+		return *group.SystemKey
+	}
+	return fmt.Sprintf("group_%d", group.ID)
+}
+
+// catalogCharacterID 目录中的角色标识，系统角色沿用配置中的id
+func catalogCharacterID(character *models.GroupCharacter) string {
+	if character.SystemKey != nil {
+		return *character.SystemKey
+	}
+	return fmt.Sprintf("char_%d", character.ID)
+}
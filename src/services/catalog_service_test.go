@@ -0,0 +1,134 @@
+package services
+
+import (
+	"testing"
+
+	"project/src/config"
+	"project/src/models"
+)
+
+// setCatalogConfig 设置测试用的配置群组和角色
+func setCatalogConfig(members ...string) {
+	config.AppConfig.LLMCharacters = []*config.LLMCharacter{
+		{ID: "ai0", Name: "调度器", Model: "qwen-plus"},
+		{ID: "ai5", Name: "豆包", Model: "qwen-plus", Avatar: "/img/doubao_new.png", Tags: []string{"聊天"}},
+		{ID: "ai6", Name: "千问", Model: "qwen-plus", Tags: []string{"文字游戏", "聊天"}},
+	}
+	config.AppConfig.LLMGroups = []*config.LLMGroup{
+		{ID: "group1", Name: "硅碳生命体交流群", Members: members, IsGroupDiscussionMode: true},
+	}
+}
+
+func TestSyncSystemGroupsIdempotent(t *testing.T) {
+	setupGroupTestDB(t)
+	setCatalogConfig("ai5", "ai6")
+	s := NewCatalogService()
+
+	for i := 0; i < 2; i++ {
+		if err := s.SyncSystemGroups(); err != nil {
+			t.Fatalf("第%d次同步失败: %v", i+1, err)
+		}
+	}
+
+	var groupCount, characterCount int64
+	config.DB.Model(&models.LlmGroup{}).Count(&groupCount)
+	config.DB.Model(&models.GroupCharacter{}).Count(&characterCount)
+	if groupCount != 1 || characterCount != 2 {
+		t.Fatalf("重复同步后应有1个群组2个角色，实际为%d个群组%d个角色", groupCount, characterCount)
+	}
+
+	// 配置变更后再次同步：改名并移除一个成员
+	config.AppConfig.LLMGroups[0].Name = "新群名"
+	config.AppConfig.LLMGroups[0].Members = []string{"ai6"}
+	if err := s.SyncSystemGroups(); err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+
+	var group models.LlmGroup
+	if err := config.DB.Preload("Characters").First(&group).Error; err != nil {
+		t.Fatalf("查询系统群组失败: %v", err)
+	}
+	if group.Name != "新群名" || !group.IsSystem() || group.Visibility != models.VisibilityPublic {
+		t.Errorf("系统群组未按配置更新: %+v", group)
+	}
+	if len(group.Characters) != 1 || *group.Characters[0].SystemKey != "ai6" {
+		t.Errorf("移除的成员应被删除，实际角色为%+v", group.Characters)
+	}
+
+	// 从配置中移除的群组不再出现在目录中
+	config.AppConfig.LLMGroups = nil
+	if err := s.SyncSystemGroups(); err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+	catalog, err := s.GetCatalog(0)
+	if err != nil {
+		t.Fatalf("获取目录失败: %v", err)
+	}
+	if len(catalog.Groups) != 0 {
+		t.Errorf("已移除的系统群组不应出现在目录中，实际为%+v", catalog.Groups)
+	}
+}
+
+func TestGetCatalogMergesUserGroups(t *testing.T) {
+	setupGroupTestDB(t)
+	setCatalogConfig("ai5", "ai6")
+	catalogService := NewCatalogService()
+	groupService := NewGroupService()
+
+	if err := catalogService.SyncSystemGroups(); err != nil {
+		t.Fatalf("同步失败: %v", err)
+	}
+
+	mine := mustCreateGroup(t, groupService, 1, "我的群", models.VisibilityPrivate)
+	character, err := groupService.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: mine.ID, Name: "小助手", Model: "qwen-plus"})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	mustCreateGroup(t, groupService, 2, "别人的群", models.VisibilityPublic)
+
+	catalog, err := catalogService.GetCatalog(1)
+	if err != nil {
+		t.Fatalf("获取目录失败: %v", err)
+	}
+	if len(catalog.Groups) != 2 {
+		t.Fatalf("目录应包含系统群组和自己的群组，实际为%+v", catalog.Groups)
+	}
+
+	system := catalog.Groups[0]
+	if system.ID != "group1" || !system.IsSystem || !system.IsGroupDiscussionMode {
+		t.Errorf("系统群组应排在前面并沿用配置id，实际为%+v", system)
+	}
+	if len(system.Members) != 2 || system.Members[0] != "ai5" || system.Members[1] != "ai6" {
+		t.Errorf("系统群组成员不正确: %v", system.Members)
+	}
+
+	own := catalog.Groups[1]
+	if own.GID != mine.ID || own.IsSystem || len(own.Members) != 1 || own.Members[0] != catalogCharacterID(character) {
+		t.Errorf("用户群组不正确: %+v", own)
+	}
+
+	ids := make(map[string]models.CatalogCharacter)
+	for _, c := range catalog.Characters {
+		ids[c.ID] = c
+	}
+	for _, id := range []string{"ai0", "ai5", "ai6", catalogCharacterID(character)} {
+		if _, ok := ids[id]; !ok {
+			t.Errorf("目录角色中缺少%s", id)
+		}
+	}
+	if len(ids) != len(catalog.Characters) {
+		t.Errorf("目录角色存在重复: %+v", catalog.Characters)
+	}
+	if ids["ai5"].Avatar != "/img/doubao_new.png" || len(ids["ai6"].Tags) != 2 {
+		t.Errorf("系统角色字段未正确同步: %+v %+v", ids["ai5"], ids["ai6"])
+	}
+
+	// 未登录时只返回系统群组
+	anonymous, err := catalogService.GetCatalog(0)
+	if err != nil {
+		t.Fatalf("获取目录失败: %v", err)
+	}
+	if len(anonymous.Groups) != 1 {
+		t.Errorf("未登录时只应返回系统群组，实际为%+v", anonymous.Groups)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"project/src/config"
+	"project/src/models"
+)
+
+// ErrInvalidBatch 批量操作参数无效
+var ErrInvalidBatch = errors.New("批量操作参数无效")
+
+// BatchCharacters 在一个事务中批量新建、更新、删除和排序群组内的角色，仅群组所有者可以操作
+// 返回操作后的群组及其全部角色
+func (s *groupService) BatchCharacters(userID, groupID uint, req *models.GroupCharacterBatchRequest) (*models.LlmGroup, error) {
+	group, err := s.getOwnedGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Create) == 0 && len(req.Update) == 0 && len(req.Delete) == 0 && len(req.Order) == 0 {
+		return nil, ErrEmptyUpdate
+	}
+
+	existingIDs, err := s.characterRepo.GetCharacterIDsByGroup(group.ID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+
+	deleted := make(map[uint]bool, len(req.Delete))
+	for _, id := range req.Delete {
+		if !existing[id] || deleted[id] {
+			return nil, fmt.Errorf("%w: 删除的角色%d不属于该群组或重复", ErrInvalidBatch, id)
+		}
+		deleted[id] = true
+	}
+
+	updates := make(map[uint]map[string]interface{}, len(req.Update)+len(req.Order))
+	for i := range req.Update {
+		item := &req.Update[i]
+		if !existing[item.ID] || deleted[item.ID] || updates[item.ID] != nil {
+			return nil, fmt.Errorf("%w: 更新的角色%d不属于该群组、已删除或重复", ErrInvalidBatch, item.ID)
+		}
+		fields, err := characterUpdates(&item.GroupCharacterUpdateRequest)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%w: 角色%d没有需要更新的字段", ErrInvalidBatch, item.ID)
+		}
+		updates[item.ID] = fields
+	}
+	// 记录内容有变化的角色，用于写入修订记录；仅调整顺序不产生修订
+	contentChanged := make([]uint, 0, len(updates))
+	for id, fields := range updates {
+		if _, sortOnly := fields["sort_order"]; !sortOnly || len(fields) > 1 {
+			contentChanged = append(contentChanged, id)
+		}
+	}
+
+	remaining := len(existingIDs) - len(deleted)
+	if len(req.Order) > 0 {
+		if len(req.Order) != remaining {
+			return nil, fmt.Errorf("%w: order需包含群组内全部保留的角色", ErrInvalidBatch)
+		}
+		seen := make(map[uint]bool, len(req.Order))
+		for i, id := range req.Order {
+			if !existing[id] || deleted[id] || seen[id] {
+				return nil, fmt.Errorf("%w: order中的角色%d不属于该群组、已删除或重复", ErrInvalidBatch, id)
+			}
+			seen[id] = true
+			// order 决定最终顺序，覆盖单个角色的 sort_order
+			if updates[id] == nil {
+				updates[id] = make(map[string]interface{})
+			}
+			updates[id]["sort_order"] = i + 1
+		}
+	}
+
+	if maxCharacters := config.AppConfig.Group.MaxCharactersPerGroup; maxCharacters > 0 && len(req.Create) > 0 && remaining+len(req.Create) > maxCharacters {
+		return nil, ErrTooManyCharacters
+	}
+
+	// 新建的角色排在所有保留角色之后
+	nextSortOrder, err := s.characterRepo.GetMaxSortOrder(group.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, fields := range updates {
+		if sortOrder, ok := fields["sort_order"].(int); ok && sortOrder > nextSortOrder {
+			nextSortOrder = sortOrder
+		}
+	}
+	creates := make([]models.GroupCharacter, len(req.Create))
+	for i, item := range req.Create {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return nil, ErrNameRequired
+		}
+		if err := validateModel(item.Model); err != nil {
+			return nil, err
+		}
+		creates[i] = models.GroupCharacter{
+			OwnerID:      userID,
+			Name:         name,
+			Personality:  item.Personality,
+			Model:        item.Model,
+			Avatar:       item.Avatar,
+			CustomPrompt: item.CustomPrompt,
+			SortOrder:    nextSortOrder + i + 1,
+		}
+	}
+
+	for _, id := range contentChanged {
+		character, err := s.characterRepo.GetCharacterByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.ensureInitialRevision(models.RevisionEntityCharacter, id, character.OwnerID, characterSnapshot(character)); err != nil {
+			return nil, err
+		}
+	}
+
+	deleteIDs := make([]uint, 0, len(deleted))
+	for id := range deleted {
+		deleteIDs = append(deleteIDs, id)
+	}
+	if err := s.characterRepo.BatchUpdateCharacters(group.ID, creates, updates, deleteIDs); err != nil {
+		return nil, err
+	}
+
+	for i := range creates {
+		if _, err := s.recordRevision(models.RevisionEntityCharacter, creates[i].ID, userID, models.RevisionActionCreate, 0, characterSnapshot(&creates[i])); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range contentChanged {
+		character, err := s.characterRepo.GetCharacterByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.recordRevision(models.RevisionEntityCharacter, id, userID, models.RevisionActionUpdate, 0, characterSnapshot(character)); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.groupRepo.GetGroupWithCharacters(group.ID)
+}
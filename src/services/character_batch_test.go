@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"project/src/config"
+	"project/src/models"
+)
+
+func TestUpdateMergePatch(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	group := mustCreateGroup(t, s, 1, "补丁测试", models.VisibilityPublic)
+
+	character, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{
+		GID: group.ID, Name: "助手", Avatar: "avatar.png", CustomPrompt: "简洁回答", Model: "qwen-plus",
+	})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+
+	// null 清除字段，空字符串同样生效，未出现的字段不修改
+	var req models.GroupCharacterUpdateRequest
+	if err := json.Unmarshal([]byte(`{"avatar": null, "custom_prompt": ""}`), &req); err != nil {
+		t.Fatalf("解析请求失败: %v", err)
+	}
+	if !req.Avatar.Set || !req.Avatar.Null || !req.CustomPrompt.Set || req.Name.Set {
+		t.Fatalf("合并补丁字段解析不正确: %+v", req)
+	}
+	updated, err := s.UpdateCharacter(1, character.ID, &req)
+	if err != nil {
+		t.Fatalf("更新角色失败: %v", err)
+	}
+	if updated.Avatar != "" || updated.CustomPrompt != "" || updated.Name != "助手" || updated.Model != "qwen-plus" {
+		t.Errorf("合并补丁结果不正确: %+v", updated)
+	}
+
+	if _, err := s.UpdateCharacter(1, character.ID, &models.GroupCharacterUpdateRequest{Name: models.PatchNull[string]()}); !errors.Is(err, ErrNameRequired) {
+		t.Errorf("清除角色名称应返回ErrNameRequired，实际为%v", err)
+	}
+
+	updatedGroup, err := s.UpdateGroup(1, group.ID, &models.LlmGroupUpdateRequest{
+		Visibility: models.PatchNull[string](),
+		Tags:       models.PatchValue([]string{"测试"}),
+	})
+	if err != nil {
+		t.Fatalf("更新群组失败: %v", err)
+	}
+	if updatedGroup.Visibility != models.VisibilityPrivate || len(updatedGroup.Tags) != 1 {
+		t.Errorf("群组可见性应恢复为private并设置标签，实际为%+v", updatedGroup)
+	}
+	updatedGroup, err = s.UpdateGroup(1, group.ID, &models.LlmGroupUpdateRequest{Tags: models.PatchNull[[]string]()})
+	if err != nil {
+		t.Fatalf("更新群组失败: %v", err)
+	}
+	if len(updatedGroup.Tags) != 0 || updatedGroup.Name != "补丁测试" {
+		t.Errorf("null应清空标签且不影响其他字段，实际为%+v", updatedGroup)
+	}
+}
+
+func TestBatchCharacters(t *testing.T) {
+	setupGroupTestDB(t)
+	config.AppConfig.Group.MaxCharactersPerGroup = 4
+	s := NewGroupService()
+	group := mustCreateGroup(t, s, 1, "批量测试", "")
+
+	first, _ := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "甲"})
+	second, _ := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "乙"})
+	third, _ := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "丙"})
+	if first.SortOrder != 1 || third.SortOrder != 3 {
+		t.Fatalf("新角色应依次排在末尾，实际为%d、%d", first.SortOrder, third.SortOrder)
+	}
+
+	result, err := s.BatchCharacters(1, group.ID, &models.GroupCharacterBatchRequest{
+		Create: []models.GroupCharacterBatchCreate{{Name: "丁"}, {Name: "戊"}},
+		Update: []models.GroupCharacterBatchUpdate{{
+			ID:                          second.ID,
+			GroupCharacterUpdateRequest: models.GroupCharacterUpdateRequest{Personality: models.PatchValue("沉稳")},
+		}},
+		Delete: []uint{first.ID},
+		Order:  []uint{third.ID, second.ID},
+	})
+	if err != nil {
+		t.Fatalf("批量操作失败: %v", err)
+	}
+	names := make([]string, 0, len(result.Characters))
+	for _, character := range result.Characters {
+		names = append(names, character.Name)
+	}
+	if len(names) != 4 || names[0] != "丙" || names[1] != "乙" || names[2] != "丁" || names[3] != "戊" {
+		t.Fatalf("批量操作后的角色顺序不正确: %v", names)
+	}
+	if result.Characters[1].Personality != "沉稳" {
+		t.Errorf("批量更新未生效: %+v", result.Characters[1])
+	}
+	if _, total, _ := s.ListRevisions(1, models.RevisionEntityCharacter, second.ID, 1, 10); total != 2 {
+		t.Errorf("批量更新应记录修订，实际为%d条", total)
+	}
+
+	// 任一操作无效时整批不生效
+	_, err = s.BatchCharacters(1, group.ID, &models.GroupCharacterBatchRequest{
+		Update: []models.GroupCharacterBatchUpdate{{
+			ID:                          third.ID,
+			GroupCharacterUpdateRequest: models.GroupCharacterUpdateRequest{Name: models.PatchValue("改名")},
+		}},
+		Delete: []uint{first.ID},
+	})
+	if !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("删除不属于群组的角色应返回ErrInvalidBatch，实际为%v", err)
+	}
+	if character, _ := s.GetCharacter(1, third.ID); character.Name != "丙" {
+		t.Errorf("批量操作失败时不应修改角色，实际为%s", character.Name)
+	}
+
+	if _, err := s.BatchCharacters(1, group.ID, &models.GroupCharacterBatchRequest{Order: []uint{third.ID}}); !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("order未包含全部角色应返回ErrInvalidBatch，实际为%v", err)
+	}
+	if _, err := s.BatchCharacters(1, group.ID, &models.GroupCharacterBatchRequest{Create: []models.GroupCharacterBatchCreate{{Name: "己"}}}); !errors.Is(err, ErrTooManyCharacters) {
+		t.Errorf("超过角色上限应返回ErrTooManyCharacters，实际为%v", err)
+	}
+	if _, err := s.BatchCharacters(2, group.ID, &models.GroupCharacterBatchRequest{Delete: []uint{third.ID}}); !errors.Is(err, ErrGroupForbidden) {
+		t.Errorf("非群组所有者批量操作应返回ErrGroupForbidden，实际为%v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/utils"
+
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// characterCardExtensionKey 角色卡 extensions 中本应用使用的命名空间
+	characterCardExtensionKey = "botgroup"
+	// maxAvatarImageSize 导出PNG角色卡时下载头像的大小上限
+	maxAvatarImageSize = 10 << 20
+)
+
+// ErrInvalidCharacterCard 角色卡格式错误
+var ErrInvalidCharacterCard = errors.New("无效的角色卡")
+
+// ParseCharacterCard 解析角色卡，支持 Character Card V2/V1 JSON 及嵌入 chara 文本块的PNG图片
+func ParseCharacterCard(data []byte) (*models.CharacterCardV2, error) {
+	if utils.IsPNG(data) {
+		text, err := utils.ReadPNGText(data, models.CharacterCardPNGKeyword)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCharacterCard, err)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("%w: chara文本块不是有效的base64: %v", ErrInvalidCharacterCard, err)
+		}
+		data = decoded
+	}
+
+	var header struct {
+		Spec string `json:"spec"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCharacterCard, err)
+	}
+
+	card := &models.CharacterCardV2{}
+	switch header.Spec {
+	case models.CharacterCardSpecV2:
+		if err := json.Unmarshal(data, card); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCharacterCard, err)
+		}
+	case "":
+		// V1角色卡的字段直接位于顶层
+		if err := json.Unmarshal(data, &card.Data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCharacterCard, err)
+		}
+		card.Spec = models.CharacterCardSpecV2
+		card.SpecVersion = models.CharacterCardVersionV2
+	default:
+		return nil, fmt.Errorf("%w: 不支持的规范 %s", ErrInvalidCharacterCard, header.Spec)
+	}
+
+	card.Data.Name = strings.TrimSpace(card.Data.Name)
+	if card.Data.Name == "" {
+		return nil, fmt.Errorf("%w: 缺少角色名称", ErrInvalidCharacterCard)
+	}
+	return card, nil
+}
+
+// EncodeCharacterCardPNG 将角色卡以base64写入PNG图片的 chara 文本块
+// avatar为头像图片数据，非PNG格式会转换为PNG，为空或无法解码时生成纯色图片
+func EncodeCharacterCardPNG(card *models.CharacterCardV2, avatar []byte) ([]byte, error) {
+	cardJSON, err := json.Marshal(card)
+	if err != nil {
+		return nil, fmt.Errorf("序列化角色卡失败: %v", err)
+	}
+
+	pngData := avatar
+	if !utils.IsPNG(pngData) {
+		pngData, err = toPNG(avatar)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := utils.WritePNGText(pngData, models.CharacterCardPNGKeyword, base64.StdEncoding.EncodeToString(cardJSON))
+	if err != nil {
+		return nil, fmt.Errorf("写入角色卡数据失败: %v", err)
+	}
+	return result, nil
+}
+
+// cardToCharacter 将角色卡映射为角色，无法映射的字段保存到扩展信息中
+func cardToCharacter(card *models.CharacterCardV2) *models.GroupCharacter {
+	data := &card.Data
+	return &models.GroupCharacter{
+		Name:         truncateRunes(data.Name, 100),
+		Personality:  truncateRunes(data.Personality, 100),
+		CustomPrompt: cardPrompt(data),
+		Extension: &models.CharacterExtension{
+			Description:             data.Description,
+			Personality:             data.Personality,
+			Scenario:                data.Scenario,
+			FirstMes:                data.FirstMes,
+			MesExample:              data.MesExample,
+			CreatorNotes:            data.CreatorNotes,
+			SystemPrompt:            data.SystemPrompt,
+			PostHistoryInstructions: data.PostHistoryInstructions,
+			AlternateGreetings:      data.AlternateGreetings,
+			CharacterBook:           data.CharacterBook,
+			Tags:                    data.Tags,
+			Creator:                 data.Creator,
+			CharacterVersion:        data.CharacterVersion,
+			Extensions:              data.Extensions,
+		},
+	}
+}
+
+// characterToCard 将角色导出为 Character Card V2 角色卡
+func characterToCard(character *models.GroupCharacter) *models.CharacterCardV2 {
+	data := models.CharacterCardData{
+		Name:        character.Name,
+		Description: character.CustomPrompt,
+		Personality: character.Personality,
+	}
+
+	if ext := character.Extension; ext != nil {
+		data.Scenario = ext.Scenario
+		data.FirstMes = ext.FirstMes
+		data.MesExample = ext.MesExample
+		data.CreatorNotes = ext.CreatorNotes
+		data.PostHistoryInstructions = ext.PostHistoryInstructions
+		data.AlternateGreetings = ext.AlternateGreetings
+		data.CharacterBook = ext.CharacterBook
+		data.Tags = ext.Tags
+		data.Creator = ext.Creator
+		data.CharacterVersion = ext.CharacterVersion
+		data.Extensions = ext.Extensions
+
+		// 提示词未被修改时按原角色卡导出，否则以当前提示词作为描述
+		original := models.CharacterCardData{
+			Name:         character.Name,
+			Description:  ext.Description,
+			Personality:  ext.Personality,
+			Scenario:     ext.Scenario,
+			SystemPrompt: ext.SystemPrompt,
+		}
+		if cardPrompt(&original) == character.CustomPrompt {
+			data.Description = ext.Description
+			data.Personality = ext.Personality
+			data.SystemPrompt = ext.SystemPrompt
+		} else {
+			data.Scenario = ""
+		}
+	}
+
+	// 规范要求以下字段不能为null
+	if data.AlternateGreetings == nil {
+		data.AlternateGreetings = []string{}
+	}
+	if data.Tags == nil {
+		data.Tags = []string{}
+	}
+	extensions := make(map[string]interface{}, len(data.Extensions)+1)
+	for key, value := range data.Extensions {
+		extensions[key] = value
+	}
+	if character.Model != "" {
+		extensions[characterCardExtensionKey] = map[string]interface{}{"model": character.Model}
+	}
+	data.Extensions = extensions
+
+	return &models.CharacterCardV2{
+		Spec:        models.CharacterCardSpecV2,
+		SpecVersion: models.CharacterCardVersionV2,
+		Data:        data,
+	}
+}
+
+// cardPrompt 根据角色卡内容生成自定义提示词
+func cardPrompt(data *models.CharacterCardData) string {
+	var parts []string
+	if data.SystemPrompt != "" {
+		parts = append(parts, data.SystemPrompt)
+	}
+	if data.Description != "" {
+		parts = append(parts, data.Description)
+	}
+	if data.Personality != "" {
+		parts = append(parts, "性格："+data.Personality)
+	}
+	if data.Scenario != "" {
+		parts = append(parts, "场景："+data.Scenario)
+	}
+
+	// 替换角色卡中的占位符，群聊中用户的名称固定为user
+	replacer := strings.NewReplacer("{{char}}", data.Name, "{{user}}", "user")
+	return replacer.Replace(strings.Join(parts, "\n\n"))
+}
+
+// cardModel 读取角色卡中本应用导出时记录的模型
+func cardModel(card *models.CharacterCardV2) string {
+	ext, ok := card.Data.Extensions[characterCardExtensionKey].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	model, _ := ext["model"].(string)
+	return model
+}
+
+// LoadAvatarImage 下载角色头像，仅允许下载配置的图片域名下的地址
+func LoadAvatarImage(avatar string) []byte {
+	prefix, _, _ := strings.Cut(config.AppConfig.Cloudflare.ImagePrefix, "%")
+	if prefix == "" || !strings.HasPrefix(avatar, prefix) {
+		return nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(avatar)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAvatarImageSize))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// toPNG 将图片转换为PNG，无法解码时生成纯色图片
+func toPNG(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		placeholder := image.NewRGBA(image.Rect(0, 0, 400, 600))
+		draw.Draw(placeholder, placeholder.Bounds(), &image.Uniform{C: color.RGBA{R: 0xe5, G: 0xe7, B: 0xeb, A: 0xff}}, image.Point{}, draw.Src)
+		img = placeholder
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("生成PNG图片失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// truncateRunes 按字符数截断字符串
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"project/src/models"
+	"project/src/repository"
+)
+
+func TestParseCharacterCardV1AndInvalid(t *testing.T) {
+	card, err := ParseCharacterCard([]byte(`{"name":" 小明 ","description":"{{char}}是一名程序员","personality":"内向"}`))
+	if err != nil {
+		t.Fatalf("解析V1角色卡失败: %v", err)
+	}
+	if card.Spec != models.CharacterCardSpecV2 || card.Data.Name != "小明" {
+		t.Errorf("V1角色卡应转换为V2并去除名称空白，实际为%+v", card)
+	}
+	if prompt := cardPrompt(&card.Data); prompt != "小明是一名程序员\n\n性格：内向" {
+		t.Errorf("生成的提示词不正确: %q", prompt)
+	}
+
+	for _, data := range []string{`not json`, `{"spec":"chara_card_v3","data":{"name":"a"}}`, `{"spec":"chara_card_v2","data":{}}`} {
+		if _, err := ParseCharacterCard([]byte(data)); !errors.Is(err, ErrInvalidCharacterCard) {
+			t.Errorf("%s 应返回ErrInvalidCharacterCard，实际为%v", data, err)
+		}
+	}
+}
+
+func TestCharacterCardImportExportRoundTrip(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	group := mustCreateGroup(t, s, 1, "角色卡", "")
+
+	original := &models.CharacterCardV2{
+		Spec:        models.CharacterCardSpecV2,
+		SpecVersion: models.CharacterCardVersionV2,
+		Data: models.CharacterCardData{
+			Name:        "艾拉",
+			Description: "来自北方的旅行者",
+			Personality: "开朗",
+			Scenario:    "{{user}}在酒馆遇到了{{char}}",
+			FirstMes:    "你好，旅人！",
+			Tags:        []string{"奇幻"},
+			Extensions:  map[string]interface{}{"botgroup": map[string]interface{}{"model": "qwen-plus"}},
+		},
+	}
+	pngData, err := EncodeCharacterCardPNG(original, nil)
+	if err != nil {
+		t.Fatalf("生成PNG角色卡失败: %v", err)
+	}
+
+	character, err := s.ImportCharacterCard(1, group.ID, pngData, "", "")
+	if err != nil {
+		t.Fatalf("导入PNG角色卡失败: %v", err)
+	}
+	if character.Model != "qwen-plus" {
+		t.Errorf("未指定模型时应使用角色卡记录的模型，实际为%q", character.Model)
+	}
+	if character.Extension == nil || character.Extension.FirstMes != "你好，旅人！" {
+		t.Errorf("无法映射的字段应保存到扩展信息中，实际为%+v", character.Extension)
+	}
+
+	if _, _, err := s.ExportCharacterCard(2, character.ID); !errors.Is(err, repository.ErrCharacterNotFound) {
+		t.Errorf("其他用户导出私有群组角色应返回ErrCharacterNotFound，实际为%v", err)
+	}
+
+	exported, _, err := s.ExportCharacterCard(1, character.ID)
+	if err != nil {
+		t.Fatalf("导出角色卡失败: %v", err)
+	}
+	data := exported.Data
+	if data.Description != original.Data.Description || data.Scenario != original.Data.Scenario ||
+		data.FirstMes != original.Data.FirstMes || len(data.Tags) != 1 {
+		t.Errorf("提示词未修改时应按原角色卡导出，实际为%+v", data)
+	}
+
+	// 修改提示词后以当前提示词作为描述导出
+	if _, err := s.UpdateCharacter(1, character.ID, &models.GroupCharacterUpdateRequest{CustomPrompt: models.PatchValue("新的提示词")}); err != nil {
+		t.Fatalf("更新角色失败: %v", err)
+	}
+	exported, _, err = s.ExportCharacterCard(1, character.ID)
+	if err != nil {
+		t.Fatalf("导出角色卡失败: %v", err)
+	}
+	if exported.Data.Description != "新的提示词" || exported.Data.Scenario != "" {
+		t.Errorf("提示词修改后应以其作为描述导出，实际为%+v", exported.Data)
+	}
+}
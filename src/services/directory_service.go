@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+)
+
+// ErrInvalidSort 无效的排序方式
+var ErrInvalidSort = errors.New("无效的sort参数，可选值：popular、recent")
+
+// DirectoryQuery 公开目录查询参数
+type DirectoryQuery struct {
+	Keyword  string
+	Category string
+	Tag      string
+	Sort     string
+}
+
+// DirectoryService 公开群组目录服务接口
+type DirectoryService interface {
+	Categories() []string
+	SearchGroups(query *DirectoryQuery, page, pageSize int) ([]models.LlmGroup, int64, error)
+	ListForks(userID, groupID uint, page, pageSize int) ([]models.LlmGroup, int64, error)
+	ListFavorites(userID uint, page, pageSize int) ([]models.LlmGroup, int64, error)
+	GetReactions(userID, groupID uint) (*models.GroupReactionState, error)
+	SetReaction(userID, groupID uint, reactionType string, active bool) (*models.GroupReactionState, error)
+	ModerateGroup(groupID uint, req *models.GroupModerationRequest) (*models.LlmGroup, error)
+}
+
+// directoryService 公开群组目录服务实现
+type directoryService struct {
+	groupRepo    repository.LlmGroupRepository
+	reactionRepo repository.GroupReactionRepository
+}
+
+// NewDirectoryService 创建公开群组目录服务实例
+func NewDirectoryService() DirectoryService {
+	return &directoryService{
+		groupRepo:    repository.NewLlmGroupRepository(),
+		reactionRepo: repository.NewGroupReactionRepository(),
+	}
+}
+
+// Categories 获取配置的群组分类
+func (s *directoryService) Categories() []string {
+	if config.AppConfig.Group.Categories == nil {
+		return []string{}
+	}
+	return config.AppConfig.Group.Categories
+}
+
+// SearchGroups 搜索公开目录中的群组，默认按热度排序
+func (s *directoryService) SearchGroups(query *DirectoryQuery, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	sort := query.Sort
+	if sort == "" {
+		sort = repository.GroupSortPopular
+	}
+	if sort != repository.GroupSortPopular && sort != repository.GroupSortRecent {
+		return nil, 0, ErrInvalidSort
+	}
+
+	return s.groupRepo.GetGroups(repository.GroupListFilter{
+		Listed:   true,
+		Keyword:  query.Keyword,
+		Category: query.Category,
+		Tag:      query.Tag,
+		Sort:     sort,
+	}, page, pageSize)
+}
+
+// ListForks 获取克隆自指定群组且出现在公开目录中的群组
+func (s *directoryService) ListForks(userID, groupID uint, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	if _, err := s.getViewableGroup(userID, groupID); err != nil {
+		return nil, 0, err
+	}
+	return s.groupRepo.GetGroups(repository.GroupListFilter{
+		Listed:     true,
+		ForkedFrom: groupID,
+		Sort:       repository.GroupSortRecent,
+	}, page, pageSize)
+}
+
+// ListFavorites 获取当前用户收藏的群组
+func (s *directoryService) ListFavorites(userID uint, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	return s.reactionRepo.GetFavoriteGroups(userID, page, pageSize)
+}
+
+// GetReactions 获取当前用户对群组的点赞/收藏状态
+func (s *directoryService) GetReactions(userID, groupID uint) (*models.GroupReactionState, error) {
+	group, err := s.getViewableGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &models.GroupReactionState{
+		LikeCount:     group.LikeCount,
+		FavoriteCount: group.FavoriteCount,
+	}
+	if userID == 0 {
+		return state, nil
+	}
+
+	types, err := s.reactionRepo.GetUserReactionTypes(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, reactionType := range types {
+		switch reactionType {
+		case models.ReactionLike:
+			state.Liked = true
+		case models.ReactionFavorite:
+			state.Favorited = true
+		}
+	}
+	return state, nil
+}
+
+// SetReaction 点赞/收藏或取消，重复操作不会重复计数
+func (s *directoryService) SetReaction(userID, groupID uint, reactionType string, active bool) (*models.GroupReactionState, error) {
+	if userID == 0 {
+		return nil, ErrGroupForbidden
+	}
+	if _, err := s.getViewableGroup(userID, groupID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if active {
+		err = s.reactionRepo.AddReaction(userID, groupID, reactionType)
+	} else {
+		err = s.reactionRepo.RemoveReaction(userID, groupID, reactionType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReactions(userID, groupID)
+}
+
+// ModerateGroup 设置群组的审核状态，供管理员下架或恢复公开目录中的群组
+func (s *directoryService) ModerateGroup(groupID uint, req *models.GroupModerationRequest) (*models.LlmGroup, error) {
+	if _, err := s.groupRepo.GetGroupByID(groupID); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"moderation_status": req.Status,
+		"moderation_reason": req.Reason,
+	}
+	if err := s.groupRepo.UpdateGroup(groupID, updates); err != nil {
+		return nil, err
+	}
+	return s.groupRepo.GetGroupByID(groupID)
+}
+
+// getViewableGroup 获取当前用户可查看的群组，无权查看时表现为不存在
+func (s *directoryService) getViewableGroup(userID, groupID uint) (*models.LlmGroup, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if !group.CanView(userID) {
+		return nil, repository.ErrGroupNotFound
+	}
+	return group, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"project/src/models"
+)
+
+func TestDirectorySearch(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	d := NewDirectoryService()
+
+	tech, err := s.CreateGroup(1, &models.LlmGroupCreateRequest{
+		Name: "Go夜话", Description: "聊聊并发", Visibility: models.VisibilityPublic, Category: "技术", Tags: []string{" golang ", "golang", ""},
+	})
+	if err != nil {
+		t.Fatalf("创建群组失败: %v", err)
+	}
+	if len(tech.Tags) != 1 || tech.Tags[0] != "golang" || tech.PublishedAt == nil {
+		t.Errorf("标签应去重去空白且公开群组应记录公开时间，实际为%+v", tech)
+	}
+	chat := mustCreateGroup(t, s, 2, "周末闲聊", models.VisibilityPublic)
+	mustCreateGroup(t, s, 2, "私有群组", "")
+
+	if _, err := d.SetReaction(3, chat.ID, models.ReactionLike, true); err != nil {
+		t.Fatalf("点赞失败: %v", err)
+	}
+
+	groups, total, err := d.SearchGroups(&DirectoryQuery{}, 1, 10)
+	if err != nil {
+		t.Fatalf("搜索群组失败: %v", err)
+	}
+	if total != 2 || groups[0].ID != chat.ID {
+		t.Errorf("默认按热度排序且只返回公开群组，实际为%d个，首个为%+v", total, groups)
+	}
+
+	groups, _, _ = d.SearchGroups(&DirectoryQuery{Sort: "recent"}, 1, 10)
+	if len(groups) != 2 || groups[0].ID != chat.ID {
+		t.Errorf("按时间排序时最新公开的群组应排在前面，实际为%+v", groups)
+	}
+	if _, total, _ := d.SearchGroups(&DirectoryQuery{Keyword: "并发", Tag: "golang"}, 1, 10); total != 1 {
+		t.Errorf("按描述和标签搜索应返回1个群组，实际为%d个", total)
+	}
+	if _, total, _ := d.SearchGroups(&DirectoryQuery{Category: "闲聊"}, 1, 10); total != 0 {
+		t.Errorf("按分类过滤应返回0个群组，实际为%d个", total)
+	}
+	if _, _, err := d.SearchGroups(&DirectoryQuery{Sort: "hot"}, 1, 10); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("无效的排序方式应返回ErrInvalidSort，实际为%v", err)
+	}
+
+	// 管理员下架后不再出现在公开目录中，但仍可通过ID访问
+	if _, err := d.ModerateGroup(tech.ID, &models.GroupModerationRequest{Status: models.ModerationStatusHidden, Reason: "违规"}); err != nil {
+		t.Fatalf("下架群组失败: %v", err)
+	}
+	if _, total, _ := d.SearchGroups(&DirectoryQuery{}, 1, 10); total != 1 {
+		t.Errorf("下架的群组不应出现在公开目录中，实际为%d个", total)
+	}
+	if _, total, _ := s.ListGroups(0, GroupScopePublic, "", 1, 10); total != 1 {
+		t.Errorf("下架的群组不应出现在公开群组列表中，实际为%d个", total)
+	}
+	if _, err := s.GetGroup(3, tech.ID); err != nil {
+		t.Errorf("下架的群组仍应可以通过ID访问: %v", err)
+	}
+}
+
+func TestGroupReactionsAndForks(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	d := NewDirectoryService()
+
+	public := mustCreateGroup(t, s, 1, "公开群组", models.VisibilityPublic)
+	private := mustCreateGroup(t, s, 1, "私有群组", "")
+
+	// 重复点赞只计一次
+	for i := 0; i < 2; i++ {
+		if _, err := d.SetReaction(2, public.ID, models.ReactionLike, true); err != nil {
+			t.Fatalf("点赞失败: %v", err)
+		}
+	}
+	state, err := d.SetReaction(2, public.ID, models.ReactionFavorite, true)
+	if err != nil {
+		t.Fatalf("收藏失败: %v", err)
+	}
+	if !state.Liked || !state.Favorited || state.LikeCount != 1 || state.FavoriteCount != 1 {
+		t.Errorf("互动状态不正确: %+v", state)
+	}
+
+	favorites, total, err := d.ListFavorites(2, 1, 10)
+	if err != nil || total != 1 || favorites[0].ID != public.ID {
+		t.Errorf("收藏列表不正确: %+v, %v", favorites, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if state, err = d.SetReaction(2, public.ID, models.ReactionLike, false); err != nil {
+			t.Fatalf("取消点赞失败: %v", err)
+		}
+	}
+	if state.Liked || state.LikeCount != 0 {
+		t.Errorf("取消点赞后计数应为0，实际为%+v", state)
+	}
+
+	if _, err := d.SetReaction(2, public.ID, "dislike", true); err == nil {
+		t.Error("无效的互动类型应返回错误")
+	}
+	if _, err := d.SetReaction(2, private.ID, models.ReactionLike, true); err == nil {
+		t.Error("不能对无权查看的群组点赞")
+	}
+
+	fork, err := s.CloneGroup(2, public.ID, &models.GroupCloneRequest{})
+	if err != nil {
+		t.Fatalf("克隆群组失败: %v", err)
+	}
+	if fork.ForkedFrom == nil || *fork.ForkedFrom != public.ID {
+		t.Errorf("克隆的群组应记录来源群组，实际为%v", fork.ForkedFrom)
+	}
+	source, _ := s.GetGroup(1, public.ID)
+	if source.ForkCount != 1 {
+		t.Errorf("来源群组的克隆数应为1，实际为%d", source.ForkCount)
+	}
+
+	// 克隆的群组默认私有，公开后出现在克隆列表中
+	if _, total, _ := d.ListForks(1, public.ID, 1, 10); total != 0 {
+		t.Errorf("私有的克隆不应出现在克隆列表中，实际为%d个", total)
+	}
+	if _, err := s.UpdateGroup(2, fork.ID, &models.LlmGroupUpdateRequest{Visibility: models.PatchValue(models.VisibilityPublic)}); err != nil {
+		t.Fatalf("公开群组失败: %v", err)
+	}
+	if _, total, _ := d.ListForks(1, public.ID, 1, 10); total != 1 {
+		t.Errorf("公开的克隆应出现在克隆列表中，实际为%d个", total)
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExpirationTime 验证码答案的有效期
+const ExpirationTime = 5 * time.Minute
+
+// okKeyPrefix 校验通过标记的键前缀
+const okKeyPrefix = "ok:"
+
+// Store 验证码答案存储接口，多实例部署时使用共享存储（如Redis），生成和校验请求可以落在不同实例上
+type Store interface {
+	Set(key string, value []byte, ttl time.Duration) error
+	// Take 取出并删除，每个键只能成功取出一次，不存在、已过期或已被取出时返回 nil
+	Take(key string) ([]byte, error)
+}
+
+var (
+	storeMux sync.RWMutex
+	store    Store = NewMemoryStore()
+)
+
+// SetStore 替换验证码答案存储，启动时调用
+func SetStore(s Store) {
+	storeMux.Lock()
+	defer storeMux.Unlock()
+	store = s
+}
+
+func currentStore() Store {
+	storeMux.RLock()
+	defer storeMux.RUnlock()
+	return store
+}
+
+// record 保存的验证码答案，绑定生成验证码的客户端指纹
+type record struct {
+	Fingerprint string `json:"fp"`
+	Data        []byte `json:"data"`
+}
+
+// WriteCache 保存验证码答案，并绑定请求的客户端指纹
+func WriteCache(r *http.Request, key string, data []byte) error {
+	value, err := json.Marshal(record{Fingerprint: Fingerprint(r), Data: data})
+	if err != nil {
+		return err
+	}
+	return currentStore().Set(key, value, ExpirationTime)
+}
+
+// ReadCache 取出验证码答案，答案只能取出一次，无论校验是否通过都需要重新获取验证码
+// 客户端指纹与生成验证码时不一致时返回空
+func ReadCache(r *http.Request, key string) []byte {
+	value, err := currentStore().Take(key)
+	if err != nil || len(value) == 0 {
+		return []byte{}
+	}
+	var rec record
+	if err := json.Unmarshal(value, &rec); err != nil || rec.Fingerprint != Fingerprint(r) {
+		return []byte{}
+	}
+	return rec.Data
+}
+
+// SetCacheOk 记录验证码校验通过
+func SetCacheOk(r *http.Request, key string) error {
+	return currentStore().Set(okKeyPrefix+key, []byte(Fingerprint(r)), ExpirationTime)
+}
+
+// HasCacheOk 检查验证码是否已校验通过，校验通过标记只能使用一次
+func HasCacheOk(r *http.Request, key string) bool {
+	value, err := currentStore().Take(okKeyPrefix + key)
+	return err == nil && len(value) > 0 && string(value) == Fingerprint(r)
+}
+
+// Fingerprint 客户端指纹，由客户端IP和User-Agent计算
+// 客户端IP依次取 X-Real-IP、X-Forwarded-For 的第一个地址和连接地址，与中间件的取法一致
+func Fingerprint(r *http.Request) string {
+	ip := r.Header.Get("X-Real-IP")
+	if ip == "" {
+		ip = strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-For"), ",")[0])
+	}
+	if ip == "" {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+	sum := sha256.Sum256([]byte(ip + "|" + r.UserAgent()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// memoryStore 进程内存储，只适用于单实例部署
+type memoryStore struct {
+	mux   sync.Mutex
+	items map[string]*cachedata
+}
+
+// cachedata
+type cachedata = struct {
+	data     []byte
+	expireAt time.Time
+}
+
+// NewMemoryStore 创建进程内存储，并定时清理过期的答案
+func NewMemoryStore() Store {
+	s := &memoryStore{items: make(map[string]*cachedata)}
+	go s.runTimedTask()
+	return s
+}
+
+// Set .
+func (s *memoryStore) Set(key string, value []byte, ttl time.Duration) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.items[key] = &cachedata{data: value, expireAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Take .
+func (s *memoryStore) Take(key string) ([]byte, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	cd, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.items, key)
+	if time.Now().After(cd.expireAt) {
+		return nil, nil
+	}
+	return cd.data, nil
+}
+
+// runTimedTask .
+func (s *memoryStore) runTimedTask() {
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		s.checkCacheOvertime()
+	}
+}
+
+func (s *memoryStore) checkCacheOvertime() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := time.Now()
+	for key, cd := range s.items {
+		if now.After(cd.expireAt) {
+			delete(s.items, key)
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 	"project/src/services/go-captcha/internal/helper"
 
 	"github.com/golang/freetype/truetype"
@@ -128,7 +128,7 @@ func GetClickBasicCaptThemeData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "gen captcha data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -139,7 +139,7 @@ func GetClickBasicCaptThemeData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -149,15 +149,21 @@ func GetClickBasicCaptThemeData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
 	dotsByte, _ := json.Marshal(dotData)
 	key := helper.GenUniqueId()
 	//key := helper.StringToMD5(string(dotsByte))
-	cache.WriteCache(key, dotsByte)
-	fmt.Println("dot>>>>>", string(dotsByte))
+	if err := cache.WriteCache(r, key, dotsByte); err != nil {
+		bt, _ := json.Marshal(map[string]interface{}{
+			"code":    1,
+			"message": "save captcha data failed",
+		})
+		_, _ = fmt.Fprint(w, string(bt))
+		return
+	}
 
 	bt, _ := json.Marshal(map[string]interface{}{
 		"code":         0,
@@ -166,5 +172,5 @@ func GetClickBasicCaptThemeData(w http.ResponseWriter, r *http.Request) {
 		"thumb_base64": thumbImageBase64,
 	})
 
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 }
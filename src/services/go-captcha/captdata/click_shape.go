@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 	"project/src/services/go-captcha/internal/helper"
 
 	"github.com/wenlng/go-captcha-assets/resources/imagesv2"
@@ -60,7 +60,7 @@ func GetClickShapesCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "gen captcha data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -71,7 +71,7 @@ func GetClickShapesCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -81,14 +81,21 @@ func GetClickShapesCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
 	dotsByte, _ := json.Marshal(dotData)
 	key := helper.GenUniqueId()
 	//key := helper.StringToMD5(string(dotsByte))
-	cache.WriteCache(key, dotsByte)
+	if err := cache.WriteCache(r, key, dotsByte); err != nil {
+		bt, _ := json.Marshal(map[string]interface{}{
+			"code":    1,
+			"message": "save captcha data failed",
+		})
+		_, _ = fmt.Fprint(w, string(bt))
+		return
+	}
 
 	bt, _ := json.Marshal(map[string]interface{}{
 		"code":         0,
@@ -96,5 +103,5 @@ func GetClickShapesCaptData(w http.ResponseWriter, r *http.Request) {
 		"image_base64": masterImageBase64,
 		"thumb_base64": thumbImageBase64,
 	})
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 }
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 	"project/src/services/go-captcha/internal/helper"
 
 	"github.com/wenlng/go-captcha-assets/resources/imagesv2"
@@ -64,7 +64,7 @@ func GetSlideRegionCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "gen captcha data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -75,7 +75,7 @@ func GetSlideRegionCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -85,14 +85,21 @@ func GetSlideRegionCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
 	blockByte, _ := json.Marshal(blockData)
 	key := helper.GenUniqueId()
 	//key := helper.StringToMD5(string(blockByte))
-	cache.WriteCache(key, blockByte)
+	if err := cache.WriteCache(r, key, blockByte); err != nil {
+		bt, _ := json.Marshal(map[string]interface{}{
+			"code":    1,
+			"message": "save captcha data failed",
+		})
+		_, _ = fmt.Fprint(w, string(bt))
+		return
+	}
 
 	bt, _ := json.Marshal(map[string]interface{}{
 		"code":         0,
@@ -104,5 +111,5 @@ func GetSlideRegionCaptData(w http.ResponseWriter, r *http.Request) {
 		"tile_x":       blockData.DX,
 		"tile_y":       blockData.DY,
 	})
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 }
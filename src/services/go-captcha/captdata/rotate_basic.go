@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 	"project/src/services/go-captcha/internal/helper"
 
 	"github.com/wenlng/go-captcha-assets/resources/imagesv2"
@@ -49,7 +49,7 @@ func GetRotateBasicCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "gen captcha data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -60,7 +60,7 @@ func GetRotateBasicCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -70,15 +70,21 @@ func GetRotateBasicCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
 	blockByte, _ := json.Marshal(blockData)
 	key := helper.GenUniqueId()
 	//key := helper.StringToMD5(string(blockByte))
-	cache.WriteCache(key, blockByte)
-	fmt.Println("block>>>>>", string(blockByte))
+	if err := cache.WriteCache(r, key, blockByte); err != nil {
+		bt, _ := json.Marshal(map[string]interface{}{
+			"code":    1,
+			"message": "save captcha data failed",
+		})
+		_, _ = fmt.Fprint(w, string(bt))
+		return
+	}
 
 	bt, _ := json.Marshal(map[string]interface{}{
 		"code":         0,
@@ -87,5 +93,5 @@ func GetRotateBasicCaptData(w http.ResponseWriter, r *http.Request) {
 		"image_base64": masterImageBase64,
 		"thumb_base64": thumbImageBase64,
 	})
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 }
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 	"project/src/services/go-captcha/internal/helper"
 
 	"github.com/wenlng/go-captcha-assets/resources/imagesv2"
@@ -65,7 +65,7 @@ func GetSlideBasicCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "gen captcha data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -76,7 +76,7 @@ func GetSlideBasicCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -86,14 +86,21 @@ func GetSlideBasicCaptData(w http.ResponseWriter, r *http.Request) {
 			"code":    1,
 			"message": "base64 data failed",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
 	dotsByte, _ := json.Marshal(blockData)
 	key := helper.GenUniqueId()
 	//key := helper.StringToMD5(string(dotsByte))
-	cache.WriteCache(key, dotsByte)
+	if err := cache.WriteCache(r, key, dotsByte); err != nil {
+		bt, _ := json.Marshal(map[string]interface{}{
+			"code":    1,
+			"message": "save captcha data failed",
+		})
+		_, _ = fmt.Fprint(w, string(bt))
+		return
+	}
 
 	bt, _ := json.Marshal(map[string]interface{}{
 		"code":         0,
@@ -105,5 +112,5 @@ func GetSlideBasicCaptData(w http.ResponseWriter, r *http.Request) {
 		"tile_x":       blockData.DX,
 		"tile_y":       blockData.DY,
 	})
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 }
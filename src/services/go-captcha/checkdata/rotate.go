@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 	"strconv"
 
 	"github.com/wenlng/go-captcha/v2/rotate"
@@ -19,7 +19,7 @@ func CheckRotateData(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "parse form data err",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -30,17 +30,17 @@ func CheckRotateData(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "angle or key param is empty",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
-	cacheDataByte := cache.ReadCache(key)
+	cacheDataByte := cache.ReadCache(r, key)
 	if len(cacheDataByte) == 0 {
 		bt, _ := json.Marshal(map[string]interface{}{
 			"code":    code,
 			"message": "illegal key",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -50,7 +50,7 @@ func CheckRotateData(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "illegal key",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -60,12 +60,12 @@ func CheckRotateData(w http.ResponseWriter, r *http.Request) {
 
 	if chkRet {
 		code = 0
-		cache.SetCacheOk(key, true)
+		_ = cache.SetCacheOk(r, key)
 	}
 
 	bt, _ := json.Marshal(map[string]interface{}{
 		"code": code,
 	})
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 	return
 }
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 	"strconv"
 	"strings"
 
@@ -20,7 +20,7 @@ func CheckSlideData(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "parse form data err",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -31,17 +31,17 @@ func CheckSlideData(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "point or key param is empty",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
-	cacheDataByte := cache.ReadCache(key)
+	cacheDataByte := cache.ReadCache(r, key)
 	if len(cacheDataByte) == 0 {
 		bt, _ := json.Marshal(map[string]interface{}{
 			"code":    code,
 			"message": "illegal key",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 	src := strings.Split(point, ",")
@@ -52,7 +52,7 @@ func CheckSlideData(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "illegal key",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -66,12 +66,12 @@ func CheckSlideData(w http.ResponseWriter, r *http.Request) {
 
 	if chkRet {
 		code = 0
-		cache.SetCacheOk(key, true)
+		_ = cache.SetCacheOk(r, key)
 	}
 
 	bt, _ := json.Marshal(map[string]interface{}{
 		"code": code,
 	})
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 	return
 }
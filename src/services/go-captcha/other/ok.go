@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"project/src/services/go-captcha/internal/cache"
+	"project/src/services/go-captcha/cache"
 )
 
 // CheckOk .
@@ -16,7 +16,7 @@ func CheckOk(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "parse form data err",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
@@ -26,11 +26,11 @@ func CheckOk(w http.ResponseWriter, r *http.Request) {
 			"code":    code,
 			"message": "key param is empty",
 		})
-		_, _ = fmt.Fprintf(w, string(bt))
+		_, _ = fmt.Fprint(w, string(bt))
 		return
 	}
 
-	isOk := cache.HasCacheOk(key)
+	isOk := cache.HasCacheOk(r, key)
 	if isOk {
 		code = 0
 	}
@@ -39,7 +39,7 @@ func CheckOk(w http.ResponseWriter, r *http.Request) {
 		"code": code,
 		"ok":   code == 0,
 	})
-	_, _ = fmt.Fprintf(w, string(bt))
+	_, _ = fmt.Fprint(w, string(bt))
 
 	return
 }
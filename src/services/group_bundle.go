@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/utils"
+)
+
+// 群组导出包相关错误
+var (
+	ErrInvalidGroupBundle    = errors.New("无效的群组导出包")
+	ErrInvalidImportConflict = errors.New("无效的on_conflict参数，可选值：rename、replace、fail")
+)
+
+// maxRenameAttempts 自动重命名时最多尝试的次数
+const maxRenameAttempts = 100
+
+// buildGroupBundle 将群组及其角色、定时任务打包为导出包
+func buildGroupBundle(group *models.LlmGroup, tasks []models.Task) *models.GroupBundle {
+	bundle := &models.GroupBundle{
+		Format:     models.GroupBundleFormat,
+		Version:    models.GroupBundleVersion,
+		ExportedAt: time.Now(),
+		Group: models.GroupBundleGroup{
+			Name:                  group.Name,
+			Description:           group.Description,
+			IsGroupDiscussionMode: group.IsGroupDiscussionMode,
+			Category:              group.Category,
+			Tags:                  group.Tags,
+		},
+		Characters: make([]models.GroupBundleCharacter, 0, len(group.Characters)),
+		Tasks:      make([]models.GroupBundleTask, 0, len(tasks)),
+	}
+
+	refs := make(map[uint]string, len(group.Characters))
+	for i, character := range group.Characters {
+		ref := fmt.Sprintf("char_%d", i+1)
+		refs[character.ID] = ref
+		bundle.Characters = append(bundle.Characters, models.GroupBundleCharacter{
+			Ref:          ref,
+			Name:         character.Name,
+			Personality:  character.Personality,
+			Model:        character.Model,
+			Avatar:       character.Avatar,
+			CustomPrompt: character.CustomPrompt,
+			Tags:         character.Tags,
+			RAG:          character.RAG,
+			Knowledge:    character.Knowledge,
+			Extension:    character.Extension,
+		})
+	}
+
+	for _, task := range tasks {
+		ref, ok := refs[task.CharacterID]
+		if !ok {
+			continue
+		}
+		bundle.Tasks = append(bundle.Tasks, models.GroupBundleTask{
+			CharacterRef: ref,
+			Name:         task.Name,
+			Description:  task.Description,
+			Prompt:       task.Prompt,
+			CronExpr:     task.CronExpr,
+			Enabled:      task.Enabled,
+		})
+	}
+	return bundle
+}
+
+// validateGroupBundle 校验导出包的格式、版本、角色和定时任务
+func validateGroupBundle(bundle *models.GroupBundle) error {
+	if bundle.Format != models.GroupBundleFormat {
+		return fmt.Errorf("%w: 未知的格式 %q", ErrInvalidGroupBundle, bundle.Format)
+	}
+	if bundle.Version < 1 || bundle.Version > models.GroupBundleVersion {
+		return fmt.Errorf("%w: 不支持的版本 %d", ErrInvalidGroupBundle, bundle.Version)
+	}
+	if strings.TrimSpace(bundle.Group.Name) == "" {
+		return fmt.Errorf("%w: 缺少群组名称", ErrInvalidGroupBundle)
+	}
+
+	if maxCharacters := config.AppConfig.Group.MaxCharactersPerGroup; maxCharacters > 0 && len(bundle.Characters) > maxCharacters {
+		return ErrTooManyCharacters
+	}
+	refs := make(map[string]bool, len(bundle.Characters))
+	for _, character := range bundle.Characters {
+		if strings.TrimSpace(character.Name) == "" {
+			return fmt.Errorf("%w: 缺少角色名称", ErrInvalidGroupBundle)
+		}
+		if character.Ref != "" {
+			if refs[character.Ref] {
+				return fmt.Errorf("%w: 角色标识 %s 重复", ErrInvalidGroupBundle, character.Ref)
+			}
+			refs[character.Ref] = true
+		}
+		if err := validateModel(character.Model); err != nil {
+			return err
+		}
+	}
+
+	for _, task := range bundle.Tasks {
+		if !refs[task.CharacterRef] {
+			return fmt.Errorf("%w: 定时任务 %q 引用了不存在的角色 %s", ErrInvalidGroupBundle, task.Name, task.CharacterRef)
+		}
+		if strings.TrimSpace(task.Name) == "" || strings.TrimSpace(task.Prompt) == "" {
+			return fmt.Errorf("%w: 定时任务缺少名称或发言指令", ErrInvalidGroupBundle)
+		}
+		if _, err := utils.ParseCronExpression(task.CronExpr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importGroupBundle 按冲突处理方式将导出包保存为当前用户的私有群组，forkedFrom为克隆来源群组ID
+func (s *groupService) importGroupBundle(userID uint, bundle *models.GroupBundle, onConflict string, forkedFrom *uint) (*models.LlmGroup, error) {
+	if userID == 0 {
+		return nil, ErrGroupForbidden
+	}
+	if err := validateGroupBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	group := &models.LlmGroup{
+		OwnerID:               userID,
+		Name:                  truncateRunes(strings.TrimSpace(bundle.Group.Name), 100),
+		Description:           bundle.Group.Description,
+		Visibility:            models.VisibilityPrivate,
+		IsGroupDiscussionMode: bundle.Group.IsGroupDiscussionMode,
+		Category:              bundle.Group.Category,
+		Tags:                  normalizeTags(bundle.Group.Tags),
+		ForkedFrom:            forkedFrom,
+	}
+	// 其他环境导出的分类可能不在本环境的分类中
+	if validateCategory(group.Category) != nil {
+		group.Category = ""
+	}
+
+	// 被覆盖的群组原有的任务数，计算任务数量上限时扣除
+	var replacedTasks int
+	switch onConflict {
+	case models.ImportConflictRename:
+		name, err := s.uniqueGroupName(userID, group.Name)
+		if err != nil {
+			return nil, err
+		}
+		group.Name = name
+	case models.ImportConflictFail:
+		if err := s.checkGroupName(userID, group.Name, 0); err != nil {
+			return nil, err
+		}
+	case models.ImportConflictReplace:
+		existing, err := s.groupRepo.GetGroupByName(userID, group.Name)
+		switch {
+		case errors.Is(err, repository.ErrGroupNotFound):
+		case err != nil:
+			return nil, err
+		default:
+			// 覆盖时保留原群组的可见性
+			group.ID = existing.ID
+			group.Visibility = existing.Visibility
+			tasks, err := s.taskRepo.GetTasksByGroup(existing.ID)
+			if err != nil {
+				return nil, err
+			}
+			replacedTasks = len(tasks)
+		}
+	default:
+		return nil, ErrInvalidImportConflict
+	}
+
+	characters := make([]models.GroupCharacter, len(bundle.Characters))
+	refs := make(map[string]*models.GroupCharacter, len(bundle.Characters))
+	for i, item := range bundle.Characters {
+		characters[i] = models.GroupCharacter{
+			OwnerID:      userID,
+			Name:         truncateRunes(strings.TrimSpace(item.Name), 100),
+			Personality:  truncateRunes(item.Personality, 100),
+			Model:        item.Model,
+			Avatar:       avatarKey(item.Avatar),
+			CustomPrompt: item.CustomPrompt,
+			Tags:         item.Tags,
+			RAG:          item.RAG,
+			Knowledge:    item.Knowledge,
+			Extension:    item.Extension,
+			SortOrder:    i + 1,
+		}
+		if item.Ref != "" {
+			refs[item.Ref] = &characters[i]
+		}
+	}
+
+	tasks := make([]models.Task, 0, len(bundle.Tasks))
+	for _, item := range bundle.Tasks {
+		task := models.Task{
+			OwnerID:     userID,
+			Character:   refs[item.CharacterRef],
+			Name:        truncateRunes(strings.TrimSpace(item.Name), 100),
+			Description: item.Description,
+			Prompt:      item.Prompt,
+			CronExpr:    item.CronExpr,
+			Enabled:     item.Enabled,
+			Status:      models.TaskStatusPending,
+		}
+		if task.Enabled {
+			nextRunAt, err := utils.NextCronTime(task.CronExpr, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			task.NextRunAt = &nextRunAt
+		}
+		tasks = append(tasks, task)
+	}
+
+	if maxTasks := config.AppConfig.Scheduler.MaxTasksPerUser; maxTasks > 0 && len(tasks) > 0 {
+		count, err := s.taskRepo.CountTasksByOwner(userID)
+		if err != nil {
+			return nil, err
+		}
+		if count-int64(replacedTasks)+int64(len(tasks)) > int64(maxTasks) {
+			return nil, ErrTaskLimitExceeded
+		}
+	}
+
+	if err := s.groupRepo.SaveGroupWithMembers(group, characters, tasks); err != nil {
+		return nil, err
+	}
+	return s.groupRepo.GetGroupWithCharacters(group.ID)
+}
+
+// uniqueGroupName 为用户生成不重复的群组名称，重名时依次追加“ (2)”、“ (3)”……
+func (s *groupService) uniqueGroupName(ownerID uint, name string) (string, error) {
+	candidate := name
+	for i := 2; i <= maxRenameAttempts; i++ {
+		exists, err := s.groupRepo.ExistsGroupName(ownerID, candidate, 0)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncateRunes(name, 100-len(suffix)) + suffix
+	}
+	return "", ErrGroupNameExists
+}
+
+// avatarKey 将本站图片服务的完整头像URL还原为存储的图片ID，其他地址原样保留
+func avatarKey(avatar string) string {
+	prefix, suffix, found := strings.Cut(config.AppConfig.Cloudflare.ImagePrefix, "%s")
+	if !found || prefix == "" {
+		return avatar
+	}
+	if strings.HasPrefix(avatar, prefix) && strings.HasSuffix(avatar, suffix) && len(avatar) > len(prefix)+len(suffix) {
+		return avatar[len(prefix) : len(avatar)-len(suffix)]
+	}
+	return avatar
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"project/src/models"
+	"project/src/repository"
+)
+
+// mustCreateGroupWithTask 创建包含一个角色和一个定时任务的群组
+func mustCreateGroupWithTask(t *testing.T, s GroupService, ownerID uint, name, visibility string) *models.LlmGroup {
+	t.Helper()
+	group := mustCreateGroup(t, s, ownerID, name, visibility)
+	character, err := s.CreateCharacter(ownerID, &models.GroupCharacterCreateRequest{
+		GID: group.ID, Name: "播报员", Model: "qwen-plus", Avatar: "/img/robot.png", CustomPrompt: "你负责播报新闻",
+	})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	if _, err := NewTaskService().CreateTask(ownerID, &models.TaskCreateRequest{
+		GID: group.ID, CharacterID: character.ID, Name: "早间新闻", Prompt: "总结今天的新闻", CronExpr: "0 8 * * *",
+	}); err != nil {
+		t.Fatalf("创建定时任务失败: %v", err)
+	}
+	return group
+}
+
+func TestGroupExportImport(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	source := mustCreateGroupWithTask(t, s, 1, "新闻播报", models.VisibilityPublic)
+
+	bundle, err := s.ExportGroup(1, source.ID)
+	if err != nil {
+		t.Fatalf("导出群组失败: %v", err)
+	}
+	if len(bundle.Characters) != 1 || len(bundle.Tasks) != 1 || bundle.Tasks[0].CharacterRef != bundle.Characters[0].Ref {
+		t.Fatalf("导出包内容不正确: %+v", bundle)
+	}
+
+	// 默认自动重命名
+	imported, err := s.ImportGroup(1, bundle, "")
+	if err != nil {
+		t.Fatalf("导入群组失败: %v", err)
+	}
+	if imported.Name != "新闻播报 (2)" || imported.Visibility != models.VisibilityPrivate || len(imported.Characters) != 1 {
+		t.Errorf("导入的群组不正确: %+v", imported)
+	}
+	if imported.Characters[0].Avatar != "/img/robot.png" {
+		t.Errorf("头像应按URL原样导入，实际为%q", imported.Characters[0].Avatar)
+	}
+	tasks, err := repository.NewSchedulerRepository().GetTasksByGroup(imported.ID)
+	if err != nil || len(tasks) != 1 || tasks[0].CharacterID != imported.Characters[0].ID || tasks[0].NextRunAt == nil {
+		t.Errorf("定时任务应关联导入后的角色，实际为%+v, %v", tasks, err)
+	}
+
+	if _, err := s.ImportGroup(1, bundle, models.ImportConflictFail); !errors.Is(err, ErrGroupNameExists) {
+		t.Errorf("on_conflict=fail时重名应返回ErrGroupNameExists，实际为%v", err)
+	}
+
+	// 覆盖同名群组时替换其角色和定时任务
+	bundle.Characters[0].Name = "新播报员"
+	replaced, err := s.ImportGroup(1, bundle, models.ImportConflictReplace)
+	if err != nil {
+		t.Fatalf("覆盖导入失败: %v", err)
+	}
+	if replaced.ID != source.ID || len(replaced.Characters) != 1 || replaced.Characters[0].Name != "新播报员" {
+		t.Errorf("覆盖导入应替换原群组的角色，实际为%+v", replaced)
+	}
+	if tasks, _ := repository.NewSchedulerRepository().GetTasksByGroup(source.ID); len(tasks) != 1 {
+		t.Errorf("覆盖导入后原群组应只有导入的定时任务，实际有%d个", len(tasks))
+	}
+
+	if _, err := s.ImportGroup(1, bundle, "merge"); !errors.Is(err, ErrInvalidImportConflict) {
+		t.Errorf("无效的冲突处理方式应返回ErrInvalidImportConflict，实际为%v", err)
+	}
+	bundle.Version = models.GroupBundleVersion + 1
+	if _, err := s.ImportGroup(1, bundle, ""); !errors.Is(err, ErrInvalidGroupBundle) {
+		t.Errorf("不支持的版本应返回ErrInvalidGroupBundle，实际为%v", err)
+	}
+}
+
+func TestCloneGroup(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	public := mustCreateGroupWithTask(t, s, 1, "公开群组", models.VisibilityPublic)
+	private := mustCreateGroup(t, s, 1, "私有群组", "")
+
+	clone, err := s.CloneGroup(2, public.ID, &models.GroupCloneRequest{})
+	if err != nil {
+		t.Fatalf("克隆公开群组失败: %v", err)
+	}
+	if clone.OwnerID != 2 || clone.Name != "公开群组" || len(clone.Characters) != 1 || clone.Characters[0].OwnerID != 2 {
+		t.Errorf("克隆的群组及角色应属于当前用户，实际为%+v", clone)
+	}
+	if tasks, _ := repository.NewSchedulerRepository().GetTasksByGroup(clone.ID); len(tasks) != 0 {
+		t.Errorf("克隆他人的群组不应复制定时任务，实际有%d个", len(tasks))
+	}
+
+	if _, err := s.CloneGroup(2, private.ID, &models.GroupCloneRequest{}); !errors.Is(err, repository.ErrGroupNotFound) {
+		t.Errorf("克隆无权查看的群组应返回ErrGroupNotFound，实际为%v", err)
+	}
+
+	own, err := s.CloneGroup(1, public.ID, &models.GroupCloneRequest{})
+	if err != nil {
+		t.Fatalf("克隆自己的群组失败: %v", err)
+	}
+	if own.Name != "公开群组 (2)" {
+		t.Errorf("克隆自己的群组应自动重命名，实际为%q", own.Name)
+	}
+	if tasks, _ := repository.NewSchedulerRepository().GetTasksByGroup(own.ID); len(tasks) != 1 {
+		t.Errorf("克隆自己的群组应复制定时任务，实际有%d个", len(tasks))
+	}
+
+	if _, err := s.CloneGroup(1, public.ID, &models.GroupCloneRequest{Name: "私有群组"}); !errors.Is(err, ErrGroupNameExists) {
+		t.Errorf("指定的名称重名时应返回ErrGroupNameExists，实际为%v", err)
+	}
+}
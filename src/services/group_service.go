@@ -0,0 +1,560 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+)
+
+// 列表查询范围
+const (
+	GroupScopeMine   = "mine"   // 当前用户自己的群组/角色
+	GroupScopePublic = "public" // 公开群组及其角色
+)
+
+// 群组业务错误
+var (
+	ErrGroupForbidden      = errors.New("无权操作该群组")
+	ErrCharacterForbidden  = errors.New("无权操作该角色")
+	ErrGroupNameExists     = errors.New("已存在同名群组")
+	ErrTooManyCharacters   = errors.New("群组角色数量已达上限")
+	ErrInvalidModel        = errors.New("不支持的模型")
+	ErrInvalidScope        = errors.New("无效的scope参数，可选值：mine、public")
+	ErrEmptyUpdate         = errors.New("至少需要提供一个更新字段")
+	ErrTargetGroupNotFound = errors.New("指定的群组不存在")
+	ErrInvalidCategory     = errors.New("无效的群组分类")
+	ErrGroupDeleted        = errors.New("所属群组已被删除，请先恢复群组")
+	ErrNameRequired        = errors.New("名称不能为空")
+)
+
+// CharacterQuery 角色列表查询参数
+type CharacterQuery struct {
+	Scope string
+	GID   uint
+	Name  string
+	Model string
+}
+
+// GroupService 群组与角色服务接口
+type GroupService interface {
+	CreateGroup(ownerID uint, req *models.LlmGroupCreateRequest) (*models.LlmGroup, error)
+	ListGroups(userID uint, scope, name string, page, pageSize int) ([]models.LlmGroup, int64, error)
+	GetGroup(userID, groupID uint) (*models.LlmGroup, error)
+	UpdateGroup(userID, groupID uint, req *models.LlmGroupUpdateRequest) (*models.LlmGroup, error)
+	DeleteGroup(userID, groupID uint) error
+
+	CreateCharacter(userID uint, req *models.GroupCharacterCreateRequest) (*models.GroupCharacter, error)
+	ListCharacters(userID uint, query *CharacterQuery, page, pageSize int) ([]models.GroupCharacter, int64, error)
+	ListCharactersByGroup(userID, groupID uint, page, pageSize int) (*models.LlmGroup, []models.GroupCharacter, int64, error)
+	GetCharacter(userID, characterID uint) (*models.GroupCharacter, error)
+	UpdateCharacter(userID, characterID uint, req *models.GroupCharacterUpdateRequest) (*models.GroupCharacter, error)
+	DeleteCharacter(userID, characterID uint) error
+	BatchCharacters(userID, groupID uint, req *models.GroupCharacterBatchRequest) (*models.LlmGroup, error)
+
+	ImportCharacterCard(userID, groupID uint, data []byte, model, avatar string) (*models.GroupCharacter, error)
+	ExportCharacterCard(userID, characterID uint) (*models.CharacterCardV2, *models.GroupCharacter, error)
+
+	ExportGroup(userID, groupID uint) (*models.GroupBundle, error)
+	ImportGroup(userID uint, bundle *models.GroupBundle, onConflict string) (*models.LlmGroup, error)
+	CloneGroup(userID, groupID uint, req *models.GroupCloneRequest) (*models.LlmGroup, error)
+
+	ListRevisions(userID uint, entityType string, entityID uint, page, pageSize int) ([]models.Revision, int64, error)
+	DiffRevisions(userID uint, entityType string, entityID uint, from, to int) (*models.RevisionDiff, error)
+	RollbackRevision(userID uint, entityType string, entityID uint, version int) (*models.Revision, error)
+
+	ListDeletedGroups(userID uint, page, pageSize int) ([]models.LlmGroup, int64, error)
+	ListDeletedCharacters(userID uint, page, pageSize int) ([]models.GroupCharacter, int64, error)
+	RestoreGroup(userID, groupID uint) (*models.LlmGroup, error)
+	RestoreCharacter(userID, characterID uint) (*models.GroupCharacter, error)
+}
+
+// groupService 群组与角色服务实现
+type groupService struct {
+	groupRepo     repository.LlmGroupRepository
+	characterRepo repository.GroupCharacterRepository
+	taskRepo      repository.SchedulerRepository
+	revisionRepo  repository.RevisionRepository
+}
+
+// NewGroupService 创建群组与角色服务实例
+func NewGroupService() GroupService {
+	return &groupService{
+		groupRepo:     repository.NewLlmGroupRepository(),
+		characterRepo: repository.NewGroupCharacterRepository(),
+		taskRepo:      repository.NewSchedulerRepository(),
+		revisionRepo:  repository.NewRevisionRepository(),
+	}
+}
+
+// CreateGroup 创建群组，同一用户下群组名称不能重复
+func (s *groupService) CreateGroup(ownerID uint, req *models.LlmGroupCreateRequest) (*models.LlmGroup, error) {
+	if err := s.checkGroupName(ownerID, req.Name, 0); err != nil {
+		return nil, err
+	}
+	if err := validateCategory(req.Category); err != nil {
+		return nil, err
+	}
+
+	group := &models.LlmGroup{
+		OwnerID:     ownerID,
+		Name:        req.Name,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+		Category:    req.Category,
+		Tags:        normalizeTags(req.Tags),
+	}
+	if group.Visibility == "" {
+		group.Visibility = models.VisibilityPrivate
+	}
+	if group.Visibility == models.VisibilityPublic {
+		now := time.Now()
+		group.PublishedAt = &now
+	}
+
+	if err := s.groupRepo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+	if _, err := s.recordRevision(models.RevisionEntityGroup, group.ID, ownerID, models.RevisionActionCreate, 0, groupSnapshot(group)); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// ListGroups 获取群组列表
+func (s *groupService) ListGroups(userID uint, scope, name string, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	filter := repository.GroupListFilter{Name: name}
+	switch scope {
+	case GroupScopeMine:
+		filter.OwnerID = userID
+	case GroupScopePublic:
+		filter.Listed = true
+	default:
+		return nil, 0, ErrInvalidScope
+	}
+	return s.groupRepo.GetGroups(filter, page, pageSize)
+}
+
+// GetGroup 获取群组详情，对无权查看的用户表现为不存在
+func (s *groupService) GetGroup(userID, groupID uint) (*models.LlmGroup, error) {
+	group, err := s.groupRepo.GetGroupWithCharacters(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if !group.CanView(userID) {
+		return nil, repository.ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// UpdateGroup 更新群组
+func (s *groupService) UpdateGroup(userID, groupID uint, req *models.LlmGroupUpdateRequest) (*models.LlmGroup, error) {
+	group, err := s.getOwnedGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if name, ok := req.Name.Get(); ok {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, ErrNameRequired
+		}
+		if name != group.Name {
+			if err := s.checkGroupName(group.OwnerID, name, group.ID); err != nil {
+				return nil, err
+			}
+		}
+		updates["name"] = name
+	}
+	if description, ok := req.Description.Get(); ok {
+		updates["description"] = description
+	}
+	if visibility, ok := req.Visibility.Get(); ok {
+		if visibility == "" {
+			visibility = models.VisibilityPrivate
+		}
+		updates["visibility"] = visibility
+		// 首次公开时记录公开时间，用于公开目录按时间排序
+		if visibility == models.VisibilityPublic && group.PublishedAt == nil {
+			updates["published_at"] = time.Now()
+		}
+	}
+	if category, ok := req.Category.Get(); ok {
+		if err := validateCategory(category); err != nil {
+			return nil, err
+		}
+		updates["category"] = category
+	}
+	if tags, ok := req.Tags.Get(); ok {
+		data, err := json.Marshal(normalizeTags(tags))
+		if err != nil {
+			return nil, fmt.Errorf("序列化标签失败: %v", err)
+		}
+		updates["tags"] = string(data)
+	}
+	if len(updates) == 0 {
+		return nil, ErrEmptyUpdate
+	}
+
+	if err := s.ensureInitialRevision(models.RevisionEntityGroup, group.ID, group.OwnerID, groupSnapshot(group)); err != nil {
+		return nil, err
+	}
+	if err := s.groupRepo.UpdateGroup(groupID, updates); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.recordRevision(models.RevisionEntityGroup, groupID, userID, models.RevisionActionUpdate, 0, groupSnapshot(updated)); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteGroup 将群组及其角色移入回收站
+func (s *groupService) DeleteGroup(userID, groupID uint) error {
+	if _, err := s.getOwnedGroup(userID, groupID); err != nil {
+		return err
+	}
+	return s.groupRepo.DeleteGroup(groupID)
+}
+
+// CreateCharacter 在自己的群组中创建角色
+func (s *groupService) CreateCharacter(userID uint, req *models.GroupCharacterCreateRequest) (*models.GroupCharacter, error) {
+	character := &models.GroupCharacter{
+		GID:          req.GID,
+		Name:         req.Name,
+		Personality:  req.Personality,
+		Model:        req.Model,
+		Avatar:       req.Avatar,
+		CustomPrompt: req.CustomPrompt,
+	}
+	if err := s.createCharacter(userID, character); err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+// ImportCharacterCard 从角色卡（JSON或PNG）导入角色到自己的群组
+// 未指定模型时使用角色卡中记录的模型
+func (s *groupService) ImportCharacterCard(userID, groupID uint, data []byte, model, avatar string) (*models.GroupCharacter, error) {
+	card, err := ParseCharacterCard(data)
+	if err != nil {
+		return nil, err
+	}
+
+	character := cardToCharacter(card)
+	character.GID = groupID
+	character.Model = model
+	if character.Model == "" {
+		character.Model = cardModel(card)
+	}
+	character.Avatar = avatar
+
+	if err := s.createCharacter(userID, character); err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+// ExportCharacterCard 将角色导出为角色卡，可查看角色的用户都可以导出
+func (s *groupService) ExportCharacterCard(userID, characterID uint) (*models.CharacterCardV2, *models.GroupCharacter, error) {
+	character, err := s.GetCharacter(userID, characterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return characterToCard(character), character, nil
+}
+
+// ExportGroup 将群组导出为导出包，可查看群组的用户都可以导出，定时发言设置仅群组所有者可以导出
+func (s *groupService) ExportGroup(userID, groupID uint) (*models.GroupBundle, error) {
+	group, err := s.GetGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []models.Task
+	if group.IsOwnedBy(userID) {
+		tasks, err = s.taskRepo.GetTasksByGroup(group.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buildGroupBundle(group, tasks), nil
+}
+
+// ImportGroup 从导出包导入群组，onConflict 为同名群组的处理方式，默认自动重命名
+func (s *groupService) ImportGroup(userID uint, bundle *models.GroupBundle, onConflict string) (*models.LlmGroup, error) {
+	if onConflict == "" {
+		onConflict = models.ImportConflictRename
+	}
+	return s.importGroupBundle(userID, bundle, onConflict, nil)
+}
+
+// CloneGroup 将可查看的群组连同角色深拷贝到当前用户名下，克隆自己的群组时同时复制定时发言设置
+// 指定了新名称时重名返回冲突错误，否则沿用原名称并自动重命名
+func (s *groupService) CloneGroup(userID, groupID uint, req *models.GroupCloneRequest) (*models.LlmGroup, error) {
+	if userID == 0 {
+		return nil, ErrGroupForbidden
+	}
+	bundle, err := s.ExportGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	onConflict := models.ImportConflictRename
+	if req.Name != "" {
+		bundle.Group.Name = req.Name
+		onConflict = models.ImportConflictFail
+	}
+	return s.importGroupBundle(userID, bundle, onConflict, &groupID)
+}
+
+// createCharacter 校验群组归属、模型和角色数量上限后创建角色
+func (s *groupService) createCharacter(userID uint, character *models.GroupCharacter) error {
+	group, err := s.groupRepo.GetGroupByID(character.GID)
+	if err != nil {
+		if errors.Is(err, repository.ErrGroupNotFound) {
+			return ErrTargetGroupNotFound
+		}
+		return err
+	}
+	if !group.IsOwnedBy(userID) {
+		return ErrGroupForbidden
+	}
+	if err := validateModel(character.Model); err != nil {
+		return err
+	}
+
+	if maxCharacters := config.AppConfig.Group.MaxCharactersPerGroup; maxCharacters > 0 {
+		count, err := s.characterRepo.CountCharactersByGroup(group.ID)
+		if err != nil {
+			return err
+		}
+		if count >= int64(maxCharacters) {
+			return ErrTooManyCharacters
+		}
+	}
+
+	// 新角色排在群组末尾
+	maxSortOrder, err := s.characterRepo.GetMaxSortOrder(group.ID)
+	if err != nil {
+		return err
+	}
+	character.SortOrder = maxSortOrder + 1
+	character.OwnerID = userID
+	if err := s.characterRepo.CreateCharacter(character); err != nil {
+		return err
+	}
+	_, err = s.recordRevision(models.RevisionEntityCharacter, character.ID, userID, models.RevisionActionCreate, 0, characterSnapshot(character))
+	return err
+}
+
+// ListCharacters 获取角色列表
+func (s *groupService) ListCharacters(userID uint, query *CharacterQuery, page, pageSize int) ([]models.GroupCharacter, int64, error) {
+	filter := repository.CharacterListFilter{
+		GID:   query.GID,
+		Name:  query.Name,
+		Model: query.Model,
+	}
+	switch query.Scope {
+	case GroupScopeMine:
+		filter.OwnerID = userID
+	case GroupScopePublic:
+		filter.PublicOnly = true
+	default:
+		return nil, 0, ErrInvalidScope
+	}
+	return s.characterRepo.GetCharacters(filter, page, pageSize)
+}
+
+// ListCharactersByGroup 获取群组下的角色列表
+func (s *groupService) ListCharactersByGroup(userID, groupID uint, page, pageSize int) (*models.LlmGroup, []models.GroupCharacter, int64, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if !group.CanView(userID) {
+		return nil, nil, 0, repository.ErrGroupNotFound
+	}
+
+	characters, total, err := s.characterRepo.GetCharactersByGroup(groupID, page, pageSize)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return group, characters, total, nil
+}
+
+// GetCharacter 获取角色详情，角色的可见性跟随所属群组
+// 回收站中的角色仍可查看（deleted_at 非空），以便历史对话正常展示
+func (s *groupService) GetCharacter(userID, characterID uint) (*models.GroupCharacter, error) {
+	character, err := s.characterRepo.GetCharacterIncludingDeleted(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if character.Group == nil || !character.Group.CanView(userID) {
+		return nil, repository.ErrCharacterNotFound
+	}
+	return character, nil
+}
+
+// UpdateCharacter 更新角色
+func (s *groupService) UpdateCharacter(userID, characterID uint, req *models.GroupCharacterUpdateRequest) (*models.GroupCharacter, error) {
+	character, err := s.getEditableCharacter(userID, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates, err := characterUpdates(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return nil, ErrEmptyUpdate
+	}
+
+	if err := s.ensureInitialRevision(models.RevisionEntityCharacter, characterID, character.OwnerID, characterSnapshot(character)); err != nil {
+		return nil, err
+	}
+	if err := s.characterRepo.UpdateCharacter(characterID, updates); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.characterRepo.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.recordRevision(models.RevisionEntityCharacter, characterID, userID, models.RevisionActionUpdate, 0, characterSnapshot(updated)); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteCharacter 将角色移入回收站
+func (s *groupService) DeleteCharacter(userID, characterID uint) error {
+	if _, err := s.getEditableCharacter(userID, characterID); err != nil {
+		return err
+	}
+	return s.characterRepo.DeleteCharacter(characterID)
+}
+
+// getOwnedGroup 获取当前用户拥有的群组
+func (s *groupService) getOwnedGroup(userID, groupID uint) (*models.LlmGroup, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if !group.IsOwnedBy(userID) {
+		return nil, ErrGroupForbidden
+	}
+	return group, nil
+}
+
+// getEditableCharacter 获取当前用户可修改的角色，角色所有者或所属群组的所有者可以修改
+func (s *groupService) getEditableCharacter(userID, characterID uint) (*models.GroupCharacter, error) {
+	character, err := s.characterRepo.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 {
+		return nil, ErrCharacterForbidden
+	}
+	if character.OwnerID == userID {
+		return character, nil
+	}
+	if character.Group != nil && character.Group.IsOwnedBy(userID) {
+		return character, nil
+	}
+	return nil, ErrCharacterForbidden
+}
+
+// checkGroupName 校验同一用户下群组名称唯一
+func (s *groupService) checkGroupName(ownerID uint, name string, excludeID uint) error {
+	exists, err := s.groupRepo.ExistsGroupName(ownerID, name, excludeID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrGroupNameExists
+	}
+	return nil
+}
+
+// validateCategory 校验群组分类是否在配置的分类中，未配置分类或未指定分类时不校验
+func validateCategory(category string) error {
+	categories := config.AppConfig.Group.Categories
+	if category == "" || len(categories) == 0 {
+		return nil
+	}
+	for _, item := range categories {
+		if item == category {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidCategory, category)
+}
+
+// normalizeTags 去除标签首尾空白，并去掉空标签和重复标签
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// characterUpdates 将角色更新请求转换为待更新的字段
+func characterUpdates(req *models.GroupCharacterUpdateRequest) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	if name, ok := req.Name.Get(); ok {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, ErrNameRequired
+		}
+		updates["name"] = name
+	}
+	if personality, ok := req.Personality.Get(); ok {
+		updates["personality"] = personality
+	}
+	if model, ok := req.Model.Get(); ok {
+		if err := validateModel(model); err != nil {
+			return nil, err
+		}
+		updates["model"] = model
+	}
+	if avatar, ok := req.Avatar.Get(); ok {
+		updates["avatar"] = avatar
+	}
+	if customPrompt, ok := req.CustomPrompt.Get(); ok {
+		updates["custom_prompt"] = customPrompt
+	}
+	if sortOrder, ok := req.SortOrder.Get(); ok {
+		updates["sort_order"] = sortOrder
+	}
+	return updates, nil
+}
+
+// validateModel 校验模型是否在配置的 llm_models 中，未指定模型时不校验
+func validateModel(model string) error {
+	if model == "" {
+		return nil
+	}
+	if _, ok := config.AppConfig.LLMModels[model]; !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidModel, model)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupGroupTestDB 使用内存SQLite初始化测试数据库和配置
+func setupGroupTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+	if err := db.AutoMigrate(&models.LlmGroup{}, &models.GroupCharacter{}, &models.Task{}, &models.TaskExecution{}, &models.GroupReaction{}, &models.Revision{}); err != nil {
+		t.Fatalf("迁移表结构失败: %v", err)
+	}
+
+	oldDB, oldConfig := config.DB, config.AppConfig
+	config.DB = db
+	config.AppConfig.LLMModels = map[string]string{"qwen-plus": "aliyun"}
+	config.AppConfig.Group.MaxCharactersPerGroup = 2
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		config.DB, config.AppConfig = oldDB, oldConfig
+	})
+}
+
+func mustCreateGroup(t *testing.T, s GroupService, ownerID uint, name, visibility string) *models.LlmGroup {
+	t.Helper()
+	group, err := s.CreateGroup(ownerID, &models.LlmGroupCreateRequest{Name: name, Visibility: visibility})
+	if err != nil {
+		t.Fatalf("创建群组失败: %v", err)
+	}
+	return group
+}
+
+func TestGroupNameUniquePerOwner(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+
+	first := mustCreateGroup(t, s, 1, "周末闲聊", "")
+	if first.Visibility != models.VisibilityPrivate {
+		t.Errorf("默认可见性应为private，实际为%s", first.Visibility)
+	}
+
+	if _, err := s.CreateGroup(1, &models.LlmGroupCreateRequest{Name: "周末闲聊"}); !errors.Is(err, ErrGroupNameExists) {
+		t.Errorf("同一用户重复创建同名群组应返回ErrGroupNameExists，实际为%v", err)
+	}
+
+	// 不同用户可以使用相同名称
+	mustCreateGroup(t, s, 2, "周末闲聊", "")
+
+	second := mustCreateGroup(t, s, 1, "技术讨论", "")
+	if _, err := s.UpdateGroup(1, second.ID, &models.LlmGroupUpdateRequest{Name: models.PatchValue("周末闲聊")}); !errors.Is(err, ErrGroupNameExists) {
+		t.Errorf("重命名为已存在的名称应返回ErrGroupNameExists，实际为%v", err)
+	}
+
+	// 保持原名称更新其他字段不应触发重名校验
+	updated, err := s.UpdateGroup(1, second.ID, &models.LlmGroupUpdateRequest{Name: models.PatchValue("技术讨论"), Description: models.PatchValue("聊聊Go")})
+	if err != nil {
+		t.Fatalf("更新群组失败: %v", err)
+	}
+	if updated.Description != "聊聊Go" {
+		t.Errorf("群组描述未更新: %q", updated.Description)
+	}
+
+	if _, err := s.UpdateGroup(1, second.ID, &models.LlmGroupUpdateRequest{}); !errors.Is(err, ErrEmptyUpdate) {
+		t.Errorf("空更新应返回ErrEmptyUpdate，实际为%v", err)
+	}
+}
+
+func TestGroupVisibilityAndOwnership(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+
+	private := mustCreateGroup(t, s, 1, "私有群", models.VisibilityPrivate)
+	unlisted := mustCreateGroup(t, s, 1, "链接可见群", models.VisibilityUnlisted)
+	mustCreateGroup(t, s, 1, "公开群", models.VisibilityPublic)
+
+	if _, err := s.GetGroup(2, private.ID); !errors.Is(err, repository.ErrGroupNotFound) {
+		t.Errorf("其他用户查看私有群组应返回ErrGroupNotFound，实际为%v", err)
+	}
+	if _, err := s.GetGroup(1, private.ID); err != nil {
+		t.Errorf("所有者查看私有群组失败: %v", err)
+	}
+	if _, err := s.GetGroup(0, unlisted.ID); err != nil {
+		t.Errorf("未登录用户应可查看unlisted群组: %v", err)
+	}
+
+	mine, total, err := s.ListGroups(1, GroupScopeMine, "", 1, 10)
+	if err != nil || total != 3 || len(mine) != 3 {
+		t.Errorf("mine列表应返回3个群组，实际total=%d len=%d err=%v", total, len(mine), err)
+	}
+	public, total, err := s.ListGroups(2, GroupScopePublic, "", 1, 10)
+	if err != nil || total != 1 || len(public) != 1 || public[0].Name != "公开群" {
+		t.Errorf("public列表应只返回公开群组，实际total=%d groups=%v err=%v", total, public, err)
+	}
+	if _, _, err := s.ListGroups(1, "all", "", 1, 10); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("无效scope应返回ErrInvalidScope，实际为%v", err)
+	}
+
+	if _, err := s.UpdateGroup(2, private.ID, &models.LlmGroupUpdateRequest{Name: models.PatchValue("抢过来")}); !errors.Is(err, ErrGroupForbidden) {
+		t.Errorf("非所有者更新群组应返回ErrGroupForbidden，实际为%v", err)
+	}
+	if err := s.DeleteGroup(2, private.ID); !errors.Is(err, ErrGroupForbidden) {
+		t.Errorf("非所有者删除群组应返回ErrGroupForbidden，实际为%v", err)
+	}
+	if err := s.DeleteGroup(1, 9999); !errors.Is(err, repository.ErrGroupNotFound) {
+		t.Errorf("删除不存在的群组应返回ErrGroupNotFound，实际为%v", err)
+	}
+}
+
+func TestCreateCharacterRules(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+
+	group := mustCreateGroup(t, s, 1, "角色测试", "")
+
+	if _, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: 9999, Name: "路人"}); !errors.Is(err, ErrTargetGroupNotFound) {
+		t.Errorf("群组不存在应返回ErrTargetGroupNotFound，实际为%v", err)
+	}
+	if _, err := s.CreateCharacter(2, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "路人"}); !errors.Is(err, ErrGroupForbidden) {
+		t.Errorf("向他人群组添加角色应返回ErrGroupForbidden，实际为%v", err)
+	}
+	if _, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "路人", Model: "gpt-unknown"}); !errors.Is(err, ErrInvalidModel) {
+		t.Errorf("未配置的模型应返回ErrInvalidModel，实际为%v", err)
+	}
+
+	for _, name := range []string{"小助手", "杠精"} {
+		character, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: name, Model: "qwen-plus"})
+		if err != nil {
+			t.Fatalf("创建角色失败: %v", err)
+		}
+		if character.OwnerID != 1 {
+			t.Errorf("角色所有者应为1，实际为%d", character.OwnerID)
+		}
+	}
+
+	if _, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "第三位"}); !errors.Is(err, ErrTooManyCharacters) {
+		t.Errorf("超过角色上限应返回ErrTooManyCharacters，实际为%v", err)
+	}
+}
+
+func TestCharacterPermissions(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+
+	group := mustCreateGroup(t, s, 1, "权限测试", models.VisibilityPrivate)
+	character, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "小助手", Model: "qwen-plus"})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+
+	if _, err := s.GetCharacter(2, character.ID); !errors.Is(err, repository.ErrCharacterNotFound) {
+		t.Errorf("其他用户查看私有群组的角色应返回ErrCharacterNotFound，实际为%v", err)
+	}
+	if _, err := s.UpdateCharacter(2, character.ID, &models.GroupCharacterUpdateRequest{Name: models.PatchValue("改名")}); !errors.Is(err, ErrCharacterForbidden) {
+		t.Errorf("非所有者更新角色应返回ErrCharacterForbidden，实际为%v", err)
+	}
+	if _, err := s.UpdateCharacter(1, character.ID, &models.GroupCharacterUpdateRequest{Model: models.PatchValue("gpt-unknown")}); !errors.Is(err, ErrInvalidModel) {
+		t.Errorf("更新为未配置的模型应返回ErrInvalidModel，实际为%v", err)
+	}
+
+	updated, err := s.UpdateCharacter(1, character.ID, &models.GroupCharacterUpdateRequest{Personality: models.PatchValue("热心")})
+	if err != nil {
+		t.Fatalf("更新角色失败: %v", err)
+	}
+	if updated.Personality != "热心" || updated.Name != "小助手" {
+		t.Errorf("角色更新结果不正确: %+v", updated)
+	}
+
+	if err := s.DeleteCharacter(2, character.ID); !errors.Is(err, ErrCharacterForbidden) {
+		t.Errorf("非所有者删除角色应返回ErrCharacterForbidden，实际为%v", err)
+	}
+
+	// 删除群组时角色一并移入回收站，仍可查看但不可修改
+	if err := s.DeleteGroup(1, group.ID); err != nil {
+		t.Fatalf("删除群组失败: %v", err)
+	}
+	if deleted, err := s.GetCharacter(1, character.ID); err != nil || !deleted.DeletedAt.Valid {
+		t.Errorf("群组删除后角色应标记为已删除，实际为%+v, %v", deleted, err)
+	}
+	if _, err := s.UpdateCharacter(1, character.ID, &models.GroupCharacterUpdateRequest{Name: models.PatchValue("新名字")}); !errors.Is(err, repository.ErrCharacterNotFound) {
+		t.Errorf("群组删除后修改角色应返回ErrCharacterNotFound，实际为%v", err)
+	}
+}
@@ -2,10 +2,15 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"project/src/config"
+	"project/src/constants"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -20,12 +25,20 @@ type KVItem struct {
 // KVService KV存储服务接口
 type KVService interface {
 	Set(key, value string, ttl time.Duration) error
+	SetNX(key, value string, ttl time.Duration) (bool, error)                   // 键不存在时才设置，用于分布式锁、发送冷却和一次性标记
+	Incr(key string, ttl time.Duration) (int64, error)                          // 计数加1并返回新值，键不存在时从0开始并设置过期时间，用于限流计数
+	CompareAndSet(key, expected, value string, ttl time.Duration) (bool, error) // 当前值等于 expected 时才设置，用于续期分布式锁
+	CompareAndDelete(key, expected string) (bool, error)                        // 当前值等于 expected 时才删除，用于释放分布式锁
 	Get(key string) (string, error)
+	GetDel(key string) (string, error) // 取出并删除键值，并发调用时只有一个调用方能取到，用于一次性令牌
 	Delete(key string) error
 	Keys(pattern string) ([]string, error) // 新增：获取匹配模式的所有键
 	Close() error
 }
 
+// ErrKVUnavailable Redis不可用且降级策略为 fail_closed，或Redis不可用时执行 SetNX 等锁操作
+var ErrKVUnavailable = errors.New("存储服务暂不可用，请稍后再试")
+
 // redisKVService Redis版本的KV存储服务实现
 type redisKVService struct {
 	client *redis.Client
@@ -38,39 +51,239 @@ type memoryKVService struct {
 	mutex   sync.RWMutex
 }
 
-// NewKVService 创建KV存储服务实例
-func NewKVService(redisConfig config.RedisConfig) KVService {
-	// 尝试连接Redis
-	if redisService, err := newRedisKVService(redisConfig); err == nil {
-		return redisService
+// resilientKVService 带降级策略的KV存储服务，Redis连接中断时按降级策略处理，并在后台按退避间隔重连
+type resilientKVService struct {
+	redis      KVService
+	fallback   KVService // memory 策略下共享的内存存储，fail_closed 策略下为 nil
+	ping       func() error
+	healthy    atomic.Bool
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// ValidateRedisConfig 检查Redis配置，启动时调用，配置有误时返回错误
+func ValidateRedisConfig(cfg config.RedisConfig) error {
+	switch cfg.DegradedMode {
+	case "", constants.KVDegradedMemory, constants.KVDegradedFailClosed:
+		return nil
+	default:
+		return fmt.Errorf("Redis降级策略 %q 不受支持，可选 memory、fail_closed", cfg.DegradedMode)
 	}
+}
 
-	// Redis连接失败，使用内存版本
-	fmt.Println("Redis连接失败，使用内存KV存储")
-	return newMemoryKVService()
+// NewKVService 创建KV存储服务实例，Redis连接失败时按降级策略处理并在后台重连
+// 每个实例持有独立的连接池，应用启动时创建一个实例并注入到各处理函数和中间件
+func NewKVService(redisConfig config.RedisConfig) KVService {
+	redisService := newRedisKVService(redisConfig)
+	s := newResilientKVService(redisService, redisConfig.DegradedMode, func() error {
+		return redisService.client.Ping(redisService.ctx).Err()
+	})
+
+	// 测试连接
+	if err := s.ping(); err != nil {
+		s.markDown(err)
+	} else {
+		fmt.Println("Redis连接成功，使用Redis KV存储")
+	}
+	return s
 }
 
-// newRedisKVService 创建Redis KV服务
-func newRedisKVService(redisConfig config.RedisConfig) (KVService, error) {
+// newRedisKVService 创建Redis KV服务，不检查连接
+func newRedisKVService(redisConfig config.RedisConfig) *redisKVService {
 	// 创建Redis客户端
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", redisConfig.Host, redisConfig.Port),
-		Password: redisConfig.Password,
-		DB:       redisConfig.DB,
+		Addr:         fmt.Sprintf("%s:%s", redisConfig.Host, redisConfig.Port),
+		Password:     redisConfig.Password,
+		DB:           redisConfig.DB,
+		PoolSize:     redisConfig.PoolSize,
+		MinIdleConns: redisConfig.MinIdleConns,
+		DialTimeout:  constants.KVDialTimeout,
+		ReadTimeout:  constants.KVReadTimeout,
+		WriteTimeout: constants.KVWriteTimeout,
 	})
 
-	ctx := context.Background()
+	return &redisKVService{
+		client: rdb,
+		ctx:    context.Background(),
+	}
+}
 
-	// 测试连接
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("Redis连接失败: %v", err)
+// newResilientKVService 创建带降级策略的KV存储服务，初始状态为连接正常
+func newResilientKVService(redisService KVService, degradedMode string, ping func() error) *resilientKVService {
+	s := &resilientKVService{
+		redis:      redisService,
+		ping:       ping,
+		minBackoff: constants.KVReconnectMinBackoff,
+		maxBackoff: constants.KVReconnectMaxBackoff,
+		done:       make(chan struct{}),
 	}
+	if degradedMode != constants.KVDegradedFailClosed {
+		s.fallback = newMemoryKVService()
+	}
+	s.healthy.Store(true)
+	return s
+}
 
-	fmt.Println("Redis连接成功，使用Redis KV存储")
-	return &redisKVService{
-		client: rdb,
-		ctx:    ctx,
-	}, nil
+// do 连接正常时在Redis上执行操作，连接错误时标记为断开并按降级策略处理
+func (s *resilientKVService) do(op func(kv KVService) error) error {
+	if s.healthy.Load() {
+		err := op(s.redis)
+		if !isConnectionError(err) {
+			return err
+		}
+		s.markDown(err)
+	}
+	if s.fallback == nil {
+		return ErrKVUnavailable
+	}
+	return op(s.fallback)
+}
+
+// doLock 执行 SetNX 等需要多实例互斥的操作，Redis不可用时无论降级策略如何都返回 ErrKVUnavailable
+// 内存存储只在当前实例内有效，降级后每个实例都能拿到同一把锁，冷却和一次性标记也不再跨实例生效
+func (s *resilientKVService) doLock(op func(kv KVService) error) error {
+	if !s.healthy.Load() {
+		return ErrKVUnavailable
+	}
+	err := op(s.redis)
+	if isConnectionError(err) {
+		s.markDown(err)
+		return ErrKVUnavailable
+	}
+	return err
+}
+
+// markDown 标记Redis连接断开并启动重连，并发调用时只启动一个重连协程
+func (s *resilientKVService) markDown(err error) {
+	if !s.healthy.CompareAndSwap(true, false) {
+		return
+	}
+	if s.fallback != nil {
+		log.Printf("Redis连接失败: %v，降级为内存KV存储，后台重连中", err)
+	} else {
+		log.Printf("Redis连接失败: %v，依赖存储的请求将被拒绝，后台重连中", err)
+	}
+	go s.reconnect()
+}
+
+// reconnect 按退避间隔重连Redis，连接恢复后切回Redis
+// 降级期间写入内存存储的数据不会同步到Redis
+func (s *resilientKVService) reconnect() {
+	backoff := s.minBackoff
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-time.After(backoff):
+		}
+		if err := s.ping(); err != nil {
+			if backoff *= 2; backoff > s.maxBackoff {
+				backoff = s.maxBackoff
+			}
+			continue
+		}
+		s.healthy.Store(true)
+		log.Println("Redis连接已恢复，切回Redis KV存储")
+		return
+	}
+}
+
+// isConnectionError 判断是否为连接错误，键不存在和Redis返回的命令错误不算
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return false
+	}
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
+}
+
+// Set 设置键值对
+func (s *resilientKVService) Set(key, value string, ttl time.Duration) error {
+	return s.do(func(kv KVService) error {
+		return kv.Set(key, value, ttl)
+	})
+}
+
+// SetNX 在键不存在时设置键值对，Redis不可用时不降级
+func (s *resilientKVService) SetNX(key, value string, ttl time.Duration) (ok bool, err error) {
+	err = s.doLock(func(kv KVService) error {
+		ok, err = kv.SetNX(key, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+// Incr 计数加1
+func (s *resilientKVService) Incr(key string, ttl time.Duration) (count int64, err error) {
+	err = s.do(func(kv KVService) error {
+		count, err = kv.Incr(key, ttl)
+		return err
+	})
+	return count, err
+}
+
+// CompareAndSet 当前值等于 expected 时设置键值对，Redis不可用时不降级
+func (s *resilientKVService) CompareAndSet(key, expected, value string, ttl time.Duration) (ok bool, err error) {
+	err = s.doLock(func(kv KVService) error {
+		ok, err = kv.CompareAndSet(key, expected, value, ttl)
+		return err
+	})
+	return ok, err
+}
+
+// CompareAndDelete 当前值等于 expected 时删除键，Redis不可用时不降级
+func (s *resilientKVService) CompareAndDelete(key, expected string) (ok bool, err error) {
+	err = s.doLock(func(kv KVService) error {
+		ok, err = kv.CompareAndDelete(key, expected)
+		return err
+	})
+	return ok, err
+}
+
+// Get 获取键值
+func (s *resilientKVService) Get(key string) (value string, err error) {
+	err = s.do(func(kv KVService) error {
+		value, err = kv.Get(key)
+		return err
+	})
+	return value, err
+}
+
+// GetDel 取出并删除键值
+func (s *resilientKVService) GetDel(key string) (value string, err error) {
+	err = s.do(func(kv KVService) error {
+		value, err = kv.GetDel(key)
+		return err
+	})
+	return value, err
+}
+
+// Delete 删除键
+func (s *resilientKVService) Delete(key string) error {
+	return s.do(func(kv KVService) error {
+		return kv.Delete(key)
+	})
+}
+
+// Keys 获取匹配模式的所有键
+func (s *resilientKVService) Keys(pattern string) (keys []string, err error) {
+	err = s.do(func(kv KVService) error {
+		keys, err = kv.Keys(pattern)
+		return err
+	})
+	return keys, err
+}
+
+// Close 停止重连并关闭连接池，应用退出时调用
+func (s *resilientKVService) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.redis.Close()
+	})
+	return err
 }
 
 // Set Redis版本设置键值对
@@ -78,6 +291,51 @@ func (r *redisKVService) Set(key, value string, ttl time.Duration) error {
 	return r.client.Set(r.ctx, key, value, ttl).Err()
 }
 
+// SetNX Redis版本在键不存在时设置键值对
+func (r *redisKVService) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(r.ctx, key, value, ttl).Result()
+}
+
+// incrScript 计数加1，首次创建时设置过期时间，保证两步操作的原子性
+var incrScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count`)
+
+// Incr Redis版本计数加1
+func (r *redisKVService) Incr(key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(r.ctx, r.client, []string{key}, ttl.Milliseconds()).Int64()
+}
+
+// compareAndSetScript 比较当前值后设置，保证检查锁持有者和续期的原子性
+var compareAndSetScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0`)
+
+// compareAndDeleteScript 比较当前值后删除，避免释放其他实例持有的锁
+var compareAndDeleteScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// CompareAndSet Redis版本当前值等于 expected 时设置键值对
+func (r *redisKVService) CompareAndSet(key, expected, value string, ttl time.Duration) (bool, error) {
+	n, err := compareAndSetScript.Run(r.ctx, r.client, []string{key}, expected, value, ttl.Milliseconds()).Int64()
+	return n == 1, err
+}
+
+// CompareAndDelete Redis版本当前值等于 expected 时删除键
+func (r *redisKVService) CompareAndDelete(key, expected string) (bool, error) {
+	n, err := compareAndDeleteScript.Run(r.ctx, r.client, []string{key}, expected).Int64()
+	return n == 1, err
+}
+
 // Get Redis版本获取键值
 func (r *redisKVService) Get(key string) (string, error) {
 	val, err := r.client.Get(r.ctx, key).Result()
@@ -87,6 +345,15 @@ func (r *redisKVService) Get(key string) (string, error) {
 	return val, err
 }
 
+// GetDel Redis版本取出并删除键值
+func (r *redisKVService) GetDel(key string) (string, error) {
+	val, err := r.client.GetDel(r.ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
 // Delete Redis版本删除键
 func (r *redisKVService) Delete(key string) error {
 	return r.client.Del(r.ctx, key).Err()
@@ -132,6 +399,71 @@ func (kv *memoryKVService) Set(key, value string, ttl time.Duration) error {
 	return nil
 }
 
+// SetNX 内存版本在键不存在（或已过期）时设置键值对
+func (kv *memoryKVService) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	if item, exists := kv.storage[key]; exists && time.Now().Before(item.ExpiresAt) {
+		return false, nil
+	}
+
+	kv.storage[key] = &KVItem{
+		Value:     value,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	return true, nil
+}
+
+// Incr 内存版本计数加1，键不存在或已过期时从0开始
+func (kv *memoryKVService) Incr(key string, ttl time.Duration) (int64, error) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	item, exists := kv.storage[key]
+	if !exists || time.Now().After(item.ExpiresAt) {
+		item = &KVItem{Value: "0", ExpiresAt: time.Now().Add(ttl)}
+		kv.storage[key] = item
+	}
+	count, err := strconv.ParseInt(item.Value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("键 %s 的值不是整数", key)
+	}
+	count++
+	item.Value = strconv.FormatInt(count, 10)
+	return count, nil
+}
+
+// CompareAndSet 内存版本当前值等于 expected（且未过期）时设置键值对
+func (kv *memoryKVService) CompareAndSet(key, expected, value string, ttl time.Duration) (bool, error) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	item, exists := kv.storage[key]
+	if !exists || time.Now().After(item.ExpiresAt) || item.Value != expected {
+		return false, nil
+	}
+	kv.storage[key] = &KVItem{
+		Value:     value,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	return true, nil
+}
+
+// CompareAndDelete 内存版本当前值等于 expected（且未过期）时删除键
+func (kv *memoryKVService) CompareAndDelete(key, expected string) (bool, error) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	item, exists := kv.storage[key]
+	if !exists || time.Now().After(item.ExpiresAt) || item.Value != expected {
+		return false, nil
+	}
+	delete(kv.storage, key)
+	return true, nil
+}
+
 // Get 内存版本获取键值
 func (kv *memoryKVService) Get(key string) (string, error) {
 	kv.mutex.RLock()
@@ -142,15 +474,30 @@ func (kv *memoryKVService) Get(key string) (string, error) {
 		return "", nil // 键不存在返回空字符串
 	}
 
-	// 检查是否过期
+	// 过期的键只在读锁下视为不存在，由 cleanup 持写锁删除，并发读取同一个键时不会同时修改 map
 	if time.Now().After(item.ExpiresAt) {
-		delete(kv.storage, key)
 		return "", nil
 	}
 
 	return item.Value, nil
 }
 
+// GetDel 内存版本取出并删除键值
+func (kv *memoryKVService) GetDel(key string) (string, error) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+
+	item, exists := kv.storage[key]
+	if !exists {
+		return "", nil
+	}
+	delete(kv.storage, key)
+	if time.Now().After(item.ExpiresAt) {
+		return "", nil
+	}
+	return item.Value, nil
+}
+
 // Delete 内存版本删除键
 func (kv *memoryKVService) Delete(key string) error {
 	kv.mutex.Lock()
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"project/src/config"
+	"project/src/constants"
+)
+
+// newUnreachableKVService 创建连接不可用端口的KV服务，reachable 控制重连时 ping 是否成功
+func newUnreachableKVService(t *testing.T, degradedMode string, reachable *atomic.Bool) *resilientKVService {
+	t.Helper()
+	redisService := newRedisKVService(config.RedisConfig{Host: "127.0.0.1", Port: "1"})
+	s := newResilientKVService(redisService, degradedMode, func() error {
+		if !reachable.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	s.minBackoff, s.maxBackoff = 10*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestKVServiceMemoryFallback(t *testing.T) {
+	var reachable atomic.Bool
+	s := newUnreachableKVService(t, constants.KVDegradedMemory, &reachable)
+
+	// Redis不可用时降级到共享的内存存储，之后的请求读到同一份数据
+	if err := s.Set("sms:+8613800138000", "123456", time.Minute); err != nil {
+		t.Fatalf("降级后写入应成功: %v", err)
+	}
+	if s.healthy.Load() {
+		t.Fatalf("连接错误后应标记为断开")
+	}
+	if value, err := s.Get("sms:+8613800138000"); err != nil || value != "123456" {
+		t.Errorf("应读到降级期间写入的值: %q, %v", value, err)
+	}
+	if count, _ := s.Incr("counter", time.Minute); count != 1 {
+		t.Errorf("降级期间计数应正常: %d", count)
+	}
+
+	// 锁操作不降级到内存，否则每个实例都能拿到同一把锁
+	if _, err := s.SetNX("lock", "1", time.Minute); !errors.Is(err, ErrKVUnavailable) {
+		t.Errorf("降级期间SetNX应返回ErrKVUnavailable，实际为%v", err)
+	}
+	if _, err := s.CompareAndSet("lock", "1", "1", time.Minute); !errors.Is(err, ErrKVUnavailable) {
+		t.Errorf("降级期间CompareAndSet应返回ErrKVUnavailable，实际为%v", err)
+	}
+
+	// 重连成功后切回Redis
+	reachable.Store(true)
+	deadline := time.Now().Add(time.Second)
+	for !s.healthy.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !s.healthy.Load() {
+		t.Errorf("ping 成功后应恢复连接")
+	}
+}
+
+func TestKVServiceFailClosed(t *testing.T) {
+	var reachable atomic.Bool
+	s := newUnreachableKVService(t, constants.KVDegradedFailClosed, &reachable)
+
+	if err := s.Set("key", "value", time.Minute); !errors.Is(err, ErrKVUnavailable) {
+		t.Errorf("fail_closed 策略应返回ErrKVUnavailable，实际为%v", err)
+	}
+	if _, err := s.Get("key"); !errors.Is(err, ErrKVUnavailable) {
+		t.Errorf("fail_closed 策略应返回ErrKVUnavailable，实际为%v", err)
+	}
+	if _, err := s.SetNX("lock", "1", time.Minute); !errors.Is(err, ErrKVUnavailable) {
+		t.Errorf("fail_closed 策略应返回ErrKVUnavailable，实际为%v", err)
+	}
+}
+
+func TestMemoryKVServiceConcurrentExpiredGet(t *testing.T) {
+	kv := newMemoryKVService()
+	kv.Set("expired", "1", -time.Second)
+
+	// 降级后全进程共用一个内存存储，并发读取已过期的键不能修改 map
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := kv.Get("expired"); err != nil || value != "" {
+				t.Errorf("已过期的键应返回空值: %q, %v", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestValidateRedisConfig(t *testing.T) {
+	for _, mode := range []string{"", "memory", "fail_closed"} {
+		if err := ValidateRedisConfig(config.RedisConfig{DegradedMode: mode}); err != nil {
+			t.Errorf("%q 不应报错: %v", mode, err)
+		}
+	}
+	if err := ValidateRedisConfig(config.RedisConfig{DegradedMode: "fail_open"}); err == nil {
+		t.Errorf("不支持的降级策略应报错")
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/src/config"
+)
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer 按配置创建邮件发送器：smtp 通过SMTP服务器发送，file 保存为 .eml 文件，其他值只打印到日志
+// 启动时由 ValidateEmailConfig 保证非开发模式下只使用 smtp
+func NewMailer(cfg config.EmailConfig) Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return &smtpMailer{config: cfg}
+	case "file":
+		return &fileMailer{from: cfg.From, dir: cfg.OutputDir}
+	default:
+		return &logMailer{}
+	}
+}
+
+// ValidateEmailConfig 检查邮件配置，启动时调用，配置有误时返回错误
+// file 和 log 驱动会把登录链接写到本地文件或日志中，只能在开发模式下使用
+func ValidateEmailConfig(cfg config.EmailConfig, devMode bool) error {
+	switch cfg.Driver {
+	case "smtp":
+		if cfg.SMTP.Host == "" {
+			return fmt.Errorf("smtp 驱动缺少服务器地址smtp.host")
+		}
+		if _, err := mail.ParseAddress(cfg.From); err != nil {
+			return fmt.Errorf("发件人地址from无效: %v", err)
+		}
+		return nil
+	case "", "file", "log":
+		if devMode {
+			return nil
+		}
+		if cfg.Driver == "" {
+			return fmt.Errorf("未配置邮件驱动email.driver，生产环境须使用 smtp")
+		}
+		return fmt.Errorf("邮件驱动 %s 只能在 dev_mode 下使用，生产环境须使用 smtp", cfg.Driver)
+	default:
+		return fmt.Errorf("邮件驱动 %q 不受支持，可选 smtp、file、log", cfg.Driver)
+	}
+}
+
+// smtpMailer SMTP邮件发送器
+type smtpMailer struct {
+	config config.EmailConfig
+}
+
+// Send 通过SMTP服务器发送纯文本邮件，465 端口使用 TLS 直连，其他端口由 net/smtp 在服务器支持时升级 STARTTLS
+func (m *smtpMailer) Send(to, subject, body string) error {
+	from, err := mail.ParseAddress(m.config.From)
+	if err != nil {
+		return fmt.Errorf("发件人地址无效: %v", err)
+	}
+	addr := net.JoinHostPort(m.config.SMTP.Host, strconv.Itoa(m.config.SMTP.Port))
+	var auth smtp.Auth
+	if m.config.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", m.config.SMTP.Username, m.config.SMTP.Password, m.config.SMTP.Host)
+	}
+	msg := buildMessage(m.config.From, to, subject, body)
+
+	if m.config.SMTP.Port != 465 {
+		if err := smtp.SendMail(addr, auth, from.Address, []string{to}, msg); err != nil {
+			return fmt.Errorf("发送邮件失败: %v", err)
+		}
+		return nil
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: m.config.SMTP.Host})
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	client, err := smtp.NewClient(conn, m.config.SMTP.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP认证失败: %v", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return client.Quit()
+}
+
+// fileMailer 开发环境使用，每封邮件保存为一个 .eml 文件
+type fileMailer struct {
+	from string
+	dir  string
+}
+
+// Send 将邮件写入输出目录
+func (m *fileMailer) Send(to, subject, body string) error {
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return fmt.Errorf("创建邮件目录失败: %v", err)
+	}
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102-150405.000000"), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+	if err := os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, to, subject, body), 0644); err != nil {
+		return fmt.Errorf("保存邮件失败: %v", err)
+	}
+	return nil
+}
+
+// logMailer 开发环境使用，只把邮件内容打印到日志
+type logMailer struct{}
+
+// Send 打印邮件内容
+func (m *logMailer) Send(to, subject, body string) error {
+	log.Printf("邮件(未发送) To: %s Subject: %s\n%s", to, subject, body)
+	return nil
+}
+
+// buildMessage 生成纯文本邮件，主题按 RFC 2047 编码以支持中文
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,470 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"project/src/auth"
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+)
+
+// 第三方登录错误
+var (
+	ErrOAuthProviderNotFound = errors.New("第三方登录方式不存在或未启用")
+	ErrInvalidOAuthState     = errors.New("登录请求已过期或无效，请重新登录")
+	ErrOAuthFailed           = errors.New("第三方登录失败")
+)
+
+// oidcMetadataTTL OIDC 发现文档和 JWKS 的缓存时间
+const oidcMetadataTTL = time.Hour
+
+// OAuthService 第三方登录服务接口
+type OAuthService interface {
+	Providers() []models.OAuthProviderInfo
+	AuthorizeURL(provider string) (*models.OAuthAuthorizeData, error)
+	Login(provider, code, state string, client models.LoginClient) (*models.UserData, error)
+}
+
+// oauthService 第三方登录服务实现，授权码流程使用 PKCE，state 保存在KV存储中
+type oauthService struct {
+	providers   []config.OAuthProviderConfig
+	stateTTL    time.Duration
+	kvService   KVService
+	userService UserService
+	httpClient  *http.Client
+}
+
+// NewOAuthService 创建第三方登录服务实例
+func NewOAuthService(kvService KVService) OAuthService {
+	return &oauthService{
+		providers:   config.AppConfig.OAuth.Providers,
+		stateTTL:    time.Duration(config.AppConfig.OAuth.StateTTL) * time.Second,
+		kvService:   kvService,
+		userService: NewUserService(kvService),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ValidateOAuthConfig 检查第三方登录配置，启动时调用，配置有误时返回错误
+func ValidateOAuthConfig(cfg config.OAuthConfig) error {
+	names := make(map[string]bool, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		switch {
+		case p.Name == "":
+			return fmt.Errorf("第三方登录提供商缺少name")
+		case len(p.Name) > 20:
+			return fmt.Errorf("第三方登录提供商 %s 的name不能超过20个字符", p.Name)
+		case p.Name == constants.LoginTypePhone || p.Name == constants.LoginTypeWechat || p.Name == constants.LoginTypeEmail:
+			return fmt.Errorf("第三方登录提供商不能命名为 %s", p.Name)
+		case names[p.Name]:
+			return fmt.Errorf("第三方登录提供商 %s 重复", p.Name)
+		case p.ClientID == "" || p.ClientSecret == "":
+			return fmt.Errorf("第三方登录提供商 %s 缺少client_id或client_secret", p.Name)
+		case p.RedirectURL == "":
+			return fmt.Errorf("第三方登录提供商 %s 缺少redirect_url", p.Name)
+		}
+		switch p.Type {
+		case constants.OAuthTypeGitHub, constants.OAuthTypeGoogle:
+		case constants.OAuthTypeOIDC:
+			if p.Issuer == "" {
+				return fmt.Errorf("第三方登录提供商 %s 缺少issuer", p.Name)
+			}
+		default:
+			return fmt.Errorf("第三方登录提供商 %s 的类型 %q 不受支持，可选 github、google、oidc", p.Name, p.Type)
+		}
+		names[p.Name] = true
+	}
+	return nil
+}
+
+// Providers 返回已启用的第三方登录提供商
+func (s *oauthService) Providers() []models.OAuthProviderInfo {
+	providers := make([]models.OAuthProviderInfo, 0, len(s.providers))
+	for _, p := range s.providers {
+		providers = append(providers, models.OAuthProviderInfo{Name: p.Name, Type: p.Type, DisplayName: oauthDisplayName(p)})
+	}
+	return providers
+}
+
+// oauthDisplayName 返回提供商的显示名称，未配置时使用提供商标识
+func oauthDisplayName(p config.OAuthProviderConfig) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.Name
+}
+
+// AuthorizeURL 生成授权地址，state、PKCE code_verifier 和 nonce 保存到KV存储，回调时校验
+func (s *oauthService) AuthorizeURL(provider string) (*models.OAuthAuthorizeData, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := s.endpoints(p)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := randomToken(constants.OAuthStateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("生成state失败: %v", err)
+	}
+	verifier, err := randomToken(constants.OAuthStateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("生成code_verifier失败: %v", err)
+	}
+	saved := models.OAuthState{Provider: p.Name, CodeVerifier: verifier}
+	if p.Type != constants.OAuthTypeGitHub {
+		if saved.Nonce, err = randomToken(constants.OAuthStateBytes); err != nil {
+			return nil, fmt.Errorf("生成nonce失败: %v", err)
+		}
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("序列化登录请求失败: %v", err)
+	}
+	if err := s.kvService.Set(constants.OAuthStatePrefix+state, string(data), s.stateTTL); err != nil {
+		return nil, fmt.Errorf("保存登录请求失败: %v", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(oauthScopes(p), " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if saved.Nonce != "" {
+		query.Set("nonce", saved.Nonce)
+	}
+
+	return &models.OAuthAuthorizeData{
+		AuthURL:   endpoints.AuthorizationEndpoint + "?" + query.Encode(),
+		State:     state,
+		ExpiresIn: int(s.stateTTL.Seconds()),
+	}, nil
+}
+
+// Login 校验 state 后用授权码换取第三方账号信息，再按登录身份查找或创建用户并签发令牌
+func (s *oauthService) Login(provider, code, state string, client models.LoginClient) (*models.UserData, error) {
+	p, err := s.provider(provider)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.takeState(state)
+	if err != nil {
+		return nil, err
+	}
+	if saved.Provider != p.Name {
+		return nil, ErrInvalidOAuthState
+	}
+
+	endpoints, err := s.endpoints(p)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.exchangeCode(p, endpoints, code, saved.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var info *models.OAuthUserInfo
+	if p.Type == constants.OAuthTypeGitHub {
+		info, err = s.githubUserInfo(endpoints, token.AccessToken)
+	} else {
+		info, err = s.oidcUserInfo(p, endpoints, token, saved.Nonce)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Nickname == "" {
+		info.Nickname = fmt.Sprintf("%s用户", oauthDisplayName(p))
+	}
+	client.LoginType = p.Name
+	return s.userService.LoginWithOAuth(p.Name, info, client)
+}
+
+// provider 按名称查找已启用的提供商
+func (s *oauthService) provider(name string) (config.OAuthProviderConfig, error) {
+	for _, p := range s.providers {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return config.OAuthProviderConfig{}, ErrOAuthProviderNotFound
+}
+
+// takeState 一次性取出授权请求的服务端状态，过期或已使用时返回 ErrInvalidOAuthState
+// 读取和删除是同一个原子操作，同一 state 并发回调时只有一个请求能继续登录
+func (s *oauthService) takeState(state string) (*models.OAuthState, error) {
+	data, err := s.kvService.GetDel(constants.OAuthStatePrefix + state)
+	if err != nil {
+		return nil, fmt.Errorf("读取登录请求失败: %v", err)
+	}
+	if data == "" {
+		return nil, ErrInvalidOAuthState
+	}
+
+	var saved models.OAuthState
+	if err := json.Unmarshal([]byte(data), &saved); err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	return &saved, nil
+}
+
+// oauthScopes 返回申请的权限范围，未配置时使用提供商默认值
+func oauthScopes(p config.OAuthProviderConfig) []string {
+	if len(p.Scopes) > 0 {
+		return p.Scopes
+	}
+	if p.Type == constants.OAuthTypeGitHub {
+		return []string{"read:user"}
+	}
+	return []string{"openid", "profile", "email"}
+}
+
+// oidcMetadata OIDC 发现文档（/.well-known/openid-configuration）中用到的字段及提供商的 JWKS
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	jwks      *auth.JWKSet
+	fetchedAt time.Time
+}
+
+// oidcMetadataCache 按签发者缓存 OIDC 发现文档和 JWKS，避免每次登录都请求提供商
+var oidcMetadataCache = struct {
+	sync.Mutex
+	entries map[string]*oidcMetadata
+}{entries: make(map[string]*oidcMetadata)}
+
+// endpoints 返回提供商的授权、令牌和用户信息端点，GitHub 使用固定端点，OIDC 提供商通过发现文档获取
+func (s *oauthService) endpoints(p config.OAuthProviderConfig) (*oidcMetadata, error) {
+	if p.Type == constants.OAuthTypeGitHub {
+		return &oidcMetadata{
+			AuthorizationEndpoint: constants.GitHubAuthURL,
+			TokenEndpoint:         constants.GitHubTokenURL,
+			UserinfoEndpoint:      constants.GitHubUserInfoURL,
+		}, nil
+	}
+
+	issuer := p.Issuer
+	if issuer == "" && p.Type == constants.OAuthTypeGoogle {
+		issuer = constants.GoogleIssuer
+	}
+
+	oidcMetadataCache.Lock()
+	cached, ok := oidcMetadataCache.entries[issuer]
+	oidcMetadataCache.Unlock()
+	if ok && time.Since(cached.fetchedAt) < oidcMetadataTTL {
+		return cached, nil
+	}
+
+	// 请求提供商时不持有缓存锁，一个提供商响应慢不会阻塞其他提供商的登录；并发的缓存未命中会重复请求，后写入的结果覆盖先写入的
+	var metadata oidcMetadata
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := s.getJSON(discoveryURL, "", &metadata); err != nil {
+		return nil, fmt.Errorf("获取OIDC配置失败: %v", err)
+	}
+	if metadata.Issuer != issuer || metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC配置无效: %s", discoveryURL)
+	}
+	metadata.fetchedAt = time.Now()
+	oidcMetadataCache.Lock()
+	oidcMetadataCache.entries[issuer] = &metadata
+	oidcMetadataCache.Unlock()
+	return &metadata, nil
+}
+
+// keys 返回提供商的 JWKS，refresh 为 true 时重新获取（提供商轮换了签名密钥）
+func (s *oauthService) keys(metadata *oidcMetadata, refresh bool) (auth.JWKSet, error) {
+	oidcMetadataCache.Lock()
+	cached := metadata.jwks
+	oidcMetadataCache.Unlock()
+	if cached != nil && !refresh {
+		return *cached, nil
+	}
+
+	var jwks auth.JWKSet
+	if err := s.getJSON(metadata.JWKSURI, "", &jwks); err != nil {
+		return auth.JWKSet{}, fmt.Errorf("获取OIDC签名公钥失败: %v", err)
+	}
+	oidcMetadataCache.Lock()
+	metadata.jwks = &jwks
+	oidcMetadataCache.Unlock()
+	return jwks, nil
+}
+
+// oauthToken 令牌端点的响应
+type oauthToken struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode 使用授权码和 PKCE code_verifier 换取第三方令牌
+func (s *oauthService) exchangeCode(p config.OAuthProviderConfig, endpoints *oidcMetadata, code, verifier string) (*oauthToken, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoints.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("创建令牌请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token oauthToken
+	if err := s.doJSON(req, &token); err != nil && token.Error == "" {
+		return nil, fmt.Errorf("%w: 换取令牌失败: %v", ErrOAuthFailed, err)
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrOAuthFailed, token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: 未返回access_token", ErrOAuthFailed)
+	}
+	return &token, nil
+}
+
+// githubUserInfo 获取 GitHub 账号信息，以数字用户ID作为登录身份（登录名可以修改）
+func (s *oauthService) githubUserInfo(endpoints *oidcMetadata, accessToken string) (*models.OAuthUserInfo, error) {
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+		Email     string `json:"email"`
+	}
+	if err := s.getJSON(endpoints.UserinfoEndpoint, accessToken, &user); err != nil {
+		return nil, fmt.Errorf("%w: 获取GitHub用户信息失败: %v", ErrOAuthFailed, err)
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("%w: GitHub用户信息缺少id", ErrOAuthFailed)
+	}
+
+	nickname := user.Name
+	if nickname == "" {
+		nickname = user.Login
+	}
+	return &models.OAuthUserInfo{
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Nickname:  nickname,
+		AvatarURL: user.AvatarURL,
+		Email:     user.Email,
+	}, nil
+}
+
+// oidcUserInfo 校验 ID Token 并以其中的 sub 作为登录身份，ID Token 不含昵称时从用户信息端点补充
+func (s *oauthService) oidcUserInfo(p config.OAuthProviderConfig, endpoints *oidcMetadata, token *oauthToken, nonce string) (*models.OAuthUserInfo, error) {
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: 未返回id_token", ErrOAuthFailed)
+	}
+
+	keys, err := s.keys(endpoints, false)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := auth.VerifyIDToken(token.IDToken, keys, endpoints.Issuer, p.ClientID, nonce, time.Now())
+	if errors.Is(err, auth.ErrTokenUnknownKey) {
+		if keys, err = s.keys(endpoints, true); err != nil {
+			return nil, err
+		}
+		claims, err = auth.VerifyIDToken(token.IDToken, keys, endpoints.Issuer, p.ClientID, nonce, time.Now())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: ID Token无效: %v", ErrOAuthFailed, err)
+	}
+
+	info := &models.OAuthUserInfo{
+		Subject:   claims.Subject,
+		Nickname:  claims.Name,
+		AvatarURL: claims.Picture,
+		Email:     claims.Email,
+	}
+	if info.Nickname == "" && endpoints.UserinfoEndpoint != "" {
+		var profile struct {
+			Subject           string `json:"sub"`
+			Name              string `json:"name"`
+			PreferredUsername string `json:"preferred_username"`
+			Picture           string `json:"picture"`
+			Email             string `json:"email"`
+		}
+		// 用户信息只用于补充昵称和头像，获取失败不影响登录
+		if err := s.getJSON(endpoints.UserinfoEndpoint, token.AccessToken, &profile); err != nil {
+			fmt.Printf("获取OIDC用户信息失败: %v\n", err)
+		} else if profile.Subject == claims.Subject {
+			info.Nickname = profile.Name
+			if info.Nickname == "" {
+				info.Nickname = profile.PreferredUsername
+			}
+			if info.AvatarURL == "" {
+				info.AvatarURL = profile.Picture
+			}
+			if info.Email == "" {
+				info.Email = profile.Email
+			}
+		}
+	}
+	return info, nil
+}
+
+// getJSON 发送 GET 请求并解析 JSON 响应，accessToken 非空时携带 Bearer 令牌
+func (s *oauthService) getJSON(rawURL, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return s.doJSON(req, v)
+}
+
+// doJSON 发送请求并解析 JSON 响应，非 2xx 状态码时仍尝试解析响应体以便读取错误信息
+func (s *oauthService) doJSON(req *http.Request, v interface{}) error {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("解析响应失败(HTTP %d): %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
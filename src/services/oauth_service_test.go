@@ -0,0 +1,278 @@
+package services
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"project/src/auth"
+	"project/src/config"
+	"project/src/models"
+)
+
+// mockOIDCServer 本地模拟的 OIDC 提供商，授权页由测试直接登记授权码代替
+type mockOIDCServer struct {
+	*httptest.Server
+	key   *rsa.PrivateKey
+	mutex sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+// mockAuthorization 授权码对应的授权请求
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	subject   string
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+	m := &mockOIDCServer{key: key, codes: make(map[string]mockAuthorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"userinfo_endpoint":      m.URL + "/userinfo",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.JWKSet{Keys: []auth.JWK{{
+			KeyType:   "RSA",
+			KeyID:     "mock-key",
+			Use:       "sig",
+			Algorithm: auth.AlgRS256,
+			N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.mutex.Lock()
+		authorization, ok := m.codes[r.PostForm.Get("code")]
+		delete(m.codes, r.PostForm.Get("code"))
+		m.mutex.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || r.PostForm.Get("client_secret") != "mock-secret" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != authorization.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"id_token":     m.idToken(t, authorization),
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"sub": "unused"})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize 模拟用户在授权页同意授权，返回回调地址中的授权码
+func (m *mockOIDCServer) authorize(t *testing.T, authURL, subject string) string {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("解析授权地址失败: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("nonce") == "" || query.Get("client_id") != "mock-client" {
+		t.Fatalf("授权地址缺少PKCE或nonce参数: %s", authURL)
+	}
+
+	code := "code-" + subject
+	m.mutex.Lock()
+	m.codes[code] = mockAuthorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: subject}
+	m.mutex.Unlock()
+	return code
+}
+
+// idToken 签发 RS256 ID Token
+func (m *mockOIDCServer) idToken(t *testing.T, authorization mockAuthorization) string {
+	header, _ := json.Marshal(map[string]string{"alg": auth.AlgRS256, "kid": "mock-key"})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"iss":   m.URL,
+		"aud":   "mock-client",
+		"sub":   authorization.subject,
+		"nonce": authorization.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"name":  "OIDC用户",
+	})
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Errorf("签名ID Token失败: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOAuthLoginWithMockOIDC(t *testing.T) {
+	users, _ := setupUserTestService(t)
+	server := newMockOIDCServer(t)
+	s := &oauthService{
+		providers: []config.OAuthProviderConfig{{
+			Name:         "mock",
+			Type:         "oidc",
+			ClientID:     "mock-client",
+			ClientSecret: "mock-secret",
+			Issuer:       server.URL,
+			RedirectURL:  "http://localhost:3000/oauth/callback",
+		}},
+		stateTTL:    time.Minute,
+		kvService:   users.kvService,
+		userService: users,
+		httpClient:  server.Client(),
+	}
+
+	if _, err := s.AuthorizeURL("unknown"); !errors.Is(err, ErrOAuthProviderNotFound) {
+		t.Errorf("未配置的提供商应返回ErrOAuthProviderNotFound，实际为%v", err)
+	}
+
+	authorize, err := s.AuthorizeURL("mock")
+	if err != nil {
+		t.Fatalf("生成授权地址失败: %v", err)
+	}
+	code := server.authorize(t, authorize.AuthURL, "subject-1")
+
+	userData, err := s.Login("mock", code, authorize.State, models.LoginClient{})
+	if err != nil {
+		t.Fatalf("第三方登录失败: %v", err)
+	}
+	if userData.Token == "" || userData.RefreshToken == "" || userData.User.Nickname != "OIDC用户" {
+		t.Errorf("登录结果不正确: %+v", userData)
+	}
+	identity, err := users.identityRepo.GetIdentity("mock", "subject-1")
+	if err != nil || identity.UserID != userData.User.ID {
+		t.Errorf("应创建第三方登录身份: %+v, %v", identity, err)
+	}
+	sessions, _ := users.ListSessions(userData.User.ID, "")
+	if len(sessions) != 1 || sessions[0].LoginType != "mock" {
+		t.Errorf("登录会话应记录第三方登录方式: %+v", sessions)
+	}
+
+	// state 只能使用一次
+	if _, err := s.Login("mock", code, authorize.State, models.LoginClient{}); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Errorf("重复使用的state应返回ErrInvalidOAuthState，实际为%v", err)
+	}
+
+	// 授权码与 PKCE code_verifier 不匹配时换取令牌失败
+	first, _ := s.AuthorizeURL("mock")
+	second, _ := s.AuthorizeURL("mock")
+	code = server.authorize(t, first.AuthURL, "subject-2")
+	if _, err := s.Login("mock", code, second.State, models.LoginClient{}); !errors.Is(err, ErrOAuthFailed) {
+		t.Errorf("code_verifier不匹配应返回ErrOAuthFailed，实际为%v", err)
+	}
+}
+
+func TestOAuthStateUsedOnce(t *testing.T) {
+	s := &oauthService{
+		providers: []config.OAuthProviderConfig{{Name: "github", Type: "github", ClientID: "id", RedirectURL: "http://localhost/cb"}},
+		stateTTL:  time.Minute,
+		kvService: newMemoryKVService(),
+	}
+	authorize, err := s.AuthorizeURL("github")
+	if err != nil {
+		t.Fatalf("生成授权地址失败: %v", err)
+	}
+
+	// 同一 state 并发回调，只有一个请求能取出
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.takeState(authorize.State); err == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("state应只能使用一次，实际成功%d次", succeeded)
+	}
+}
+
+func TestOIDCDiscoveryDoesNotBlockOtherIssuers(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := newMockOIDCServer(t)
+
+	s := &oauthService{httpClient: &http.Client{Timeout: 5 * time.Second}}
+	go s.endpoints(config.OAuthProviderConfig{Type: "oidc", Issuer: slow.URL})
+	<-started
+
+	// 慢的提供商还未响应时，其他提供商的发现文档和 JWKS 请求不应被阻塞
+	done := make(chan error, 1)
+	go func() {
+		metadata, err := s.endpoints(config.OAuthProviderConfig{Type: "oidc", Issuer: fast.URL})
+		if err == nil {
+			_, err = s.keys(metadata, false)
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("获取OIDC配置失败: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("一个提供商响应慢时不应阻塞其他提供商")
+	}
+}
+
+func TestValidateOAuthConfig(t *testing.T) {
+	valid := config.OAuthProviderConfig{
+		Name: "github", Type: "github", ClientID: "id", ClientSecret: "secret", RedirectURL: "http://localhost/cb",
+	}
+	if err := ValidateOAuthConfig(config.OAuthConfig{Providers: []config.OAuthProviderConfig{valid}}); err != nil {
+		t.Errorf("有效配置不应报错: %v", err)
+	}
+
+	reserved := valid
+	reserved.Name = "wechat"
+	oidc := valid
+	oidc.Name, oidc.Type = "keycloak", "oidc"
+	unknown := valid
+	unknown.Type = "saml"
+	for _, providers := range [][]config.OAuthProviderConfig{{reserved}, {oidc}, {unknown}, {valid, valid}} {
+		if err := ValidateOAuthConfig(config.OAuthConfig{Providers: providers}); err == nil {
+			t.Errorf("无效配置应报错: %+v", providers)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"project/src/models"
+	"project/src/utils"
+)
+
+// ErrInvalidRevisionEntity 不支持修订记录的对象类型
+var ErrInvalidRevisionEntity = errors.New("无效的修订对象类型")
+
+// ListRevisions 获取群组或角色的修订记录，仅可修改该对象的用户可以查看
+func (s *groupService) ListRevisions(userID uint, entityType string, entityID uint, page, pageSize int) ([]models.Revision, int64, error) {
+	if _, err := s.getRevisionSnapshot(userID, entityType, entityID); err != nil {
+		return nil, 0, err
+	}
+	return s.revisionRepo.GetRevisions(entityType, entityID, page, pageSize)
+}
+
+// DiffRevisions 比较同一对象的两个版本，返回有变化的字段
+func (s *groupService) DiffRevisions(userID uint, entityType string, entityID uint, from, to int) (*models.RevisionDiff, error) {
+	if _, err := s.getRevisionSnapshot(userID, entityType, entityID); err != nil {
+		return nil, err
+	}
+
+	fromRevision, err := s.revisionRepo.GetRevision(entityType, entityID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.revisionRepo.GetRevision(entityType, entityID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldFields, newFields map[string]interface{}
+	if err := json.Unmarshal(fromRevision.Snapshot, &oldFields); err != nil {
+		return nil, fmt.Errorf("解析修订快照失败: %v", err)
+	}
+	if err := json.Unmarshal(toRevision.Snapshot, &newFields); err != nil {
+		return nil, fmt.Errorf("解析修订快照失败: %v", err)
+	}
+
+	fields := make([]string, 0, len(oldFields)+len(newFields))
+	for field := range oldFields {
+		fields = append(fields, field)
+	}
+	for field := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diff := &models.RevisionDiff{From: from, To: to, Changes: []models.RevisionFieldChange{}}
+	for _, field := range fields {
+		oldValue, newValue := oldFields[field], newFields[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		change := models.RevisionFieldChange{Field: field, Old: oldValue, New: newValue}
+		// 多行文本（如提示词）附带逐行差异
+		oldText, oldIsText := oldValue.(string)
+		newText, newIsText := newValue.(string)
+		if oldIsText && newIsText && (strings.Contains(oldText, "\n") || strings.Contains(newText, "\n")) {
+			change.Lines = utils.DiffLines(oldText, newText)
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+	return diff, nil
+}
+
+// RollbackRevision 将群组或角色恢复为指定版本的内容，并记录一条新的修订
+func (s *groupService) RollbackRevision(userID uint, entityType string, entityID uint, version int) (*models.Revision, error) {
+	if _, err := s.getRevisionSnapshot(userID, entityType, entityID); err != nil {
+		return nil, err
+	}
+	target, err := s.revisionRepo.GetRevision(entityType, entityID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	switch entityType {
+	case models.RevisionEntityGroup:
+		err = s.rollbackGroup(entityID, target)
+	case models.RevisionEntityCharacter:
+		err = s.rollbackCharacter(entityID, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.getRevisionSnapshot(userID, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recordRevision(entityType, entityID, userID, models.RevisionActionRollback, version, snapshot)
+}
+
+// rollbackGroup 按快照恢复群组
+func (s *groupService) rollbackGroup(groupID uint, target *models.Revision) error {
+	var snapshot models.GroupSnapshot
+	if err := json.Unmarshal(target.Snapshot, &snapshot); err != nil {
+		return fmt.Errorf("解析修订快照失败: %v", err)
+	}
+
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return err
+	}
+	if snapshot.Name != group.Name {
+		if err := s.checkGroupName(group.OwnerID, snapshot.Name, group.ID); err != nil {
+			return err
+		}
+	}
+
+	tags, err := json.Marshal(snapshot.Tags)
+	if err != nil {
+		return fmt.Errorf("序列化标签失败: %v", err)
+	}
+	updates := map[string]interface{}{
+		"name":                     snapshot.Name,
+		"description":              snapshot.Description,
+		"visibility":               snapshot.Visibility,
+		"is_group_discussion_mode": snapshot.IsGroupDiscussionMode,
+		"category":                 snapshot.Category,
+		"tags":                     string(tags),
+	}
+	if snapshot.Visibility == models.VisibilityPublic && group.PublishedAt == nil {
+		updates["published_at"] = time.Now()
+	}
+	return s.groupRepo.UpdateGroup(groupID, updates)
+}
+
+// rollbackCharacter 按快照恢复角色
+func (s *groupService) rollbackCharacter(characterID uint, target *models.Revision) error {
+	var snapshot models.CharacterSnapshot
+	if err := json.Unmarshal(target.Snapshot, &snapshot); err != nil {
+		return fmt.Errorf("解析修订快照失败: %v", err)
+	}
+	if err := validateModel(snapshot.Model); err != nil {
+		return err
+	}
+
+	tags, err := json.Marshal(snapshot.Tags)
+	if err != nil {
+		return fmt.Errorf("序列化标签失败: %v", err)
+	}
+	updates := map[string]interface{}{
+		"name":          snapshot.Name,
+		"personality":   snapshot.Personality,
+		"model":         snapshot.Model,
+		"avatar":        snapshot.Avatar,
+		"custom_prompt": snapshot.CustomPrompt,
+		"tags":          string(tags),
+		"rag":           snapshot.RAG,
+		"knowledge":     snapshot.Knowledge,
+		"extension":     nil,
+	}
+	if snapshot.Extension != nil {
+		extension, err := json.Marshal(snapshot.Extension)
+		if err != nil {
+			return fmt.Errorf("序列化扩展信息失败: %v", err)
+		}
+		updates["extension"] = string(extension)
+	}
+	return s.characterRepo.UpdateCharacter(characterID, updates)
+}
+
+// getRevisionSnapshot 校验当前用户可修改该对象，并返回其当前内容的快照
+func (s *groupService) getRevisionSnapshot(userID uint, entityType string, entityID uint) (interface{}, error) {
+	switch entityType {
+	case models.RevisionEntityGroup:
+		group, err := s.getOwnedGroup(userID, entityID)
+		if err != nil {
+			return nil, err
+		}
+		return groupSnapshot(group), nil
+	case models.RevisionEntityCharacter:
+		character, err := s.getEditableCharacter(userID, entityID)
+		if err != nil {
+			return nil, err
+		}
+		return characterSnapshot(character), nil
+	default:
+		return nil, ErrInvalidRevisionEntity
+	}
+}
+
+// ensureInitialRevision 对象还没有修订记录时（如修订功能上线前创建的数据），补记修改前的原始内容
+func (s *groupService) ensureInitialRevision(entityType string, entityID, ownerID uint, snapshot interface{}) error {
+	count, err := s.revisionRepo.CountRevisions(entityType, entityID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err = s.recordRevision(entityType, entityID, ownerID, models.RevisionActionInitial, 0, snapshot)
+	return err
+}
+
+// recordRevision 写入一条修订记录
+func (s *groupService) recordRevision(entityType string, entityID, authorID uint, action string, sourceVersion int, snapshot interface{}) (*models.Revision, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("序列化修订快照失败: %v", err)
+	}
+
+	revision := &models.Revision{
+		EntityType:    entityType,
+		EntityID:      entityID,
+		Action:        action,
+		SourceVersion: sourceVersion,
+		AuthorID:      authorID,
+		Snapshot:      data,
+	}
+	if err := s.revisionRepo.CreateRevision(revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// groupSnapshot 生成群组快照
+func groupSnapshot(group *models.LlmGroup) models.GroupSnapshot {
+	return models.GroupSnapshot{
+		Name:                  group.Name,
+		Description:           group.Description,
+		Visibility:            group.Visibility,
+		IsGroupDiscussionMode: group.IsGroupDiscussionMode,
+		Category:              group.Category,
+		Tags:                  group.Tags,
+	}
+}
+
+// characterSnapshot 生成角色快照，头像保存为存储的图片ID
+func characterSnapshot(character *models.GroupCharacter) models.CharacterSnapshot {
+	return models.CharacterSnapshot{
+		Name:         character.Name,
+		Personality:  character.Personality,
+		Model:        character.Model,
+		Avatar:       avatarKey(character.Avatar),
+		CustomPrompt: character.CustomPrompt,
+		Tags:         character.Tags,
+		RAG:          character.RAG,
+		Knowledge:    character.Knowledge,
+		Extension:    character.Extension,
+	}
+}
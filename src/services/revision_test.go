@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"project/src/models"
+	"project/src/repository"
+	"project/src/utils"
+)
+
+func TestCharacterRevisions(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	group := mustCreateGroup(t, s, 1, "提示词调优", "")
+
+	character, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{
+		GID: group.ID, Name: "助手", CustomPrompt: "你是一名助手\n回答要简洁",
+	})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	if _, err := s.UpdateCharacter(1, character.ID, &models.GroupCharacterUpdateRequest{CustomPrompt: models.PatchValue("你是一名助手\n回答要详细")}); err != nil {
+		t.Fatalf("更新角色失败: %v", err)
+	}
+
+	revisions, total, err := s.ListRevisions(1, models.RevisionEntityCharacter, character.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("获取修订记录失败: %v", err)
+	}
+	if total != 2 || revisions[0].Version != 2 || revisions[0].Action != models.RevisionActionUpdate || revisions[0].AuthorID != 1 {
+		t.Errorf("修订记录不正确: %+v", revisions)
+	}
+
+	diff, err := s.DiffRevisions(1, models.RevisionEntityCharacter, character.ID, 1, 2)
+	if err != nil {
+		t.Fatalf("比较版本失败: %v", err)
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Field != "custom_prompt" {
+		t.Fatalf("差异应只包含提示词，实际为%+v", diff.Changes)
+	}
+	lines := diff.Changes[0].Lines
+	if len(lines) != 3 || lines[0].Op != utils.DiffEqual || lines[1].Op != utils.DiffDelete || lines[2].Op != utils.DiffInsert {
+		t.Errorf("逐行差异不正确: %+v", lines)
+	}
+
+	revision, err := s.RollbackRevision(1, models.RevisionEntityCharacter, character.ID, 1)
+	if err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	if revision.Version != 3 || revision.SourceVersion != 1 || revision.Action != models.RevisionActionRollback {
+		t.Errorf("回滚应记录新的修订，实际为%+v", revision)
+	}
+	restored, _ := s.GetCharacter(1, character.ID)
+	if restored.CustomPrompt != "你是一名助手\n回答要简洁" {
+		t.Errorf("回滚后提示词不正确: %q", restored.CustomPrompt)
+	}
+
+	if _, _, err := s.ListRevisions(2, models.RevisionEntityCharacter, character.ID, 1, 10); !errors.Is(err, ErrCharacterForbidden) {
+		t.Errorf("其他用户查看修订记录应返回ErrCharacterForbidden，实际为%v", err)
+	}
+	if _, err := s.RollbackRevision(1, models.RevisionEntityCharacter, character.ID, 9); !errors.Is(err, repository.ErrRevisionNotFound) {
+		t.Errorf("回滚到不存在的版本应返回ErrRevisionNotFound，实际为%v", err)
+	}
+}
+
+func TestGroupRevisionsInitialSnapshot(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+
+	// 模拟修订功能上线前创建的群组
+	group := &models.LlmGroup{OwnerID: 1, Name: "旧群组", Description: "原始描述", Visibility: models.VisibilityPrivate}
+	if err := repository.NewLlmGroupRepository().CreateGroup(group); err != nil {
+		t.Fatalf("创建群组失败: %v", err)
+	}
+
+	if _, err := s.UpdateGroup(1, group.ID, &models.LlmGroupUpdateRequest{Description: models.PatchValue("新描述")}); err != nil {
+		t.Fatalf("更新群组失败: %v", err)
+	}
+	revisions, total, _ := s.ListRevisions(1, models.RevisionEntityGroup, group.ID, 1, 10)
+	if total != 2 || revisions[1].Action != models.RevisionActionInitial {
+		t.Fatalf("首次修改前应补记原始内容，实际为%+v", revisions)
+	}
+
+	if _, err := s.RollbackRevision(1, models.RevisionEntityGroup, group.ID, 1); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	restored, _ := s.GetGroup(1, group.ID)
+	if restored.Description != "原始描述" {
+		t.Errorf("回滚后描述不正确: %q", restored.Description)
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/src/config"
+	"project/src/constants"
+)
+
+// RiskSignals 风险评估使用的客户端信息
+type RiskSignals struct {
+	IP        string
+	UserAgent string
+	DeviceID  string // 前端通过设备指纹请求头上报，可能为空
+}
+
+// RiskAssessment 风险评估结果
+type RiskAssessment struct {
+	Score    int      `json:"score"`
+	Decision string   `json:"decision"` // skip、easy 或 hard
+	Reasons  []string `json:"reasons"`  // 计分项，用于排查误判
+}
+
+// RiskService 风险评估服务接口，决定请求是否需要人机验证以及验证的难度
+type RiskService interface {
+	// Assess 记录一次请求并评估风险，请求频率按场景分别统计
+	Assess(scene string, signals RiskSignals) (RiskAssessment, error)
+	// Evaluate 只评估风险，不记录请求，用于获取人机验证码时选择难度
+	Evaluate(scene string, signals RiskSignals) (RiskAssessment, error)
+	RecordCaptchaResult(signals RiskSignals, passed bool) error
+	// MarkVerified 记录客户端通过了人机验证，verified_ttl 内同一场景的请求不再需要验证
+	MarkVerified(scene string, signals RiskSignals) error
+}
+
+// riskService 基于KV存储计数的风险评估实现
+type riskService struct {
+	kvService KVService
+	config    config.RiskConfig
+}
+
+// NewRiskService 创建风险评估服务实例
+func NewRiskService(kvService KVService) RiskService {
+	return newRiskService(kvService)
+}
+
+func newRiskService(kvService KVService) *riskService {
+	return &riskService{kvService: kvService, config: config.AppConfig.Risk}
+}
+
+// Assess 记录请求频率和设备后评估风险
+func (s *riskService) Assess(scene string, signals RiskSignals) (RiskAssessment, error) {
+	return s.assess(scene, signals, true)
+}
+
+// Evaluate 按已有的计数评估风险
+func (s *riskService) Evaluate(scene string, signals RiskSignals) (RiskAssessment, error) {
+	return s.assess(scene, signals, false)
+}
+
+func (s *riskService) assess(scene string, signals RiskSignals, record bool) (RiskAssessment, error) {
+	// 关闭风险评估时发送短信始终需要人机验证，匿名聊天不需要
+	if !s.config.Enabled {
+		if scene == constants.RiskSceneSMS {
+			return RiskAssessment{Decision: constants.RiskDecisionEasy}, nil
+		}
+		return RiskAssessment{Decision: constants.RiskDecisionSkip}, nil
+	}
+
+	verified, err := s.kvService.Get(s.verifiedKey(scene, signals))
+	if err != nil {
+		return RiskAssessment{}, fmt.Errorf("读取人机验证状态失败: %v", err)
+	}
+	if verified != "" {
+		return RiskAssessment{Decision: constants.RiskDecisionSkip, Reasons: []string{"verified"}}, nil
+	}
+
+	var assessment RiskAssessment
+	add := func(score int, reason string) {
+		assessment.Score += score
+		assessment.Reasons = append(assessment.Reasons, reason)
+	}
+
+	// IP请求频率
+	velocity, err := s.count(constants.RiskVelocityPrefix+scene+":"+signals.IP, s.velocityWindow(), record)
+	if err != nil {
+		return RiskAssessment{}, err
+	}
+	if limit := s.velocityLimit(scene); velocity > 2*limit {
+		add(constants.RiskScoreVelocityDouble, "velocity")
+	} else if velocity > limit {
+		add(constants.RiskScoreVelocity, "velocity")
+	}
+
+	// 人机验证失败率
+	total, err := s.count(constants.RiskCaptchaTotalPrefix+signals.IP, constants.RiskCounterWindow, false)
+	if err != nil {
+		return RiskAssessment{}, err
+	}
+	if total >= constants.RiskCaptchaMinAttempts {
+		failed, err := s.count(constants.RiskCaptchaFailPrefix+signals.IP, constants.RiskCounterWindow, false)
+		if err != nil {
+			return RiskAssessment{}, err
+		}
+		if ratio := float64(failed) / float64(total); ratio >= 0.8 {
+			add(constants.RiskScoreCaptchaFailAll, "captcha_fail")
+		} else if ratio >= 0.5 {
+			add(constants.RiskScoreCaptchaFail, "captcha_fail")
+		}
+	}
+
+	// User-Agent
+	userAgent := strings.ToLower(strings.TrimSpace(signals.UserAgent))
+	if userAgent == "" {
+		add(constants.RiskScoreEmptyUA, "empty_user_agent")
+	} else {
+		for _, keyword := range constants.RiskBotUserAgents {
+			if strings.Contains(userAgent, keyword) {
+				add(constants.RiskScoreBotUA, "bot_user_agent")
+				break
+			}
+		}
+	}
+
+	// 设备指纹，同一IP上短时间内出现大量设备多为伪造
+	if signals.DeviceID == "" {
+		add(constants.RiskScoreNoDevice, "no_device")
+	} else {
+		devices, err := s.countDevice(signals, record)
+		if err != nil {
+			return RiskAssessment{}, err
+		}
+		if devices > s.deviceIPLimit() {
+			add(constants.RiskScoreManyDevices, "many_devices")
+		}
+	}
+
+	if assessment.Score > constants.RiskScoreMax {
+		assessment.Score = constants.RiskScoreMax
+	}
+	switch {
+	case assessment.Score >= s.hardScore():
+		assessment.Decision = constants.RiskDecisionHard
+	case assessment.Score >= s.easyScore():
+		assessment.Decision = constants.RiskDecisionEasy
+	default:
+		assessment.Decision = constants.RiskDecisionSkip
+	}
+	// 发送短信有实际成本，未通过人机验证的客户端至少需要简单验证
+	if scene == constants.RiskSceneSMS && assessment.Decision == constants.RiskDecisionSkip {
+		assessment.Decision = constants.RiskDecisionEasy
+	}
+	return assessment, nil
+}
+
+// RecordCaptchaResult 记录一次人机验证结果，用于计算失败率
+func (s *riskService) RecordCaptchaResult(signals RiskSignals, passed bool) error {
+	if _, err := s.kvService.Incr(constants.RiskCaptchaTotalPrefix+signals.IP, constants.RiskCounterWindow); err != nil {
+		return fmt.Errorf("记录人机验证次数失败: %v", err)
+	}
+	if passed {
+		return nil
+	}
+	if _, err := s.kvService.Incr(constants.RiskCaptchaFailPrefix+signals.IP, constants.RiskCounterWindow); err != nil {
+		return fmt.Errorf("记录人机验证失败次数失败: %v", err)
+	}
+	return nil
+}
+
+// MarkVerified 记录客户端通过了人机验证
+func (s *riskService) MarkVerified(scene string, signals RiskSignals) error {
+	if err := s.kvService.Set(s.verifiedKey(scene, signals), "1", s.verifiedTTL()); err != nil {
+		return fmt.Errorf("保存人机验证状态失败: %v", err)
+	}
+	return nil
+}
+
+// count 读取计数，record 为 true 时先加一
+func (s *riskService) count(key string, window time.Duration, record bool) (int, error) {
+	if record {
+		count, err := s.kvService.Incr(key, window)
+		if err != nil {
+			return 0, fmt.Errorf("记录风险计数失败: %v", err)
+		}
+		return int(count), nil
+	}
+	value, err := s.kvService.Get(key)
+	if err != nil {
+		return 0, fmt.Errorf("读取风险计数失败: %v", err)
+	}
+	count, _ := strconv.Atoi(value)
+	return count, nil
+}
+
+// countDevice 返回IP上出现的设备数，record 为 true 时记录当前设备
+func (s *riskService) countDevice(signals RiskSignals, record bool) (int, error) {
+	countKey := constants.RiskDeviceCountPrefix + signals.IP
+	if record {
+		isNew, err := s.kvService.SetNX(constants.RiskDeviceSeenPrefix+signals.IP+":"+hashRiskValue(signals.DeviceID), "1", constants.RiskCounterWindow)
+		if err != nil {
+			return 0, fmt.Errorf("记录设备失败: %v", err)
+		}
+		if isNew {
+			return s.count(countKey, constants.RiskCounterWindow, true)
+		}
+	}
+	return s.count(countKey, constants.RiskCounterWindow, false)
+}
+
+// verifiedKey 人机验证状态绑定IP、User-Agent 和设备指纹
+func (s *riskService) verifiedKey(scene string, signals RiskSignals) string {
+	return constants.RiskVerifiedPrefix + scene + ":" + signals.IP + ":" + hashRiskValue(signals.UserAgent+"|"+signals.DeviceID)
+}
+
+// hashRiskValue 客户端上报的值长度不可控，摘要后再作为Key
+func hashRiskValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *riskService) velocityLimit(scene string) int {
+	if scene == constants.RiskSceneChat {
+		if s.config.ChatVelocityLimit <= 0 {
+			return constants.DefaultRiskChatVelocityLimit
+		}
+		return s.config.ChatVelocityLimit
+	}
+	if s.config.SMSVelocityLimit <= 0 {
+		return constants.DefaultRiskSMSVelocityLimit
+	}
+	return s.config.SMSVelocityLimit
+}
+
+func (s *riskService) velocityWindow() time.Duration {
+	if s.config.VelocityWindow <= 0 {
+		return constants.DefaultRiskVelocityWindow
+	}
+	return time.Duration(s.config.VelocityWindow) * time.Second
+}
+
+func (s *riskService) deviceIPLimit() int {
+	if s.config.DeviceIPLimit <= 0 {
+		return constants.DefaultRiskDeviceIPLimit
+	}
+	return s.config.DeviceIPLimit
+}
+
+func (s *riskService) verifiedTTL() time.Duration {
+	if s.config.VerifiedTTL <= 0 {
+		return constants.DefaultRiskVerifiedTTL
+	}
+	return time.Duration(s.config.VerifiedTTL) * time.Second
+}
+
+func (s *riskService) easyScore() int {
+	if s.config.EasyScore <= 0 {
+		return constants.DefaultRiskEasyScore
+	}
+	return s.config.EasyScore
+}
+
+func (s *riskService) hardScore() int {
+	if s.config.HardScore <= 0 {
+		return constants.DefaultRiskHardScore
+	}
+	return s.config.HardScore
+}
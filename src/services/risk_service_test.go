@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"project/src/config"
+	"project/src/constants"
+)
+
+// browserUA 普通浏览器的 User-Agent
+const browserUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func newTestRiskService() *riskService {
+	return &riskService{kvService: newMemoryKVService(), config: config.RiskConfig{
+		Enabled: true, EasyScore: 20, HardScore: 50, SMSVelocityLimit: 3, ChatVelocityLimit: 20, DeviceIPLimit: 2, VerifiedTTL: 60,
+	}}
+}
+
+func TestRiskUserAgentAndDevice(t *testing.T) {
+	s := newTestRiskService()
+
+	cases := []struct {
+		signals  RiskSignals
+		decision string
+	}{
+		{RiskSignals{IP: "1.1.1.1", UserAgent: browserUA, DeviceID: "d1"}, constants.RiskDecisionSkip},
+		{RiskSignals{IP: "1.1.1.2", UserAgent: browserUA}, constants.RiskDecisionSkip},
+		{RiskSignals{IP: "1.1.1.3", DeviceID: "d1"}, constants.RiskDecisionEasy},
+		{RiskSignals{IP: "1.1.1.4", UserAgent: "python-requests/2.31"}, constants.RiskDecisionHard},
+	}
+	for _, tc := range cases {
+		assessment, err := s.Assess(constants.RiskSceneChat, tc.signals)
+		if err != nil {
+			t.Fatalf("风险评估失败: %v", err)
+		}
+		if assessment.Decision != tc.decision {
+			t.Errorf("%+v 应为%s，实际为%+v", tc.signals, tc.decision, assessment)
+		}
+	}
+
+	// 同一IP上出现的设备数超过上限
+	for i := 0; i < 3; i++ {
+		s.Assess(constants.RiskSceneChat, RiskSignals{IP: "2.2.2.2", UserAgent: browserUA, DeviceID: fmt.Sprintf("d%d", i)})
+	}
+	assessment, _ := s.Evaluate(constants.RiskSceneChat, RiskSignals{IP: "2.2.2.2", UserAgent: browserUA, DeviceID: "d0"})
+	if assessment.Decision != constants.RiskDecisionEasy || assessment.Reasons[0] != "many_devices" {
+		t.Errorf("设备数超过上限应要求简单验证: %+v", assessment)
+	}
+}
+
+func TestRiskVelocityAndCaptchaFailures(t *testing.T) {
+	s := newTestRiskService()
+	signals := RiskSignals{IP: "3.3.3.3", UserAgent: browserUA, DeviceID: "d1"}
+
+	var assessment RiskAssessment
+	for i := 0; i < 4; i++ {
+		assessment, _ = s.Assess(constants.RiskSceneSMS, signals)
+	}
+	if assessment.Decision != constants.RiskDecisionEasy {
+		t.Errorf("请求频率超过上限应要求简单验证: %+v", assessment)
+	}
+	// Evaluate 不记录请求，其他场景分别统计
+	if again, _ := s.Evaluate(constants.RiskSceneSMS, signals); again.Score != assessment.Score {
+		t.Errorf("Evaluate 不应记录请求: %+v", again)
+	}
+	if chat, _ := s.Assess(constants.RiskSceneChat, signals); chat.Decision != constants.RiskDecisionSkip {
+		t.Errorf("聊天请求频率应单独统计: %+v", chat)
+	}
+
+	// 人机验证失败率达到80%
+	s.RecordCaptchaResult(signals, true)
+	for i := 0; i < 4; i++ {
+		s.RecordCaptchaResult(signals, false)
+	}
+	if assessment, _ = s.Evaluate(constants.RiskSceneChat, signals); assessment.Decision != constants.RiskDecisionHard {
+		t.Errorf("人机验证失败率过高应要求困难验证: %+v", assessment)
+	}
+
+	// 通过人机验证后同一场景不再需要验证
+	s.MarkVerified(constants.RiskSceneChat, signals)
+	if assessment, _ = s.Assess(constants.RiskSceneChat, signals); assessment.Decision != constants.RiskDecisionSkip {
+		t.Errorf("通过人机验证后应跳过验证: %+v", assessment)
+	}
+	if assessment, _ = s.Evaluate(constants.RiskSceneSMS, signals); assessment.Decision == constants.RiskDecisionSkip {
+		t.Errorf("其他场景仍需验证: %+v", assessment)
+	}
+	other := RiskSignals{IP: signals.IP, UserAgent: browserUA, DeviceID: "d2"}
+	if assessment, _ = s.Evaluate(constants.RiskSceneChat, other); assessment.Decision == constants.RiskDecisionSkip {
+		t.Errorf("验证状态应绑定设备: %+v", assessment)
+	}
+}
+
+func TestRiskSMSRequiresCaptcha(t *testing.T) {
+	s := &riskService{kvService: newMemoryKVService(), config: config.RiskConfig{Enabled: true}}
+	signals := RiskSignals{IP: "5.5.5.5", UserAgent: browserUA}
+
+	// 默认配置下首次请求的浏览器客户端风险分低于 easy_score，发送短信仍需要人机验证
+	assessment, err := s.Assess(constants.RiskSceneSMS, signals)
+	if err != nil {
+		t.Fatalf("风险评估失败: %v", err)
+	}
+	if assessment.Score >= constants.DefaultRiskEasyScore || assessment.Decision != constants.RiskDecisionEasy {
+		t.Errorf("未验证的客户端发送短信应要求简单验证: %+v", assessment)
+	}
+
+	s.MarkVerified(constants.RiskSceneSMS, signals)
+	if assessment, _ = s.Assess(constants.RiskSceneSMS, signals); assessment.Decision != constants.RiskDecisionSkip {
+		t.Errorf("通过人机验证后应跳过验证: %+v", assessment)
+	}
+}
+
+func TestRiskDisabled(t *testing.T) {
+	s := &riskService{kvService: newMemoryKVService()}
+	signals := RiskSignals{IP: "4.4.4.4", UserAgent: "curl/8.0"}
+
+	if assessment, _ := s.Assess(constants.RiskSceneSMS, signals); assessment.Decision != constants.RiskDecisionEasy {
+		t.Errorf("关闭风险评估时发送短信应始终需要验证: %+v", assessment)
+	}
+	if assessment, _ := s.Assess(constants.RiskSceneChat, signals); assessment.Decision != constants.RiskDecisionSkip {
+		t.Errorf("关闭风险评估时匿名聊天不需要验证: %+v", assessment)
+	}
+}
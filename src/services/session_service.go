@@ -126,6 +126,20 @@ func (s *SessionService) UpdateSessionByScene(qrScene string, status string, use
 	return s.SaveSession(session)
 }
 
+// FailSessionByScene 根据场景值将会话标记为失败并记录原因
+func (s *SessionService) FailSessionByScene(qrScene string, openID string, message string) error {
+	session, err := s.GetSessionByScene(qrScene)
+	if err != nil {
+		return fmt.Errorf("获取会话失败: %v", err)
+	}
+
+	session.Status = constants.SessionStatusFailed
+	session.OpenID = openID
+	session.Message = message
+
+	return s.SaveSession(session)
+}
+
 // DeleteSession 删除会话
 func (s *SessionService) DeleteSession(sessionID string) error {
 	key := constants.WechatLoginSessionPrefix + sessionID
@@ -242,7 +256,8 @@ func (s *SessionService) IsValidSessionStatus(status string) bool {
 	switch status {
 	case constants.SessionStatusPending,
 		constants.SessionStatusSuccess,
-		constants.SessionStatusExpired:
+		constants.SessionStatusExpired,
+		constants.SessionStatusFailed:
 		return true
 	default:
 		return false
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"project/src/constants"
+	"project/src/models"
+	"project/src/utils"
+)
+
+// mockSMSProvider 开发和测试环境使用，短信不真正发送，保存到KV存储后通过开发环境收件箱查看
+// 应用共用一个短信服务实例，mutex 保证同一实例内并发保存时不丢失记录
+type mockSMSProvider struct {
+	kvService KVService
+	mutex     sync.Mutex
+}
+
+func newMockSMSProvider(kvService KVService) *mockSMSProvider {
+	return &mockSMSProvider{kvService: kvService}
+}
+
+// SendCode 保存验证码短信
+func (p *mockSMSProvider) SendCode(phone, code string) error {
+	return p.save(models.SMSMessage{Phone: phone, Code: code, SentAt: time.Now()})
+}
+
+// SendTemplate 保存模板短信
+func (p *mockSMSProvider) SendTemplate(phone, templateCode string, templateParam map[string]string) error {
+	return p.save(models.SMSMessage{Phone: phone, TemplateCode: templateCode, TemplateParam: templateParam, SentAt: time.Now()})
+}
+
+// save 将短信插入该手机号收件箱的最前面，只保留最近 SMSInboxSize 条
+// 多个实例同时写入同一手机号时可能丢失记录，开发环境可以接受
+func (p *mockSMSProvider) save(message models.SMSMessage) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	key := constants.SMSInboxPrefix + message.Phone
+	messages, err := loadSMSInbox(p.kvService, key)
+	if err != nil {
+		return err
+	}
+	messages = append([]models.SMSMessage{message}, messages...)
+	if len(messages) > constants.SMSInboxSize {
+		messages = messages[:constants.SMSInboxSize]
+	}
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return fmt.Errorf("序列化短信失败: %v", err)
+	}
+	return p.kvService.Set(key, string(data), constants.SMSInboxTTL)
+}
+
+// SMSInbox 开发环境短信收件箱，读取 mock 服务商保存的短信
+type SMSInbox interface {
+	List(phone string) (map[string][]models.SMSMessage, error)
+}
+
+// smsInbox 短信收件箱实现
+type smsInbox struct {
+	kvService KVService
+}
+
+// NewSMSInbox 创建短信收件箱实例
+func NewSMSInbox(kvService KVService) SMSInbox {
+	return &smsInbox{kvService: kvService}
+}
+
+// List 按手机号分组返回最近的短信，phone 为空时返回所有手机号
+func (i *smsInbox) List(phone string) (map[string][]models.SMSMessage, error) {
+	var keys []string
+	if phone != "" {
+		phone, err := utils.NormalizePhone(phone)
+		if err != nil {
+			return nil, err
+		}
+		keys = []string{constants.SMSInboxPrefix + phone}
+	} else {
+		var err error
+		if keys, err = i.kvService.Keys(constants.SMSInboxPrefix + "*"); err != nil {
+			return nil, fmt.Errorf("读取短信收件箱失败: %v", err)
+		}
+	}
+
+	inbox := make(map[string][]models.SMSMessage, len(keys))
+	for _, key := range keys {
+		messages, err := loadSMSInbox(i.kvService, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) > 0 {
+			inbox[strings.TrimPrefix(key, constants.SMSInboxPrefix)] = messages
+		}
+	}
+	return inbox, nil
+}
+
+// loadSMSInbox 读取一个手机号的收件箱
+func loadSMSInbox(kvService KVService, key string) ([]models.SMSMessage, error) {
+	data, err := kvService.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("读取短信收件箱失败: %v", err)
+	}
+	if data == "" {
+		return nil, nil
+	}
+	var messages []models.SMSMessage
+	if err := json.Unmarshal([]byte(data), &messages); err != nil {
+		return nil, fmt.Errorf("解析短信收件箱失败: %v", err)
+	}
+	return messages, nil
+}
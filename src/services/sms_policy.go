@@ -0,0 +1,176 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/utils"
+)
+
+// 短信发送频率限制错误
+var (
+	ErrSMSTooFrequent     = errors.New("短信发送过于频繁，请稍后再试")
+	ErrSMSPhoneDailyLimit = errors.New("该手机号今日接收短信次数已达上限，请明天再试")
+	ErrSMSIPDailyLimit    = errors.New("当前网络今日发送短信次数已达上限，请明天再试")
+	ErrSMSCircuitOpen     = errors.New("短信服务繁忙，请稍后再试")
+	ErrSMSCodeLocked      = errors.New("验证码错误次数过多，请重新获取验证码")
+)
+
+// SMSPolicy 短信发送策略，限制重新发送间隔、每个手机号和IP的每日发送条数以及全站每小时发送条数
+type SMSPolicy interface {
+	AllowSend(phone, ip string) error
+}
+
+// smsPolicy 基于KV存储计数的短信发送策略实现，也负责限制验证码的校验次数
+type smsPolicy struct {
+	kvService KVService
+	config    config.SMSPolicyConfig
+}
+
+// NewSMSPolicy 创建短信发送策略实例
+func NewSMSPolicy(kvService KVService) SMSPolicy {
+	return newSMSPolicy(kvService)
+}
+
+func newSMSPolicy(kvService KVService) *smsPolicy {
+	return &smsPolicy{kvService: kvService, config: config.AppConfig.SMS.Policy}
+}
+
+// AllowSend 检查并记录一次发送，发送短信前调用，超过任一限制时返回对应的错误
+// 先检查全部限制再记录，被拒绝的请求不会占用手机号的发送间隔和每日条数，避免他人耗尽该手机号的额度
+func (p *smsPolicy) AllowSend(phone, ip string) error {
+	phone, err := utils.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	date := now.Format("20060102")
+	cooldownKey := constants.SMSCooldownPrefix + phone
+	phoneKey := constants.SMSPhoneCountPrefix + phone + ":" + date
+	ipKey := constants.SMSIPCountPrefix + ip + ":" + date
+	globalKey := constants.SMSGlobalCountPrefix + now.Format("2006010215")
+
+	// 全站熔断已打开时不再计数，直接拒绝
+	if count, err := p.count(globalKey); err != nil {
+		return err
+	} else if count >= p.globalHourlyLimit() {
+		return ErrSMSCircuitOpen
+	}
+	if cooldown, err := p.kvService.Get(cooldownKey); err != nil {
+		return fmt.Errorf("检查发送间隔失败: %v", err)
+	} else if cooldown != "" {
+		return ErrSMSTooFrequent
+	}
+	if count, err := p.count(phoneKey); err != nil {
+		return err
+	} else if count >= p.phoneDailyLimit() {
+		return ErrSMSPhoneDailyLimit
+	}
+	if count, err := p.count(ipKey); err != nil {
+		return err
+	} else if count >= p.ipDailyLimit() {
+		return ErrSMSIPDailyLimit
+	}
+
+	// 全部检查通过后记录发送；并发请求可能同时通过检查，发送间隔由 SetNX 保证，计数超过上限时仍然拒绝
+	ok, err := p.kvService.SetNX(cooldownKey, "1", p.resendInterval())
+	if err != nil {
+		return fmt.Errorf("检查发送间隔失败: %v", err)
+	}
+	if !ok {
+		return ErrSMSTooFrequent
+	}
+	if count, err := p.kvService.Incr(phoneKey, 24*time.Hour); err != nil {
+		return fmt.Errorf("记录短信发送计数失败: %v", err)
+	} else if count > int64(p.phoneDailyLimit()) {
+		return ErrSMSPhoneDailyLimit
+	}
+	if count, err := p.kvService.Incr(ipKey, 24*time.Hour); err != nil {
+		return fmt.Errorf("记录短信发送计数失败: %v", err)
+	} else if count > int64(p.ipDailyLimit()) {
+		return ErrSMSIPDailyLimit
+	}
+	if count, err := p.kvService.Incr(globalKey, time.Hour); err != nil {
+		return fmt.Errorf("记录短信发送计数失败: %v", err)
+	} else if count > int64(p.globalHourlyLimit()) {
+		log.Printf("短信发送量本小时已达上限(%d条)，暂停发送直到下一小时", p.globalHourlyLimit())
+		return ErrSMSCircuitOpen
+	}
+	return nil
+}
+
+// count 读取发送计数，键不存在时为0
+func (p *smsPolicy) count(key string) (int, error) {
+	current, err := p.kvService.Get(key)
+	if err != nil {
+		return 0, fmt.Errorf("读取短信发送计数失败: %v", err)
+	}
+	count, _ := strconv.Atoi(current)
+	return count, nil
+}
+
+// checkCodeAttempt 记录一次验证码校验，校验次数超过上限时删除验证码并返回 ErrSMSCodeLocked
+// 先计数再比较验证码，并发请求也无法超过次数上限
+func (p *smsPolicy) checkCodeAttempt(phone string) (int, error) {
+	attempts, err := p.kvService.Incr(constants.SMSCodeAttemptsPrefix+phone, constants.SMSCodeTTL)
+	if err != nil {
+		return 0, fmt.Errorf("记录验证码校验次数失败: %v", err)
+	}
+	if attempts > int64(p.codeMaxAttempts()) {
+		return 0, p.lockCode(phone)
+	}
+	return p.codeMaxAttempts() - int(attempts), nil
+}
+
+// lockCode 删除验证码，用户需要重新获取
+func (p *smsPolicy) lockCode(phone string) error {
+	if err := p.kvService.Delete(fmt.Sprintf("sms:%s", phone)); err != nil {
+		return fmt.Errorf("删除验证码失败: %v", err)
+	}
+	return ErrSMSCodeLocked
+}
+
+// resetCodeAttempts 发送新验证码时清空校验次数
+func (p *smsPolicy) resetCodeAttempts(phone string) error {
+	return p.kvService.Delete(constants.SMSCodeAttemptsPrefix + phone)
+}
+
+func (p *smsPolicy) resendInterval() time.Duration {
+	if p.config.ResendInterval <= 0 {
+		return constants.DefaultSMSResendInterval
+	}
+	return time.Duration(p.config.ResendInterval) * time.Second
+}
+
+func (p *smsPolicy) phoneDailyLimit() int {
+	if p.config.PhoneDailyLimit <= 0 {
+		return constants.DefaultSMSPhoneDailyLimit
+	}
+	return p.config.PhoneDailyLimit
+}
+
+func (p *smsPolicy) ipDailyLimit() int {
+	if p.config.IPDailyLimit <= 0 {
+		return constants.DefaultSMSIPDailyLimit
+	}
+	return p.config.IPDailyLimit
+}
+
+func (p *smsPolicy) globalHourlyLimit() int {
+	if p.config.GlobalHourlyLimit <= 0 {
+		return constants.DefaultSMSGlobalHourlyLimit
+	}
+	return p.config.GlobalHourlyLimit
+}
+
+func (p *smsPolicy) codeMaxAttempts() int {
+	if p.config.CodeMaxAttempts <= 0 {
+		return constants.DefaultSMSCodeMaxAttempts
+	}
+	return p.config.CodeMaxAttempts
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+)
+
+func TestSMSPolicySendLimits(t *testing.T) {
+	kv := newMemoryKVService()
+	p := &smsPolicy{kvService: kv, config: config.SMSPolicyConfig{
+		ResendInterval: 60, PhoneDailyLimit: 2, IPDailyLimit: 3, GlobalHourlyLimit: 4,
+	}}
+	// clearCooldown 模拟重新发送间隔已过
+	clearCooldown := func(phone string) {
+		kv.Delete(constants.SMSCooldownPrefix + phone)
+	}
+
+	if err := p.AllowSend("13800000001", "1.1.1.1"); err != nil {
+		t.Fatalf("首次发送应允许: %v", err)
+	}
+	// 同一手机号的两种写法共用限制
+	if err := p.AllowSend("+8613800000001", "1.1.1.1"); !errors.Is(err, ErrSMSTooFrequent) {
+		t.Errorf("重新发送间隔内应返回ErrSMSTooFrequent，实际为%v", err)
+	}
+	clearCooldown("+8613800000001")
+	if err := p.AllowSend("13800000001", "1.1.1.1"); err != nil {
+		t.Fatalf("间隔过后应允许发送: %v", err)
+	}
+	clearCooldown("+8613800000001")
+	if err := p.AllowSend("13800000001", "2.2.2.2"); !errors.Is(err, ErrSMSPhoneDailyLimit) {
+		t.Errorf("手机号超过每日上限应返回ErrSMSPhoneDailyLimit，实际为%v", err)
+	}
+
+	if err := p.AllowSend("13800000002", "1.1.1.1"); err != nil {
+		t.Fatalf("其他手机号应允许发送: %v", err)
+	}
+	if err := p.AllowSend("13800000003", "1.1.1.1"); !errors.Is(err, ErrSMSIPDailyLimit) {
+		t.Errorf("IP超过每日上限应返回ErrSMSIPDailyLimit，实际为%v", err)
+	}
+	// 被拒绝的请求不占用手机号的发送间隔和每日条数
+	if keys, _ := kv.Keys(constants.SMSCooldownPrefix + "+8613800000003"); len(keys) != 0 {
+		t.Errorf("被拒绝的请求不应占用发送间隔")
+	}
+	if keys, _ := kv.Keys(constants.SMSPhoneCountPrefix + "+8613800000003:*"); len(keys) != 0 {
+		t.Errorf("被拒绝的请求不应计入手机号每日条数")
+	}
+
+	// 全站每小时上限为4条，已成功发送3条
+	if err := p.AllowSend("13800000004", "3.3.3.3"); err != nil {
+		t.Fatalf("未达全站上限应允许发送: %v", err)
+	}
+	if err := p.AllowSend("13800000005", "4.4.4.4"); !errors.Is(err, ErrSMSCircuitOpen) {
+		t.Errorf("超过全站每小时上限应返回ErrSMSCircuitOpen，实际为%v", err)
+	}
+	if cooldown, _ := kv.Get(constants.SMSCooldownPrefix + "+8613800000005"); cooldown != "" {
+		t.Errorf("熔断打开后不应再记录发送")
+	}
+}
+
+func TestSMSCodeAttemptsLock(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	client := models.LoginClient{LoginType: constants.LoginTypePhone}
+
+	s.SetSMSCode("13800000021", "123456")
+	for i := 0; i < constants.DefaultSMSCodeMaxAttempts-1; i++ {
+		if _, err := s.Login("13800000021", "000000", client); !errors.Is(err, ErrInvalidSMSCode) {
+			t.Fatalf("第%d次错误应返回ErrInvalidSMSCode，实际为%v", i+1, err)
+		}
+	}
+	if _, err := s.Login("13800000021", "000000", client); !errors.Is(err, ErrSMSCodeLocked) {
+		t.Fatalf("错误次数达到上限应返回ErrSMSCodeLocked，实际为%v", err)
+	}
+	// 验证码已失效，正确的验证码也无法登录
+	if _, err := s.Login("13800000021", "123456", client); !errors.Is(err, ErrInvalidSMSCode) {
+		t.Errorf("锁定后验证码应失效，实际为%v", err)
+	}
+
+	// 重新获取验证码后校验次数重新计算
+	s.SetSMSCode("13800000021", "654321")
+	if _, err := s.Login("13800000021", "000000", client); !errors.Is(err, ErrInvalidSMSCode) {
+		t.Errorf("新验证码错误应返回ErrInvalidSMSCode，实际为%v", err)
+	}
+	if _, err := s.Login("13800000021", "654321", client); err != nil {
+		t.Errorf("新验证码应能登录: %v", err)
+	}
+}
+
+func TestSMSCodeConsumedOnce(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	client := models.LoginClient{LoginType: constants.LoginTypePhone}
+
+	s.SetSMSCode("13800000022", "123456")
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.verifySMSCode("13800000022", "123456"); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded.Load() != 1 {
+		t.Fatalf("同一个验证码并发提交应只有一次通过，实际%d次", succeeded.Load())
+	}
+
+	// 验证码已使用，再次提交无法登录
+	if _, err := s.Login("13800000022", "123456", client); !errors.Is(err, ErrInvalidSMSCode) {
+		t.Errorf("已使用的验证码应失效，实际为%v", err)
+	}
+	if attempts, _ := s.kvService.Get(constants.SMSCodeAttemptsPrefix + "+8613800000022"); attempts != "" {
+		t.Errorf("校验通过后应清空校验次数，实际为%q", attempts)
+	}
+}
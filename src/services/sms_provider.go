@@ -0,0 +1,369 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/utils"
+)
+
+// smsHTTPTimeout 调用短信服务商接口的超时时间
+const smsHTTPTimeout = 10 * time.Second
+
+// aliyunSMSProvider 阿里云短信（dysmsapi）
+type aliyunSMSProvider struct {
+	config     config.AliyunSMSConfig
+	endpoint   string
+	httpClient *http.Client
+}
+
+func newAliyunSMSProvider(cfg config.AliyunSMSConfig) *aliyunSMSProvider {
+	return &aliyunSMSProvider{config: cfg, endpoint: constants.AliyunSMSURL, httpClient: &http.Client{Timeout: smsHTTPTimeout}}
+}
+
+// SendCode 发送验证码短信
+func (p *aliyunSMSProvider) SendCode(phone, code string) error {
+	return p.SendTemplate(phone, p.config.TemplateCode, map[string]string{"code": code})
+}
+
+// SendTemplate 发送模板短信，中国大陆号码去掉国家码，其他号码为国家码加号码（不带 +）
+func (p *aliyunSMSProvider) SendTemplate(phone, templateCode string, templateParam map[string]string) error {
+	const apiVersion = "2017-05-25"
+
+	phoneNumber := strings.TrimPrefix(phone, utils.ChinaCountryCode)
+	phoneNumber = strings.TrimPrefix(phoneNumber, "+")
+
+	// 准备请求参数
+	params := map[string]string{
+		"AccessKeyId":      p.config.AccessKeyID,
+		"Action":           "SendSms",
+		"Format":           "JSON",
+		"PhoneNumbers":     phoneNumber,
+		"SignName":         p.config.SignName,
+		"TemplateCode":     templateCode,
+		"Version":          apiVersion,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureVersion": "1.0",
+		"SignatureNonce":   generateNonce(16),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+
+	// 添加模板参数
+	if len(templateParam) > 0 {
+		templateParamJSON, err := json.Marshal(templateParam)
+		if err != nil {
+			return fmt.Errorf("marshal template param failed: %v", err)
+		}
+		params["TemplateParam"] = string(templateParamJSON)
+	}
+
+	// 参数排序
+	var keys []string
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// 构建签名字符串
+	var canonicalizedQueryString []string
+	for _, key := range keys {
+		canonicalizedQueryString = append(canonicalizedQueryString,
+			fmt.Sprintf("%s=%s", url.QueryEscape(key), url.QueryEscape(params[key])))
+	}
+
+	queryString := strings.Join(canonicalizedQueryString, "&")
+	stringToSign := fmt.Sprintf("GET&%s&%s",
+		url.QueryEscape("/"),
+		url.QueryEscape(queryString))
+
+	// 计算签名
+	signature := calculateHmacSha1(stringToSign, p.config.AccessKeySecret+"&")
+
+	// 构建最终的 URL
+	finalURL := fmt.Sprintf("%s?%s&Signature=%s",
+		p.endpoint, queryString, url.QueryEscape(signature))
+
+	// 发送请求
+	resp, err := p.httpClient.Get(finalURL)
+	if err != nil {
+		return fmt.Errorf("send http request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 读取响应
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body failed: %v", err)
+	}
+
+	// 解析响应
+	var response map[string]interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("parse response failed: %v, body: %s", err, string(body))
+	}
+
+	// 检查响应状态
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http request failed with status: %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	// 检查业务状态
+	if code, ok := response["Code"].(string); !ok || code != "OK" {
+		message := "Unknown error"
+		if msg, ok := response["Message"].(string); ok {
+			message = msg
+		}
+		return fmt.Errorf("SMS send failed: %s (Code: %s)", message, code)
+	}
+
+	return nil
+}
+
+// tencentSMSProvider 腾讯云短信，使用 TC3-HMAC-SHA256 签名调用 SendSms 接口
+type tencentSMSProvider struct {
+	config     config.TencentSMSConfig
+	endpoint   string
+	httpClient *http.Client
+}
+
+func newTencentSMSProvider(cfg config.TencentSMSConfig) *tencentSMSProvider {
+	return &tencentSMSProvider{config: cfg, endpoint: "https://" + constants.TencentSMSHost, httpClient: &http.Client{Timeout: smsHTTPTimeout}}
+}
+
+// SendCode 发送验证码短信，模板第一个参数为验证码
+func (p *tencentSMSProvider) SendCode(phone, code string) error {
+	return p.send(phone, p.config.TemplateID, []string{code})
+}
+
+// SendTemplate 发送模板短信，腾讯云模板参数按位置传递，templateParam 的键为参数序号（1、2、3…）
+func (p *tencentSMSProvider) SendTemplate(phone, templateCode string, templateParam map[string]string) error {
+	keys := make([]string, 0, len(templateParam))
+	for key := range templateParam {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+	params := make([]string, len(keys))
+	for i, key := range keys {
+		params[i] = templateParam[key]
+	}
+	return p.send(phone, templateCode, params)
+}
+
+// send 调用腾讯云 SendSms 接口
+func (p *tencentSMSProvider) send(phone, templateID string, params []string) error {
+	const (
+		service     = "sms"
+		action      = "SendSms"
+		version     = "2021-01-11"
+		algorithm   = "TC3-HMAC-SHA256"
+		contentType = "application/json; charset=utf-8"
+	)
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"PhoneNumberSet":   []string{phone},
+		"SmsSdkAppId":      p.config.SdkAppID,
+		"SignName":         p.config.SignName,
+		"TemplateId":       templateID,
+		"TemplateParamSet": params,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal request failed: %v", err)
+	}
+
+	// 计算签名
+	now := time.Now().UTC()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	date := now.Format("2006-01-02")
+	canonicalRequest := fmt.Sprintf("POST\n/\n\ncontent-type:%s\nhost:%s\n\ncontent-type;host\n%s",
+		contentType, constants.TencentSMSHost, sha256Hex(payload))
+	credentialScope := date + "/" + service + "/tc3_request"
+	stringToSign := fmt.Sprintf("%s\n%s\n%s\n%s", algorithm, timestamp, credentialScope, sha256Hex([]byte(canonicalRequest)))
+	secretDate := hmacSha256([]byte("TC3"+p.config.SecretKey), date)
+	secretService := hmacSha256(secretDate, service)
+	secretSigning := hmacSha256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSha256(secretSigning, stringToSign))
+	authorization := fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=content-type;host, Signature=%s",
+		algorithm, p.config.SecretID, credentialScope, signature)
+
+	req, err := http.NewRequest(http.MethodPost, p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create http request failed: %v", err)
+	}
+	req.Host = constants.TencentSMSHost
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Timestamp", timestamp)
+	req.Header.Set("X-TC-Version", version)
+	req.Header.Set("X-TC-Region", p.config.Region)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send http request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body failed: %v", err)
+	}
+
+	var response struct {
+		Response struct {
+			Error *struct {
+				Code    string `json:"Code"`
+				Message string `json:"Message"`
+			} `json:"Error"`
+			SendStatusSet []struct {
+				Code    string `json:"Code"`
+				Message string `json:"Message"`
+			} `json:"SendStatusSet"`
+		} `json:"Response"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("parse response failed: %v, body: %s", err, string(body))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http request failed with status: %d, response: %s", resp.StatusCode, string(body))
+	}
+	if e := response.Response.Error; e != nil {
+		return fmt.Errorf("SMS send failed: %s (Code: %s)", e.Message, e.Code)
+	}
+	if len(response.Response.SendStatusSet) == 0 {
+		return fmt.Errorf("SMS send failed: empty send status, response: %s", string(body))
+	}
+	if status := response.Response.SendStatusSet[0]; status.Code != "Ok" {
+		return fmt.Errorf("SMS send failed: %s (Code: %s)", status.Message, status.Code)
+	}
+	return nil
+}
+
+// twilioSMSProvider Twilio 短信，Twilio 不使用模板，直接发送正文
+type twilioSMSProvider struct {
+	config     config.TwilioSMSConfig
+	endpoint   string
+	httpClient *http.Client
+}
+
+func newTwilioSMSProvider(cfg config.TwilioSMSConfig) *twilioSMSProvider {
+	return &twilioSMSProvider{config: cfg, endpoint: constants.TwilioSMSAPIURL, httpClient: &http.Client{Timeout: smsHTTPTimeout}}
+}
+
+// SendCode 按 code_template 生成正文发送验证码
+func (p *twilioSMSProvider) SendCode(phone, code string) error {
+	return p.SendTemplate(phone, p.config.CodeTemplate, map[string]string{"code": code})
+}
+
+// SendTemplate templateCode 作为短信正文，其中的 ${参数名} 替换为 templateParam 中的值
+func (p *twilioSMSProvider) SendTemplate(phone, templateCode string, templateParam map[string]string) error {
+	replacements := make([]string, 0, len(templateParam)*2)
+	for key, value := range templateParam {
+		replacements = append(replacements, "${"+key+"}", value)
+	}
+	body := strings.NewReplacer(replacements...).Replace(templateCode)
+
+	form := url.Values{"To": {phone}, "Body": {body}}
+	if p.config.MessagingServiceSID != "" {
+		form.Set("MessagingServiceSid", p.config.MessagingServiceSID)
+	} else {
+		form.Set("From", p.config.From)
+	}
+	req, err := http.NewRequest(http.MethodPost, p.endpoint+url.PathEscape(p.config.AccountSID)+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create http request failed: %v", err)
+	}
+	req.SetBasicAuth(p.config.AccountSID, p.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send http request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body failed: %v", err)
+	}
+	if resp.StatusCode >= 300 {
+		var response struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(respBody, &response); err != nil || response.Message == "" {
+			return fmt.Errorf("http request failed with status: %d, response: %s", resp.StatusCode, string(respBody))
+		}
+		return fmt.Errorf("SMS send failed: %s (Code: %d)", response.Message, response.Code)
+	}
+	return nil
+}
+
+// consoleSMSProvider 开发环境使用，只把短信内容打印到日志
+type consoleSMSProvider struct{}
+
+// SendCode 打印验证码
+func (p *consoleSMSProvider) SendCode(phone, code string) error {
+	log.Printf("短信(未发送) To: %s 验证码: %s", phone, code)
+	return nil
+}
+
+// SendTemplate 打印模板和参数
+func (p *consoleSMSProvider) SendTemplate(phone, templateCode string, templateParam map[string]string) error {
+	log.Printf("短信(未发送) To: %s 模板: %s 参数: %v", phone, templateCode, templateParam)
+	return nil
+}
+
+// generateNonce 生成随机字符串
+func generateNonce(length int) string {
+	bytes := make([]byte, length)
+	rand.Read(bytes)
+
+	nonce := make([]string, length)
+	for i, b := range bytes {
+		nonce[i] = fmt.Sprintf("%02x", b)
+	}
+
+	return strings.Join(nonce, "")
+}
+
+// calculateHmacSha1 计算 HMAC-SHA1 签名
+func calculateHmacSha1(message, secret string) string {
+	h := hmac.New(sha1.New, []byte(secret))
+	h.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// hmacSha256 计算 HMAC-SHA256
+func hmacSha256(key []byte, message string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
+
+// sha256Hex 计算 SHA-256 并以十六进制表示
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,157 +1,178 @@
 package services
 
 import (
-	"crypto/hmac"
-	"crypto/rand"
-	"crypto/sha1"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"project/src/config"
-	"sort"
+	"log"
+	"regexp"
 	"strings"
-	"time"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/utils"
 )
 
-// SMSService 短信服务接口
+// ErrSMSSendFailed 所有服务商均发送失败
+var ErrSMSSendFailed = errors.New("短信发送失败")
+
+// SMSService 短信服务接口，手机号支持中国大陆11位号码和 E.164 格式
 type SMSService interface {
 	SendSMS(phone, code string) error
 	SendSMSWithTemplate(phone, templateCode string, templateParam map[string]string) error
 }
 
-// smsService 短信服务实现
-type smsService struct {
-	config config.AliyunSMSConfig
+// SMSProvider 短信服务商接口，phone 为 E.164 格式
+type SMSProvider interface {
+	SendCode(phone, code string) error
+	SendTemplate(phone, templateCode string, templateParam map[string]string) error
 }
 
-// NewSMSService 创建短信服务实例
-func NewSMSService(config config.AliyunSMSConfig) SMSService {
-	return &smsService{
-		config: config,
-	}
+// smsService 按国家码路由的短信服务实现，服务商发送失败时切换到下一个
+type smsService struct {
+	providers map[string]SMSProvider
+	defaults  []string
+	routes    []config.SMSRouteConfig
 }
 
-// SendSMS 发送验证码短信
-func (s *smsService) SendSMS(phone, code string) error {
-	templateParam := map[string]string{
-		"code": code,
-	}
-	return s.SendSMSWithTemplate(phone, s.config.TemplateCode, templateParam)
-}
+// smsRoutePrefixRegex 路由前缀为 + 加1-4位国家码
+var smsRoutePrefixRegex = regexp.MustCompile(`^\+[1-9]\d{0,3}$`)
 
-// SendSMSWithTemplate 发送自定义模板短信
-func (s *smsService) SendSMSWithTemplate(phone, templateCode string, templateParam map[string]string) error {
-	const (
-		apiURL     = "https://dysmsapi.aliyuncs.com/"
-		apiVersion = "2017-05-25"
-	)
-	fmt.Println(s.config)
-
-	// 准备请求参数
-	params := map[string]string{
-		"AccessKeyId":      s.config.AccessKeyID,
-		"Action":           "SendSms",
-		"Format":           "JSON",
-		"PhoneNumbers":     phone,
-		"SignName":         s.config.SignName,
-		"TemplateCode":     templateCode,
-		"Version":          apiVersion,
-		"SignatureMethod":  "HMAC-SHA1",
-		"SignatureVersion": "1.0",
-		"SignatureNonce":   generateNonce(16),
-		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+// NewSMSService 创建短信服务实例，只创建默认服务商和路由中用到的服务商
+// 应用启动时创建一个实例供所有请求共用，kvService 供模拟服务商保存短信收件箱
+func NewSMSService(cfg config.SMSConfig, kvService KVService) SMSService {
+	s := &smsService{
+		providers: make(map[string]SMSProvider),
+		defaults:  cfg.Providers,
+		routes:    cfg.Routes,
 	}
-
-	// 添加模板参数
-	if len(templateParam) > 0 {
-		templateParamJSON, err := json.Marshal(templateParam)
-		if err != nil {
-			return fmt.Errorf("marshal template param failed: %v", err)
-		}
-		params["TemplateParam"] = string(templateParamJSON)
-	}
-
-	// 参数排序
-	var keys []string
-	for key := range params {
-		keys = append(keys, key)
+	names := append([]string{}, cfg.Providers...)
+	for _, route := range cfg.Routes {
+		names = append(names, route.Providers...)
 	}
-	sort.Strings(keys)
-
-	// 构建签名字符串
-	var canonicalizedQueryString []string
-	for _, key := range keys {
-		canonicalizedQueryString = append(canonicalizedQueryString,
-			fmt.Sprintf("%s=%s", url.QueryEscape(key), url.QueryEscape(params[key])))
+	for _, name := range names {
+		if _, ok := s.providers[name]; !ok {
+			if provider := newSMSProvider(name, cfg, kvService); provider != nil {
+				s.providers[name] = provider
+			}
+		}
 	}
+	return s
+}
 
-	queryString := strings.Join(canonicalizedQueryString, "&")
-	stringToSign := fmt.Sprintf("GET&%s&%s",
-		url.QueryEscape("/"),
-		url.QueryEscape(queryString))
-
-	// 计算签名
-	signature := calculateHmacSha1(stringToSign, s.config.AccessKeySecret+"&")
-
-	// 构建最终的 URL
-	finalURL := fmt.Sprintf("%s?%s&Signature=%s",
-		apiURL, queryString, url.QueryEscape(signature))
-
-	// 发送请求
-	resp, err := http.Get(finalURL)
-	if err != nil {
-		return fmt.Errorf("send http request failed: %v", err)
+// newSMSProvider 按名称创建短信服务商，名称不受支持时返回 nil
+func newSMSProvider(name string, cfg config.SMSConfig, kvService KVService) SMSProvider {
+	switch name {
+	case constants.SMSProviderAliyun:
+		return newAliyunSMSProvider(cfg.AliyunSMSConfig)
+	case constants.SMSProviderTencent:
+		return newTencentSMSProvider(cfg.Tencent)
+	case constants.SMSProviderTwilio:
+		return newTwilioSMSProvider(cfg.Twilio)
+	case constants.SMSProviderConsole:
+		return &consoleSMSProvider{}
+	case constants.SMSProviderMock:
+		return newMockSMSProvider(kvService)
+	default:
+		return nil
 	}
-	defer resp.Body.Close()
+}
 
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("read response body failed: %v", err)
+// ValidateSMSConfig 检查短信路由配置，启动时调用，配置有误时返回错误
+// console 和 mock 服务商不真正发送短信，只能在开发模式下使用
+func ValidateSMSConfig(cfg config.SMSConfig, devMode bool) error {
+	if len(cfg.Providers) == 0 {
+		return fmt.Errorf("短信配置缺少默认服务商providers")
 	}
-
-	// 解析响应
-	var response map[string]interface{}
-	if err := json.Unmarshal(body, &response); err != nil {
-		return fmt.Errorf("parse response failed: %v, body: %s", err, string(body))
+	if err := validateSMSProviders(cfg.Providers, devMode); err != nil {
+		return err
 	}
-
-	// 检查响应状态
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("http request failed with status: %d, response: %s", resp.StatusCode, string(body))
+	prefixes := make(map[string]bool)
+	for _, route := range cfg.Routes {
+		if len(route.Prefixes) == 0 || len(route.Providers) == 0 {
+			return fmt.Errorf("短信路由必须同时配置prefixes和providers")
+		}
+		for _, prefix := range route.Prefixes {
+			if !smsRoutePrefixRegex.MatchString(prefix) {
+				return fmt.Errorf("短信路由前缀 %q 无效，应为 + 加国家码，如 +86", prefix)
+			}
+			if prefixes[prefix] {
+				return fmt.Errorf("短信路由前缀 %s 重复", prefix)
+			}
+			prefixes[prefix] = true
+		}
+		if err := validateSMSProviders(route.Providers, devMode); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// 检查业务状态
-	if code, ok := response["Code"].(string); !ok || code != "OK" {
-		message := "Unknown error"
-		if msg, ok := response["Message"].(string); ok {
-			message = msg
+// validateSMSProviders 检查服务商名称是否受支持，非开发模式下不能使用 console 和 mock
+func validateSMSProviders(names []string, devMode bool) error {
+	for _, name := range names {
+		switch name {
+		case constants.SMSProviderAliyun, constants.SMSProviderTencent, constants.SMSProviderTwilio:
+		case constants.SMSProviderConsole, constants.SMSProviderMock:
+			if !devMode {
+				return fmt.Errorf("短信服务商 %s 不真正发送短信，只能在开发模式 dev_mode 下使用", name)
+			}
+		default:
+			return fmt.Errorf("短信服务商 %q 不受支持，可选 aliyun、tencent、twilio、console、mock", name)
 		}
-		return fmt.Errorf("SMS send failed: %s (Code: %s)", message, code)
 	}
-
 	return nil
 }
 
-// generateNonce 生成随机字符串
-func generateNonce(length int) string {
-	bytes := make([]byte, length)
-	rand.Read(bytes)
+// SendSMS 发送验证码短信
+func (s *smsService) SendSMS(phone, code string) error {
+	return s.send(phone, func(provider SMSProvider, phone string) error {
+		return provider.SendCode(phone, code)
+	})
+}
+
+// SendSMSWithTemplate 发送自定义模板短信，模板编号需在路由到的服务商中均有效
+func (s *smsService) SendSMSWithTemplate(phone, templateCode string, templateParam map[string]string) error {
+	return s.send(phone, func(provider SMSProvider, phone string) error {
+		return provider.SendTemplate(phone, templateCode, templateParam)
+	})
+}
 
-	nonce := make([]string, length)
-	for i, b := range bytes {
-		nonce[i] = fmt.Sprintf("%02x", b)
+// send 将手机号统一为 E.164 格式，按路由依次尝试服务商，直到有一个发送成功
+func (s *smsService) send(phone string, send func(provider SMSProvider, phone string) error) error {
+	phone, err := utils.NormalizePhone(phone)
+	if err != nil {
+		return err
 	}
 
-	return strings.Join(nonce, "")
+	var errs []error
+	for _, name := range s.route(phone) {
+		provider, ok := s.providers[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: 未知的短信服务商", name))
+			continue
+		}
+		if err := send(provider, phone); err != nil {
+			log.Printf("短信服务商 %s 发送失败: %v", name, err)
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("%w: 没有可用的短信服务商", ErrSMSSendFailed)
+	}
+	return fmt.Errorf("%w: %v", ErrSMSSendFailed, errors.Join(errs...))
 }
 
-// calculateHmacSha1 计算 HMAC-SHA1 签名
-func calculateHmacSha1(message, secret string) string {
-	h := hmac.New(sha1.New, []byte(secret))
-	h.Write([]byte(message))
-	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+// route 返回手机号对应的服务商列表，匹配最长的国家码前缀，没有匹配时使用默认服务商
+func (s *smsService) route(phone string) []string {
+	providers, longest := s.defaults, 0
+	for _, route := range s.routes {
+		for _, prefix := range route.Prefixes {
+			if len(prefix) > longest && strings.HasPrefix(phone, prefix) {
+				providers, longest = route.Providers, len(prefix)
+			}
+		}
+	}
+	return providers
 }
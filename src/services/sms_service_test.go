@@ -0,0 +1,240 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+	"project/src/utils"
+)
+
+// fakeSMSProvider 记录发送的短信，可模拟发送失败
+type fakeSMSProvider struct {
+	err  error
+	sent []string
+}
+
+func (p *fakeSMSProvider) SendCode(phone, code string) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.sent = append(p.sent, phone+":"+code)
+	return nil
+}
+
+func (p *fakeSMSProvider) SendTemplate(phone, templateCode string, templateParam map[string]string) error {
+	return p.SendCode(phone, templateParam["code"])
+}
+
+func TestSMSRoutingAndFailover(t *testing.T) {
+	aliyun, tencent, twilio := &fakeSMSProvider{}, &fakeSMSProvider{}, &fakeSMSProvider{}
+	s := &smsService{
+		providers: map[string]SMSProvider{"aliyun": aliyun, "tencent": tencent, "twilio": twilio},
+		defaults:  []string{"twilio"},
+		routes: []config.SMSRouteConfig{
+			{Prefixes: []string{"+86"}, Providers: []string{"aliyun", "tencent"}},
+			{Prefixes: []string{"+8"}, Providers: []string{"tencent"}},
+		},
+	}
+
+	// 不带国家码的号码按中国大陆号码路由，匹配最长的前缀
+	if err := s.SendSMS("138 0013 8000", "123456"); err != nil {
+		t.Fatalf("发送短信失败: %v", err)
+	}
+	if len(aliyun.sent) != 1 || aliyun.sent[0] != "+8613800138000:123456" || len(tencent.sent) != 0 {
+		t.Errorf("中国大陆号码应由aliyun发送: aliyun=%v tencent=%v", aliyun.sent, tencent.sent)
+	}
+
+	// 前一个服务商失败时切换到下一个
+	aliyun.err = errors.New("quota exceeded")
+	if err := s.SendSMS("+8613800138000", "654321"); err != nil {
+		t.Fatalf("切换服务商后应发送成功: %v", err)
+	}
+	if len(tencent.sent) != 1 || tencent.sent[0] != "+8613800138000:654321" {
+		t.Errorf("aliyun失败后应由tencent发送: %v", tencent.sent)
+	}
+
+	// 较短的前缀和默认服务商
+	s.SendSMS("+85291234567", "111111")
+	s.SendSMS("0014155550100", "222222")
+	if len(tencent.sent) != 2 || len(twilio.sent) != 1 || twilio.sent[0] != "+14155550100:222222" {
+		t.Errorf("路由不正确: tencent=%v twilio=%v", tencent.sent, twilio.sent)
+	}
+
+	// 全部失败时返回 ErrSMSSendFailed
+	tencent.err = errors.New("unavailable")
+	if err := s.SendSMS("13800138000", "123456"); !errors.Is(err, ErrSMSSendFailed) {
+		t.Errorf("所有服务商失败应返回ErrSMSSendFailed，实际为%v", err)
+	}
+	if err := s.SendSMS("12345", "123456"); !errors.Is(err, utils.ErrInvalidPhone) {
+		t.Errorf("无效手机号应返回ErrInvalidPhone，实际为%v", err)
+	}
+}
+
+func TestTwilioSMSProvider(t *testing.T) {
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "AC123" || pass != "token" || r.URL.Path != "/AC123/Messages.json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code": 20003, "message": "Authenticate"}`))
+			return
+		}
+		r.ParseForm()
+		form = map[string]string{"To": r.PostForm.Get("To"), "From": r.PostForm.Get("From"), "Body": r.PostForm.Get("Body")}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM123"}`))
+	}))
+	defer server.Close()
+
+	p := newTwilioSMSProvider(config.TwilioSMSConfig{
+		AccountSID: "AC123", AuthToken: "token", From: "+15005550006", CodeTemplate: "Code: ${code}",
+	})
+	p.endpoint, p.httpClient = server.URL+"/", server.Client()
+	if err := p.SendCode("+14155550100", "123456"); err != nil {
+		t.Fatalf("发送短信失败: %v", err)
+	}
+	if form["To"] != "+14155550100" || form["From"] != "+15005550006" || form["Body"] != "Code: 123456" {
+		t.Errorf("请求参数不正确: %v", form)
+	}
+
+	p.config.AuthToken = "wrong"
+	if err := p.SendCode("+14155550100", "123456"); err == nil {
+		t.Errorf("认证失败应返回错误")
+	}
+}
+
+func TestValidateSMSConfig(t *testing.T) {
+	valid := config.SMSConfig{
+		Providers: []string{"aliyun"},
+		Routes:    []config.SMSRouteConfig{{Prefixes: []string{"+86", "+852"}, Providers: []string{"tencent", "console"}}},
+	}
+	if err := ValidateSMSConfig(valid, true); err != nil {
+		t.Errorf("有效配置不应报错: %v", err)
+	}
+
+	// console 和 mock 不真正发送短信，非开发模式下无论用作默认服务商还是路由服务商都应报错
+	if err := ValidateSMSConfig(valid, false); err == nil {
+		t.Errorf("非开发模式下路由使用console应报错")
+	}
+	if err := ValidateSMSConfig(config.SMSConfig{Providers: []string{"mock"}}, false); err == nil {
+		t.Errorf("非开发模式下默认服务商使用mock应报错")
+	}
+	production := config.SMSConfig{
+		Providers: []string{"aliyun"},
+		Routes:    []config.SMSRouteConfig{{Prefixes: []string{"+1"}, Providers: []string{"twilio"}}},
+	}
+	if err := ValidateSMSConfig(production, false); err != nil {
+		t.Errorf("非开发模式下真实服务商不应报错: %v", err)
+	}
+
+	invalid := []config.SMSConfig{
+		{},
+		{Providers: []string{"unknown"}},
+		{Providers: []string{"aliyun"}, Routes: []config.SMSRouteConfig{{Prefixes: []string{"86"}, Providers: []string{"aliyun"}}}},
+		{Providers: []string{"aliyun"}, Routes: []config.SMSRouteConfig{{Prefixes: []string{"+1"}}}},
+		{Providers: []string{"aliyun"}, Routes: []config.SMSRouteConfig{
+			{Prefixes: []string{"+1"}, Providers: []string{"twilio"}},
+			{Prefixes: []string{"+1"}, Providers: []string{"console"}},
+		}},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateSMSConfig(cfg, true); err == nil {
+			t.Errorf("无效配置应报错: %+v", cfg)
+		}
+	}
+}
+
+func TestLoginWithInternationalPhone(t *testing.T) {
+	s, _ := setupUserTestService(t)
+
+	if err := s.SetSMSCode("+852 9123 4567", "123456"); err != nil {
+		t.Fatalf("保存验证码失败: %v", err)
+	}
+	userData, err := s.Login("0085291234567", "123456", models.LoginClient{LoginType: constants.LoginTypePhone})
+	if err != nil {
+		t.Fatalf("国际手机号登录失败: %v", err)
+	}
+	if userData.User.Phone != "+85291234567" || userData.User.Nickname != "用户4567" {
+		t.Errorf("手机号应保存为E.164格式: %+v", userData.User)
+	}
+
+	// 不带国家码的中国大陆号码与 +86 号码为同一身份
+	s.SetSMSCode("13800138000", "111111")
+	first, err := s.Login("13800138000", "111111", models.LoginClient{LoginType: constants.LoginTypePhone})
+	if err != nil {
+		t.Fatalf("中国大陆手机号登录失败: %v", err)
+	}
+	s.SetSMSCode("+8613800138000", "222222")
+	second, err := s.Login("+8613800138000", "222222", models.LoginClient{LoginType: constants.LoginTypePhone})
+	if err != nil || second.User.ID != first.User.ID {
+		t.Errorf("+86号码应登录同一账号: %+v, %v", second, err)
+	}
+
+	if _, err := s.Login("+86123", "123456", models.LoginClient{}); !errors.Is(err, utils.ErrInvalidPhone) {
+		t.Errorf("无效手机号应返回ErrInvalidPhone，实际为%v", err)
+	}
+}
+
+func TestMockSMSProviderInbox(t *testing.T) {
+	kv := newMemoryKVService()
+	s := &smsService{
+		providers: map[string]SMSProvider{"mock": newMockSMSProvider(kv)},
+		defaults:  []string{"mock"},
+	}
+	for i := 0; i < constants.SMSInboxSize+5; i++ {
+		if err := s.SendSMS("13800138000", fmt.Sprintf("%06d", i)); err != nil {
+			t.Fatalf("发送短信失败: %v", err)
+		}
+	}
+	s.SendSMSWithTemplate("+85291234567", "SMS_1", map[string]string{"name": "张三"})
+
+	inbox := &smsInbox{kvService: kv}
+	messages, err := inbox.List("138 0013 8000")
+	if err != nil {
+		t.Fatalf("读取收件箱失败: %v", err)
+	}
+	latest := messages["+8613800138000"]
+	if len(latest) != constants.SMSInboxSize || latest[0].Code != fmt.Sprintf("%06d", constants.SMSInboxSize+4) {
+		t.Errorf("收件箱应按时间倒序保留最近%d条: %+v", constants.SMSInboxSize, latest)
+	}
+
+	all, err := inbox.List("")
+	if err != nil || len(all) != 2 || all["+85291234567"][0].TemplateParam["name"] != "张三" {
+		t.Errorf("应返回所有手机号的短信: %+v, %v", all, err)
+	}
+	if _, err := inbox.List("123"); !errors.Is(err, utils.ErrInvalidPhone) {
+		t.Errorf("无效手机号应返回ErrInvalidPhone，实际为%v", err)
+	}
+}
+
+func TestMockSMSProviderConcurrentSend(t *testing.T) {
+	kv := newMemoryKVService()
+	// 所有请求共用一个短信服务实例，并发发送到同一手机号时不丢失记录
+	s := NewSMSService(config.SMSConfig{Providers: []string{"mock"}}, kv)
+
+	var wg sync.WaitGroup
+	for i := 0; i < constants.SMSInboxSize; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := s.SendSMS("13800138000", fmt.Sprintf("%06d", i)); err != nil {
+				t.Errorf("发送短信失败: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	messages, err := NewSMSInbox(kv).List("13800138000")
+	if err != nil {
+		t.Fatalf("读取收件箱失败: %v", err)
+	}
+	if got := len(messages["+8613800138000"]); got != constants.SMSInboxSize {
+		t.Errorf("收件箱应有%d条短信，实际%d条", constants.SMSInboxSize, got)
+	}
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/utils"
+
+	openai "github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+)
+
+const (
+	// schedulerLeaderKey 多实例部署时调度主节点锁
+	schedulerLeaderKey = "scheduler:leader"
+	// dueTasksBatchSize 每次轮询最多处理的任务数
+	dueTasksBatchSize = 50
+	// taskExecutionTimeout 单次任务执行超时时间
+	taskExecutionTimeout = 2 * time.Minute
+	// taskStaleAfter 任务处于执行中超过该时长视为实例崩溃遗留，可被重新抢占
+	taskStaleAfter = 2 * taskExecutionTimeout
+)
+
+// TaskRunner 定时任务执行器
+// 每个实例都会启动轮询，但只有持有Redis主节点锁的实例会真正执行到期任务
+// 主节点锁只用于减少无效轮询，每次触发是否执行以数据库中的抢占结果为准
+// 生成的发言只写入执行记录（TaskExecution.Output），通过执行历史接口查看，不会推送到群聊
+type TaskRunner struct {
+	repo       repository.SchedulerRepository
+	db         *gorm.DB
+	kvService  KVService
+	instanceID string
+	interval   time.Duration
+	lockTTL    time.Duration
+	generate   func(task *models.Task) (string, error)
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewTaskRunner 创建定时任务执行器
+func NewTaskRunner(kvService KVService) *TaskRunner {
+	interval := time.Duration(config.AppConfig.Scheduler.TickInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	lockTTL := time.Duration(config.AppConfig.Scheduler.LockTTL) * time.Second
+	if lockTTL <= interval {
+		lockTTL = 3 * interval
+	}
+
+	hostname, _ := os.Hostname()
+	r := &TaskRunner{
+		repo:       repository.NewSchedulerRepository(),
+		db:         config.GetDB(),
+		kvService:  kvService,
+		instanceID: fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		interval:   interval,
+		lockTTL:    lockTTL,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	r.generate = r.generatePost
+	return r
+}
+
+// Start 启动调度轮询
+func (r *TaskRunner) Start() {
+	log.Printf("定时任务调度已启动: instance=%s, interval=%s", r.instanceID, r.interval)
+	go r.loop()
+}
+
+// Stop 停止调度轮询并释放主节点锁
+func (r *TaskRunner) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+		if _, err := r.kvService.CompareAndDelete(schedulerLeaderKey, r.instanceID); err != nil {
+			log.Printf("释放调度主节点锁失败: %v", err)
+		}
+	})
+}
+
+// loop 调度主循环
+func (r *TaskRunner) loop() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.tick()
+	for {
+		select {
+		case <-ticker.C:
+			r.tick()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// tick 执行一次调度检查
+func (r *TaskRunner) tick() {
+	if !r.acquireLeader() {
+		return
+	}
+
+	now := time.Now()
+	tasks, err := r.repo.GetDueTasks(now, dueTasksBatchSize)
+	if err != nil {
+		log.Printf("获取待执行任务失败: %v", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+
+		// 执行耗时可能超过锁的有效期，每个任务开始前续期，失去主节点身份后停止本轮执行
+		if i > 0 && !r.acquireLeader() {
+			return
+		}
+
+		claimed, err := r.claimTask(task, time.Now())
+		if err != nil {
+			log.Printf("抢占任务[%d]失败: %v", task.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := r.ExecuteTask(task, models.TaskTriggerSchedule); err != nil {
+			log.Printf("任务[%d]执行失败: %v", task.ID, err)
+		}
+	}
+}
+
+// claimTask 在数据库中抢占任务的本次触发并推进下一次执行时间，避免执行期间或实例崩溃后重复触发
+// 只有 next_run_at 未被其他实例推进的任务才能抢占成功
+func (r *TaskRunner) claimTask(task *models.Task, now time.Time) (bool, error) {
+	if task.NextRunAt == nil {
+		return false, nil
+	}
+
+	var nextRunAt *time.Time
+	if next, err := utils.NextCronTime(task.CronExpr, now); err == nil {
+		nextRunAt = &next
+	} else {
+		log.Printf("任务[%d]Cron表达式无效，已停止调度: %v", task.ID, err)
+	}
+
+	claimed, err := r.repo.ClaimTask(task.ID, *task.NextRunAt, now, nextRunAt, now.Add(-taskStaleAfter))
+	if err != nil || !claimed {
+		return false, err
+	}
+	task.NextRunAt = nextRunAt
+	return true, nil
+}
+
+// acquireLeader 获取或续期调度主节点锁，续期时只有锁仍属于当前实例才会成功
+func (r *TaskRunner) acquireLeader() bool {
+	ok, err := r.kvService.SetNX(schedulerLeaderKey, r.instanceID, r.lockTTL)
+	if err != nil {
+		log.Printf("获取调度主节点锁失败: %v", err)
+		return false
+	}
+	if ok {
+		return true
+	}
+
+	// 当前实例已是主节点，续期锁
+	ok, err = r.kvService.CompareAndSet(schedulerLeaderKey, r.instanceID, r.instanceID, r.lockTTL)
+	if err != nil {
+		log.Printf("续期调度主节点锁失败: %v", err)
+		return false
+	}
+	return ok
+}
+
+// ExecuteTask 执行任务并记录执行历史
+func (r *TaskRunner) ExecuteTask(task *models.Task, trigger string) (*models.TaskExecution, error) {
+	execution := &models.TaskExecution{
+		TaskID:    task.ID,
+		Trigger:   trigger,
+		Instance:  r.instanceID,
+		StartTime: time.Now(),
+		Status:    models.ExecutionStatusRunning,
+	}
+	if err := r.repo.SaveExecution(execution); err != nil {
+		return nil, err
+	}
+
+	output, runErr := r.generate(task)
+
+	endTime := time.Now()
+	execution.EndTime = &endTime
+	taskStatus := models.TaskStatusCompleted
+	if runErr != nil {
+		execution.Status = models.ExecutionStatusFailed
+		execution.Log = runErr.Error()
+		taskStatus = models.TaskStatusFailed
+	} else {
+		execution.Status = models.ExecutionStatusSuccess
+		execution.Output = output
+		execution.Log = fmt.Sprintf("执行成功，耗时%s", endTime.Sub(execution.StartTime).Round(time.Millisecond))
+	}
+
+	if err := r.repo.UpdateExecution(execution); err != nil {
+		log.Printf("更新任务[%d]执行记录失败: %v", task.ID, err)
+	}
+	if err := r.repo.UpdateTaskRunState(task.ID, taskStatus, &execution.StartTime, task.NextRunAt); err != nil {
+		log.Printf("更新任务[%d]状态失败: %v", task.ID, err)
+	}
+
+	return execution, runErr
+}
+
+// generatePost 调用角色对应的模型生成发言内容
+func (r *TaskRunner) generatePost(task *models.Task) (string, error) {
+	var group models.LlmGroup
+	if err := r.db.First(&group, task.GID).Error; err != nil {
+		return "", fmt.Errorf("获取群组失败: %v", err)
+	}
+
+	var character models.GroupCharacter
+	if err := r.db.Where("id = ? AND gid = ?", task.CharacterID, task.GID).First(&character).Error; err != nil {
+		return "", fmt.Errorf("获取角色失败: %v", err)
+	}
+
+	provider := config.AppConfig.LLMModels[character.Model]
+	if provider == "" {
+		return "", errors.New("model not found:" + character.Model)
+	}
+	apiKey := config.AppConfig.LLMProviders[provider].APIKey
+	baseURL := config.AppConfig.LLMProviders[provider].BaseURL
+	if apiKey == "" || baseURL == "" {
+		return "", errors.New("api key is empty, model:" + character.Model)
+	}
+
+	aconfig := openai.DefaultConfig(apiKey)
+	aconfig.BaseURL = baseURL
+	client := openai.NewClientWithConfig(aconfig)
+
+	// 与聊天接口保持一致的系统提示词拼接规则
+	llmSystemPrompt := strings.Replace(config.AppConfig.LLMSystemPrompt, "#name#", character.Name, -1)
+	systemPrompt := strings.Replace(character.CustomPrompt, "#groupName#", group.Name, -1) + "\n" + llmSystemPrompt
+
+	ctx, cancel := context.WithTimeout(context.Background(), taskExecutionTimeout)
+	defer cancel()
+
+	completion, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: character.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: task.Prompt,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("调用模型失败: %v", err)
+	}
+
+	if len(completion.Choices) == 0 {
+		return "", errors.New("模型未返回内容")
+	}
+
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
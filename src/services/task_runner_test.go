@@ -0,0 +1,271 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/utils"
+)
+
+// setupTaskRunnerTest 创建群组、角色和指定数量的到期任务
+func setupTaskRunnerTest(t *testing.T, taskCount int) []models.Task {
+	t.Helper()
+	setupGroupTestDB(t)
+
+	// SQLite 内存库并发写入时会返回表锁错误，测试中串行使用连接
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		t.Fatalf("获取数据库连接失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	s := NewGroupService()
+	group := mustCreateGroup(t, s, 1, "定时发言", "")
+	character, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{
+		GID: group.ID, Name: "播报员", Model: "qwen-plus", Avatar: "/img/robot.png",
+	})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+
+	repo := repository.NewSchedulerRepository()
+	dueAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	tasks := make([]models.Task, 0, taskCount)
+	for i := 0; i < taskCount; i++ {
+		task := models.Task{
+			OwnerID: 1, GID: group.ID, CharacterID: character.ID, Name: "早间新闻",
+			Prompt: "总结今天的新闻", CronExpr: "0 8 * * *", Enabled: true,
+			Status: models.TaskStatusPending, NextRunAt: &dueAt,
+		}
+		if err := repo.SaveTask(&task); err != nil {
+			t.Fatalf("创建任务失败: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// newTestTaskRunner 创建不调用模型的执行器，并统计每个任务的执行次数
+func newTestTaskRunner(kv KVService, runs *sync.Map) *TaskRunner {
+	r := NewTaskRunner(kv)
+	r.generate = func(task *models.Task) (string, error) {
+		count, _ := runs.LoadOrStore(task.ID, new(atomic.Int32))
+		count.(*atomic.Int32).Add(1)
+		return "早上好", nil
+	}
+	return r
+}
+
+func TestNextCronTime(t *testing.T) {
+	from := time.Date(2026, 3, 2, 7, 30, 0, 0, time.Local)
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 8 * * *", time.Date(2026, 3, 2, 8, 0, 0, 0, time.Local)},
+		{"30 9 * * 1-5", time.Date(2026, 3, 2, 9, 30, 0, 0, time.Local)},
+		{"@daily", time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local)},
+		{"@every 2h", from.Add(2 * time.Hour)},
+	}
+	for _, c := range cases {
+		got, err := utils.NextCronTime(c.expr, from)
+		if err != nil || !got.Equal(c.want) {
+			t.Errorf("%s: 期望%v，实际%v, %v", c.expr, c.want, got, err)
+		}
+	}
+
+	if _, err := utils.NextCronTime("61 * * * *", from); !errors.Is(err, utils.ErrInvalidCronExpr) {
+		t.Errorf("无效表达式应返回ErrInvalidCronExpr: %v", err)
+	}
+}
+
+func TestClaimTaskOnlyOnce(t *testing.T) {
+	task := setupTaskRunnerTest(t, 1)[0]
+	repo := repository.NewSchedulerRepository()
+
+	now := time.Now()
+	next := now.Add(time.Hour)
+	staleBefore := now.Add(-taskStaleAfter)
+	claimed, err := repo.ClaimTask(task.ID, *task.NextRunAt, now, &next, staleBefore)
+	if err != nil || !claimed {
+		t.Fatalf("首次抢占应成功: %v, %v", claimed, err)
+	}
+
+	// 另一个实例持有同一份过期快照，next_run_at 已被推进，抢占失败
+	claimed, err = repo.ClaimTask(task.ID, *task.NextRunAt, now, &next, staleBefore)
+	if err != nil || claimed {
+		t.Fatalf("重复抢占应失败: %v, %v", claimed, err)
+	}
+
+	// 执行中的任务即使到期也不能再次抢占
+	claimed, _ = repo.ClaimTask(task.ID, next, now, nil, staleBefore)
+	if claimed {
+		t.Fatalf("执行中的任务不应被抢占")
+	}
+
+	// 执行中超时的任务视为实例崩溃遗留，可以重新抢占
+	claimed, _ = repo.ClaimTask(task.ID, next, now, nil, now.Add(time.Second))
+	if !claimed {
+		t.Fatalf("执行超时的任务应能重新抢占")
+	}
+}
+
+func TestTaskRunnersRunEachDueTaskOnce(t *testing.T) {
+	tasks := setupTaskRunnerTest(t, 5)
+	kv := newMemoryKVService()
+
+	var runs sync.Map
+	runners := []*TaskRunner{newTestTaskRunner(kv, &runs), newTestTaskRunner(kv, &runs)}
+
+	for round := 0; round < 3; round++ {
+		var wg sync.WaitGroup
+		for _, r := range runners {
+			wg.Add(1)
+			go func(r *TaskRunner) {
+				defer wg.Done()
+				r.tick()
+			}(r)
+		}
+		wg.Wait()
+	}
+
+	for _, task := range tasks {
+		count, ok := runs.Load(task.ID)
+		if !ok || count.(*atomic.Int32).Load() != 1 {
+			t.Errorf("任务[%d]应执行一次", task.ID)
+		}
+	}
+
+	var executions int64
+	config.DB.Model(&models.TaskExecution{}).Where("status = ?", models.ExecutionStatusSuccess).Count(&executions)
+	if executions != int64(len(tasks)) {
+		t.Errorf("应有%d条成功的执行记录，实际%d", len(tasks), executions)
+	}
+}
+
+func TestTaskRunnersWithoutSharedLock(t *testing.T) {
+	tasks := setupTaskRunnerTest(t, 5)
+
+	// 两个实例各自认为自己是主节点（如KV降级为各自的内存存储），依靠数据库抢占保证不重复执行
+	var runs sync.Map
+	runners := []*TaskRunner{newTestTaskRunner(newMemoryKVService(), &runs), newTestTaskRunner(newMemoryKVService(), &runs)}
+
+	var wg sync.WaitGroup
+	for _, r := range runners {
+		wg.Add(1)
+		go func(r *TaskRunner) {
+			defer wg.Done()
+			r.tick()
+		}(r)
+	}
+	wg.Wait()
+
+	for _, task := range tasks {
+		count, ok := runs.Load(task.ID)
+		if !ok || count.(*atomic.Int32).Load() != 1 {
+			t.Errorf("任务[%d]应执行一次", task.ID)
+		}
+	}
+}
+
+func TestTaskRunnerLeaderLock(t *testing.T) {
+	setupTaskRunnerTest(t, 0)
+	kv := newMemoryKVService()
+	var runs sync.Map
+	leader, follower := newTestTaskRunner(kv, &runs), newTestTaskRunner(kv, &runs)
+
+	if !leader.acquireLeader() {
+		t.Fatalf("首个实例应成为主节点")
+	}
+	if follower.acquireLeader() {
+		t.Fatalf("锁被占用时其他实例不应成为主节点")
+	}
+	if !leader.acquireLeader() {
+		t.Fatalf("主节点应能续期")
+	}
+
+	// 非持有者停止时不能释放主节点锁
+	leader.Start()
+	follower.Start()
+	follower.Stop()
+	if owner, _ := kv.Get(schedulerLeaderKey); owner != leader.instanceID {
+		t.Fatalf("非持有者不应释放锁，当前持有者%q", owner)
+	}
+
+	leader.Stop()
+	if owner, _ := kv.Get(schedulerLeaderKey); owner != "" {
+		t.Fatalf("主节点停止后应释放锁，当前持有者%q", owner)
+	}
+	if !follower.acquireLeader() {
+		t.Fatalf("锁释放后其他实例应能成为主节点")
+	}
+}
+
+func TestTaskRunnerNotLeaderWhileKVDegraded(t *testing.T) {
+	setupTaskRunnerTest(t, 0)
+	var reachable atomic.Bool
+	kv := newUnreachableKVService(t, constants.KVDegradedMemory, &reachable)
+
+	// 降级为各实例独立的内存存储时，所有实例都会拿到锁，因此不应成为主节点
+	var runs sync.Map
+	if newTestTaskRunner(kv, &runs).acquireLeader() {
+		t.Fatalf("KV降级期间不应成为调度主节点")
+	}
+}
+
+func TestUpdateTaskKeepsRunState(t *testing.T) {
+	task := setupTaskRunnerTest(t, 1)[0]
+	repo := repository.NewSchedulerRepository()
+	s := NewTaskService()
+
+	// 更新请求读取任务后，执行器抢占了该任务
+	now := time.Now()
+	claimed, err := repo.ClaimTask(task.ID, *task.NextRunAt, now, nil, now.Add(-taskStaleAfter))
+	if err != nil || !claimed {
+		t.Fatalf("抢占任务失败: %v, %v", claimed, err)
+	}
+
+	updated, err := s.UpdateTask(1, task.ID, &models.TaskUpdateRequest{Name: "晚间新闻"})
+	if err != nil {
+		t.Fatalf("更新任务失败: %v", err)
+	}
+	if updated.Name != "晚间新闻" {
+		t.Errorf("名称应已更新，实际%q", updated.Name)
+	}
+	if updated.Status != models.TaskStatusRunning || updated.LastRunAt == nil {
+		t.Errorf("更新任务不应覆盖执行中的状态，实际%q, %v", updated.Status, updated.LastRunAt)
+	}
+}
+
+func TestUpdateTaskClearDescription(t *testing.T) {
+	task := setupTaskRunnerTest(t, 1)[0]
+	s := NewTaskService()
+
+	if _, err := s.UpdateTask(1, task.ID, &models.TaskUpdateRequest{Description: models.PatchValue("每天早上八点")}); err != nil {
+		t.Fatalf("更新描述失败: %v", err)
+	}
+	// 未出现 description 字段时保留原描述
+	updated, err := s.UpdateTask(1, task.ID, &models.TaskUpdateRequest{Prompt: "总结昨天的新闻"})
+	if err != nil || updated.Description != "每天早上八点" {
+		t.Fatalf("未修改描述时应保留原值，实际%q, %v", updated.Description, err)
+	}
+
+	updated, err = s.UpdateTask(1, task.ID, &models.TaskUpdateRequest{Description: models.PatchValue("")})
+	if err != nil || updated.Description != "" {
+		t.Errorf("空字符串应清空描述，实际%q, %v", updated.Description, err)
+	}
+	if _, err := s.UpdateTask(1, task.ID, &models.TaskUpdateRequest{Description: models.PatchValue("每天早上八点")}); err != nil {
+		t.Fatalf("更新描述失败: %v", err)
+	}
+	updated, err = s.UpdateTask(1, task.ID, &models.TaskUpdateRequest{Description: models.PatchNull[string]()})
+	if err != nil || updated.Description != "" {
+		t.Errorf("null 应清空描述，实际%q, %v", updated.Description, err)
+	}
+}
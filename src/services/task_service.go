@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+	"project/src/utils"
+
+	"gorm.io/gorm"
+)
+
+// 定时任务业务错误
+var (
+	ErrTaskForbidden       = errors.New("无权操作该任务")
+	ErrTaskLimitExceeded   = errors.New("定时任务数量已达上限")
+	ErrTaskGroupNotFound   = errors.New("指定的群组不存在")
+	ErrTaskCharacterNotFit = errors.New("指定的角色不存在或不属于该群组")
+)
+
+// TaskService 定时任务服务接口
+type TaskService interface {
+	CreateTask(ownerID uint, req *models.TaskCreateRequest) (*models.Task, error)
+	ListTasks(ownerID uint, page, pageSize int) ([]models.Task, int64, error)
+	GetTask(ownerID, taskID uint) (*models.Task, error)
+	UpdateTask(ownerID, taskID uint, req *models.TaskUpdateRequest) (*models.Task, error)
+	DeleteTask(ownerID, taskID uint) error
+	ListExecutions(ownerID, taskID uint, page, pageSize int) ([]models.TaskExecution, int64, error)
+}
+
+// taskService 定时任务服务实现
+type taskService struct {
+	repo repository.SchedulerRepository
+	db   *gorm.DB
+}
+
+// NewTaskService 创建定时任务服务实例
+func NewTaskService() TaskService {
+	return &taskService{
+		repo: repository.NewSchedulerRepository(),
+		db:   config.GetDB(),
+	}
+}
+
+// CreateTask 创建定时任务
+func (s *taskService) CreateTask(ownerID uint, req *models.TaskCreateRequest) (*models.Task, error) {
+	if maxTasks := config.AppConfig.Scheduler.MaxTasksPerUser; maxTasks > 0 {
+		count, err := s.repo.CountTasksByOwner(ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= int64(maxTasks) {
+			return nil, ErrTaskLimitExceeded
+		}
+	}
+
+	if err := s.checkTarget(ownerID, req.GID, req.CharacterID); err != nil {
+		return nil, err
+	}
+
+	nextRunAt, err := utils.NextCronTime(req.CronExpr, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{
+		OwnerID:     ownerID,
+		GID:         req.GID,
+		CharacterID: req.CharacterID,
+		Name:        req.Name,
+		Description: req.Description,
+		Prompt:      req.Prompt,
+		CronExpr:    req.CronExpr,
+		Enabled:     true,
+		Status:      models.TaskStatusPending,
+	}
+	if req.Enabled != nil {
+		task.Enabled = *req.Enabled
+	}
+	if task.Enabled {
+		task.NextRunAt = &nextRunAt
+	}
+
+	if err := s.repo.SaveTask(task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// ListTasks 获取用户的定时任务列表
+func (s *taskService) ListTasks(ownerID uint, page, pageSize int) ([]models.Task, int64, error) {
+	return s.repo.GetTasksByOwner(ownerID, page, pageSize)
+}
+
+// GetTask 获取单个定时任务
+func (s *taskService) GetTask(ownerID, taskID uint) (*models.Task, error) {
+	task, err := s.repo.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.OwnerID != ownerID {
+		return nil, ErrTaskForbidden
+	}
+	return task, nil
+}
+
+// UpdateTask 更新定时任务
+func (s *taskService) UpdateTask(ownerID, taskID uint, req *models.TaskUpdateRequest) (*models.Task, error) {
+	task, err := s.GetTask(ownerID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只更新请求中的字段，执行器同时写入的运行状态和执行时间不会被覆盖
+	updates := make(map[string]interface{})
+	if req.CharacterID != 0 && req.CharacterID != task.CharacterID {
+		if err := s.checkTarget(ownerID, task.GID, req.CharacterID); err != nil {
+			return nil, err
+		}
+		updates["character_id"] = req.CharacterID
+	}
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if description, ok := req.Description.Get(); ok {
+		updates["description"] = description
+	}
+	if req.Prompt != "" {
+		updates["prompt"] = req.Prompt
+	}
+	cronExpr, enabled := task.CronExpr, task.Enabled
+	if req.CronExpr != "" {
+		if _, err := utils.ParseCronExpression(req.CronExpr); err != nil {
+			return nil, err
+		}
+		cronExpr = req.CronExpr
+		updates["cron_expr"] = cronExpr
+	}
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+		updates["enabled"] = enabled
+	}
+
+	// 表达式或启用状态变化后重新计算下一次执行时间
+	var nextRunAt *time.Time
+	if enabled {
+		next, err := utils.NextCronTime(cronExpr, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		nextRunAt = &next
+	}
+	updates["next_run_at"] = nextRunAt
+
+	if err := s.repo.UpdateTask(taskID, updates); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTaskByID(taskID)
+}
+
+// DeleteTask 删除定时任务
+func (s *taskService) DeleteTask(ownerID, taskID uint) error {
+	if _, err := s.GetTask(ownerID, taskID); err != nil {
+		return err
+	}
+	return s.repo.DeleteTask(taskID)
+}
+
+// ListExecutions 获取定时任务的执行记录
+func (s *taskService) ListExecutions(ownerID, taskID uint, page, pageSize int) ([]models.TaskExecution, int64, error) {
+	if _, err := s.GetTask(ownerID, taskID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.GetExecutionsByTask(taskID, page, pageSize)
+}
+
+// checkTarget 校验群组存在、属于当前用户且角色属于该群组
+func (s *taskService) checkTarget(ownerID, gid, characterID uint) error {
+	var group models.LlmGroup
+	if err := s.db.First(&group, gid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTaskGroupNotFound
+		}
+		return fmt.Errorf("查询群组失败: %v", err)
+	}
+	if !group.IsOwnedBy(ownerID) {
+		return ErrTaskForbidden
+	}
+
+	var character models.GroupCharacter
+	if err := s.db.Where("id = ? AND gid = ?", characterID, gid).First(&character).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTaskCharacterNotFit
+		}
+		return fmt.Errorf("查询角色失败: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+)
+
+// ListDeletedGroups 获取当前用户回收站中的群组
+func (s *groupService) ListDeletedGroups(userID uint, page, pageSize int) ([]models.LlmGroup, int64, error) {
+	if userID == 0 {
+		return []models.LlmGroup{}, 0, nil
+	}
+	return s.groupRepo.GetDeletedGroups(userID, page, pageSize)
+}
+
+// ListDeletedCharacters 获取当前用户回收站中单独删除的角色，随群组一起删除的角色随群组恢复
+func (s *groupService) ListDeletedCharacters(userID uint, page, pageSize int) ([]models.GroupCharacter, int64, error) {
+	if userID == 0 {
+		return []models.GroupCharacter{}, 0, nil
+	}
+	return s.characterRepo.GetDeletedCharacters(userID, page, pageSize)
+}
+
+// RestoreGroup 从回收站恢复群组，随群组一起删除的角色一并恢复
+func (s *groupService) RestoreGroup(userID, groupID uint) (*models.LlmGroup, error) {
+	group, err := s.groupRepo.GetDeletedGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if !group.IsOwnedBy(userID) {
+		return nil, ErrGroupForbidden
+	}
+	// 删除后可能已创建同名群组
+	if err := s.checkGroupName(group.OwnerID, group.Name, group.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.groupRepo.RestoreGroup(group); err != nil {
+		return nil, err
+	}
+	return s.groupRepo.GetGroupByID(groupID)
+}
+
+// RestoreCharacter 从回收站恢复角色，所属群组须未被删除
+func (s *groupService) RestoreCharacter(userID, characterID uint) (*models.GroupCharacter, error) {
+	character, err := s.characterRepo.GetDeletedCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 || (character.OwnerID != userID && (character.Group == nil || !character.Group.IsOwnedBy(userID))) {
+		return nil, ErrCharacterForbidden
+	}
+	if _, err := s.groupRepo.GetGroupByID(character.GID); err != nil {
+		if errors.Is(err, repository.ErrGroupNotFound) {
+			return nil, ErrGroupDeleted
+		}
+		return nil, err
+	}
+
+	if maxCharacters := config.AppConfig.Group.MaxCharactersPerGroup; maxCharacters > 0 {
+		count, err := s.characterRepo.CountCharactersByGroup(character.GID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= int64(maxCharacters) {
+			return nil, ErrTooManyCharacters
+		}
+	}
+
+	if err := s.characterRepo.RestoreCharacter(characterID); err != nil {
+		return nil, err
+	}
+	return s.characterRepo.GetCharacterByID(characterID)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"project/src/config"
+	"project/src/repository"
+)
+
+// TrashPurger 回收站清理器，定期彻底删除超过保留期的群组和角色
+// 清理操作可重复执行，多实例部署时无需选主
+type TrashPurger struct {
+	groupRepo     repository.LlmGroupRepository
+	characterRepo repository.GroupCharacterRepository
+	retention     time.Duration
+	interval      time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewTrashPurger 创建回收站清理器
+func NewTrashPurger() *TrashPurger {
+	interval := time.Duration(config.AppConfig.Group.TrashPurgeInterval) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &TrashPurger{
+		groupRepo:     repository.NewLlmGroupRepository(),
+		characterRepo: repository.NewGroupCharacterRepository(),
+		retention:     time.Duration(config.AppConfig.Group.TrashRetentionDays) * 24 * time.Hour,
+		interval:      interval,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start 启动定期清理
+func (p *TrashPurger) Start() {
+	log.Printf("回收站清理已启动: retention=%s, interval=%s", p.retention, p.interval)
+	go p.loop()
+}
+
+// Stop 停止定期清理
+func (p *TrashPurger) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+	})
+}
+
+// loop 清理主循环
+func (p *TrashPurger) loop() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.Purge(time.Now())
+	for {
+		select {
+		case <-ticker.C:
+			p.Purge(time.Now())
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Purge 彻底删除在 now 减去保留期之前移入回收站的群组和角色
+func (p *TrashPurger) Purge(now time.Time) {
+	before := now.Add(-p.retention)
+
+	groups, err := p.groupRepo.PurgeDeletedGroups(before)
+	if err != nil {
+		log.Printf("清理回收站群组失败: %v", err)
+	}
+	characters, err := p.characterRepo.PurgeDeletedCharacters(before)
+	if err != nil {
+		log.Printf("清理回收站角色失败: %v", err)
+	}
+	if groups > 0 || characters > 0 {
+		log.Printf("回收站清理完成: 群组%d个, 角色%d个", groups, characters)
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"project/src/config"
+	"project/src/models"
+	"project/src/repository"
+)
+
+func TestDeleteAndRestoreGroup(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	group := mustCreateGroup(t, s, 1, "回收站测试", "")
+
+	kept, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "保留"})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	removed, err := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "先删除"})
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	if err := s.DeleteCharacter(1, removed.ID); err != nil {
+		t.Fatalf("删除角色失败: %v", err)
+	}
+	// 保证角色与群组的删除时间不同
+	time.Sleep(10 * time.Millisecond)
+	if err := s.DeleteGroup(1, group.ID); err != nil {
+		t.Fatalf("删除群组失败: %v", err)
+	}
+
+	if _, err := s.GetGroup(1, group.ID); !errors.Is(err, repository.ErrGroupNotFound) {
+		t.Errorf("已删除的群组应返回ErrGroupNotFound，实际为%v", err)
+	}
+	character, err := s.GetCharacter(1, kept.ID)
+	if err != nil || !character.DeletedAt.Valid {
+		t.Errorf("已删除的角色仍应可查看以便展示历史对话，实际为%+v, %v", character, err)
+	}
+
+	groups, total, _ := s.ListDeletedGroups(1, 1, 10)
+	if total != 1 || groups[0].ID != group.ID {
+		t.Errorf("回收站群组不正确: %+v", groups)
+	}
+	if _, total, _ := s.ListDeletedCharacters(1, 1, 10); total != 0 {
+		t.Errorf("群组已删除时其角色不应单独出现在回收站，实际为%d个", total)
+	}
+
+	if _, err := s.RestoreGroup(2, group.ID); !errors.Is(err, ErrGroupForbidden) {
+		t.Errorf("其他用户恢复群组应返回ErrGroupForbidden，实际为%v", err)
+	}
+	if _, err := s.RestoreGroup(1, group.ID); err != nil {
+		t.Fatalf("恢复群组失败: %v", err)
+	}
+	_, characters, _, _ := s.ListCharactersByGroup(1, group.ID, 1, 10)
+	if len(characters) != 1 || characters[0].ID != kept.ID {
+		t.Errorf("恢复群组应只恢复随群组删除的角色，实际为%+v", characters)
+	}
+
+	deleted, total, _ := s.ListDeletedCharacters(1, 1, 10)
+	if total != 1 || deleted[0].ID != removed.ID {
+		t.Fatalf("回收站角色不正确: %+v", deleted)
+	}
+	if _, err := s.RestoreCharacter(1, removed.ID); err != nil {
+		t.Fatalf("恢复角色失败: %v", err)
+	}
+	if _, err := s.RestoreCharacter(1, removed.ID); !errors.Is(err, repository.ErrCharacterNotFound) {
+		t.Errorf("重复恢复应返回ErrCharacterNotFound，实际为%v", err)
+	}
+}
+
+func TestRestoreChecks(t *testing.T) {
+	setupGroupTestDB(t)
+	s := NewGroupService()
+	group := mustCreateGroup(t, s, 1, "同名群组", "")
+	character, _ := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "角色"})
+
+	if err := s.DeleteCharacter(1, character.ID); err != nil {
+		t.Fatalf("删除角色失败: %v", err)
+	}
+	if err := s.DeleteGroup(1, group.ID); err != nil {
+		t.Fatalf("删除群组失败: %v", err)
+	}
+	if _, err := s.RestoreCharacter(1, character.ID); !errors.Is(err, ErrGroupDeleted) {
+		t.Errorf("群组已删除时恢复角色应返回ErrGroupDeleted，实际为%v", err)
+	}
+
+	mustCreateGroup(t, s, 1, "同名群组", "")
+	if _, err := s.RestoreGroup(1, group.ID); !errors.Is(err, ErrGroupNameExists) {
+		t.Errorf("已有同名群组时恢复应返回ErrGroupNameExists，实际为%v", err)
+	}
+}
+
+func TestTrashPurge(t *testing.T) {
+	setupGroupTestDB(t)
+	config.AppConfig.Group.TrashRetentionDays = 30
+	s := NewGroupService()
+
+	group := mustCreateGroup(t, s, 1, "待清理", "")
+	character, _ := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: group.ID, Name: "角色"})
+	alive := mustCreateGroup(t, s, 1, "保留群组", "")
+	single, _ := s.CreateCharacter(1, &models.GroupCharacterCreateRequest{GID: alive.ID, Name: "单独删除"})
+
+	if err := s.DeleteGroup(1, group.ID); err != nil {
+		t.Fatalf("删除群组失败: %v", err)
+	}
+	if err := s.DeleteCharacter(1, single.ID); err != nil {
+		t.Fatalf("删除角色失败: %v", err)
+	}
+
+	purger := NewTrashPurger()
+	purger.Purge(time.Now())
+	if _, total, _ := s.ListDeletedGroups(1, 1, 10); total != 1 {
+		t.Fatalf("未超过保留期的群组不应被清理")
+	}
+
+	purger.Purge(time.Now().Add(31 * 24 * time.Hour))
+	if _, total, _ := s.ListDeletedGroups(1, 1, 10); total != 0 {
+		t.Errorf("超过保留期的群组应被清理")
+	}
+	if _, total, _ := s.ListDeletedCharacters(1, 1, 10); total != 0 {
+		t.Errorf("超过保留期的角色应被清理")
+	}
+	if _, err := s.GetCharacter(1, character.ID); !errors.Is(err, repository.ErrCharacterNotFound) {
+		t.Errorf("清理后角色应不存在，实际为%v", err)
+	}
+	if count, _ := repository.NewRevisionRepository().CountRevisions(models.RevisionEntityGroup, group.ID); count != 0 {
+		t.Errorf("清理后修订记录应一并删除，实际剩余%d条", count)
+	}
+	if _, err := s.GetGroup(1, alive.ID); err != nil {
+		t.Errorf("未删除的群组不应受影响: %v", err)
+	}
+}
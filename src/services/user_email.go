@@ -0,0 +1,288 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+	"project/src/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 邮箱登录错误
+var (
+	ErrInvalidEmail       = errors.New("无效的邮箱地址")
+	ErrInvalidPassword    = fmt.Errorf("密码长度必须在%d-%d个字符之间", constants.MinPasswordLength, constants.MaxPasswordLength)
+	ErrInvalidCredentials = errors.New("邮箱或密码错误")
+	ErrInvalidEmailToken  = errors.New("链接无效或已过期")
+	ErrEmailTooFrequent   = errors.New("邮件发送过于频繁，请稍后再试")
+)
+
+// dummyPasswordHash 邮箱不存在时也进行一次 bcrypt 比较，避免通过响应时间判断邮箱是否已注册
+var dummyPasswordHash = []byte("$2a$10$Pf7U4a6XFE5d7U8WOikWiuDYxHjeKvHcd/rQbJ2AQ9IhQWo92n4Om")
+
+// RegisterWithEmail 邮箱注册，注册信息暂存在KV存储中，用户点击验证邮件中的链接后才创建账号
+// 邮箱已注册时向该邮箱发送账号已存在的提醒，返回结果与未注册时相同，避免暴露邮箱是否已注册
+func (s *userService) RegisterWithEmail(email, password, nickname string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	if err := s.reserveEmailSend(email); err != nil {
+		return err
+	}
+
+	// 查找账号前生成密码摘要，已注册和未注册的邮箱响应时间相同
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("生成密码摘要失败: %v", err)
+	}
+	if _, err := s.identityRepo.GetIdentity(constants.LoginTypeEmail, email); err == nil {
+		if err := s.mailer.Send(email, "您已注册账号",
+			"您好，有人使用该邮箱申请注册，但该邮箱已注册账号，请直接登录。\n\n如果忘记密码，可以通过找回密码设置新密码；如果不是您本人操作，请忽略此邮件。"); err != nil {
+			return fmt.Errorf("发送邮件失败: %v", err)
+		}
+		return nil
+	} else if !errors.Is(err, repository.ErrIdentityNotFound) {
+		return fmt.Errorf("查找用户失败: %v", err)
+	}
+	if nickname == "" {
+		nickname = defaultEmailNickname(email)
+	}
+	pending, err := json.Marshal(models.PendingEmailRegistration{Email: email, PasswordHash: string(hash), Nickname: nickname})
+	if err != nil {
+		return fmt.Errorf("序列化注册信息失败: %v", err)
+	}
+	return s.sendEmailLink(email, constants.EmailVerifyPrefix, string(pending), constants.EmailVerifyTTL,
+		constants.EmailVerifyPath, "验证您的邮箱",
+		"您好，请点击以下链接完成注册（%s内有效）：\n\n%s\n\n如果不是您本人操作，请忽略此邮件。")
+}
+
+// VerifyEmail 校验注册验证链接，创建账号并签发令牌
+func (s *userService) VerifyEmail(token string, client models.LoginClient) (*models.UserData, error) {
+	data, err := s.takeEmailToken(constants.EmailVerifyPrefix, token)
+	if err != nil {
+		return nil, err
+	}
+	var pending models.PendingEmailRegistration
+	if err := json.Unmarshal([]byte(data), &pending); err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+
+	// 验证链接发出后，该邮箱可能已通过免密登录创建了账号，此时链接作废
+	if _, err := s.identityRepo.GetIdentity(constants.LoginTypeEmail, pending.Email); err == nil {
+		return nil, ErrInvalidEmailToken
+	} else if !errors.Is(err, repository.ErrIdentityNotFound) {
+		return nil, fmt.Errorf("查找用户失败: %v", err)
+	}
+
+	// 账号和密码在同一个事务中创建，不会留下没有密码的账号
+	user, err := s.userRepo.CreateUserWithPassword(pending.Nickname, pending.PasswordHash, &models.UserIdentity{Provider: constants.LoginTypeEmail, Subject: pending.Email})
+	if err != nil {
+		return nil, fmt.Errorf("创建用户失败: %v", err)
+	}
+
+	client.LoginType = constants.LoginTypeEmail
+	return s.IssueTokens(user, client)
+}
+
+// LoginWithPassword 邮箱密码登录
+func (s *userService) LoginWithPassword(email, password string, client models.LoginClient) (*models.UserData, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	identity, err := s.identityRepo.GetIdentity(constants.LoginTypeEmail, email)
+	if err != nil {
+		if !errors.Is(err, repository.ErrIdentityNotFound) {
+			return nil, fmt.Errorf("查找用户失败: %v", err)
+		}
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	user, err := s.userRepo.GetUserByID(identity.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	// 通过免密登录创建的账号没有密码，需要先找回密码设置
+	if user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.userRepo.UpdateLastLoginTime(user.ID); err != nil {
+		return nil, fmt.Errorf("更新登录时间失败: %v", err)
+	}
+	client.LoginType = constants.LoginTypeEmail
+	return s.IssueTokens(user, client)
+}
+
+// RequestPasswordReset 发送重置密码邮件，邮箱未注册时不发送也不报错，避免暴露邮箱是否已注册
+// 发送间隔在查找账号前检查，已注册和未注册的邮箱频繁请求时返回相同的错误
+func (s *userService) RequestPasswordReset(email string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := s.reserveEmailSend(email); err != nil {
+		return err
+	}
+	if _, err := s.identityRepo.GetIdentity(constants.LoginTypeEmail, email); err != nil {
+		if errors.Is(err, repository.ErrIdentityNotFound) {
+			return nil
+		}
+		return fmt.Errorf("查找用户失败: %v", err)
+	}
+
+	return s.sendEmailLink(email, constants.PasswordResetPrefix, email, constants.PasswordResetTTL,
+		constants.PasswordResetPath, "重置密码",
+		"您好，请点击以下链接设置新密码（%s内有效）：\n\n%s\n\n如果不是您本人操作，请忽略此邮件，您的密码不会改变。")
+}
+
+// ResetPassword 校验重置密码链接并设置新密码，该账号的全部登录会话随即失效
+func (s *userService) ResetPassword(token, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	email, err := s.takeEmailToken(constants.PasswordResetPrefix, token)
+	if err != nil {
+		return err
+	}
+	identity, err := s.identityRepo.GetIdentity(constants.LoginTypeEmail, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrIdentityNotFound) {
+			return ErrInvalidEmailToken
+		}
+		return fmt.Errorf("查找用户失败: %v", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("生成密码摘要失败: %v", err)
+	}
+	if err := s.userRepo.UpdateUserPassword(identity.UserID, string(hash)); err != nil {
+		return err
+	}
+	_, err = s.sessionRepo.RevokeOtherSessions(identity.UserID, "")
+	return err
+}
+
+// SendMagicLink 发送免密登录邮件，邮箱未注册时点击链接后自动创建账号
+func (s *userService) SendMagicLink(email string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := s.reserveEmailSend(email); err != nil {
+		return err
+	}
+	return s.sendEmailLink(email, constants.MagicLinkPrefix, email, constants.MagicLinkTTL,
+		constants.MagicLinkPath, "登录链接",
+		"您好，请点击以下链接登录（%s内有效，只能使用一次）：\n\n%s\n\n如果不是您本人操作，请忽略此邮件。")
+}
+
+// LoginWithMagicLink 校验免密登录链接，按邮箱查找或创建用户并签发令牌
+func (s *userService) LoginWithMagicLink(token string, client models.LoginClient) (*models.UserData, error) {
+	email, err := s.takeEmailToken(constants.MagicLinkPrefix, token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.findOrCreateUser(constants.LoginTypeEmail, email, defaultEmailNickname(email))
+	if err != nil {
+		return nil, err
+	}
+	client.LoginType = constants.LoginTypeEmail
+	return s.IssueTokens(user, client)
+}
+
+// reserveEmailSend 占用邮箱的发送间隔，同一邮箱在发送间隔内只能发送一次
+func (s *userService) reserveEmailSend(email string) error {
+	ok, err := s.kvService.SetNX(constants.EmailSendLockPrefix+email, "1", constants.EmailSendInterval)
+	if err != nil {
+		return fmt.Errorf("检查发送频率失败: %v", err)
+	}
+	if !ok {
+		return ErrEmailTooFrequent
+	}
+	return nil
+}
+
+// sendEmailLink 生成一次性链接令牌，将 value 以令牌摘要为键保存到KV存储，并发送包含链接的邮件
+// body 中的两个 %s 依次为有效期和链接；调用前应先通过 reserveEmailSend 检查发送间隔
+func (s *userService) sendEmailLink(email, prefix, value string, ttl time.Duration, path, subject, body string) error {
+	token, err := randomToken(constants.EmailTokenBytes)
+	if err != nil {
+		return fmt.Errorf("生成链接失败: %v", err)
+	}
+	if err := s.kvService.Set(prefix+hashRefreshToken(token), value, ttl); err != nil {
+		return fmt.Errorf("保存链接失败: %v", err)
+	}
+
+	link := strings.TrimSuffix(config.AppConfig.Email.LinkBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+	if err := s.mailer.Send(email, subject, fmt.Sprintf(body, formatTTL(ttl), link)); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return nil
+}
+
+// takeEmailToken 一次性取出链接令牌对应的值，不存在或已过期时返回 ErrInvalidEmailToken
+// 读取和删除是同一个原子操作，同一链接并发提交时只有一个请求能取到
+func (s *userService) takeEmailToken(prefix, token string) (string, error) {
+	if token == "" {
+		return "", ErrInvalidEmailToken
+	}
+	value, err := s.kvService.GetDel(prefix + hashRefreshToken(token))
+	if err != nil {
+		return "", fmt.Errorf("读取链接失败: %v", err)
+	}
+	if value == "" {
+		return "", ErrInvalidEmailToken
+	}
+	return value, nil
+}
+
+// normalizeEmail 校验邮箱格式并统一为小写，不接受带显示名称的地址
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > 100 {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(email), nil
+}
+
+// validatePassword 校验密码长度，bcrypt 只使用前72字节
+func validatePassword(password string) error {
+	if len(password) < constants.MinPasswordLength || len(password) > constants.MaxPasswordLength {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// defaultEmailNickname 使用邮箱@前的部分作为默认昵称
+func defaultEmailNickname(email string) string {
+	return truncateRunes(email[:strings.Index(email, "@")], 50)
+}
+
+// formatTTL 将链接有效期格式化为邮件中展示的文字
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d小时", int(ttl.Hours()))
+	}
+	return fmt.Sprintf("%d分钟", int(ttl.Minutes()))
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+)
+
+// recordingMailer 记录发送的邮件，测试中代替真实的邮件发送器
+type recordingMailer struct {
+	mutex sync.Mutex
+	sent  []sentMail
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// lastToken 取出最近一封邮件中链接的 token 参数
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatalf("没有发送邮件")
+	}
+	link := regexp.MustCompile(`\S+\?token=\S+`).FindString(m.sent[len(m.sent)-1].body)
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Query().Get("token") == "" {
+		t.Fatalf("邮件中没有链接: %s", m.sent[len(m.sent)-1].body)
+	}
+	return parsed.Query().Get("token")
+}
+
+// clearSendLock 清除邮件发送间隔限制
+func clearSendLock(s *userService, email string) {
+	s.kvService.Delete(constants.EmailSendLockPrefix + email)
+}
+
+func TestEmailRegisterVerifyAndLogin(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	mailer := s.mailer.(*recordingMailer)
+
+	if err := s.RegisterWithEmail("not-an-email", "password123", ""); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("无效邮箱应返回ErrInvalidEmail，实际为%v", err)
+	}
+	if err := s.RegisterWithEmail("alice@example.com", "short", ""); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("过短的密码应返回ErrInvalidPassword，实际为%v", err)
+	}
+	if err := s.RegisterWithEmail("Alice@Example.com", "password123", ""); err != nil {
+		t.Fatalf("邮箱注册失败: %v", err)
+	}
+	if err := s.RegisterWithEmail("alice@example.com", "password123", ""); !errors.Is(err, ErrEmailTooFrequent) {
+		t.Errorf("发送间隔内重复发送应返回ErrEmailTooFrequent，实际为%v", err)
+	}
+
+	// 验证前不能登录
+	if _, err := s.LoginWithPassword("alice@example.com", "password123", models.LoginClient{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("未验证的邮箱不能登录，实际为%v", err)
+	}
+
+	token := mailer.lastToken(t)
+	userData, err := s.VerifyEmail(token, models.LoginClient{})
+	if err != nil {
+		t.Fatalf("验证邮箱失败: %v", err)
+	}
+	if userData.User.Nickname != "alice" || userData.Token == "" {
+		t.Errorf("注册结果不正确: %+v", userData)
+	}
+	if _, err := s.VerifyEmail(token, models.LoginClient{}); !errors.Is(err, ErrInvalidEmailToken) {
+		t.Errorf("验证链接只能使用一次，实际为%v", err)
+	}
+
+	if _, err := s.LoginWithPassword("alice@example.com", "wrong-password", models.LoginClient{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("错误的密码应返回ErrInvalidCredentials，实际为%v", err)
+	}
+	loggedIn, err := s.LoginWithPassword("ALICE@example.com", "password123", models.LoginClient{})
+	if err != nil || loggedIn.User.ID != userData.User.ID {
+		t.Fatalf("密码登录失败: %v", err)
+	}
+
+	// 已注册的邮箱返回相同的结果，只向该邮箱发送账号已存在的提醒
+	clearSendLock(s, "alice@example.com")
+	sent := len(mailer.sent)
+	if err := s.RegisterWithEmail("alice@example.com", "password123", ""); err != nil {
+		t.Errorf("已注册的邮箱应返回与未注册时相同的结果，实际为%v", err)
+	}
+	if len(mailer.sent) != sent+1 || mailer.sent[sent].to != "alice@example.com" || strings.Contains(mailer.sent[sent].body, "token=") {
+		t.Errorf("已注册的邮箱应收到不含注册链接的提醒邮件: %+v", mailer.sent[sent:])
+	}
+	if err := s.RegisterWithEmail("alice@example.com", "password123", ""); !errors.Is(err, ErrEmailTooFrequent) {
+		t.Errorf("已注册的邮箱发送间隔应与未注册时相同，实际为%v", err)
+	}
+
+	// 验证前该邮箱已通过免密登录注册，验证链接作废
+	if err := s.RegisterWithEmail("carol@example.com", "password123", ""); err != nil {
+		t.Fatalf("邮箱注册失败: %v", err)
+	}
+	verifyToken := mailer.lastToken(t)
+	clearSendLock(s, "carol@example.com")
+	if err := s.SendMagicLink("carol@example.com"); err != nil {
+		t.Fatalf("发送免密登录邮件失败: %v", err)
+	}
+	if _, err := s.LoginWithMagicLink(mailer.lastToken(t), models.LoginClient{}); err != nil {
+		t.Fatalf("免密登录失败: %v", err)
+	}
+	if _, err := s.VerifyEmail(verifyToken, models.LoginClient{}); !errors.Is(err, ErrInvalidEmailToken) {
+		t.Errorf("邮箱已注册时验证链接应失效，实际为%v", err)
+	}
+}
+
+func TestPasswordResetAndMagicLink(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	mailer := s.mailer.(*recordingMailer)
+
+	// 未注册的邮箱不发送重置邮件，也不报错
+	if err := s.RequestPasswordReset("bob@example.com"); err != nil || len(mailer.sent) != 0 {
+		t.Fatalf("未注册的邮箱不应发送重置邮件: %v", err)
+	}
+	// 发送间隔与已注册的邮箱相同，不能据此判断邮箱是否已注册
+	if err := s.RequestPasswordReset("bob@example.com"); !errors.Is(err, ErrEmailTooFrequent) {
+		t.Fatalf("未注册的邮箱频繁请求也应返回ErrEmailTooFrequent，实际为%v", err)
+	}
+
+	// 免密登录自动创建账号，此时没有密码
+	clearSendLock(s, "bob@example.com")
+	if err := s.SendMagicLink("bob@example.com"); err != nil {
+		t.Fatalf("发送免密登录邮件失败: %v", err)
+	}
+	magic, err := s.LoginWithMagicLink(mailer.lastToken(t), models.LoginClient{})
+	if err != nil {
+		t.Fatalf("免密登录失败: %v", err)
+	}
+	if _, err := s.LoginWithPassword("bob@example.com", "password123", models.LoginClient{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("未设置密码的账号不能密码登录，实际为%v", err)
+	}
+
+	// 找回密码设置新密码，已登录的会话全部失效
+	clearSendLock(s, "bob@example.com")
+	if err := s.RequestPasswordReset("bob@example.com"); err != nil {
+		t.Fatalf("发送重置密码邮件失败: %v", err)
+	}
+	if err := s.ResetPassword(mailer.lastToken(t), "new-password"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if _, err := s.ValidateToken(magic.Token); err == nil {
+		t.Errorf("重置密码后原有会话应失效")
+	}
+	userData, err := s.LoginWithPassword("bob@example.com", "new-password", models.LoginClient{})
+	if err != nil || userData.User.ID != magic.User.ID {
+		t.Fatalf("使用新密码登录失败: %v", err)
+	}
+
+	// 已有账号再次免密登录不会创建新用户
+	clearSendLock(s, "bob@example.com")
+	if err := s.SendMagicLink("bob@example.com"); err != nil {
+		t.Fatalf("发送免密登录邮件失败: %v", err)
+	}
+	again, err := s.LoginWithMagicLink(mailer.lastToken(t), models.LoginClient{})
+	if err != nil || again.User.ID != magic.User.ID {
+		t.Errorf("免密登录应登录到已有账号: %v", err)
+	}
+}
+
+func TestEmailTokenUsedOnce(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	mailer := s.mailer.(*recordingMailer)
+
+	if err := s.SendMagicLink("carol@example.com"); err != nil {
+		t.Fatalf("发送免密登录邮件失败: %v", err)
+	}
+	token := mailer.lastToken(t)
+
+	// 同一链接并发提交，只有一个请求能登录
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	succeeded := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.takeEmailToken(constants.MagicLinkPrefix, token); err == nil {
+				mutex.Lock()
+				succeeded++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("链接应只能使用一次，实际成功%d次", succeeded)
+	}
+}
+
+func TestValidateEmailConfig(t *testing.T) {
+	smtp := config.EmailConfig{Driver: "smtp", From: "群聊 <noreply@example.com>"}
+	smtp.SMTP.Host = "smtp.example.com"
+
+	cases := []struct {
+		cfg     config.EmailConfig
+		devMode bool
+		valid   bool
+	}{
+		{smtp, false, true},
+		{config.EmailConfig{Driver: "smtp", From: "noreply@example.com"}, false, false},
+		{config.EmailConfig{}, false, false},
+		{config.EmailConfig{Driver: "log"}, false, false},
+		{config.EmailConfig{Driver: "file"}, false, false},
+		{config.EmailConfig{}, true, true},
+		{config.EmailConfig{Driver: "log"}, true, true},
+		{config.EmailConfig{Driver: "sendmail"}, true, false},
+	}
+	for _, tc := range cases {
+		if err := ValidateEmailConfig(tc.cfg, tc.devMode); (err == nil) != tc.valid {
+			t.Errorf("driver=%q dev_mode=%v 期望有效=%v，实际错误为%v", tc.cfg.Driver, tc.devMode, tc.valid, err)
+		}
+	}
+}
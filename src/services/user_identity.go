@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"project/src/constants"
+	"project/src/models"
+	"project/src/repository"
+)
+
+// 登录身份绑定与账号合并错误
+var (
+	ErrIdentityBoundToOther = errors.New("该手机号或微信已绑定其他账号，可通过账号合并将两个账号合并")
+	ErrIdentityAlreadyBound = errors.New("该手机号或微信已绑定当前账号")
+	ErrLastIdentity         = errors.New("至少需要保留一种登录方式")
+	ErrMergeSameAccount     = errors.New("不能合并同一个账号")
+	ErrInvalidMergeToken    = errors.New("待合并账号的登录凭证无效")
+)
+
+// ListIdentities 获取用户绑定的登录身份
+func (s *userService) ListIdentities(userID uint) ([]models.UserIdentity, error) {
+	return s.identityRepo.GetUserIdentities(userID)
+}
+
+// BindPhone 校验短信验证码后为用户绑定手机号
+func (s *userService) BindPhone(userID uint, phone, code string) (*models.UserIdentity, error) {
+	phone, err := s.verifySMSCode(phone, code)
+	if err != nil {
+		return nil, err
+	}
+	return s.bindIdentity(userID, constants.LoginTypePhone, phone)
+}
+
+// BindWechat 为用户绑定微信，由绑定二维码的扫码回调调用
+func (s *userService) BindWechat(userID uint, openID string) (*models.UserIdentity, error) {
+	return s.bindIdentity(userID, constants.LoginTypeWechat, openID)
+}
+
+// UnbindIdentity 解绑用户的登录身份，至少保留一种登录方式
+func (s *userService) UnbindIdentity(userID, id uint) error {
+	identity, err := s.identityRepo.GetIdentityByID(id)
+	if err != nil {
+		return err
+	}
+	// 不暴露其他用户的登录身份是否存在
+	if identity.UserID != userID {
+		return repository.ErrIdentityNotFound
+	}
+
+	identities, err := s.identityRepo.GetUserIdentities(userID)
+	if err != nil {
+		return err
+	}
+	if len(identities) <= 1 {
+		return ErrLastIdentity
+	}
+	return s.identityRepo.DeleteIdentity(identity)
+}
+
+// MergeAccount 将另一个账号合并到当前账号，sourceToken 为另一个账号的访问令牌，用于证明用户同时拥有两个账号
+// 合并后另一个账号的群组、角色、定时任务、点赞收藏和登录身份归当前账号所有，另一个账号不能再登录
+func (s *userService) MergeAccount(userID uint, sourceToken string) (*models.AccountMergeResult, error) {
+	source, err := s.ValidateToken(sourceToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMergeToken, err)
+	}
+	if source.ID == userID {
+		return nil, ErrMergeSameAccount
+	}
+	return s.userRepo.MergeUsers(source.ID, userID)
+}
+
+// bindIdentity 为用户绑定登录身份，身份已属于其他用户时需要先合并账号
+func (s *userService) bindIdentity(userID uint, provider, subject string) (*models.UserIdentity, error) {
+	existing, err := s.identityRepo.GetIdentity(provider, subject)
+	if err == nil {
+		if existing.UserID == userID {
+			return nil, ErrIdentityAlreadyBound
+		}
+		return nil, ErrIdentityBoundToOther
+	}
+	if !errors.Is(err, repository.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	identity := &models.UserIdentity{UserID: userID, Provider: provider, Subject: subject}
+	if err := s.identityRepo.CreateIdentity(identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
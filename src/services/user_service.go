@@ -1,65 +1,100 @@
 package services
 
 import (
-	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
-	"encoding/json"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"project/src/auth"
 	"project/src/config"
+	"project/src/constants"
 	"project/src/models"
 	"project/src/repository"
+	"project/src/utils"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// JWTHeader JWT 头部
-type JWTHeader struct {
-	Alg string `json:"alg"`
-	Typ string `json:"typ"`
-}
-
-// JWTPayload JWT 负载
-type JWTPayload struct {
-	UserID string `json:"userId"`
-	Exp    int64  `json:"exp"`
-	Iat    int64  `json:"iat"`
-}
+// 登录令牌错误
+var (
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，登录会话已失效，请重新登录")
+	ErrSessionRevoked      = errors.New("登录会话已失效，请重新登录")
+	ErrUserDisabled        = errors.New("用户已被禁用")
+	ErrInvalidSMSCode      = errors.New("验证码错误或已过期")
+)
 
 // UserService 用户服务接口
 type UserService interface {
-	Login(phone, code string) (*models.UserData, error)
+	Login(phone, code string, client models.LoginClient) (*models.UserData, error)
 	ValidateToken(token string) (*models.User, error)
+	ValidateSession(token string) (*models.User, string, error)
+	RefreshToken(refreshToken string) (*models.UserData, error)
+	Logout(accessToken, refreshToken string) error
+	ListSessions(userID uint, currentSessionID string) ([]models.UserSession, error)
+	RevokeUserSession(userID, id uint) error
+	RevokeOtherSessions(userID uint, currentSessionID string) (int64, error)
+	ListIdentities(userID uint) ([]models.UserIdentity, error)
+	BindPhone(userID uint, phone, code string) (*models.UserIdentity, error)
+	BindWechat(userID uint, openID string) (*models.UserIdentity, error)
+	UnbindIdentity(userID, id uint) error
+	MergeAccount(userID uint, sourceToken string) (*models.AccountMergeResult, error)
+	CreateAPIKey(userID uint, req models.APIKeyCreateRequest) (*models.APIKeyCreateResult, error)
+	ListAPIKeys(userID uint) ([]models.APIKey, error)
+	RevokeAPIKey(userID, id uint) error
+	ValidateAPIKey(key string) (*models.User, *models.APIKey, error)
 	SetSMSCode(phone, code string) error
 	UpdateNickname(userID uint, nickname string) error
 	UpdateAvatar(userID uint, avatarURL string) error
 	GetUserByID(userID uint) (*models.User, error)
 
 	// 微信登录相关方法
-	LoginWithWechat(openID, nickname, avatarURL, qrScene string) (*models.UserData, error)
+	LoginWithWechat(openID, nickname, avatarURL, qrScene string, client models.LoginClient) (*models.UserData, error)
+	LoginWithOAuth(provider string, info *models.OAuthUserInfo, client models.LoginClient) (*models.UserData, error)
+
+	// 邮箱登录相关方法
+	RegisterWithEmail(email, password, nickname string) error
+	VerifyEmail(token string, client models.LoginClient) (*models.UserData, error)
+	LoginWithPassword(email, password string, client models.LoginClient) (*models.UserData, error)
+	RequestPasswordReset(email string) error
+	ResetPassword(token, password string) error
+	SendMagicLink(email string) error
+	LoginWithMagicLink(token string, client models.LoginClient) (*models.UserData, error)
+	EnsureWechatUser(openID, nickname, avatarURL, qrScene string) (*models.User, error)
 	GetWechatUserByOpenID(openID string) (*models.WechatUser, error)
-	CreateWechatUser(openID, nickname, avatarURL, qrScene string, userID uint) (*models.WechatUser, error)
-	GenerateJWTToken(userID uint) (string, int, error)
+	CreateWechatUser(openID, nickname, avatarURL, qrScene string) (*models.WechatUser, error)
+	IssueTokens(user *models.User, client models.LoginClient) (*models.UserData, error)
 }
 
 // userService 用户服务实现
 type userService struct {
-	userRepo       repository.UserRepository
-	wechatUserRepo repository.WechatUserRepository
-	kvService      KVService
-	jwtSecret      string
+	userRepo         repository.UserRepository
+	wechatUserRepo   repository.WechatUserRepository
+	identityRepo     repository.UserIdentityRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	sessionRepo      repository.UserSessionRepository
+	apiKeyRepo       repository.APIKeyRepository
+	kvService        KVService
+	mailer           Mailer
+	tokenIssuer      auth.TokenIssuer
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(jwtSecret string, redisConfig config.RedisConfig) UserService {
+func NewUserService(kvService KVService) UserService {
 	return &userService{
-		userRepo:       repository.NewUserRepository(),
-		wechatUserRepo: repository.NewWechatUserRepository(),
-		kvService:      NewKVService(redisConfig),
-		jwtSecret:      jwtSecret,
+		userRepo:         repository.NewUserRepository(),
+		wechatUserRepo:   repository.NewWechatUserRepository(),
+		identityRepo:     repository.NewUserIdentityRepository(),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(),
+		sessionRepo:      repository.NewUserSessionRepository(),
+		apiKeyRepo:       repository.NewAPIKeyRepository(),
+		kvService:        kvService,
+		mailer:           NewMailer(config.AppConfig.Email),
+		tokenIssuer:      auth.GetTokenIssuer(),
 	}
 }
 func (s *userService) GetUserByID(userID uint) (*models.User, error) {
@@ -67,114 +102,254 @@ func (s *userService) GetUserByID(userID uint) (*models.User, error) {
 }
 
 // Login 用户登录
-func (s *userService) Login(phone, code string) (*models.UserData, error) {
-	// 验证手机号格式
-	if !s.isValidPhone(phone) {
-		return nil, fmt.Errorf("无效的手机号码")
+func (s *userService) Login(phone, code string, client models.LoginClient) (*models.UserData, error) {
+	phone, err := s.verifySMSCode(phone, code)
+	if err != nil {
+		return nil, err
 	}
 
-	// 验证验证码格式
-	if !s.isValidCode(code) {
-		return nil, fmt.Errorf("验证码格式错误")
+	// 查询手机号对应的用户，不存在时创建新用户
+	nickname := fmt.Sprintf("用户%s", phone[len(phone)-4:]) // 使用手机号后4位作为昵称
+	user, err := s.findOrCreateUser(constants.LoginTypePhone, phone, nickname)
+	if err != nil {
+		return nil, err
 	}
 
-	// 开发环境验证码检查
-	// 使用 GO_ENV 环境变量判断是否为开发环境（在 docker-compose.dev.yaml 中设置）
-	goEnv := os.Getenv("GO_ENV")
-	isDevEnv := goEnv == "development"
+	// 签发访问令牌和刷新令牌
+	return s.IssueTokens(user, client)
+}
 
-	if isDevEnv && code == "888888" {
-		// 开发环境使用固定验证码 888888，跳过正常验证流程
-		fmt.Printf("开发环境使用固定验证码: %s (GO_ENV=%s)\n", code, goEnv)
-	} else {
-		// 正常环境或非固定验证码，进行正常验证
-		storedCode, err := s.kvService.Get(fmt.Sprintf("sms:%s", phone))
-		if err != nil {
-			return nil, fmt.Errorf("获取验证码失败: %v", err)
-		}
+// ValidateToken 验证JWT token
+func (s *userService) ValidateToken(token string) (*models.User, error) {
+	user, _, err := s.ValidateSession(token)
+	return user, err
+}
 
-		if storedCode == "" || storedCode != code {
-			return nil, fmt.Errorf("验证码错误或已过期")
-		}
+// ValidateSession 验证JWT token，同时返回其所属的登录会话ID（升级前签发的令牌没有会话ID）
+func (s *userService) ValidateSession(token string) (*models.User, string, error) {
+	// 去除 Bearer 前缀
+	if strings.HasPrefix(token, "Bearer ") {
+		token = strings.TrimPrefix(token, "Bearer ")
 	}
 
-	// 查询用户是否存在
-	user, err := s.userRepo.GetUserByPhone(phone)
+	// 验证 token
+	claims, err := s.tokenIssuer.Validate(token)
 	if err != nil {
-		// 用户不存在，创建新用户
-		nickname := fmt.Sprintf("用户%s", phone[7:]) // 使用手机号后4位作为昵称
-		user, err = s.userRepo.CreateUser(phone, "", nickname)
+		return nil, "", fmt.Errorf("无效的token: %v", err)
+	}
+
+	// 检查登录会话是否已退出或被吊销，并记录最近活跃时间
+	if claims.SessionID != "" {
+		session, err := s.sessionRepo.GetSessionBySessionID(claims.SessionID)
 		if err != nil {
-			return nil, fmt.Errorf("创建用户失败: %v", err)
+			if errors.Is(err, repository.ErrUserSessionNotFound) {
+				return nil, "", ErrSessionRevoked
+			}
+			return nil, "", fmt.Errorf("检查登录会话失败: %v", err)
 		}
-	} else {
-		// 用户存在，更新登录时间
-		err = s.userRepo.UpdateLastLoginTime(user.ID)
-		if err != nil {
-			return nil, fmt.Errorf("更新登录时间失败: %v", err)
+		if session.RevokedAt != nil {
+			return nil, "", ErrSessionRevoked
+		}
+		if err := s.sessionRepo.TouchSession(session.SessionID, time.Now()); err != nil {
+			// 更新失败不影响本次请求，只记录错误
+			fmt.Printf("更新登录会话活跃时间失败: %v\n", err)
 		}
+	}
 
-		// 重新获取用户信息（确保获取到更新后的时间）
-		user, err = s.userRepo.GetUserByPhone(phone)
-		if err != nil {
-			return nil, fmt.Errorf("获取用户信息失败: %v", err)
+	// 获取用户信息
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("无效的用户ID: %v", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(uint(userID))
+	if err != nil {
+		return nil, "", fmt.Errorf("用户不存在: %v", err)
+	}
+	if user.Status != models.UserStatusActive {
+		return nil, "", ErrUserDisabled
+	}
+
+	return user, claims.SessionID, nil
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌，旧的刷新令牌随即作废
+// 已作废的刷新令牌被再次使用时，吊销其所属的整个登录会话
+func (s *userService) RefreshToken(refreshToken string) (*models.UserData, error) {
+	token, err := s.refreshTokenRepo.GetRefreshTokenByHash(hashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if token.UsedAt != nil {
+		return nil, s.revokeReusedSession(token.SessionID)
+	}
+
+	user, err := s.userRepo.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("用户不存在: %v", err)
+	}
+	if user.Status != models.UserStatusActive {
+		if err := s.revokeSession(token.SessionID); err != nil {
+			return nil, err
 		}
+		return nil, ErrUserDisabled
 	}
 
-	// 生成 JWT token
-	token, err := s.generateToken(strconv.Itoa(int(user.ID)))
+	next, plain, err := newRefreshToken(user.ID, token.SessionID)
 	if err != nil {
-		return nil, fmt.Errorf("生成token失败: %v", err)
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.RotateRefreshToken(token, next); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenUsed) {
+			return nil, s.revokeReusedSession(token.SessionID)
+		}
+		return nil, err
+	}
+	if err := s.sessionRepo.ExtendSession(token.SessionID, next.ExpiresAt); err != nil {
+		return nil, err
 	}
 
-	// 删除验证码
-	err = s.kvService.Delete(fmt.Sprintf("sms:%s", phone))
+	accessToken, expiresIn, err := s.generateAccessToken(user.ID, token.SessionID)
 	if err != nil {
-		// 删除失败不影响登录流程，只记录错误
-		fmt.Printf("删除验证码失败: %v\n", err)
+		return nil, fmt.Errorf("生成token失败: %v", err)
 	}
+	return &models.UserData{
+		Token:        accessToken,
+		RefreshToken: plain,
+		ExpiresIn:    expiresIn,
+		User:         user,
+	}, nil
+}
 
-	// 返回用户数据
-	userData := &models.UserData{
-		Token: token,
-		User:  user,
+// Logout 退出登录，吊销访问令牌和刷新令牌所属的登录会话
+// 两个令牌任一有效即可，已过期的访问令牌同样可以用于退出
+func (s *userService) Logout(accessToken, refreshToken string) error {
+	sessions := make(map[string]bool, 2)
+	if accessToken != "" {
+		if claims, err := s.tokenIssuer.Parse(strings.TrimPrefix(accessToken, "Bearer ")); err == nil && claims.SessionID != "" {
+			sessions[claims.SessionID] = true
+		}
+	}
+	if refreshToken != "" {
+		token, err := s.refreshTokenRepo.GetRefreshTokenByHash(hashRefreshToken(refreshToken))
+		if err != nil && !errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return err
+		}
+		if token != nil {
+			sessions[token.SessionID] = true
+		}
+	}
+	if len(sessions) == 0 {
+		return ErrInvalidRefreshToken
 	}
 
-	return userData, nil
+	for sessionID := range sessions {
+		if err := s.revokeSession(sessionID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// ValidateToken 验证JWT token
-func (s *userService) ValidateToken(token string) (*models.User, error) {
-	// 去除 Bearer 前缀
-	if strings.HasPrefix(token, "Bearer ") {
-		token = strings.TrimPrefix(token, "Bearer ")
+// IssueTokens 为用户创建新的登录会话，签发访问令牌和刷新令牌
+func (s *userService) IssueTokens(user *models.User, client models.LoginClient) (*models.UserData, error) {
+	if user.Status != models.UserStatusActive {
+		return nil, ErrUserDisabled
 	}
 
-	// 验证 token
-	payload, err := s.validateToken(token)
+	sessionID, err := randomToken(16)
 	if err != nil {
-		return nil, fmt.Errorf("无效的token: %v", err)
+		return nil, fmt.Errorf("生成会话ID失败: %v", err)
 	}
-
-	fmt.Println("payload", payload)
-	// 获取用户信息
-	userID, err := strconv.ParseUint(payload.UserID, 10, 64)
+	token, plain, err := newRefreshToken(user.ID, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("无效的用户ID: %v", err)
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.UserSession{
+		SessionID:  sessionID,
+		UserID:     user.ID,
+		LoginType:  client.LoginType,
+		Device:     utils.DescribeUserAgent(client.UserAgent),
+		UserAgent:  truncateRunes(client.UserAgent, 512),
+		IP:         client.IP,
+		LastSeenAt: now,
+		ExpiresAt:  token.ExpiresAt,
+	}
+	if err := s.sessionRepo.CreateSession(session, token); err != nil {
+		return nil, err
 	}
 
-	user, err := s.userRepo.GetUserByID(uint(userID))
+	accessToken, expiresIn, err := s.generateAccessToken(user.ID, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("用户不存在: %v", err)
+		return nil, fmt.Errorf("生成token失败: %v", err)
 	}
+	return &models.UserData{
+		Token:        accessToken,
+		RefreshToken: plain,
+		ExpiresIn:    expiresIn,
+		User:         user,
+	}, nil
+}
 
-	return user, nil
+// revokeSession 吊销登录会话，其刷新令牌随即作废，已签发的访问令牌也不再被接受
+func (s *userService) revokeSession(sessionID string) error {
+	return s.sessionRepo.RevokeSession(sessionID)
 }
 
-// isValidPhone 验证手机号格式（中国大陆）
-func (s *userService) isValidPhone(phone string) bool {
-	phoneRegex := regexp.MustCompile(`^1[3-9]\d{9}$`)
-	return phoneRegex.MatchString(phone)
+// revokeReusedSession 刷新令牌被重复使用时吊销整个登录会话
+func (s *userService) revokeReusedSession(sessionID string) error {
+	if err := s.revokeSession(sessionID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// newRefreshToken 生成刷新令牌，返回待保存的记录和明文令牌
+func newRefreshToken(userID uint, sessionID string) (*models.RefreshToken, string, error) {
+	plain, err := randomToken(constants.RefreshTokenBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("生成刷新令牌失败: %v", err)
+	}
+	ttl := time.Duration(config.AppConfig.RefreshTokenTTL) * time.Second
+	if ttl <= 0 {
+		ttl = 30 * 24 * time.Hour
+	}
+	return &models.RefreshToken{
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenHash: hashRefreshToken(plain),
+		ExpiresAt: time.Now().Add(ttl),
+	}, plain, nil
+}
+
+// hashRefreshToken 计算刷新令牌的摘要
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken 生成指定字节数的随机令牌（Base64URL编码）
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// accessTokenTTL 访问令牌有效期
+func accessTokenTTL() time.Duration {
+	if config.AppConfig.AccessTokenTTL <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(config.AppConfig.AccessTokenTTL) * time.Second
 }
 
 // isValidCode 验证验证码格式（6位数字）
@@ -185,9 +360,16 @@ func (s *userService) isValidCode(code string) bool {
 
 // SetSMSCode 设置短信验证码（用于测试或与短信服务集成）
 func (s *userService) SetSMSCode(phone, code string) error {
+	phone, err := utils.NormalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	// 新验证码重新计算校验次数
+	if err := newSMSPolicy(s.kvService).resetCodeAttempts(phone); err != nil {
+		return err
+	}
 	key := fmt.Sprintf("sms:%s", phone)
-	// 设置5分钟过期时间
-	return s.kvService.Set(key, code, 5*time.Minute)
+	return s.kvService.Set(key, code, constants.SMSCodeTTL)
 }
 
 // UpdateNickname 更新用户昵称
@@ -212,152 +394,123 @@ func (s *userService) UpdateAvatar(userID uint, avatarURL string) error {
 	return s.userRepo.UpdateUserAvatar(userID, avatarURL)
 }
 
-// generateToken 生成 JWT token
-func (s *userService) generateToken(userID string) (string, error) {
-	// 创建头部
-	header := JWTHeader{
-		Alg: "HS256",
-		Typ: "JWT",
-	}
-
-	// 创建负载
-	now := time.Now().Unix()
-	payload := JWTPayload{
-		UserID: userID,
-		Exp:    now + (7 * 24 * 60 * 60), // 7天过期
-		Iat:    now,
-	}
-
-	// Base64URL 编码
-	headerEncoded, err := s.base64URLEncode(header)
-	if err != nil {
-		return "", err
-	}
-
-	payloadEncoded, err := s.base64URLEncode(payload)
+// generateAccessToken 为登录会话生成访问令牌，返回令牌及有效期(秒)
+func (s *userService) generateAccessToken(userID uint, sessionID string) (string, int, error) {
+	ttl := accessTokenTTL()
+	token, err := s.tokenIssuer.Issue(auth.Claims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		SessionID: sessionID,
+	}, ttl)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
+	return token, int(ttl / time.Second), nil
+}
 
-	// 生成签名
-	signature, err := s.generateSignature(headerEncoded + "." + payloadEncoded)
+// LoginWithWechat 微信登录
+func (s *userService) LoginWithWechat(openID, nickname, avatarURL, qrScene string, client models.LoginClient) (*models.UserData, error) {
+	user, err := s.EnsureWechatUser(openID, nickname, avatarURL, qrScene)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// 组合最终的 token
-	token := fmt.Sprintf("%s.%s.%s", headerEncoded, payloadEncoded, signature)
-	return token, nil
+	// 签发访问令牌和刷新令牌
+	return s.IssueTokens(user, client)
 }
 
-// validateToken 验证 JWT token
-func (s *userService) validateToken(token string) (*JWTPayload, error) {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid token format")
-	}
-
-	headerEncoded := parts[0]
-	payloadEncoded := parts[1]
-	signature := parts[2]
-
-	// 验证签名
-	expectedSignature, err := s.generateSignature(headerEncoded + "." + payloadEncoded)
+// LoginWithOAuth 第三方账号登录，与微信登录相同，按登录身份查找或创建用户后签发令牌
+func (s *userService) LoginWithOAuth(provider string, info *models.OAuthUserInfo, client models.LoginClient) (*models.UserData, error) {
+	user, err := s.findOrCreateUser(provider, info.Subject, info.Nickname)
 	if err != nil {
 		return nil, err
 	}
 
-	if signature != expectedSignature {
-		return nil, fmt.Errorf("invalid signature")
-	}
+	// 签发访问令牌和刷新令牌
+	return s.IssueTokens(user, client)
+}
 
-	// 解码负载
-	payload, err := s.base64URLDecode(payloadEncoded)
+// EnsureWechatUser 查找或创建微信用户并更新登录时间，不签发令牌
+// 用于微信服务器回调，令牌由网页端轮询扫码状态时签发
+func (s *userService) EnsureWechatUser(openID, nickname, avatarURL, qrScene string) (*models.User, error) {
+	return s.findOrCreateUser(constants.LoginTypeWechat, openID, nickname)
+}
+
+// findOrCreateUser 根据登录身份查找用户并更新登录时间，身份未绑定任何用户时创建新用户
+func (s *userService) findOrCreateUser(provider, subject, nickname string) (*models.User, error) {
+	identity, err := s.identityRepo.GetIdentity(provider, subject)
 	if err != nil {
-		return nil, err
+		if !errors.Is(err, repository.ErrIdentityNotFound) {
+			return nil, fmt.Errorf("查找用户失败: %v", err)
+		}
+		user, err := s.userRepo.CreateUser(nickname, &models.UserIdentity{Provider: provider, Subject: subject})
+		if err != nil {
+			return nil, fmt.Errorf("创建用户失败: %v", err)
+		}
+		return user, nil
 	}
 
-	var jwtPayload JWTPayload
-	if err := json.Unmarshal(payload, &jwtPayload); err != nil {
-		return nil, err
+	// 用户存在，更新登录时间
+	if err := s.userRepo.UpdateLastLoginTime(identity.UserID); err != nil {
+		return nil, fmt.Errorf("更新登录时间失败: %v", err)
 	}
 
-	// 检查过期时间
-	if time.Now().Unix() > jwtPayload.Exp {
-		return nil, fmt.Errorf("token expired")
+	// 重新获取用户信息（确保获取到更新后的时间）
+	user, err := s.userRepo.GetUserByID(identity.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %v", err)
 	}
-
-	return &jwtPayload, nil
+	return user, nil
 }
 
-// base64URLEncode Base64URL 编码
-func (s *userService) base64URLEncode(data interface{}) (string, error) {
-	jsonData, err := json.Marshal(data)
+// verifySMSCode 校验手机号和短信验证码，返回 E.164 格式的手机号
+// 校验通过时取出并删除验证码，同一个验证码并发提交时只有一个请求能通过
+func (s *userService) verifySMSCode(phone, code string) (string, error) {
+	// 验证手机号格式
+	phone, err := utils.NormalizePhone(phone)
 	if err != nil {
 		return "", err
 	}
 
-	encoded := base64.URLEncoding.EncodeToString(jsonData)
-	// 移除填充字符
-	encoded = strings.TrimRight(encoded, "=")
-	return encoded, nil
-}
-
-// base64URLDecode Base64URL 解码
-func (s *userService) base64URLDecode(data string) ([]byte, error) {
-	// 添加必要的填充字符
-	switch len(data) % 4 {
-	case 2:
-		data += "=="
-	case 3:
-		data += "="
+	// 验证验证码格式
+	if !s.isValidCode(code) {
+		return "", ErrInvalidSMSCode
 	}
 
-	return base64.URLEncoding.DecodeString(data)
-}
-
-// generateSignature 生成 HMAC-SHA256 签名
-func (s *userService) generateSignature(data string) (string, error) {
-	h := hmac.New(sha256.New, []byte(s.jwtSecret))
-	h.Write([]byte(data))
-	signature := base64.URLEncoding.EncodeToString(h.Sum(nil))
-	// 移除填充字符
-	signature = strings.TrimRight(signature, "=")
-	return signature, nil
-}
+	// 读取已发送的验证码
+	storedCode, err := s.kvService.Get(fmt.Sprintf("sms:%s", phone))
+	if err != nil {
+		return "", fmt.Errorf("获取验证码失败: %v", err)
+	}
 
-// LoginWithWechat 微信登录
-func (s *userService) LoginWithWechat(openID, nickname, avatarURL, qrScene string) (*models.UserData, error) {
-	// 1. 查找是否已存在该用户（通过 OpenID）
-	user, err := s.userRepo.GetUserByOpenID(openID)
-	if err != nil && err.Error() != "user not found" {
-		return nil, fmt.Errorf("查找用户失败: %v", err)
+	if storedCode == "" {
+		return "", ErrInvalidSMSCode
 	}
 
-	if user != nil {
-		// 更新最后登录时间
-		err = s.userRepo.UpdateLastLoginTime(user.ID)
-		if err != nil {
-			return nil, fmt.Errorf("更新最后登录时间失败: %v", err)
-		}
-	} else {
-		// 用户不存在，创建新用户
-		user, err = s.userRepo.CreateUser("", openID, nickname) // 微信用户phone为空
-		if err != nil {
-			return nil, fmt.Errorf("创建基础用户失败: %v", err)
+	// 限制校验次数，错误次数达到上限后验证码失效
+	policy := newSMSPolicy(s.kvService)
+	remaining, err := policy.checkCodeAttempt(phone)
+	if err != nil {
+		return "", err
+	}
+	if storedCode != code {
+		if remaining == 0 {
+			return "", policy.lockCode(phone)
 		}
+		return "", ErrInvalidSMSCode
 	}
 
-	// 生成JWT token
-	token, _, err := s.GenerateJWTToken(user.ID)
+	// 原子地取出验证码，已被其他请求取走或已重新发送时视为无效
+	consumed, err := s.kvService.GetDel(fmt.Sprintf("sms:%s", phone))
 	if err != nil {
-		return nil, fmt.Errorf("生成JWT token失败: %v", err)
+		return "", fmt.Errorf("删除验证码失败: %v", err)
 	}
-
-	return &models.UserData{
-		User:  user,
-		Token: token,
-	}, nil
+	if consumed != code {
+		return "", ErrInvalidSMSCode
+	}
+	if err := policy.resetCodeAttempts(phone); err != nil {
+		return "", fmt.Errorf("清空验证码校验次数失败: %v", err)
+	}
+	return phone, nil
 }
 
 // GetWechatUserByOpenID 根据OpenID获取微信用户
@@ -366,9 +519,8 @@ func (s *userService) GetWechatUserByOpenID(openID string) (*models.WechatUser,
 }
 
 // CreateWechatUser 创建微信用户
-func (s *userService) CreateWechatUser(openID, nickname, avatarURL, qrScene string, userID uint) (*models.WechatUser, error) {
+func (s *userService) CreateWechatUser(openID, nickname, avatarURL, qrScene string) (*models.WechatUser, error) {
 	wechatUser := &models.WechatUser{
-		UID:           userID,
 		OpenID:        openID,
 		Nickname:      nickname,
 		AvatarURL:     avatarURL,
@@ -384,49 +536,3 @@ func (s *userService) CreateWechatUser(openID, nickname, avatarURL, qrScene stri
 
 	return wechatUser, nil
 }
-
-// GenerateJWTToken 生成JWT Token（提取为公共方法）
-func (s *userService) GenerateJWTToken(userID uint) (string, int, error) {
-	expiresIn := 7 * 24 * 60 * 60 // 7天，单位：秒
-	expTime := time.Now().Add(time.Duration(expiresIn) * time.Second).Unix()
-
-	claims := JWTPayload{
-		UserID: fmt.Sprintf("%d", userID),
-		Exp:    expTime,
-		Iat:    time.Now().Unix(),
-	}
-
-	// 构建完整token
-	header := map[string]string{
-		"alg": "HS256",
-		"typ": "JWT",
-	}
-
-	headerJSON, err := json.Marshal(header)
-	if err != nil {
-		return "", 0, err
-	}
-
-	claimsJSON, err := json.Marshal(claims)
-	if err != nil {
-		return "", 0, err
-	}
-
-	// Base64URL 编码
-	headerB64 := base64.URLEncoding.EncodeToString(headerJSON)
-	claimsB64 := base64.URLEncoding.EncodeToString(claimsJSON)
-
-	// 移除填充字符
-	headerB64 = strings.TrimRight(headerB64, "=")
-	claimsB64 = strings.TrimRight(claimsB64, "=")
-
-	// 签名
-	data := headerB64 + "." + claimsB64
-	signature, err := s.generateSignature(data)
-	if err != nil {
-		return "", 0, err
-	}
-
-	token := data + "." + signature
-	return token, expiresIn, nil
-}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"project/src/auth"
+	"project/src/config"
+	"project/src/constants"
+	"project/src/models"
+	"project/src/repository"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupUserTestService(t *testing.T) (*userService, *gorm.DB) {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("打开SQLite失败: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserIdentity{}, &models.RefreshToken{}, &models.UserSession{}, &models.APIKey{},
+		&models.LlmGroup{}, &models.GroupCharacter{}, &models.Task{}, &models.TaskExecution{}, &models.GroupReaction{}, &models.Revision{}); err != nil {
+		t.Fatalf("迁移表结构失败: %v", err)
+	}
+
+	tokenIssuer, err := auth.NewTokenIssuer(config.AuthConfig{Issuer: "test", Audience: "test"}, "test-secret")
+	if err != nil {
+		t.Fatalf("创建令牌签发器失败: %v", err)
+	}
+
+	oldDB, oldConfig := config.DB, config.AppConfig
+	config.DB = db
+	config.AppConfig.AccessTokenTTL = 900
+	config.AppConfig.RefreshTokenTTL = 3600
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		config.DB, config.AppConfig = oldDB, oldConfig
+	})
+
+	return &userService{
+		userRepo:         repository.NewUserRepository(),
+		wechatUserRepo:   repository.NewWechatUserRepository(),
+		identityRepo:     repository.NewUserIdentityRepository(),
+		refreshTokenRepo: repository.NewRefreshTokenRepository(),
+		sessionRepo:      repository.NewUserSessionRepository(),
+		apiKeyRepo:       repository.NewAPIKeyRepository(),
+		kvService:        newMemoryKVService(),
+		mailer:           &recordingMailer{},
+		tokenIssuer:      tokenIssuer,
+	}, db
+}
+
+func mustIssueTokens(t *testing.T, s *userService, phone string) *models.UserData {
+	t.Helper()
+	user, err := s.userRepo.CreateUser("测试用户", &models.UserIdentity{Provider: constants.LoginTypePhone, Subject: phone})
+	if err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	userData, err := s.IssueTokens(user, models.LoginClient{LoginType: constants.LoginTypePhone})
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	return userData
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	login := mustIssueTokens(t, s, "13800000001")
+	if login.ExpiresIn != 900 || login.RefreshToken == "" {
+		t.Fatalf("登录应返回短期访问令牌和刷新令牌，实际为%+v", login)
+	}
+
+	refreshed, err := s.RefreshToken(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("刷新令牌失败: %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Errorf("刷新后应签发新的刷新令牌")
+	}
+	if _, err := s.ValidateToken(refreshed.Token); err != nil {
+		t.Errorf("新的访问令牌应有效: %v", err)
+	}
+
+	// 旧刷新令牌被再次使用，视为泄露，整个会话失效
+	if _, err := s.RefreshToken(login.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("重复使用刷新令牌应返回ErrRefreshTokenReused，实际为%v", err)
+	}
+	if _, err := s.RefreshToken(refreshed.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("会话吊销后新的刷新令牌也应失效，实际为%v", err)
+	}
+	if _, err := s.ValidateToken(refreshed.Token); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("会话吊销后访问令牌应失效，实际为%v", err)
+	}
+
+	if _, err := s.RefreshToken("not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("无效的刷新令牌应返回ErrInvalidRefreshToken，实际为%v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	first := mustIssueTokens(t, s, "13800000002")
+	second, err := s.IssueTokens(first.User, models.LoginClient{LoginType: constants.LoginTypePhone})
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+
+	if err := s.Logout("Bearer "+first.Token, first.RefreshToken); err != nil {
+		t.Fatalf("退出登录失败: %v", err)
+	}
+	if _, err := s.ValidateToken(first.Token); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("退出后访问令牌应失效，实际为%v", err)
+	}
+	if _, err := s.RefreshToken(first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("退出后刷新令牌应失效，实际为%v", err)
+	}
+
+	// 其他设备的登录会话不受影响
+	if _, err := s.ValidateToken(second.Token); err != nil {
+		t.Errorf("其他会话的访问令牌应仍然有效: %v", err)
+	}
+	if err := s.Logout("", ""); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("未提供令牌时应返回ErrInvalidRefreshToken，实际为%v", err)
+	}
+}
+
+func TestDisabledUser(t *testing.T) {
+	s, db := setupUserTestService(t)
+	login := mustIssueTokens(t, s, "13800000003")
+
+	if err := db.Model(&models.User{}).Where("id = ?", login.User.ID).Update("status", models.UserStatusDisabled).Error; err != nil {
+		t.Fatalf("禁用用户失败: %v", err)
+	}
+	if _, err := s.ValidateToken(login.Token); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("禁用用户的访问令牌应失效，实际为%v", err)
+	}
+	if _, err := s.RefreshToken(login.RefreshToken); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("禁用用户不能刷新令牌，实际为%v", err)
+	}
+}
+
+func TestUserSessions(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	desktop := mustIssueTokens(t, s, "13800000004")
+	phone, err := s.IssueTokens(desktop.User, models.LoginClient{
+		LoginType: constants.LoginTypeWechat,
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148 MicroMessenger/8.0.40",
+		IP:        "10.0.0.2",
+	})
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	other := mustIssueTokens(t, s, "13800000005")
+
+	_, sessionID, err := s.ValidateSession(desktop.Token)
+	if err != nil || sessionID == "" {
+		t.Fatalf("访问令牌应携带登录会话ID: %q, %v", sessionID, err)
+	}
+	sessions, err := s.ListSessions(desktop.User.ID, sessionID)
+	if err != nil {
+		t.Fatalf("获取登录会话失败: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("应有2个登录会话，实际为%d", len(sessions))
+	}
+	var current, mobile *models.UserSession
+	for i := range sessions {
+		if sessions[i].Current {
+			current = &sessions[i]
+		} else {
+			mobile = &sessions[i]
+		}
+	}
+	if current == nil || mobile == nil || mobile.Device != "微信 · iPhone" || mobile.IP != "10.0.0.2" || mobile.LoginType != constants.LoginTypeWechat {
+		t.Fatalf("登录会话信息不正确: %+v", sessions)
+	}
+
+	// 不能吊销其他用户的会话
+	if err := s.RevokeUserSession(other.User.ID, mobile.ID); !errors.Is(err, repository.ErrUserSessionNotFound) {
+		t.Errorf("吊销其他用户的会话应返回ErrUserSessionNotFound，实际为%v", err)
+	}
+
+	// 退出其他设备后，仅当前会话有效
+	revoked, err := s.RevokeOtherSessions(desktop.User.ID, sessionID)
+	if err != nil || revoked != 1 {
+		t.Fatalf("应吊销1个会话，实际为%d, %v", revoked, err)
+	}
+	if _, err := s.ValidateToken(phone.Token); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("被吊销会话的访问令牌应失效，实际为%v", err)
+	}
+	if _, err := s.RefreshToken(phone.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("被吊销会话的刷新令牌应失效，实际为%v", err)
+	}
+	if _, err := s.ValidateToken(desktop.Token); err != nil {
+		t.Errorf("当前会话应仍然有效: %v", err)
+	}
+	if _, err := s.ValidateToken(other.Token); err != nil {
+		t.Errorf("其他用户的会话不应受影响: %v", err)
+	}
+
+	// 吊销当前会话等同于退出登录
+	if err := s.RevokeUserSession(desktop.User.ID, current.ID); err != nil {
+		t.Fatalf("吊销当前会话失败: %v", err)
+	}
+	if _, err := s.ValidateToken(desktop.Token); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("吊销后当前会话的访问令牌应失效，实际为%v", err)
+	}
+	if sessions, _ := s.ListSessions(desktop.User.ID, sessionID); len(sessions) != 0 {
+		t.Errorf("已吊销的会话不应出现在列表中，实际为%d个", len(sessions))
+	}
+}
+
+func TestBindAndUnbindIdentity(t *testing.T) {
+	s, _ := setupUserTestService(t)
+	login := mustIssueTokens(t, s, "13800000006")
+	other := mustIssueTokens(t, s, "13800000007")
+	userID := login.User.ID
+
+	wechat, err := s.BindWechat(userID, "openid-1")
+	if err != nil {
+		t.Fatalf("绑定微信失败: %v", err)
+	}
+	if _, err := s.BindWechat(userID, "openid-1"); !errors.Is(err, ErrIdentityAlreadyBound) {
+		t.Errorf("重复绑定应返回ErrIdentityAlreadyBound，实际为%v", err)
+	}
+	if _, err := s.BindWechat(other.User.ID, "openid-1"); !errors.Is(err, ErrIdentityBoundToOther) {
+		t.Errorf("绑定其他账号的微信应返回ErrIdentityBoundToOther，实际为%v", err)
+	}
+
+	// 绑定后使用微信登录的是同一个账号
+	identity, err := s.identityRepo.GetIdentity(constants.LoginTypeWechat, "openid-1")
+	if err != nil || identity.UserID != userID {
+		t.Fatalf("微信应绑定到当前账号: %+v, %v", identity, err)
+	}
+
+	if err := s.UnbindIdentity(other.User.ID, wechat.ID); !errors.Is(err, repository.ErrIdentityNotFound) {
+		t.Errorf("解绑其他用户的身份应返回ErrIdentityNotFound，实际为%v", err)
+	}
+	identities, _ := s.ListIdentities(userID)
+	for _, identity := range identities {
+		if identity.Provider == constants.LoginTypePhone {
+			if err := s.UnbindIdentity(userID, identity.ID); err != nil {
+				t.Fatalf("解绑手机号失败: %v", err)
+			}
+		}
+	}
+	if user, _ := s.GetUserByID(userID); user.Phone != "" {
+		t.Errorf("解绑后展示手机号应清空，实际为%q", user.Phone)
+	}
+	if err := s.UnbindIdentity(userID, wechat.ID); !errors.Is(err, ErrLastIdentity) {
+		t.Errorf("解绑最后一种登录方式应返回ErrLastIdentity，实际为%v", err)
+	}
+}
+
+func TestMergeAccount(t *testing.T) {
+	s, db := setupUserTestService(t)
+	target := mustIssueTokens(t, s, "13800000008")
+	source := mustIssueTokens(t, s, "13800000009")
+	if _, err := s.BindWechat(source.User.ID, "openid-2"); err != nil {
+		t.Fatalf("绑定微信失败: %v", err)
+	}
+
+	groups := []models.LlmGroup{
+		{Name: "同名群组", OwnerID: target.User.ID},
+		{Name: "同名群组", OwnerID: source.User.ID, LikeCount: 1},
+	}
+	if err := db.Create(&groups).Error; err != nil {
+		t.Fatalf("创建群组失败: %v", err)
+	}
+	if err := db.Create(&models.GroupCharacter{Name: "角色", OwnerID: source.User.ID}).Error; err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	// 两个账号都点赞了同一个群组
+	reactions := []models.GroupReaction{
+		{GID: groups[0].ID, UserID: target.User.ID, Type: models.ReactionLike},
+		{GID: groups[0].ID, UserID: source.User.ID, Type: models.ReactionLike},
+	}
+	if err := db.Create(&reactions).Error; err != nil {
+		t.Fatalf("创建点赞失败: %v", err)
+	}
+	db.Model(&models.LlmGroup{}).Where("id = ?", groups[0].ID).Update("like_count", 2)
+
+	if _, err := s.MergeAccount(target.User.ID, target.Token); !errors.Is(err, ErrMergeSameAccount) {
+		t.Errorf("合并同一个账号应返回ErrMergeSameAccount，实际为%v", err)
+	}
+	if _, err := s.MergeAccount(target.User.ID, "not-a-token"); !errors.Is(err, ErrInvalidMergeToken) {
+		t.Errorf("无效的令牌应返回ErrInvalidMergeToken，实际为%v", err)
+	}
+
+	result, err := s.MergeAccount(target.User.ID, source.Token)
+	if err != nil {
+		t.Fatalf("合并账号失败: %v", err)
+	}
+	if result.Groups != 1 || result.Characters != 1 || result.Identities != 2 {
+		t.Errorf("合并结果不正确: %+v", result)
+	}
+
+	var merged models.LlmGroup
+	db.First(&merged, groups[1].ID)
+	if merged.OwnerID != target.User.ID || merged.Name != "同名群组 (2)" {
+		t.Errorf("群组应转移并重命名，实际为%+v", merged)
+	}
+	var liked models.LlmGroup
+	db.First(&liked, groups[0].ID)
+	var likes int64
+	db.Model(&models.GroupReaction{}).Where("gid = ?", groups[0].ID).Count(&likes)
+	if liked.LikeCount != 1 || likes != 1 {
+		t.Errorf("重复的点赞应只保留一条，实际计数%d、记录%d", liked.LikeCount, likes)
+	}
+
+	// 来源账号不能再使用，其登录方式指向目标账号
+	if _, err := s.ValidateToken(source.Token); err == nil {
+		t.Errorf("被合并账号的访问令牌应失效")
+	}
+	identity, err := s.identityRepo.GetIdentity(constants.LoginTypeWechat, "openid-2")
+	if err != nil || identity.UserID != target.User.ID {
+		t.Errorf("被合并账号的微信应登录到目标账号: %+v, %v", identity, err)
+	}
+	identities, _ := s.ListIdentities(target.User.ID)
+	if len(identities) != 3 {
+		t.Errorf("目标账号应有3个登录身份，实际为%d", len(identities))
+	}
+}
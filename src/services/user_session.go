@@ -0,0 +1,37 @@
+package services
+
+import (
+	"project/src/models"
+	"project/src/repository"
+)
+
+// ListSessions 获取用户当前有效的登录会话，并标记当前请求所属的会话
+func (s *userService) ListSessions(userID uint, currentSessionID string) ([]models.UserSession, error) {
+	sessions, err := s.sessionRepo.GetActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = currentSessionID != "" && sessions[i].SessionID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeUserSession 吊销用户的指定登录会话，吊销当前会话等同于退出登录
+func (s *userService) RevokeUserSession(userID, id uint) error {
+	session, err := s.sessionRepo.GetSessionByID(id)
+	if err != nil {
+		return err
+	}
+	// 不暴露其他用户的会话是否存在
+	if session.UserID != userID {
+		return repository.ErrUserSessionNotFound
+	}
+	return s.revokeSession(session.SessionID)
+}
+
+// RevokeOtherSessions 吊销用户除当前会话外的全部登录会话，返回吊销的会话数量
+// 升级前签发的令牌没有会话ID，此时吊销全部会话
+func (s *userService) RevokeOtherSessions(userID uint, currentSessionID string) (int64, error) {
+	return s.sessionRepo.RevokeOtherSessions(userID, currentSessionID)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha1"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"project/src/config"
@@ -109,6 +110,11 @@ func (s *WechatCallbackService) HandleSubscribeEvent(msg *WechatMessage) (*Wecha
 		return s.createErrorReply(msg, "会话已失效或已完成"), nil
 	}
 
+	// 绑定微信的二维码：将微信绑定到生成二维码的用户，不登录
+	if session.BindUserID != 0 {
+		return s.handleBindWechat(msg, qrScene, session.BindUserID)
+	}
+
 	// 处理用户登录逻辑
 	userID, err := s.handleUserLogin(msg.FromUserName, qrScene)
 	if err != nil {
@@ -127,17 +133,49 @@ func (s *WechatCallbackService) HandleSubscribeEvent(msg *WechatMessage) (*Wecha
 
 // handleUserLogin 处理用户登录逻辑
 func (s *WechatCallbackService) handleUserLogin(openID, qrScene string) (uint, error) {
-	// 统一使用 UserService.LoginWithWechat 处理所有情况
-	// 该方法会自动处理新用户创建和老用户更新
+	// 统一使用 UserService.EnsureWechatUser 处理所有情况
+	// 该方法会自动处理新用户创建和老用户更新；令牌和登录会话在网页端轮询扫码状态时创建
 	nickname := "微信用户" // 可以从微信API获取真实昵称
 	avatarURL := ""    // 可以从微信API获取真实头像
 
-	userData, err := s.userService.LoginWithWechat(openID, nickname, avatarURL, qrScene)
+	user, err := s.userService.EnsureWechatUser(openID, nickname, avatarURL, qrScene)
 	if err != nil {
 		return 0, fmt.Errorf("处理微信用户登录失败: %v", err)
 	}
 
-	return userData.User.ID, nil
+	return user.ID, nil
+}
+
+// handleBindWechat 处理绑定微信的扫码事件，绑定失败时将原因写入会话供网页端展示
+func (s *WechatCallbackService) handleBindWechat(msg *WechatMessage, qrScene string, userID uint) (*WechatReplyMessage, error) {
+	if _, err := s.userService.BindWechat(userID, msg.FromUserName); err != nil {
+		if errors.Is(err, ErrIdentityBoundToOther) || errors.Is(err, ErrIdentityAlreadyBound) {
+			if err := s.sessionService.FailSessionByScene(qrScene, msg.FromUserName, err.Error()); err != nil {
+				return s.createErrorReply(msg, "更新会话状态失败"), fmt.Errorf("更新会话状态失败: %v", err)
+			}
+			return &WechatReplyMessage{
+				ToUserName:   msg.FromUserName,
+				FromUserName: msg.ToUserName,
+				CreateTime:   time.Now().Unix(),
+				MsgType:      "text",
+				Content:      fmt.Sprintf("❌ 绑定失败：%s。", err.Error()),
+			}, nil
+		}
+		return s.createErrorReply(msg, "绑定处理失败"), fmt.Errorf("绑定微信失败: %v", err)
+	}
+
+	err := s.sessionService.UpdateSessionByScene(qrScene, constants.SessionStatusSuccess, userID, msg.FromUserName)
+	if err != nil {
+		return s.createErrorReply(msg, "更新会话状态失败"), fmt.Errorf("更新会话状态失败: %v", err)
+	}
+
+	return &WechatReplyMessage{
+		ToUserName:   msg.FromUserName,
+		FromUserName: msg.ToUserName,
+		CreateTime:   time.Now().Unix(),
+		MsgType:      "text",
+		Content:      "🎉 绑定成功！您可以使用该微信扫码登录了。",
+	}, nil
 }
 
 // validateSceneFormat 验证场景值格式
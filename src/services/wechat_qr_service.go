@@ -70,6 +70,16 @@ type QRScene struct {
 
 // GenerateQRCode 生成微信临时二维码
 func (s *WechatQRService) GenerateQRCode() (*models.WechatLoginData, error) {
+	return s.generateQRCode(0)
+}
+
+// GenerateBindQRCode 生成绑定微信的临时二维码，扫码后将微信绑定到指定用户
+func (s *WechatQRService) GenerateBindQRCode(userID uint) (*models.WechatLoginData, error) {
+	return s.generateQRCode(userID)
+}
+
+// generateQRCode 生成临时二维码并保存会话，bindUserID 非0时为绑定微信的二维码
+func (s *WechatQRService) generateQRCode(bindUserID uint) (*models.WechatLoginData, error) {
 	// 1. 生成唯一的场景值
 	qrScene, err := s.generateUniqueScene()
 	if err != nil {
@@ -104,13 +114,14 @@ func (s *WechatQRService) GenerateQRCode() (*models.WechatLoginData, error) {
 	}
 
 	loginSession := &models.LoginSession{
-		SessionID: sessionID,
-		QRScene:   qrScene,
-		Status:    constants.SessionStatusPending,
-		UserID:    0,
-		OpenID:    "",
-		CreatedAt: time.Now().Unix(),
-		ExpiresAt: time.Now().Add(time.Duration(expiresIn) * time.Second).Unix(),
+		SessionID:  sessionID,
+		QRScene:    qrScene,
+		Status:     constants.SessionStatusPending,
+		UserID:     0,
+		OpenID:     "",
+		BindUserID: bindUserID,
+		CreatedAt:  time.Now().Unix(),
+		ExpiresAt:  time.Now().Add(time.Duration(expiresIn) * time.Second).Unix(),
 	}
 
 	err = s.sessionService.SaveSession(loginSession)
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPhone 手机号格式无效
+var ErrInvalidPhone = errors.New("手机号格式无效")
+
+// ChinaCountryCode 中国大陆国家码，不带国家码的11位手机号按中国大陆号码处理
+const ChinaCountryCode = "+86"
+
+var (
+	// 中国大陆手机号
+	mainlandPhoneRegex = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	// E.164 格式：+国家码+号码，不含 + 最多15位数字
+	e164Regex = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+	// 手机号中允许出现的分隔符
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "")
+)
+
+// NormalizePhone 将手机号统一为 E.164 格式（如 +8613800138000），忽略空格、短横线和括号
+// 不带国家码的11位号码视为中国大陆手机号，00 开头的国际冠码写法等同于 +
+func NormalizePhone(phone string) (string, error) {
+	phone = phoneSeparators.Replace(strings.TrimSpace(phone))
+	switch {
+	case mainlandPhoneRegex.MatchString(phone):
+		phone = ChinaCountryCode + phone
+	case strings.HasPrefix(phone, "00"):
+		phone = "+" + phone[2:]
+	}
+	if !e164Regex.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	// 中国大陆号码额外校验号段
+	if strings.HasPrefix(phone, ChinaCountryCode) && !mainlandPhoneRegex.MatchString(phone[len(ChinaCountryCode):]) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+// IsValidPhone 验证手机号格式，支持中国大陆11位手机号和带国家码的国际号码
+func IsValidPhone(phone string) bool {
+	_, err := NormalizePhone(phone)
+	return err == nil
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// pngSignature PNG文件头
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// ErrInvalidPNG PNG文件格式错误
+var ErrInvalidPNG = errors.New("无效的PNG文件")
+
+// ErrPNGTextNotFound PNG中不存在指定的文本块
+var ErrPNGTextNotFound = errors.New("PNG中不存在指定的文本块")
+
+// IsPNG 判断数据是否为PNG文件
+func IsPNG(data []byte) bool {
+	return bytes.HasPrefix(data, pngSignature)
+}
+
+// pngChunk PNG数据块
+type pngChunk struct {
+	Type string
+	Data []byte
+}
+
+// ReadPNGText 读取PNG中指定关键字的 tEXt 文本块
+func ReadPNGText(data []byte, keyword string) (string, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return "", err
+	}
+	for _, chunk := range chunks {
+		if chunk.Type != "tEXt" {
+			continue
+		}
+		if key, text, ok := bytes.Cut(chunk.Data, []byte{0}); ok && string(key) == keyword {
+			return string(text), nil
+		}
+	}
+	return "", ErrPNGTextNotFound
+}
+
+// WritePNGText 向PNG写入指定关键字的 tEXt 文本块，已存在的同名文本块会被替换
+func WritePNGText(data []byte, keyword, text string) ([]byte, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	for _, chunk := range chunks {
+		if chunk.Type == "tEXt" {
+			if key, _, ok := bytes.Cut(chunk.Data, []byte{0}); ok && string(key) == keyword {
+				continue
+			}
+		}
+		// 文本块写在 IEND 之前
+		if chunk.Type == "IEND" {
+			writePNGChunk(&buf, "tEXt", append(append([]byte(keyword), 0), text...))
+		}
+		writePNGChunk(&buf, chunk.Type, chunk.Data)
+	}
+	return buf.Bytes(), nil
+}
+
+// readPNGChunks 解析PNG数据块
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !IsPNG(data) {
+		return nil, ErrInvalidPNG
+	}
+
+	var chunks []pngChunk
+	offset := len(pngSignature)
+	for offset < len(data) {
+		// 长度(4) + 类型(4) + 数据 + CRC(4)
+		if offset+8 > len(data) {
+			return nil, ErrInvalidPNG
+		}
+		length := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		chunkType := string(data[offset+4 : offset+8])
+		end := offset + 8 + length + 4
+		if length < 0 || end > len(data) {
+			return nil, ErrInvalidPNG
+		}
+		chunks = append(chunks, pngChunk{
+			Type: chunkType,
+			Data: data[offset+8 : offset+8+length],
+		})
+		offset = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	if len(chunks) == 0 || chunks[len(chunks)-1].Type != "IEND" {
+		return nil, ErrInvalidPNG
+	}
+	return chunks, nil
+}
+
+// writePNGChunk 写入一个PNG数据块并计算CRC
+func writePNGChunk(buf *bytes.Buffer, chunkType string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], chunkType)
+	buf.Write(header[:])
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
@@ -0,0 +1,47 @@
+package utils
+
+import "strings"
+
+// userAgentBrowsers 浏览器识别规则，按顺序匹配（Edge、微信等基于 Chrome 内核，需先于 Chrome 判断）
+var userAgentBrowsers = []struct {
+	token string
+	name  string
+}{
+	{"MicroMessenger", "微信"},
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+// userAgentSystems 操作系统识别规则，按顺序匹配（iPhone/iPad 的 UA 中包含 Mac OS X，Android 的 UA 中包含 Linux）
+var userAgentSystems = []struct {
+	token string
+	name  string
+}{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// DescribeUserAgent 根据 User-Agent 生成简短的设备描述，如 "Chrome · Windows"，无法识别时返回空字符串
+func DescribeUserAgent(userAgent string) string {
+	var parts []string
+	for _, browser := range userAgentBrowsers {
+		if strings.Contains(userAgent, browser.token) {
+			parts = append(parts, browser.name)
+			break
+		}
+	}
+	for _, system := range userAgentSystems {
+		if strings.Contains(userAgent, system.token) {
+			parts = append(parts, system.name)
+			break
+		}
+	}
+	return strings.Join(parts, " · ")
+}
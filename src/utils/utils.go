@@ -3,16 +3,21 @@ package utils
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"math/rand"
 	"regexp"
+	"strconv"
 	"time"
+
+	"github.com/robfig/cron/v3"
 )
 
 // GenerateID 生成唯一ID
 func GenerateID() string {
 	timestamp := time.Now().UnixNano()
 	hash := md5.Sum([]byte(time.Now().String()))
-	return hex.EncodeToString(hash[:]) + string(timestamp)
+	return hex.EncodeToString(hash[:]) + strconv.FormatInt(timestamp, 10)
 }
 
 // GenerateRandomCode 生成指定长度的随机数字验证码
@@ -34,18 +39,29 @@ func FormatTime(t time.Time) string {
 	return t.Format("2006-01-02 15:04:05")
 }
 
+// ErrInvalidCronExpr Cron表达式无效
+var ErrInvalidCronExpr = errors.New("无效的Cron表达式")
+
 // ParseCronExpression 解析Cron表达式
+// 支持标准的5段式表达式（分 时 日 月 周）以及 @daily、@every 1h 等描述符
 func ParseCronExpression(expr string) (bool, error) {
-	// 这里可以添加Cron表达式解析逻辑
-	// 简单实现，实际应使用cron库
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrInvalidCronExpr, err)
+	}
 	return true, nil
 }
 
-// IsValidPhone 验证手机号格式（中国大陆）
-func IsValidPhone(phone string) bool {
-	// 中国大陆手机号正则表达式
-	phoneRegex := regexp.MustCompile(`^1[3-9]\d{9}$`)
-	return phoneRegex.MatchString(phone)
+// NextCronTime 计算Cron表达式在指定时间之后的下一次触发时间
+func NextCronTime(expr string, from time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidCronExpr, err)
+	}
+	next := schedule.Next(from)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: 没有后续触发时间", ErrInvalidCronExpr)
+	}
+	return next, nil
 }
 
 // IsValidCode 验证验证码格式（4-8位数字）